@@ -0,0 +1,139 @@
+package activitypub
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/util"
+)
+
+// Account lists that can be imported and exported, named like the files of Mastodon's export
+const (
+	ListFollowing       = "following_accounts.csv"
+	ListBlockedAccounts = "blocked_accounts.csv"
+	ListBlockedDomains  = "blocked_domains.csv"
+)
+
+// AccountLists are all account lists, in the order they are offered
+var AccountLists = []string{ListFollowing, ListBlockedAccounts, ListBlockedDomains}
+
+// Columns of Mastodon's following list CSV export
+var followingCSVHeader = []string{"Account address", "Show boosts", "Notify on new posts", "Languages"}
+
+// AccountListByName returns the account list a name refers to, either its file name
+// or a short name: following, blocks or domain-blocks
+func AccountListByName(name string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case ListFollowing, "following", "follows":
+		return ListFollowing, nil
+	case ListBlockedAccounts, "blocks", "blocked":
+		return ListBlockedAccounts, nil
+	case ListBlockedDomains, "domain-blocks", "domains":
+		return ListBlockedDomains, nil
+	}
+	return "", fmt.Errorf("unknown list %q, expected one of %s", name, strings.Join(AccountLists, ", "))
+}
+
+// ParseAccountListCSV reads the entries of an account list in Mastodon's CSV format:
+// the first column of each row, skipping the header of the following list
+func ParseAccountListCSV(r io.Reader) ([]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	if len(records) > 0 && strings.EqualFold(strings.TrimSpace(records[0][0]), followingCSVHeader[0]) {
+		records = records[1:]
+	}
+
+	var entries []string
+	for _, record := range records {
+		entry := strings.TrimSpace(record[0])
+		if entry == "" {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// WriteAccountListCSV writes an account list in Mastodon's CSV format
+// Only the following list has a header and more than one column
+func WriteAccountListCSV(w io.Writer, list string, entries []string) error {
+	writer := csv.NewWriter(w)
+	if list == ListFollowing {
+		if err := writer.Write(followingCSVHeader); err != nil {
+			return err
+		}
+	}
+	for _, entry := range entries {
+		record := []string{entry}
+		if list == ListFollowing {
+			record = []string{entry, "true", "false", ""}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ExportAccountList returns the entries of an account list of a local account:
+// the addresses of followed or blocked accounts, or the blocked domains
+func ExportAccountList(localAccount *domain.Account, list string, conf *util.AppConfig) ([]string, error) {
+	database := db.GetDB()
+	entries := []string{}
+
+	switch list {
+	case ListFollowing:
+		err, follows := database.ReadFollowingByAccountId(localAccount.Id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read follows: %w", err)
+		}
+		for _, follow := range *follows {
+			if follow.IsLocal {
+				if err, acc := database.ReadAccById(follow.TargetAccountId); err == nil && acc != nil {
+					entries = append(entries, fmt.Sprintf("%s@%s", acc.Username, conf.Conf.SslDomain))
+				}
+				continue
+			}
+			if err, remoteAcc := database.ReadRemoteAccountById(follow.TargetAccountId); err == nil && remoteAcc != nil {
+				entries = append(entries, fmt.Sprintf("%s@%s", remoteAcc.Username, remoteAcc.Domain))
+			}
+		}
+
+	case ListBlockedAccounts, ListBlockedDomains:
+		err, blocks := database.ReadBlocksByAccountId(localAccount.Id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read blocks: %w", err)
+		}
+		for _, block := range *blocks {
+			if block.IsDomain != (list == ListBlockedDomains) {
+				continue
+			}
+			if block.IsDomain {
+				entries = append(entries, block.Target)
+				continue
+			}
+			// Actors that are no longer cached are exported by their URI, which imports just as well
+			if err, remoteAcc := database.ReadRemoteAccountByURI(block.Target); err == nil && remoteAcc != nil {
+				entries = append(entries, fmt.Sprintf("%s@%s", remoteAcc.Username, remoteAcc.Domain))
+			} else {
+				entries = append(entries, block.Target)
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown list %q", list)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,78 @@
+package activitypub
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseAccountListCSV(t *testing.T) {
+	following := "Account address,Show boosts,Notify on new posts,Languages\n" +
+		"alice@mastodon.example,true,false,\n" +
+		"bob@other.example,false,false,en\n" +
+		"\n"
+	entries, err := ParseAccountListCSV(strings.NewReader(following))
+	if err != nil {
+		t.Fatalf("ParseAccountListCSV failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0] != "alice@mastodon.example" || entries[1] != "bob@other.example" {
+		t.Errorf("Expected both followed accounts, got %v", entries)
+	}
+
+	// Block lists have no header and a single column
+	entries, err = ParseAccountListCSV(strings.NewReader("spammer@spam.example\ntroll@troll.example\n"))
+	if err != nil {
+		t.Fatalf("ParseAccountListCSV failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0] != "spammer@spam.example" {
+		t.Errorf("Expected both blocked accounts, got %v", entries)
+	}
+
+	if _, err := ParseAccountListCSV(strings.NewReader("\"unterminated\n")); err == nil {
+		t.Error("Expected an error for invalid CSV")
+	}
+}
+
+func TestWriteAccountListCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteAccountListCSV(&buf, ListFollowing, []string{"alice@mastodon.example"}); err != nil {
+		t.Fatalf("WriteAccountListCSV failed: %v", err)
+	}
+	want := "Account address,Show boosts,Notify on new posts,Languages\nalice@mastodon.example,true,false,\n"
+	if buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteAccountListCSV(&buf, ListBlockedDomains, []string{"spam.example"}); err != nil {
+		t.Fatalf("WriteAccountListCSV failed: %v", err)
+	}
+	if buf.String() != "spam.example\n" {
+		t.Errorf("Expected a plain domain list, got %q", buf.String())
+	}
+
+	// Exported lists import again
+	entries, _ := ParseAccountListCSV(strings.NewReader(want))
+	if len(entries) != 1 || entries[0] != "alice@mastodon.example" {
+		t.Errorf("Expected the exported list to parse, got %v", entries)
+	}
+}
+
+func TestAccountListByName(t *testing.T) {
+	tests := map[string]string{
+		"following_accounts.csv": ListFollowing,
+		"following":              ListFollowing,
+		"blocked_accounts.csv":   ListBlockedAccounts,
+		"Blocks":                 ListBlockedAccounts,
+		"blocked_domains.csv":    ListBlockedDomains,
+		"domain-blocks":          ListBlockedDomains,
+	}
+	for name, want := range tests {
+		if got, err := AccountListByName(name); err != nil || got != want {
+			t.Errorf("AccountListByName(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := AccountListByName("muted_accounts.csv"); err == nil {
+		t.Error("Expected an error for an unsupported list")
+	}
+}
@@ -24,7 +24,10 @@ type ActorResponse struct {
 	Summary           string      `json:"summary"`
 	Inbox             string      `json:"inbox"`
 	Outbox            string      `json:"outbox"`
-	Icon              struct {
+	Endpoints         struct {
+		SharedInbox string `json:"sharedInbox"`
+	} `json:"endpoints"`
+	Icon struct {
 		Type      string `json:"type"`
 		MediaType string `json:"mediaType"`
 		URL       string `json:"url"`
@@ -34,21 +37,17 @@ type ActorResponse struct {
 		Owner        string `json:"owner"`
 		PublicKeyPem string `json:"publicKeyPem"`
 	} `json:"publicKey"`
+	AssertionMethod interface{} `json:"assertionMethod"` // Multikeys, e.g. Ed25519 keys for RFC 9421 signatures
 }
 
 // FetchRemoteActor fetches an actor from a remote server and stores in cache
 func FetchRemoteActor(actorURI string) (*domain.RemoteAccount, error) {
-	// Create HTTP request with Accept: application/activity+json
-	req, err := http.NewRequest("GET", actorURI, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	if IsDomainSuspended(actorURI) {
+		return nil, fmt.Errorf("domain of %s is suspended", actorURI)
 	}
 
-	req.Header.Set("Accept", "application/activity+json")
-	req.Header.Set("User-Agent", "stegodon/1.0 ActivityPub")
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	// Signed HTTP request with Accept: application/activity+json
+	resp, err := fetchSigned(actorURI)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -73,6 +72,9 @@ func FetchRemoteActor(actorURI string) (*domain.RemoteAccount, error) {
 	if actor.ID == "" || actor.Inbox == "" || actor.PublicKey.PublicKeyPem == "" {
 		return nil, fmt.Errorf("actor missing required fields")
 	}
+	if err := checkActorDocument(&actor, actorURI, resp.Request.URL.String()); err != nil {
+		return nil, err
+	}
 
 	// Extract domain from actor URI
 	domainName, err := extractDomain(actor.ID)
@@ -80,23 +82,42 @@ func FetchRemoteActor(actorURI string) (*domain.RemoteAccount, error) {
 		return nil, err
 	}
 
+	// Avatars of instances whose media is rejected are not shown
+	avatarURL := actor.Icon.URL
+	if rejectsMedia(actor.ID) {
+		avatarURL = ""
+	}
+
+	// Keep the id of an actor fetched before, so follows and likes still point to it
+	database := db.GetDB()
+	accountId := uuid.New()
+	err, existing := database.ReadRemoteAccountByURI(actor.ID)
+	if err == nil && existing != nil {
+		accountId = existing.Id
+	}
+
+	// Ed25519 keys are optional, actors without one are verified with their RSA key
+	ed25519KeyId, ed25519KeyPem := ed25519AssertionKey(actor.ID, actor.AssertionMethod)
+
 	// Create RemoteAccount
 	remoteAcc := &domain.RemoteAccount{
-		Id:            uuid.New(),
-		Username:      actor.PreferredUsername,
-		Domain:        domainName,
-		ActorURI:      actor.ID,
-		DisplayName:   actor.Name,
-		Summary:       actor.Summary,
-		InboxURI:      actor.Inbox,
-		OutboxURI:     actor.Outbox,
-		PublicKeyPem:  actor.PublicKey.PublicKeyPem,
-		AvatarURL:     actor.Icon.URL,
-		LastFetchedAt: time.Now(),
+		Id:             accountId,
+		Username:       actor.PreferredUsername,
+		Domain:         domainName,
+		ActorURI:       actor.ID,
+		DisplayName:    actor.Name,
+		Summary:        actor.Summary,
+		InboxURI:       actor.Inbox,
+		SharedInboxURI: actor.Endpoints.SharedInbox,
+		OutboxURI:      actor.Outbox,
+		PublicKeyPem:   actor.PublicKey.PublicKeyPem,
+		Ed25519KeyId:   ed25519KeyId,
+		Ed25519KeyPem:  ed25519KeyPem,
+		AvatarURL:      avatarURL,
+		LastFetchedAt:  time.Now(),
 	}
 
 	// Store in database
-	database := db.GetDB()
 	err = database.CreateRemoteAccount(remoteAcc)
 	if err != nil {
 		// If already exists, try to update
@@ -106,9 +127,92 @@ func FetchRemoteActor(actorURI string) (*domain.RemoteAccount, error) {
 		}
 	}
 
+	if existing != nil && existing.PublicKeyPem != remoteAcc.PublicKeyPem {
+		recordKeyChange(remoteAcc.ActorURI, existing.PublicKeyPem, remoteAcc.PublicKeyPem)
+	}
+	if existing != nil && existing.Ed25519KeyPem != "" && existing.Ed25519KeyPem != remoteAcc.Ed25519KeyPem {
+		recordKeyChange(remoteAcc.ActorURI, existing.Ed25519KeyPem, remoteAcc.Ed25519KeyPem)
+	}
+
 	return remoteAcc, nil
 }
 
+// checkActorDocument checks that a fetched actor document speaks for itself:
+// its id lives on the server it was requested from and served by, and its key is owned by it
+// Otherwise any server could publish a key for an actor of another server
+func checkActorDocument(actor *ActorResponse, requestedURI string, servedFrom string) error {
+	if !sameOrigin(actor.ID, requestedURI) || !sameOrigin(actor.ID, servedFrom) {
+		return fmt.Errorf("%w: %s served %s", ErrActorMismatch, servedFrom, actor.ID)
+	}
+	if actor.PublicKey.Owner != actor.ID || !keyOwnedBy(actor.PublicKey.ID, actor.ID) {
+		return fmt.Errorf("%w: key %s owned by %s", ErrKeyOwnerMismatch, actor.PublicKey.ID, actor.PublicKey.Owner)
+	}
+	return nil
+}
+
+// resolveKeyOwner returns the actor a keyId belongs to
+// Keys at <actor>#fragment are the actor's (e.g. Mastodon). Keys at a path of their own
+// (e.g. GoToSocial's <actor>/main-key) belong to a cached actor they live under,
+// or to the owner their key document names
+func resolveKeyOwner(keyId string) (string, error) {
+	if owner, _, found := strings.Cut(keyId, "#"); found {
+		return owner, nil
+	}
+
+	if i := strings.LastIndex(keyId, "/"); i > 0 {
+		parent := keyId[:i]
+		if err, cached := db.GetDB().ReadRemoteAccountByURI(parent); err == nil && cached != nil {
+			return parent, nil
+		}
+	}
+
+	key, err := FetchRemoteObject(keyId)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch key %s: %w", keyId, err)
+	}
+	owner, _ := key["owner"].(string)
+	if publicKey, ok := key["publicKey"].(map[string]interface{}); ok && owner == "" {
+		owner, _ = publicKey["owner"].(string)
+	}
+	if !keyOwnedBy(keyId, owner) {
+		return "", fmt.Errorf("%w: key %s, owner %q", ErrKeyOwnerMismatch, keyId, owner)
+	}
+	return owner, nil
+}
+
+// FetchRemoteObject fetches an ActivityPub object (e.g. a Note) from a remote server
+func FetchRemoteObject(objectURI string) (map[string]interface{}, error) {
+	if IsDomainSuspended(objectURI) {
+		return nil, fmt.Errorf("domain of %s is suspended", objectURI)
+	}
+
+	resp, err := fetchSigned(objectURI)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("object fetch failed with status: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var object map[string]interface{}
+	if err := json.Unmarshal(body, &object); err != nil {
+		return nil, fmt.Errorf("failed to parse object JSON: %w", err)
+	}
+
+	if id, _ := object["id"].(string); id == "" {
+		return nil, fmt.Errorf("object missing id")
+	}
+
+	return object, nil
+}
+
 // GetOrFetchActor returns actor from cache or fetches if not cached/stale
 func GetOrFetchActor(actorURI string) (*domain.RemoteAccount, error) {
 	database := db.GetDB()
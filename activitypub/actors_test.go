@@ -2,11 +2,51 @@ package activitypub
 
 import (
 	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/deemkeen/stegodon/db"
 )
 
+const testKeyPem = "-----BEGIN PUBLIC KEY-----\ntest\n-----END PUBLIC KEY-----"
+
+// newActorServer serves the documents built by makeDocument at any path, e.g. actors and notes
+// makeDocument gets the URL of the server to build ids on it
+func newActorServer(t *testing.T, makeDocument func(serverURL string, path string) map[string]interface{}) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		doc := makeDocument(server.URL, r.URL.Path)
+		if doc == nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/activity+json")
+		json.NewEncoder(w).Encode(doc)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// testActor returns an actor document with the given id and key owner
+func testActor(id string, keyOwner string, keyPem string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                id,
+		"type":              "Person",
+		"preferredUsername": id[strings.LastIndex(id, "/")+1:],
+		"inbox":             id + "/inbox",
+		"publicKey": map[string]interface{}{
+			"id":           keyOwner + "#main-key",
+			"owner":        keyOwner,
+			"publicKeyPem": keyPem,
+		},
+	}
+}
+
 func TestActorResponseUnmarshal(t *testing.T) {
 	// Test unmarshaling a typical ActivityPub actor response
 	jsonData := `{
@@ -540,3 +580,44 @@ func TestCacheFreshnessLogic(t *testing.T) {
 		})
 	}
 }
+
+func TestFetchRemoteActor(t *testing.T) {
+	server := newActorServer(t, func(serverURL string, path string) map[string]interface{} {
+		return testActor(serverURL+path, serverURL+path, testKeyPem)
+	})
+
+	actorURI := server.URL + "/users/alice"
+	actor, err := FetchRemoteActor(actorURI)
+	if err != nil {
+		t.Fatalf("FetchRemoteActor failed: %v", err)
+	}
+	if actor.ActorURI != actorURI || actor.Username != "alice" {
+		t.Errorf("Expected alice at %s, got %s at %s", actorURI, actor.Username, actor.ActorURI)
+	}
+}
+
+func TestFetchRemoteActorMismatchedId(t *testing.T) {
+	victim := "https://victim.example/users/x"
+	server := newActorServer(t, func(serverURL string, path string) map[string]interface{} {
+		return testActor(victim, victim, testKeyPem)
+	})
+
+	_, err := FetchRemoteActor(server.URL + "/users/x")
+	if !errors.Is(err, ErrActorMismatch) {
+		t.Fatalf("Expected ErrActorMismatch for an actor of another server, got %v", err)
+	}
+	if err, stored := db.GetDB().ReadRemoteAccountByURI(victim); err == nil && stored != nil {
+		t.Error("The spoofed actor must not be stored")
+	}
+}
+
+func TestFetchRemoteActorForeignKeyOwner(t *testing.T) {
+	server := newActorServer(t, func(serverURL string, path string) map[string]interface{} {
+		return testActor(serverURL+path, serverURL+"/users/mallory", testKeyPem)
+	})
+
+	_, err := FetchRemoteActor(server.URL + "/users/bob")
+	if !errors.Is(err, ErrKeyOwnerMismatch) {
+		t.Fatalf("Expected ErrKeyOwnerMismatch for a key of another actor, got %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package activitypub
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Circuit breaker states of a destination host
+const (
+	BreakerClosed   = "closed"    // Deliveries go through
+	BreakerOpen     = "open"      // Deliveries are paused until RetryAt
+	BreakerHalfOpen = "half-open" // A single trial delivery is in flight
+)
+
+const (
+	breakerThreshold   = 5               // Consecutive host failures that open the breaker
+	breakerCooldown    = 5 * time.Minute // First pause of an opened breaker, doubled on every failed trial
+	breakerMaxCooldown = 6 * time.Hour
+)
+
+// HostStatus describes the delivery health of a destination host
+type HostStatus struct {
+	Host      string
+	State     string
+	Failures  int       // Consecutive failed deliveries
+	LastError string    // Error of the last failed delivery
+	RetryAt   time.Time // When an open breaker lets a trial delivery through
+
+	cooldown time.Duration // Length of the current pause
+}
+
+// circuitBreakers tracks failing destination hosts
+// Hosts without recent failures have no entry
+type circuitBreakers struct {
+	mu    sync.Mutex
+	hosts map[string]*HostStatus
+	now   func() time.Time
+}
+
+// breakers holds the circuit breakers of the delivery worker pool
+var breakers = newCircuitBreakers()
+
+func newCircuitBreakers() *circuitBreakers {
+	return &circuitBreakers{
+		hosts: make(map[string]*HostStatus),
+		now:   time.Now,
+	}
+}
+
+// allow reports whether a delivery to the host may be attempted now
+// Once the pause of an open breaker is over, a single trial delivery is let through.
+// When deliveries are paused, the time of the next trial is returned (zero while a trial is in flight)
+func (cb *circuitBreakers) allow(host string) (bool, time.Time) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	status, ok := cb.hosts[host]
+	if !ok {
+		return true, time.Time{}
+	}
+
+	switch status.State {
+	case BreakerOpen:
+		if cb.now().Before(status.RetryAt) {
+			return false, status.RetryAt
+		}
+		status.State = BreakerHalfOpen
+		return true, time.Time{}
+	case BreakerHalfOpen:
+		return false, time.Time{}
+	default:
+		return true, time.Time{}
+	}
+}
+
+// success closes the breaker of a host that answered
+func (cb *circuitBreakers) success(host string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	delete(cb.hosts, host)
+}
+
+// cancel returns a half-open breaker to open when its trial delivery never reached the host,
+// so the next delivery is let through as a new trial
+func (cb *circuitBreakers) cancel(host string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if status, ok := cb.hosts[host]; ok && status.State == BreakerHalfOpen {
+		status.State = BreakerOpen
+	}
+}
+
+// failure records a failed delivery and opens the breaker once the host keeps failing
+// Returns the end of the pause when the breaker opened
+func (cb *circuitBreakers) failure(host string, err error) time.Time {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	status, ok := cb.hosts[host]
+	if !ok {
+		status = &HostStatus{Host: host, State: BreakerClosed}
+		cb.hosts[host] = status
+	}
+	status.Failures++
+	status.LastError = err.Error()
+
+	switch {
+	case status.State == BreakerHalfOpen:
+		// The trial failed, pause twice as long as before
+		status.State = BreakerOpen
+		status.cooldown = min(2*status.cooldown, breakerMaxCooldown)
+		status.RetryAt = cb.now().Add(status.cooldown)
+		return status.RetryAt
+	case status.State == BreakerClosed && status.Failures >= breakerThreshold:
+		status.State = BreakerOpen
+		status.cooldown = breakerCooldown
+		status.RetryAt = cb.now().Add(status.cooldown)
+		return status.RetryAt
+	}
+	return time.Time{}
+}
+
+// statuses returns the hosts with recent failures, ordered by host
+func (cb *circuitBreakers) statuses() []HostStatus {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	statuses := make([]HostStatus, 0, len(cb.hosts))
+	for _, status := range cb.hosts {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Host < statuses[j].Host
+	})
+	return statuses
+}
+
+// reset forgets the failures of a host so deliveries resume immediately
+func (cb *circuitBreakers) reset(host string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	delete(cb.hosts, host)
+}
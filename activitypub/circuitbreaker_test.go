@@ -0,0 +1,121 @@
+package activitypub
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestBreakers(now *time.Time) *circuitBreakers {
+	cb := newCircuitBreakers()
+	cb.now = func() time.Time { return *now }
+	return cb
+}
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	now := time.Now()
+	cb := newTestBreakers(&now)
+	failure := errors.New("connection refused")
+
+	for i := 1; i < breakerThreshold; i++ {
+		if retryAt := cb.failure("down.example", failure); !retryAt.IsZero() {
+			t.Fatalf("Breaker opened after %d failures", i)
+		}
+		if allowed, _ := cb.allow("down.example"); !allowed {
+			t.Fatalf("Expected deliveries to be allowed after %d failures", i)
+		}
+	}
+
+	retryAt := cb.failure("down.example", failure)
+	if !retryAt.Equal(now.Add(breakerCooldown)) {
+		t.Errorf("Expected breaker to open until %v, got %v", now.Add(breakerCooldown), retryAt)
+	}
+	allowed, paused := cb.allow("down.example")
+	if allowed || !paused.Equal(retryAt) {
+		t.Errorf("Expected deliveries to be paused until %v, got allowed=%v until %v", retryAt, allowed, paused)
+	}
+
+	// Other hosts are not affected
+	if allowed, _ := cb.allow("up.example"); !allowed {
+		t.Error("Expected deliveries to other hosts to be allowed")
+	}
+}
+
+func TestCircuitBreakerHalfOpenTrial(t *testing.T) {
+	now := time.Now()
+	cb := newTestBreakers(&now)
+	failure := errors.New("status 503")
+
+	for i := 0; i < breakerThreshold; i++ {
+		cb.failure("down.example", failure)
+	}
+
+	now = now.Add(breakerCooldown)
+	if allowed, _ := cb.allow("down.example"); !allowed {
+		t.Fatal("Expected a trial delivery after the pause")
+	}
+	// Only a single trial at a time
+	if allowed, retryAt := cb.allow("down.example"); allowed || !retryAt.IsZero() {
+		t.Errorf("Expected no second trial, got allowed=%v retryAt=%v", allowed, retryAt)
+	}
+
+	// A failed trial pauses twice as long
+	retryAt := cb.failure("down.example", failure)
+	if !retryAt.Equal(now.Add(2 * breakerCooldown)) {
+		t.Errorf("Expected doubled pause until %v, got %v", now.Add(2*breakerCooldown), retryAt)
+	}
+
+	// A successful trial closes the breaker
+	now = retryAt
+	if allowed, _ := cb.allow("down.example"); !allowed {
+		t.Fatal("Expected a trial delivery after the pause")
+	}
+	cb.success("down.example")
+	if len(cb.statuses()) != 0 {
+		t.Errorf("Expected no failing hosts, got %+v", cb.statuses())
+	}
+}
+
+func TestCircuitBreakerCancelledTrial(t *testing.T) {
+	now := time.Now()
+	cb := newTestBreakers(&now)
+
+	for i := 0; i < breakerThreshold; i++ {
+		cb.failure("down.example", errors.New("timeout"))
+	}
+	now = now.Add(breakerCooldown)
+	cb.allow("down.example")
+
+	// A trial that never reached the host lets the next one through
+	cb.cancel("down.example")
+	if allowed, _ := cb.allow("down.example"); !allowed {
+		t.Error("Expected a new trial after a cancelled one")
+	}
+}
+
+func TestCircuitBreakerMaxCooldown(t *testing.T) {
+	now := time.Now()
+	cb := newTestBreakers(&now)
+
+	for i := 0; i < breakerThreshold; i++ {
+		cb.failure("down.example", errors.New("timeout"))
+	}
+	for i := 0; i < 20; i++ {
+		now = cb.statuses()[0].RetryAt
+		cb.allow("down.example")
+		cb.failure("down.example", errors.New("timeout"))
+	}
+
+	status := cb.statuses()[0]
+	if pause := status.RetryAt.Sub(now); pause != breakerMaxCooldown {
+		t.Errorf("Expected pause to be capped at %v, got %v", breakerMaxCooldown, pause)
+	}
+	if status.LastError != "timeout" || status.State != BreakerOpen {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+
+	cb.reset("down.example")
+	if allowed, _ := cb.allow("down.example"); !allowed {
+		t.Error("Expected deliveries to resume after a reset")
+	}
+}
@@ -0,0 +1,154 @@
+package activitypub
+
+import (
+	"sort"
+	"time"
+
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
+)
+
+// DeliveryHost summarizes the queued and dead deliveries to a destination host
+type DeliveryHost struct {
+	Host       string
+	Inboxes    []string // Inboxes on the host with queued or dead deliveries
+	Pending    int
+	Dead       int
+	LastError  string // Error of the most recent dead letter
+	LastStatus int    // HTTP status of the most recent dead letter
+	LastFailed time.Time
+	Breaker    HostStatus // Zero State when deliveries to the host aren't failing
+}
+
+// DeliveryHosts returns the hosts with queued or dead deliveries or an active circuit breaker, ordered by host
+func DeliveryHosts() ([]DeliveryHost, error) {
+	database := db.GetDB()
+
+	pending, err := database.CountDeliveriesByInbox()
+	if err != nil {
+		return nil, err
+	}
+	err, dead := database.ReadDeadDeliveries()
+	if err != nil {
+		return nil, err
+	}
+	if dead == nil {
+		dead = &[]domain.DeadDelivery{}
+	}
+
+	return groupDeliveryHosts(pending, *dead, breakers.statuses()), nil
+}
+
+// groupDeliveryHosts groups queue counts per inbox, dead letters (most recent first) and breaker states by host
+func groupDeliveryHosts(pending map[string]int, dead []domain.DeadDelivery, statuses []HostStatus) []DeliveryHost {
+	hosts := make(map[string]*DeliveryHost)
+	get := func(host string) *DeliveryHost {
+		if _, ok := hosts[host]; !ok {
+			hosts[host] = &DeliveryHost{Host: host}
+		}
+		return hosts[host]
+	}
+	addInbox := func(summary *DeliveryHost, inboxURI string) {
+		for _, inbox := range summary.Inboxes {
+			if inbox == inboxURI {
+				return
+			}
+		}
+		summary.Inboxes = append(summary.Inboxes, inboxURI)
+	}
+
+	for inboxURI, count := range pending {
+		summary := get(deliveryHost(inboxURI))
+		summary.Pending += count
+		addInbox(summary, inboxURI)
+	}
+	for _, item := range dead {
+		summary := get(deliveryHost(item.InboxURI))
+		if summary.Dead == 0 {
+			summary.LastError = item.LastError
+			summary.LastStatus = item.LastStatus
+			summary.LastFailed = item.FailedAt
+		}
+		summary.Dead++
+		addInbox(summary, item.InboxURI)
+	}
+	for _, status := range statuses {
+		get(status.Host).Breaker = status
+	}
+
+	result := make([]DeliveryHost, 0, len(hosts))
+	for _, summary := range hosts {
+		sort.Strings(summary.Inboxes)
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Host < result[j].Host
+	})
+	return result
+}
+
+// hostInboxes returns the inboxes of a host with queued or dead deliveries
+func hostInboxes(host string) ([]string, error) {
+	summaries, err := DeliveryHosts()
+	if err != nil {
+		return nil, err
+	}
+	for _, summary := range summaries {
+		if summary.Host == host {
+			return summary.Inboxes, nil
+		}
+	}
+	return nil, nil
+}
+
+// RetryDeliveryHost closes the circuit breaker of a host and makes its queued deliveries due immediately
+func RetryDeliveryHost(host string) error {
+	breakers.reset(host)
+
+	inboxes, err := hostInboxes(host)
+	if err != nil {
+		return err
+	}
+	for _, inboxURI := range inboxes {
+		if err := db.GetDB().RetryDeliveriesNow(inboxURI); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RequeueDeliveryHost moves the dead letters of a host back into the delivery queue
+func RequeueDeliveryHost(host string) (int, error) {
+	breakers.reset(host)
+
+	inboxes, err := hostInboxes(host)
+	if err != nil {
+		return 0, err
+	}
+	requeued := 0
+	for _, inboxURI := range inboxes {
+		count, err := db.GetDB().RequeueDeadDeliveries(inboxURI)
+		requeued += count
+		if err != nil {
+			return requeued, err
+		}
+	}
+	return requeued, nil
+}
+
+// PurgeDeliveryHost deletes the dead letters of a host
+func PurgeDeliveryHost(host string) (int, error) {
+	inboxes, err := hostInboxes(host)
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, inboxURI := range inboxes {
+		count, err := db.GetDB().PurgeDeadDeliveries(inboxURI)
+		purged += count
+		if err != nil {
+			return purged, err
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,53 @@
+package activitypub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deemkeen/stegodon/domain"
+)
+
+func TestGroupDeliveryHosts(t *testing.T) {
+	pending := map[string]int{
+		"https://mastodon.example/inbox":           3,
+		"https://mastodon.example/users/bob/inbox": 1,
+		"https://up.example/inbox":                 2,
+	}
+	dead := []domain.DeadDelivery{
+		{InboxURI: "https://mastodon.example/inbox", LastError: "status 410", LastStatus: 410, FailedAt: time.Now()},
+		{InboxURI: "https://gone.example/inbox", LastError: "timeout", FailedAt: time.Now()},
+		{InboxURI: "https://mastodon.example/inbox", LastError: "status 502", LastStatus: 502, FailedAt: time.Now().Add(-time.Hour)},
+	}
+	statuses := []HostStatus{{Host: "broken.example", State: BreakerOpen, Failures: 5}}
+
+	hosts := groupDeliveryHosts(pending, dead, statuses)
+	if len(hosts) != 4 {
+		t.Fatalf("Expected 4 hosts, got %d: %+v", len(hosts), hosts)
+	}
+
+	// Hosts are ordered by name
+	names := []string{"broken.example", "gone.example", "mastodon.example", "up.example"}
+	for i, name := range names {
+		if hosts[i].Host != name {
+			t.Errorf("Expected host %d to be %s, got %s", i, name, hosts[i].Host)
+		}
+	}
+
+	mastodon := hosts[2]
+	if mastodon.Pending != 4 || mastodon.Dead != 2 {
+		t.Errorf("Expected 4 queued and 2 dead deliveries, got %d and %d", mastodon.Pending, mastodon.Dead)
+	}
+	if mastodon.LastStatus != 410 {
+		t.Errorf("Expected the most recent dead letter's status, got %d", mastodon.LastStatus)
+	}
+	if len(mastodon.Inboxes) != 2 {
+		t.Errorf("Expected 2 inboxes, got %v", mastodon.Inboxes)
+	}
+
+	if hosts[0].Breaker.State != BreakerOpen || hosts[0].Pending != 0 {
+		t.Errorf("Expected an open breaker without deliveries, got %+v", hosts[0])
+	}
+	if hosts[3].Breaker.State != "" || hosts[3].Dead != 0 {
+		t.Errorf("Expected a healthy host, got %+v", hosts[3])
+	}
+}
@@ -2,38 +2,128 @@ package activitypub
 
 import (
 	"bytes"
+	"crypto/rsa"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/deemkeen/stegodon/db"
 	"github.com/deemkeen/stegodon/domain"
 	"github.com/deemkeen/stegodon/util"
+	"github.com/google/uuid"
 )
 
-// StartDeliveryWorker starts a background worker that processes the delivery queue
+const (
+	defaultDeliveryWorkers = 8
+	defaultDeliveryPerHost = 2
+	deliveryBatchSize      = 500
+	deliveryPollInterval   = 2 * time.Second
+)
+
+// deliveryClient is shared by all deliveries so connections to remote servers are reused
+var deliveryClient = newDeliveryClient()
+
+func newDeliveryClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 100
+	transport.MaxIdleConnsPerHost = 4
+	transport.IdleConnTimeout = 90 * time.Second
+	return &http.Client{Timeout: 30 * time.Second, Transport: transport}
+}
+
+// deliveryError is a failed delivery that reached the network
+// StatusCode is 0 when the remote server didn't answer
+type deliveryError struct {
+	StatusCode int
+	err        error
+}
+
+func (e *deliveryError) Error() string {
+	return e.err.Error()
+}
+
+func (e *deliveryError) Unwrap() error {
+	return e.err
+}
+
+// hostFailure reports whether a delivery failed because of the remote server,
+// i.e. it didn't answer, is overloaded or broken
+func hostFailure(err error) bool {
+	var de *deliveryError
+	if !errors.As(err, &de) {
+		return false
+	}
+	return de.StatusCode == 0 || de.StatusCode == http.StatusTooManyRequests || de.StatusCode >= 500
+}
+
+// deliveryHost returns the host an inbox belongs to
+func deliveryHost(inboxURI string) string {
+	parsed, err := url.Parse(inboxURI)
+	if err != nil {
+		return inboxURI
+	}
+	return strings.ToLower(parsed.Host)
+}
+
+// deliveryPool delivers queued activities with a fixed number of workers,
+// limiting the concurrent deliveries to a single host
+type deliveryPool struct {
+	conf    *util.AppConfig
+	perHost int
+	jobs    chan domain.DeliveryQueueItem
+
+	mu       sync.Mutex
+	inFlight map[uuid.UUID]bool
+	hostLoad map[string]int
+}
+
+// StartDeliveryWorker starts the background workers that process the delivery queue
 func StartDeliveryWorker(conf *util.AppConfig) {
-	log.Println("Starting ActivityPub delivery worker...")
+	workers := conf.Conf.DeliveryWorkers
+	if workers <= 0 {
+		workers = defaultDeliveryWorkers
+	}
+	perHost := conf.Conf.DeliveryPerHost
+	if perHost <= 0 {
+		perHost = defaultDeliveryPerHost
+	}
+	log.Printf("Starting ActivityPub delivery worker (%d workers, %d per host)...", workers, perHost)
+
+	pool := &deliveryPool{
+		conf:     conf,
+		perHost:  perHost,
+		jobs:     make(chan domain.DeliveryQueueItem),
+		inFlight: make(map[uuid.UUID]bool),
+		hostLoad: make(map[string]int),
+	}
+	for i := 0; i < workers; i++ {
+		go pool.work()
+	}
 
-	ticker := time.NewTicker(10 * time.Second)
+	ticker := time.NewTicker(deliveryPollInterval)
 	go func() {
 		for range ticker.C {
-			processDeliveryQueue(conf)
+			pool.dispatch()
 		}
 	}()
 }
 
-// processDeliveryQueue processes pending deliveries from the queue
-func processDeliveryQueue(conf *util.AppConfig) {
+// dispatch hands pending deliveries to the workers
+// Deliveries already in flight or to hosts at their limit are left for a later round,
+// deliveries to hosts with an open circuit breaker are postponed until it lets a trial through
+func (p *deliveryPool) dispatch() {
 	database := db.GetDB()
 
-	// Get pending deliveries (max 50 at a time)
-	err, items := database.ReadPendingDeliveries(50)
+	err, items := database.ReadPendingDeliveries(deliveryBatchSize)
 	if err != nil {
 		log.Printf("DeliveryWorker: Failed to read queue: %v", err)
 		return
@@ -43,29 +133,111 @@ func processDeliveryQueue(conf *util.AppConfig) {
 		return
 	}
 
-	log.Printf("DeliveryWorker: Processing %d pending deliveries", len(*items))
-
 	for _, item := range *items {
-		if err := deliverActivity(&item, conf); err != nil {
-			// Failed delivery - retry with exponential backoff
-			item.Attempts++
-			backoffMinutes := []int{1, 5, 15, 60, 240, 1440}[min(item.Attempts-1, 5)]
-			item.NextRetryAt = time.Now().Add(time.Duration(backoffMinutes) * time.Minute)
-
-			if item.Attempts >= 10 {
-				// Give up after 10 attempts
-				log.Printf("DeliveryWorker: Giving up on delivery to %s after %d attempts", item.InboxURI, item.Attempts)
-				database.DeleteDelivery(item.Id)
-			} else {
-				log.Printf("DeliveryWorker: Delivery to %s failed (attempt %d), retry in %dm: %v",
-					item.InboxURI, item.Attempts, backoffMinutes, err)
-				database.UpdateDeliveryAttempt(item.Id, item.Attempts, item.NextRetryAt)
-			}
-		} else {
-			// Successful delivery - remove from queue
-			log.Printf("DeliveryWorker: Successfully delivered to %s", item.InboxURI)
+		// Nothing is delivered to suspended instances
+		if IsDomainSuspended(item.InboxURI) {
+			log.Printf("DeliveryWorker: Dropping delivery to suspended domain %s", item.InboxURI)
 			database.DeleteDelivery(item.Id)
+			continue
+		}
+
+		host := deliveryHost(item.InboxURI)
+		if !p.acquire(item.Id, host) {
+			continue
+		}
+
+		if allowed, retryAt := breakers.allow(host); !allowed {
+			p.release(item.Id, host)
+			if !retryAt.IsZero() {
+				database.UpdateDeliveryAttempt(item.Id, item.Attempts, retryAt)
+			}
+			continue
+		}
+
+		p.jobs <- item
+	}
+}
+
+// acquire reserves a delivery slot for the item on its host
+func (p *deliveryPool) acquire(id uuid.UUID, host string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.inFlight[id] || p.hostLoad[host] >= p.perHost {
+		return false
+	}
+	p.inFlight[id] = true
+	p.hostLoad[host]++
+	return true
+}
+
+// release frees the delivery slot of the item
+func (p *deliveryPool) release(id uuid.UUID, host string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.inFlight, id)
+	if p.hostLoad[host]--; p.hostLoad[host] <= 0 {
+		delete(p.hostLoad, host)
+	}
+}
+
+// work delivers the items handed out by dispatch
+func (p *deliveryPool) work() {
+	for item := range p.jobs {
+		p.deliver(item)
+		p.release(item.Id, deliveryHost(item.InboxURI))
+	}
+}
+
+// deliver attempts a single delivery, updating the queue and the host's circuit breaker
+func (p *deliveryPool) deliver(item domain.DeliveryQueueItem) {
+	database := db.GetDB()
+	host := deliveryHost(item.InboxURI)
+
+	err := deliverActivity(&item, p.conf)
+	if err == nil {
+		// Successful delivery - remove from queue
+		breakers.success(host)
+		log.Printf("DeliveryWorker: Successfully delivered to %s", item.InboxURI)
+		database.DeleteDelivery(item.Id)
+		return
+	}
+
+	lastStatus := 0
+	var de *deliveryError
+	if errors.As(err, &de) {
+		lastStatus = de.StatusCode
+	}
+
+	switch {
+	case hostFailure(err):
+		if retryAt := breakers.failure(host, err); !retryAt.IsZero() {
+			log.Printf("DeliveryWorker: %s keeps failing, pausing deliveries until %s", host, retryAt.Format(time.RFC3339))
+		}
+	case de != nil:
+		// The host answered, it just didn't accept this activity
+		breakers.success(host)
+	default:
+		// The host was never contacted
+		breakers.cancel(host)
+	}
+
+	// Failed delivery - retry with exponential backoff
+	item.Attempts++
+	backoffMinutes := []int{1, 5, 15, 60, 240, 1440}[min(item.Attempts-1, 5)]
+	item.NextRetryAt = time.Now().Add(time.Duration(backoffMinutes) * time.Minute)
+
+	if item.Attempts >= 10 {
+		// Give up after 10 attempts, keeping the activity as a dead letter
+		log.Printf("DeliveryWorker: Giving up on delivery to %s after %d attempts", item.InboxURI, item.Attempts)
+		if err := database.MoveDeliveryToDeadLetters(&item, err.Error(), lastStatus); err != nil {
+			log.Printf("DeliveryWorker: Failed to keep dead letter for %s: %v", item.InboxURI, err)
 		}
+	} else {
+		log.Printf("DeliveryWorker: Delivery to %s failed (attempt %d), retry in %dm: %v",
+			item.InboxURI, item.Attempts, backoffMinutes, err)
+		database.UpdateDeliveryAttempt(item.Id, item.Attempts, item.NextRetryAt)
 	}
 }
 
@@ -104,49 +276,47 @@ func deliverActivity(item *domain.DeliveryQueueItem, conf *util.AppConfig) error
 		return fmt.Errorf("failed to parse private key: %w", err)
 	}
 
-	// Calculate digest for HTTP signature
-	activityBytes := []byte(item.ActivityJSON)
-	hash := sha256.Sum256(activityBytes)
-	digest := "SHA-256=" + base64.StdEncoding.EncodeToString(hash[:])
-
-	// Create HTTP request
-	req, err := http.NewRequest("POST", item.InboxURI, bytes.NewReader(activityBytes))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/activity+json")
-	req.Header.Set("Accept", "application/activity+json")
-	req.Header.Set("User-Agent", "stegodon/1.0 ActivityPub")
-	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
-	req.Header.Set("Host", req.URL.Host)
-	req.Header.Set("Digest", digest)
-
-	// Sign request
+	// Send the signed request
 	keyID := fmt.Sprintf("https://%s/users/%s#main-key", conf.Conf.SslDomain, username)
-	if err := SignRequest(req, privateKey, keyID); err != nil {
-		return fmt.Errorf("failed to sign request: %w", err)
-	}
-
-	// Send request
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := sendSigned(deliveryClient, item.InboxURI, func(scheme signatureScheme) (*http.Request, error) {
+		return newSignedPost(item.InboxURI, []byte(item.ActivityJSON), privateKey, keyID, scheme)
+	})
 	if err != nil {
-		return err
+		return &deliveryError{err: err}
 	}
 	defer resp.Body.Close()
+	// Drain the response so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("remote server returned status: %d", resp.StatusCode)
+		return &deliveryError{
+			StatusCode: resp.StatusCode,
+			err:        fmt.Errorf("remote server returned status: %d", resp.StatusCode),
+		}
 	}
 
 	return nil
 }
 
-// min returns the minimum of two integers
-func min(a, b int) int {
-	if a < b {
-		return a
+// newSignedPost creates a POST of an activity to an inbox, signed with the given scheme
+// Both digest headers are set, each scheme signs its own
+func newSignedPost(inboxURI string, body []byte, privateKey *rsa.PrivateKey, keyId string, scheme signatureScheme) (*http.Request, error) {
+	req, err := http.NewRequest("POST", inboxURI, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	hash := sha256.Sum256(body)
+	req.Header.Set("Content-Type", "application/activity+json")
+	req.Header.Set("Accept", "application/activity+json")
+	req.Header.Set("User-Agent", "stegodon/1.0 ActivityPub")
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("Digest", "SHA-256="+base64.StdEncoding.EncodeToString(hash[:]))
+	req.Header.Set("Content-Digest", contentDigest(body))
+
+	if err := signRequest(req, privateKey, keyId, scheme); err != nil {
+		return nil, fmt.Errorf("failed to sign request: %w", err)
 	}
-	return b
+	return req, nil
 }
@@ -0,0 +1,69 @@
+package activitypub
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHostFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"no answer", &deliveryError{err: errors.New("connection refused")}, true},
+		{"server error", &deliveryError{StatusCode: 502, err: errors.New("status 502")}, true},
+		{"rate limited", &deliveryError{StatusCode: 429, err: errors.New("status 429")}, true},
+		{"rejected", &deliveryError{StatusCode: 401, err: errors.New("status 401")}, false},
+		{"gone", &deliveryError{StatusCode: 410, err: errors.New("status 410")}, false},
+		{"wrapped", fmt.Errorf("request failed: %w", &deliveryError{StatusCode: 503, err: errors.New("status 503")}), true},
+		{"local", errors.New("failed to parse private key"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hostFailure(tt.err); got != tt.want {
+				t.Errorf("hostFailure() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeliveryHost(t *testing.T) {
+	if host := deliveryHost("https://Mastodon.Example/inbox"); host != "mastodon.example" {
+		t.Errorf("Expected mastodon.example, got %s", host)
+	}
+	if host := deliveryHost("https://remote.example:8443/users/bob/inbox"); host != "remote.example:8443" {
+		t.Errorf("Expected remote.example:8443, got %s", host)
+	}
+}
+
+func TestDeliveryPoolHostLimit(t *testing.T) {
+	pool := &deliveryPool{
+		perHost:  2,
+		inFlight: make(map[uuid.UUID]bool),
+		hostLoad: make(map[string]int),
+	}
+	first, second, third := uuid.New(), uuid.New(), uuid.New()
+
+	if !pool.acquire(first, "slow.example") || !pool.acquire(second, "slow.example") {
+		t.Fatal("Expected two slots on the host")
+	}
+	if pool.acquire(third, "slow.example") {
+		t.Error("Expected the host to be at capacity")
+	}
+	if pool.acquire(first, "slow.example") {
+		t.Error("Expected an item in flight not to be handed out twice")
+	}
+	if !pool.acquire(third, "fast.example") {
+		t.Error("Expected other hosts to have free slots")
+	}
+
+	pool.release(first, "slow.example")
+	if !pool.acquire(uuid.New(), "slow.example") {
+		t.Error("Expected a released slot to be free again")
+	}
+}
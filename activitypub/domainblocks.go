@@ -0,0 +1,215 @@
+package activitypub
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/google/uuid"
+)
+
+// Columns of Mastodon's domain blocklist CSV export
+var domainBlocksCSVHeader = []string{"#domain", "#severity", "#reject_media", "#reject_reports", "#public_comment", "#obfuscate"}
+
+// InstanceDomainBlock returns the admin block applying to a host, including blocks of its parent domains
+// Returns nil if the host is not blocked
+func InstanceDomainBlock(host string) *domain.DomainBlock {
+	database := db.GetDB()
+	host = strings.ToLower(host)
+	for host != "" {
+		if err, block := database.ReadDomainBlockByDomain(host); err == nil && block != nil {
+			return block
+		}
+		dot := strings.Index(host, ".")
+		if dot < 0 {
+			break
+		}
+		host = host[dot+1:]
+	}
+	return nil
+}
+
+// domainBlockOf returns the admin block applying to the host of a URI
+func domainBlockOf(uri string) *domain.DomainBlock {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Hostname() == "" {
+		return nil
+	}
+	return InstanceDomainBlock(parsed.Hostname())
+}
+
+// IsDomainSuspended reports whether the server of a URI is suspended by the admins
+func IsDomainSuspended(uri string) bool {
+	block := domainBlockOf(uri)
+	return block != nil && block.Severity == domain.DomainBlockSuspend
+}
+
+// rejectsMedia reports whether media from the server of a URI is dropped
+func rejectsMedia(uri string) bool {
+	block := domainBlockOf(uri)
+	return block != nil && block.RejectMedia
+}
+
+// stripMedia removes attachments and images from an activity and its embedded object
+// The body is returned unchanged if it can't be parsed
+func stripMedia(body []byte) []byte {
+	var activity map[string]interface{}
+	if err := json.Unmarshal(body, &activity); err != nil {
+		return body
+	}
+
+	for _, key := range []string{"attachment", "icon", "image"} {
+		delete(activity, key)
+	}
+	if object, ok := activity["object"].(map[string]interface{}); ok {
+		for _, key := range []string{"attachment", "icon", "image"} {
+			delete(object, key)
+		}
+	}
+
+	stripped, err := json.Marshal(activity)
+	if err != nil {
+		return body
+	}
+	return stripped
+}
+
+// ParseDomainBlocksCSV reads a domain blocklist in Mastodon's CSV format
+// Files without a header are read as a plain list of domains to suspend
+func ParseDomainBlocksCSV(r io.Reader) ([]domain.DomainBlock, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+
+	// Column positions, defaulting to the Mastodon column order
+	columns := map[string]int{"domain": 0, "severity": 1, "reject_media": 2, "public_comment": 4}
+	if len(records) > 0 && strings.HasPrefix(records[0][0], "#") {
+		columns = make(map[string]int)
+		for i, name := range records[0] {
+			columns[strings.TrimPrefix(strings.TrimSpace(name), "#")] = i
+		}
+		records = records[1:]
+		if _, ok := columns["domain"]; !ok {
+			return nil, fmt.Errorf("CSV header has no #domain column")
+		}
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var blocks []domain.DomainBlock
+	for _, record := range records {
+		domainName := strings.ToLower(field(record, "domain"))
+		if domainName == "" {
+			continue
+		}
+
+		severity := strings.ToLower(field(record, "severity"))
+		switch severity {
+		case domain.DomainBlockSilence, domain.DomainBlockSuspend, domain.DomainBlockNoop:
+		case "":
+			severity = domain.DomainBlockSuspend
+		default:
+			return nil, fmt.Errorf("unknown severity %q for %s", severity, domainName)
+		}
+
+		rejectMedia, _ := strconv.ParseBool(field(record, "reject_media"))
+
+		blocks = append(blocks, domain.DomainBlock{
+			Id:            uuid.New(),
+			Domain:        domainName,
+			Severity:      severity,
+			RejectMedia:   rejectMedia,
+			PublicComment: field(record, "public_comment"),
+			CreatedAt:     time.Now(),
+		})
+	}
+	return blocks, nil
+}
+
+// WriteDomainBlocksCSV writes a domain blocklist in Mastodon's CSV format
+func WriteDomainBlocksCSV(w io.Writer, blocks []domain.DomainBlock) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(domainBlocksCSVHeader); err != nil {
+		return err
+	}
+	for _, block := range blocks {
+		record := []string{
+			block.Domain,
+			block.Severity,
+			strconv.FormatBool(block.RejectMedia),
+			"false",
+			block.PublicComment,
+			"false",
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ImportDomainBlocks adds or updates the domain blocks listed in a CSV file
+// Returns the number of imported blocks
+func ImportDomainBlocks(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	blocks, err := ParseDomainBlocksCSV(file)
+	if err != nil {
+		return 0, err
+	}
+
+	database := db.GetDB()
+	for i := range blocks {
+		if err := database.UpsertDomainBlock(&blocks[i]); err != nil {
+			return i, fmt.Errorf("failed to import %s: %w", blocks[i].Domain, err)
+		}
+	}
+	return len(blocks), nil
+}
+
+// ExportDomainBlocks writes all domain blocks to a CSV file
+// Returns the number of exported blocks
+func ExportDomainBlocks(path string) (int, error) {
+	err, blocks := db.GetDB().ReadDomainBlocks()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read domain blocks: %w", err)
+	}
+	if blocks == nil {
+		blocks = &[]domain.DomainBlock{}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if err := WriteDomainBlocksCSV(file, *blocks); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return len(*blocks), nil
+}
@@ -0,0 +1,108 @@
+package activitypub
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/deemkeen/stegodon/domain"
+)
+
+func TestParseDomainBlocksCSV(t *testing.T) {
+	input := `#domain,#severity,#reject_media,#reject_reports,#public_comment,#obfuscate
+spam.example,suspend,false,false,Spam,false
+Loud.Example,silence,true,false,,false
+pics.example,noop,true,false,Too many pictures,false
+`
+	blocks, err := ParseDomainBlocksCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseDomainBlocksCSV failed: %v", err)
+	}
+	if len(blocks) != 3 {
+		t.Fatalf("Expected 3 blocks, got %d", len(blocks))
+	}
+
+	if blocks[0].Domain != "spam.example" || blocks[0].Severity != domain.DomainBlockSuspend || blocks[0].PublicComment != "Spam" {
+		t.Errorf("Unexpected first block: %+v", blocks[0])
+	}
+	if blocks[1].Domain != "loud.example" || blocks[1].Severity != domain.DomainBlockSilence || !blocks[1].RejectMedia {
+		t.Errorf("Unexpected second block: %+v", blocks[1])
+	}
+	if blocks[2].Severity != domain.DomainBlockNoop || !blocks[2].RejectMedia {
+		t.Errorf("Unexpected third block: %+v", blocks[2])
+	}
+}
+
+func TestParseDomainBlocksCSVWithoutHeader(t *testing.T) {
+	blocks, err := ParseDomainBlocksCSV(strings.NewReader("spam.example\nother.example,silence\n"))
+	if err != nil {
+		t.Fatalf("ParseDomainBlocksCSV failed: %v", err)
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("Expected 2 blocks, got %d", len(blocks))
+	}
+	if blocks[0].Severity != domain.DomainBlockSuspend {
+		t.Errorf("Expected plain domains to be suspended, got %s", blocks[0].Severity)
+	}
+	if blocks[1].Severity != domain.DomainBlockSilence {
+		t.Errorf("Expected silence, got %s", blocks[1].Severity)
+	}
+}
+
+func TestParseDomainBlocksCSVUnknownSeverity(t *testing.T) {
+	_, err := ParseDomainBlocksCSV(strings.NewReader("#domain,#severity\nspam.example,obliterate\n"))
+	if err == nil {
+		t.Error("Expected an error for an unknown severity")
+	}
+}
+
+func TestWriteDomainBlocksCSVRoundTrip(t *testing.T) {
+	blocks := []domain.DomainBlock{
+		{Domain: "spam.example", Severity: domain.DomainBlockSuspend, PublicComment: "Spam, lots of it"},
+		{Domain: "pics.example", Severity: domain.DomainBlockNoop, RejectMedia: true},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteDomainBlocksCSV(&buf, blocks); err != nil {
+		t.Fatalf("WriteDomainBlocksCSV failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "#domain,#severity,#reject_media,#reject_reports,#public_comment,#obfuscate\n") {
+		t.Errorf("Expected Mastodon header, got %q", buf.String())
+	}
+
+	parsed, err := ParseDomainBlocksCSV(&buf)
+	if err != nil {
+		t.Fatalf("ParseDomainBlocksCSV failed: %v", err)
+	}
+	if len(parsed) != 2 {
+		t.Fatalf("Expected 2 blocks, got %d", len(parsed))
+	}
+	if parsed[0].PublicComment != "Spam, lots of it" {
+		t.Errorf("Expected comment to survive the round trip, got %q", parsed[0].PublicComment)
+	}
+	if !parsed[1].RejectMedia || parsed[1].Severity != domain.DomainBlockNoop {
+		t.Errorf("Unexpected second block: %+v", parsed[1])
+	}
+}
+
+func TestStripMedia(t *testing.T) {
+	body := []byte(`{"type":"Create","actor":"https://pics.example/users/bob","object":{"type":"Note","content":"hi","attachment":[{"type":"Image","url":"https://pics.example/a.png"}]}}`)
+
+	var activity map[string]interface{}
+	if err := json.Unmarshal(stripMedia(body), &activity); err != nil {
+		t.Fatalf("Failed to parse stripped activity: %v", err)
+	}
+	object := activity["object"].(map[string]interface{})
+	if _, ok := object["attachment"]; ok {
+		t.Error("Expected attachments to be removed")
+	}
+	if object["content"] != "hi" {
+		t.Errorf("Expected content to be kept, got %v", object["content"])
+	}
+
+	invalid := []byte("not json")
+	if string(stripMedia(invalid)) != "not json" {
+		t.Error("Expected unparsable bodies to be returned unchanged")
+	}
+}
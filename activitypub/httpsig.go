@@ -1,23 +1,61 @@
 package activitypub
 
 import (
-	"code.superseriousbusiness.org/httpsig"
+	"crypto/ed25519"
 	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"net/http"
+	"regexp"
 	"strings"
+	"time"
+
+	"code.superseriousbusiness.org/httpsig"
+	"github.com/deemkeen/stegodon/domain"
 )
 
+// Window for the Date of signed requests: how old it may be, and how far ahead of our clock
+const (
+	signatureMaxAge    = 12 * time.Hour
+	signatureClockSkew = time.Hour
+)
+
+// Errors returned when checking signed inbox requests
+var (
+	ErrDigestMismatch  = errors.New("digest does not match body")
+	ErrRequestExpired  = errors.New("request date outside the allowed window")
+	ErrUnsignedHeaders = errors.New("signature does not cover the required headers")
+)
+
+// Headers the signatures of an inbox POST and of a fetch must cover
+var (
+	requiredPostHeaders = []string{"(request-target)", "host", "date", "digest"}
+	requiredGetHeaders  = []string{"(request-target)", "host", "date"}
+)
+
+// Matches the headers parameter of a Signature header
+var signedHeadersPattern = regexp.MustCompile(`(?:^|,)\s*headers\s*=\s*"([^"]*)"`)
+
 // SignRequest signs an outgoing HTTP request with the given private key
 // keyId format: "https://example.com/users/alice#main-key"
+// The digest is only signed for requests carrying a Digest header, GETs have none
 func SignRequest(req *http.Request, privateKey *rsa.PrivateKey, keyId string) error {
+	headers := []string{"(request-target)", "host", "date"}
+	if req.Header.Get("Digest") != "" {
+		headers = append(headers, "digest")
+	}
+
 	// Create signer with required headers
 	signer, _, err := httpsig.NewSigner(
 		[]httpsig.Algorithm{httpsig.RSA_SHA256},
 		httpsig.DigestSha256,
-		[]string{"(request-target)", "host", "date", "digest"},
+		headers,
 		httpsig.Signature,
 		0,
 	)
@@ -30,45 +68,227 @@ func SignRequest(req *http.Request, privateKey *rsa.PrivateKey, keyId string) er
 }
 
 // VerifyRequest verifies the HTTP signature on an incoming request
+// Both RFC 9421 and draft-cavage signatures are accepted, with RSA or Ed25519 keys.
 // Returns the actor URI if valid, error otherwise
 func VerifyRequest(req *http.Request, publicKeyPem string) (string, error) {
-	// Create verifier from request
+	// Parse public key from PEM
+	pubKey, err := parseVerificationKey(publicKeyPem)
+	if err != nil {
+		return "", err
+	}
+
+	var keyId string
+	if isMessageSignature(req) {
+		sig, err := parseMessageSignature(req)
+		if err != nil {
+			return "", err
+		}
+		if err := verifyMessageSignature(req, sig, pubKey); err != nil {
+			return "", fmt.Errorf("signature verification failed: %w", err)
+		}
+		keyId = sig.KeyId
+	} else {
+		// Create verifier from request
+		verifier, err := httpsig.NewVerifier(req)
+		if err != nil {
+			return "", fmt.Errorf("failed to create verifier: %w", err)
+		}
+
+		// Verify the signature
+		algorithm := httpsig.RSA_SHA256
+		if _, ok := pubKey.(ed25519.PublicKey); ok {
+			algorithm = httpsig.ED25519
+		}
+		if err := verifier.Verify(pubKey, algorithm); err != nil {
+			return "", fmt.Errorf("signature verification failed: %w", err)
+		}
+
+		// Extract keyId from signature header
+		keyId = verifier.KeyId()
+	}
+
+	// Extract actor URI from keyId
+	// keyId is usually "https://example.com/users/alice#main-key"
+	// We want "https://example.com/users/alice"
+	actorURI := strings.Split(keyId, "#")[0]
+
+	return actorURI, nil
+}
+
+// verifyActorRequest verifies the HTTP signature on an incoming request with the actor's key its keyId names
+func verifyActorRequest(req *http.Request, actor *domain.RemoteAccount) (string, error) {
+	keyId, err := signatureKeyId(req)
+	if err != nil {
+		return "", err
+	}
+	return VerifyRequest(req, actorKeyPem(actor, keyId))
+}
+
+// signatureKeyId returns the keyId of the RFC 9421 or draft-cavage signature of a request
+func signatureKeyId(req *http.Request) (string, error) {
+	if isMessageSignature(req) {
+		sig, err := parseMessageSignature(req)
+		if err != nil {
+			return "", err
+		}
+		return sig.KeyId, nil
+	}
+
 	verifier, err := httpsig.NewVerifier(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to create verifier: %w", err)
 	}
+	return verifier.KeyId(), nil
+}
 
-	// Parse public key from PEM
-	block, _ := pem.Decode([]byte(publicKeyPem))
-	if block == nil {
-		return "", fmt.Errorf("failed to parse PEM block")
+// checkSignedPost checks what the signature of an inbox POST can't check by itself:
+// the signature covers the body digest and date, the digest matches the body and the date is recent
+func checkSignedPost(req *http.Request, body []byte, now time.Time) error {
+	if isMessageSignature(req) {
+		return checkMessageSignedPost(req, body, now)
+	}
+	if err := requireSignedHeaders(req.Header.Get("Signature"), requiredPostHeaders); err != nil {
+		return err
+	}
+	if err := VerifyDigest(req.Header.Get("Digest"), body); err != nil {
+		return err
 	}
+	return CheckDate(req.Header.Get("Date"), now)
+}
 
-	pubKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+// checkSignedGet checks that the signature of a fetch covers the request and its date, and that the date is recent
+func checkSignedGet(req *http.Request, now time.Time) error {
+	if isMessageSignature(req) {
+		return checkMessageSignedGet(req, now)
+	}
+	if err := requireSignedHeaders(req.Header.Get("Signature"), requiredGetHeaders); err != nil {
+		return err
+	}
+	return CheckDate(req.Header.Get("Date"), now)
+}
+
+// requireSignedHeaders checks that a Signature header lists all the given headers as signed
+func requireSignedHeaders(signature string, required []string) error {
+	match := signedHeadersPattern.FindStringSubmatch(signature)
+	if match == nil {
+		return fmt.Errorf("%w: no headers listed", ErrUnsignedHeaders)
+	}
+
+	signed := make(map[string]bool)
+	for _, header := range strings.Fields(strings.ToLower(match[1])) {
+		signed[header] = true
+	}
+	for _, header := range required {
+		if !signed[header] {
+			return fmt.Errorf("%w: %s is not signed", ErrUnsignedHeaders, header)
+		}
+	}
+	return nil
+}
+
+// VerifyDigest checks an RFC 3230 Digest header against a request body
+// SHA-256 and SHA-512 digests are supported, every supported digest in the header must match
+func VerifyDigest(header string, body []byte) error {
+	if header == "" {
+		return fmt.Errorf("%w: missing Digest header", ErrDigestMismatch)
+	}
+
+	checked := 0
+	for _, part := range strings.Split(header, ",") {
+		algorithm, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+
+		var sum []byte
+		switch strings.ToUpper(algorithm) {
+		case "SHA-256":
+			hash := sha256.Sum256(body)
+			sum = hash[:]
+		case "SHA-512":
+			hash := sha512.Sum512(body)
+			sum = hash[:]
+		default:
+			continue
+		}
+
+		expected, err := base64.StdEncoding.DecodeString(value)
+		if err != nil || subtle.ConstantTimeCompare(expected, sum) != 1 {
+			return fmt.Errorf("%w: %s", ErrDigestMismatch, algorithm)
+		}
+		checked++
+	}
+
+	if checked == 0 {
+		return fmt.Errorf("%w: no supported algorithm in %q", ErrDigestMismatch, header)
+	}
+	return nil
+}
+
+// CheckDate checks that the Date header of a request is within the allowed window around now
+func CheckDate(header string, now time.Time) error {
+	if header == "" {
+		return fmt.Errorf("%w: missing Date header", ErrRequestExpired)
+	}
+	date, err := http.ParseTime(header)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse public key: %w", err)
+		return fmt.Errorf("%w: invalid Date %q", ErrRequestExpired, header)
+	}
+	if date.Before(now.Add(-signatureMaxAge)) || date.After(now.Add(signatureClockSkew)) {
+		return fmt.Errorf("%w: %s", ErrRequestExpired, header)
 	}
+	return nil
+}
 
-	rsaPubKey, ok := pubKey.(*rsa.PublicKey)
-	if !ok {
-		return "", fmt.Errorf("not an RSA public key")
+// Errors returned by VerifyFetch
+var (
+	ErrMissingSignature = errors.New("missing signature")
+	ErrFetchForbidden   = errors.New("requesting actor is blocked")
+)
+
+// VerifyFetch checks the HTTP signature of a GET for one of a user's resources in secure mode
+// The signature has to be recent and made with a key of the actor it resolves to.
+// Requests from suspended domains and from actors or domains the user blocked are refused
+// with ErrFetchForbidden. Returns the actor URI of the verified key
+func VerifyFetch(req *http.Request, username string) (string, error) {
+	if req.Header.Get("Signature") == "" {
+		return "", ErrMissingSignature
+	}
+	if err := checkSignedGet(req, time.Now()); err != nil {
+		return "", err
 	}
 
-	// Verify the signature
-	err = verifier.Verify(rsaPubKey, httpsig.RSA_SHA256)
+	keyId, err := signatureKeyId(req)
 	if err != nil {
-		return "", fmt.Errorf("signature verification failed: %w", err)
+		return "", err
 	}
 
-	// Extract keyId from signature header
-	keyId := verifier.KeyId()
+	// Check blocks before fetching anything from the requesting server
+	keyDocument := strings.SplitN(keyId, "#", 2)[0]
+	if IsDomainSuspended(keyDocument) || isBlockedByUser(username, keyDocument) {
+		return keyDocument, ErrFetchForbidden
+	}
 
-	// Extract actor URI from keyId
-	// keyId is usually "https://example.com/users/alice#main-key"
-	// We want "https://example.com/users/alice"
-	actorURI := strings.Split(keyId, "#")[0]
+	actorURI, err := resolveKeyOwner(keyId)
+	if err != nil {
+		return keyDocument, err
+	}
+	if actorURI != keyDocument && isBlockedByUser(username, actorURI) {
+		return actorURI, ErrFetchForbidden
+	}
 
-	return actorURI, nil
+	remoteActor, err := GetOrFetchActor(actorURI)
+	if err != nil {
+		return actorURI, fmt.Errorf("failed to fetch actor %s: %w", actorURI, err)
+	}
+	if !keyOwnedBy(keyId, remoteActor.ActorURI) {
+		return actorURI, fmt.Errorf("%w: key %s, actor %s", ErrKeyOwnerMismatch, keyId, remoteActor.ActorURI)
+	}
+
+	if _, err := verifyActorRequest(req, remoteActor); err != nil {
+		return actorURI, err
+	}
+	return remoteActor.ActorURI, nil
 }
 
 // ParsePrivateKey converts PEM string to *rsa.PrivateKey
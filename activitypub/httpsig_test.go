@@ -5,10 +5,13 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
+	"crypto/sha512"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/pem"
+	"errors"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 )
@@ -402,3 +405,207 @@ func TestKeyIdWithoutFragment(t *testing.T) {
 		t.Errorf("Expected actor URI '%s', got '%s'", keyId, actorURI)
 	}
 }
+
+func TestVerifyFetchMissingSignature(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://example.com/users/alice/outbox", nil)
+
+	_, err := VerifyFetch(req, "alice")
+	if err != ErrMissingSignature {
+		t.Errorf("Expected ErrMissingSignature, got %v", err)
+	}
+}
+
+// signedFetch returns a GET for uri signed with keyId at the given date
+func signedFetch(t *testing.T, uri string, privateKey *rsa.PrivateKey, keyId string, date time.Time) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest("GET", uri, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Date", date.UTC().Format(http.TimeFormat))
+	req.Header.Set("Host", req.URL.Host)
+	if err := SignRequest(req, privateKey, keyId); err != nil {
+		t.Fatalf("SignRequest failed: %v", err)
+	}
+	return req
+}
+
+func TestVerifyFetchStaleDate(t *testing.T) {
+	privateKey, _, err := generateTestKeyPair()
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	req := signedFetch(t, "https://example.com/users/alice/outbox", privateKey,
+		"https://remote.example/users/bob#main-key", time.Now().Add(-24*time.Hour))
+	if _, err := VerifyFetch(req, "alice"); !errors.Is(err, ErrRequestExpired) {
+		t.Errorf("Expected ErrRequestExpired for a day old fetch, got %v", err)
+	}
+}
+
+func TestVerifyFetchPathKeyId(t *testing.T) {
+	privateKey, publicKey, err := generateTestKeyPair()
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	publicPEM, err := publicKeyToPEM(publicKey)
+	if err != nil {
+		t.Fatalf("Failed to convert public key to PEM: %v", err)
+	}
+
+	// GoToSocial publishes keys at <actor>/main-key, the key document names the owner
+	server := newActorServer(t, func(serverURL string, path string) map[string]interface{} {
+		owner := serverURL + "/users/carol"
+		key := map[string]interface{}{
+			"id":           owner + "/main-key",
+			"owner":        owner,
+			"publicKeyPem": publicPEM,
+		}
+		switch path {
+		case "/users/carol":
+			return map[string]interface{}{
+				"id":                owner,
+				"type":              "Person",
+				"preferredUsername": "carol",
+				"inbox":             owner + "/inbox",
+				"publicKey":         key,
+			}
+		case "/users/carol/main-key", "/users/mallory/main-key":
+			return key
+		}
+		return nil
+	})
+	actorURI := server.URL + "/users/carol"
+
+	req := signedFetch(t, "https://example.com/users/alice/outbox", privateKey, actorURI+"/main-key", time.Now())
+	verified, err := VerifyFetch(req, "alice")
+	if err != nil {
+		t.Fatalf("VerifyFetch failed: %v", err)
+	}
+	if verified != actorURI {
+		t.Errorf("Expected actor %s, got %s", actorURI, verified)
+	}
+
+	// A key living under one actor can't be claimed by another
+	req = signedFetch(t, "https://example.com/users/alice/outbox", privateKey, server.URL+"/users/mallory/main-key", time.Now())
+	if _, err := VerifyFetch(req, "alice"); !errors.Is(err, ErrKeyOwnerMismatch) {
+		t.Errorf("Expected ErrKeyOwnerMismatch for a key owned by another actor, got %v", err)
+	}
+}
+
+func TestVerifyDigest(t *testing.T) {
+	body := []byte(`{"type":"Create","object":{}}`)
+	sum512 := sha512.Sum512(body)
+	sha512Digest := "SHA-512=" + base64.StdEncoding.EncodeToString(sum512[:])
+
+	tests := []struct {
+		name    string
+		header  string
+		wantErr bool
+	}{
+		{"SHA-256", calculateDigest(body), false},
+		{"SHA-512", sha512Digest, false},
+		{"both", calculateDigest(body) + ", " + sha512Digest, false},
+		{"lowercase algorithm", "sha-256=" + strings.TrimPrefix(calculateDigest(body), "SHA-256="), false},
+		{"unsupported ignored", "MD5=abc, " + calculateDigest(body), false},
+		{"missing", "", true},
+		{"only unsupported", "MD5=abc", true},
+		{"substituted body", calculateDigest([]byte(`{"type":"Delete"}`)), true},
+		{"one of two wrong", calculateDigest(body) + ", SHA-512=" + base64.StdEncoding.EncodeToString([]byte("wrong")), true},
+		{"invalid base64", "SHA-256=***", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyDigest(tt.header, body)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyDigest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrDigestMismatch) {
+				t.Errorf("Expected ErrDigestMismatch, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckDate(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		header  string
+		wantErr bool
+	}{
+		{"now", now.Format(http.TimeFormat), false},
+		{"an hour old", now.Add(-time.Hour).Format(http.TimeFormat), false},
+		{"slightly ahead", now.Add(30 * time.Minute).Format(http.TimeFormat), false},
+		{"too old", now.Add(-13 * time.Hour).Format(http.TimeFormat), true},
+		{"too far ahead", now.Add(2 * time.Hour).Format(http.TimeFormat), true},
+		{"missing", "", true},
+		{"invalid", "yesterday", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckDate(tt.header, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckDate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckSignedPost(t *testing.T) {
+	privateKey, _, err := generateTestKeyPair()
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	body := []byte(`{"type":"Follow"}`)
+	newRequest := func(digest bool) *http.Request {
+		req, _ := http.NewRequest("POST", "https://example.com/inbox", bytes.NewReader(body))
+		req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+		req.Header.Set("Host", "example.com")
+		if digest {
+			req.Header.Set("Digest", calculateDigest(body))
+		}
+		if err := SignRequest(req, privateKey, "https://remote.example/users/bob#main-key"); err != nil {
+			t.Fatalf("SignRequest failed: %v", err)
+		}
+		return req
+	}
+
+	if err := checkSignedPost(newRequest(true), body, time.Now()); err != nil {
+		t.Errorf("Expected a signed POST to pass, got %v", err)
+	}
+
+	// Signed without the digest, the body could be swapped
+	if err := checkSignedPost(newRequest(false), body, time.Now()); !errors.Is(err, ErrUnsignedHeaders) {
+		t.Errorf("Expected ErrUnsignedHeaders, got %v", err)
+	}
+
+	if err := checkSignedPost(newRequest(true), []byte(`{"type":"Delete"}`), time.Now()); !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("Expected ErrDigestMismatch, got %v", err)
+	}
+
+	// A captured request replayed a day later
+	if err := checkSignedPost(newRequest(true), body, time.Now().Add(24*time.Hour)); !errors.Is(err, ErrRequestExpired) {
+		t.Errorf("Expected ErrRequestExpired, got %v", err)
+	}
+}
+
+func TestRequireSignedHeaders(t *testing.T) {
+	signature := `keyId="https://remote.example/users/bob#main-key",algorithm="rsa-sha256",headers="(request-target) host date digest",signature="abc"`
+	if err := requireSignedHeaders(signature, requiredPostHeaders); err != nil {
+		t.Errorf("Expected all headers to be signed, got %v", err)
+	}
+
+	partial := `keyId="https://remote.example/users/bob#main-key",headers="(request-target) host date",signature="abc"`
+	if err := requireSignedHeaders(partial, requiredPostHeaders); !errors.Is(err, ErrUnsignedHeaders) {
+		t.Errorf("Expected ErrUnsignedHeaders, got %v", err)
+	}
+
+	if err := requireSignedHeaders(`keyId="x",signature="abc"`, requiredPostHeaders); !errors.Is(err, ErrUnsignedHeaders) {
+		t.Errorf("Expected ErrUnsignedHeaders without a headers list, got %v", err)
+	}
+}
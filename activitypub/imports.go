@@ -0,0 +1,174 @@
+package activitypub
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/util"
+	"github.com/google/uuid"
+)
+
+// ErrImportRunning is returned when an account starts an import while another one is still running
+var ErrImportRunning = errors.New("an import is already running")
+
+// ImportError is an entry of an account list that couldn't be imported
+type ImportError struct {
+	Entry string
+	Err   string
+}
+
+// ImportJob is the progress of an account list import running in the background
+type ImportJob struct {
+	List       string
+	Total      int
+	Processed  int
+	Imported   int
+	Skipped    int // Accounts already followed or blocked
+	Errors     []ImportError
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Finished   bool
+}
+
+// importJobs keeps the latest import of each account
+var importJobs = struct {
+	sync.Mutex
+	byAccount map[uuid.UUID]*ImportJob
+}{byAccount: make(map[uuid.UUID]*ImportJob)}
+
+// errAlreadyImported marks entries that are skipped because they are already followed or blocked
+var errAlreadyImported = errors.New("already imported")
+
+// StartImport imports the entries of an account list in the background
+// The returned channel is closed when the import finished, its progress is available through ImportStatus
+func StartImport(localAccount *domain.Account, list string, entries []string, conf *util.AppConfig) (<-chan struct{}, error) {
+	if _, err := AccountListByName(list); err != nil {
+		return nil, err
+	}
+	return startImportJob(localAccount.Id, list, entries, func(entry string) error {
+		return importEntry(localAccount, list, entry, conf)
+	})
+}
+
+// startImportJob runs importOne for each entry in the background, recording the progress of the account
+func startImportJob(accountId uuid.UUID, list string, entries []string, importOne func(entry string) error) (<-chan struct{}, error) {
+	importJobs.Lock()
+	if job, ok := importJobs.byAccount[accountId]; ok && !job.Finished {
+		importJobs.Unlock()
+		return nil, ErrImportRunning
+	}
+	job := &ImportJob{List: list, Total: len(entries), StartedAt: time.Now()}
+	importJobs.byAccount[accountId] = job
+	importJobs.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, entry := range entries {
+			err := importOne(entry)
+
+			importJobs.Lock()
+			job.Processed++
+			switch {
+			case err == nil:
+				job.Imported++
+			case errors.Is(err, errAlreadyImported):
+				job.Skipped++
+			default:
+				job.Errors = append(job.Errors, ImportError{Entry: entry, Err: err.Error()})
+			}
+			importJobs.Unlock()
+		}
+
+		importJobs.Lock()
+		job.Finished = true
+		job.FinishedAt = time.Now()
+		log.Printf("Imported %d of %d entries of %s (%d skipped, %d failed)", job.Imported, job.Total, list, job.Skipped, len(job.Errors))
+		importJobs.Unlock()
+	}()
+	return done, nil
+}
+
+// ImportStatus returns a copy of the latest import of an account, nil if it didn't import anything yet
+func ImportStatus(accountId uuid.UUID) *ImportJob {
+	importJobs.Lock()
+	defer importJobs.Unlock()
+
+	job, ok := importJobs.byAccount[accountId]
+	if !ok {
+		return nil
+	}
+	status := *job
+	status.Errors = append([]ImportError(nil), job.Errors...)
+	return &status
+}
+
+// importEntry follows or blocks the account or domain of one entry of an account list
+func importEntry(localAccount *domain.Account, list string, entry string, conf *util.AppConfig) error {
+	database := db.GetDB()
+
+	switch list {
+	case ListFollowing:
+		// Local users are followed directly
+		if username, ok := localAddress(entry, conf); ok {
+			err, target := database.ReadAccByUsername(username)
+			if err != nil || target == nil {
+				return fmt.Errorf("no local user %s", username)
+			}
+			if target.Id == localAccount.Id {
+				return errAlreadyImported
+			}
+			if following, err := database.IsFollowingLocal(localAccount.Id, target.Id); err == nil && following {
+				return errAlreadyImported
+			}
+			return database.CreateLocalFollow(localAccount.Id, target.Id)
+		}
+
+		actorURI, err := ResolveActorHandle(entry)
+		if err != nil {
+			return err
+		}
+		if followsActor(localAccount, actorURI) {
+			return errAlreadyImported
+		}
+		return SendFollow(localAccount, actorURI, conf)
+
+	case ListBlockedAccounts:
+		actorURI, err := ResolveActorHandle(entry)
+		if err != nil {
+			return err
+		}
+		if blocked, err := database.IsBlocked(localAccount.Id, actorURI, ""); err == nil && blocked {
+			return errAlreadyImported
+		}
+		return SendBlock(localAccount, actorURI, conf)
+
+	case ListBlockedDomains:
+		err := BlockDomain(localAccount, entry)
+		if errors.Is(err, ErrDomainAlreadyBlocked) {
+			return errAlreadyImported
+		}
+		return err
+	}
+	return fmt.Errorf("unknown list %q", list)
+}
+
+// localAddress returns the username of an address or actor URI of a user on this server
+func localAddress(entry string, conf *util.AppConfig) (string, bool) {
+	localActors := fmt.Sprintf("https://%s/users/", conf.Conf.SslDomain)
+	if username, ok := strings.CutPrefix(entry, localActors); ok {
+		return username, username != ""
+	}
+
+	username, host, ok := strings.Cut(strings.TrimPrefix(entry, "@"), "@")
+	if !ok || username == "" || !strings.EqualFold(host, conf.Conf.SslDomain) {
+		return "", false
+	}
+	return username, true
+}
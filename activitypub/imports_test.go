@@ -0,0 +1,84 @@
+package activitypub
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/deemkeen/stegodon/util"
+	"github.com/google/uuid"
+)
+
+func TestStartImportJob(t *testing.T) {
+	accountId := uuid.New()
+	if ImportStatus(accountId) != nil {
+		t.Fatal("Expected no import before the first one")
+	}
+
+	release := make(chan struct{})
+	done, err := startImportJob(accountId, ListFollowing, []string{"a@x.example", "b@x.example", "c@x.example"}, func(entry string) error {
+		<-release
+		switch entry {
+		case "b@x.example":
+			return errAlreadyImported
+		case "c@x.example":
+			return errors.New("webfinger failed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("startImportJob failed: %v", err)
+	}
+
+	// Only one import at a time per account
+	if _, err := startImportJob(accountId, ListFollowing, nil, nil); !errors.Is(err, ErrImportRunning) {
+		t.Errorf("Expected ErrImportRunning, got %v", err)
+	}
+
+	close(release)
+	<-done
+
+	job := ImportStatus(accountId)
+	if !job.Finished || job.Total != 3 || job.Processed != 3 {
+		t.Fatalf("Expected a finished import of 3 entries, got %+v", job)
+	}
+	if job.Imported != 1 || job.Skipped != 1 || len(job.Errors) != 1 {
+		t.Errorf("Expected 1 imported, 1 skipped and 1 failed, got %+v", job)
+	}
+	if job.Errors[0].Entry != "c@x.example" || job.Errors[0].Err != "webfinger failed" {
+		t.Errorf("Expected the failed entry with its error, got %+v", job.Errors[0])
+	}
+
+	// A finished import can be followed by the next one
+	done, err = startImportJob(accountId, ListBlockedDomains, nil, func(string) error { return nil })
+	if err != nil {
+		t.Fatalf("Expected a new import after the last one finished, got %v", err)
+	}
+	<-done
+	if job := ImportStatus(accountId); job.List != ListBlockedDomains {
+		t.Errorf("Expected the latest import, got %s", job.List)
+	}
+}
+
+func TestLocalAddress(t *testing.T) {
+	conf := &util.AppConfig{}
+	conf.Conf.SslDomain = "stegodon.example"
+
+	tests := []struct {
+		entry    string
+		username string
+		ok       bool
+	}{
+		{"alice@stegodon.example", "alice", true},
+		{"@alice@Stegodon.Example", "alice", true},
+		{"https://stegodon.example/users/alice", "alice", true},
+		{"alice@mastodon.example", "", false},
+		{"https://mastodon.example/users/alice", "", false},
+		{"@stegodon.example", "", false},
+	}
+	for _, tt := range tests {
+		username, ok := localAddress(tt.entry, conf)
+		if username != tt.username || ok != tt.ok {
+			t.Errorf("localAddress(%q) = %q, %v, want %q, %v", tt.entry, username, ok, tt.username, tt.ok)
+		}
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/deemkeen/stegodon/db"
@@ -32,14 +34,73 @@ type FollowActivity struct {
 	Object  string      `json:"object"` // URI of the person being followed
 }
 
-// HandleInbox processes incoming ActivityPub activities
+// HandleInbox accepts activities delivered to a user's inbox
+// Verified activities are stored and processed in the background by the inbox worker
 func HandleInbox(w http.ResponseWriter, r *http.Request, username string, conf *util.AppConfig) {
+	body, activity, ok := readActivity(w, r)
+	if !ok || rejectReplay(w, activity, username) {
+		return
+	}
+
+	// Drop activities from actors and domains the user blocked, without fetching them
+	if isBlockedByUser(username, activity.Actor) {
+		log.Printf("Inbox: Dropping %s from blocked actor %s", activity.Type, activity.Actor)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if _, ok := verifyActivity(w, r, activity); !ok {
+		return
+	}
+
+	acceptActivity(w, body, activity, username)
+}
+
+// HandleSharedInbox accepts activities delivered to the shared inbox
+// The activity is verified and stored once, the inbox worker processes it for every local recipient
+func HandleSharedInbox(w http.ResponseWriter, r *http.Request, conf *util.AppConfig) {
+	body, activity, ok := readActivity(w, r)
+	if !ok || rejectReplay(w, activity, "") {
+		return
+	}
+
+	if _, ok := verifyActivity(w, r, activity); !ok {
+		return
+	}
+
+	acceptActivity(w, body, activity, "")
+}
+
+// acceptActivity stores a verified activity for the inbox worker and answers 202 Accepted
+func acceptActivity(w http.ResponseWriter, body []byte, activity *Activity, username string) {
+	// Drop attachments and images from instances whose media is rejected
+	if rejectsMedia(activity.Actor) {
+		body = stripMedia(body)
+	}
+	// Boosted posts from other servers are fetched from their origin
+	body = stripForeignObject(body, activity)
+
+	if err := storeActivity(body, activity, username); err != nil {
+		log.Printf("Inbox: Failed to store activity: %v", err)
+		http.Error(w, "Failed to store activity", http.StatusInternalServerError)
+		return
+	}
+	wakeInboxWorker()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// readActivity reads and parses an incoming activity and refuses suspended instances
+// The body must match the signed Digest header and the signed Date must be recent,
+// and the activity must follow the same-origin rules of checkOrigin.
+// On failure the error response is written and ok is false
+func readActivity(w http.ResponseWriter, r *http.Request) ([]byte, *Activity, bool) {
 	// Verify HTTP signature
 	signature := r.Header.Get("Signature")
 	if signature == "" {
 		log.Printf("Inbox: Missing HTTP signature")
 		http.Error(w, "Missing signature", http.StatusUnauthorized)
-		return
+		return nil, nil, false
 	}
 
 	// Read request body
@@ -47,39 +108,156 @@ func HandleInbox(w http.ResponseWriter, r *http.Request, username string, conf *
 	if err != nil {
 		log.Printf("Inbox: Failed to read body: %v", err)
 		http.Error(w, "Failed to read body", http.StatusBadRequest)
-		return
+		return nil, nil, false
 	}
 	defer r.Body.Close()
 
+	// The signature only protects the body through a signed digest, and old requests must not be replayed
+	if err := checkSignedPost(r, body, time.Now()); err != nil {
+		log.Printf("Inbox: Rejecting request: %v", err)
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return nil, nil, false
+	}
+
 	// Parse activity
 	var activity Activity
 	if err := json.Unmarshal(body, &activity); err != nil {
 		log.Printf("Inbox: Failed to parse activity: %v", err)
 		http.Error(w, "Invalid activity", http.StatusBadRequest)
-		return
+		return nil, nil, false
 	}
 
 	log.Printf("Inbox: Received %s from %s", activity.Type, activity.Actor)
 
+	// An actor can only create, update, delete or undo what belongs to its own server
+	if err := checkOrigin(body, &activity); err != nil {
+		log.Printf("Inbox: Rejecting %s %s from %s: %v", activity.Type, activity.ID, activity.Actor, err)
+		http.Error(w, "Object not owned by actor", http.StatusForbidden)
+		return nil, nil, false
+	}
+
+	// Refuse everything from suspended instances
+	if IsDomainSuspended(activity.Actor) {
+		log.Printf("Inbox: Rejecting %s from suspended domain of %s", activity.Type, activity.Actor)
+		http.Error(w, "Domain is blocked", http.StatusForbidden)
+		return nil, nil, false
+	}
+
+	return body, &activity, true
+}
+
+// rejectReplay refuses activities that were already received, before anything is fetched or stored
+// Only a delivery of a stored activity to the personal inbox of a user it wasn't received for yet is accepted.
+// When the activity is rejected, the error response is written and true is returned
+func rejectReplay(w http.ResponseWriter, activity *Activity, username string) bool {
+	activityURI, _ := activityURIs(activity)
+	database := db.GetDB()
+	err, existing := database.ReadActivityByURI(activityURI)
+	if err != nil || existing == nil {
+		return false
+	}
+	if !existing.Local && username != "" && username != existing.InboxUsername && !isActivityRecipient(existing.Id, username) {
+		return false
+	}
+
+	log.Printf("Inbox: Rejecting replayed %s %s from %s", activity.Type, activityURI, activity.Actor)
+	http.Error(w, "Activity already received", http.StatusConflict)
+	return true
+}
+
+// isActivityRecipient reports whether a stored activity was already received for a local user
+func isActivityRecipient(activityId uuid.UUID, username string) bool {
+	err, recipients := db.GetDB().ReadActivityRecipients(activityId)
+	if err != nil {
+		// Rather refuse a delivery than risk handling it twice
+		return true
+	}
+	for _, recipient := range *recipients {
+		if recipient.Username == username {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyActivity fetches the sending actor and checks the HTTP signature with its key
+// The request must be signed with a key of the actor the activity claims to be from.
+// When the cached key doesn't verify the signature, the actor is refetched once in a while.
+// On failure the error response is written and ok is false
+func verifyActivity(w http.ResponseWriter, r *http.Request, activity *Activity) (*domain.RemoteAccount, bool) {
+	if err := checkKeyOwner(r, activity.Actor); err != nil {
+		log.Printf("Inbox: Rejecting %s %s: %v", activity.Type, activity.ID, err)
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return nil, false
+	}
+
 	// Fetch remote actor to verify and cache
 	remoteActor, err := GetOrFetchActor(activity.Actor)
 	if err != nil {
 		log.Printf("Inbox: Failed to fetch actor %s: %v", activity.Actor, err)
 		http.Error(w, "Failed to verify actor", http.StatusBadRequest)
-		return
+		return nil, false
 	}
 
 	// Verify HTTP signature with actor's public key
-	_, err = VerifyRequest(r, remoteActor.PublicKeyPem)
+	keyOwner, err := verifyActorRequest(r, remoteActor)
 	if err != nil {
-		log.Printf("Inbox: Signature verification failed: %v", err)
+		// The cached key may be outdated when the actor rotated it, try once more with a fresh one
+		if refreshed, ok := refreshActorKey(remoteActor); ok {
+			remoteActor = refreshed
+			keyOwner, err = verifyActorRequest(r, remoteActor)
+		}
+	}
+	if err != nil {
+		log.Printf("Inbox: Signature verification failed for %s: %v", activity.Actor, err)
 		http.Error(w, "Invalid signature", http.StatusUnauthorized)
-		return
+		return nil, false
 	}
+	if !keyOwnedBy(keyOwner, remoteActor.ActorURI) {
+		log.Printf("Inbox: Rejecting %s %s: signing key %s doesn't belong to fetched actor %s", activity.Type, activity.ID, keyOwner, remoteActor.ActorURI)
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	return remoteActor, true
+}
+
+// storeActivity stores an incoming activity for processing, once per activity URI
+// username is the owner of the personal inbox it was delivered to, empty for the shared inbox.
+// When an already stored activity arrives at the personal inbox of another user,
+// it is processed for that user only
+func storeActivity(body []byte, activity *Activity, username string) error {
+	activityURI, objectURI := activityURIs(activity)
 
-	// Store activity in database
 	database := db.GetDB()
+	if err, existing := database.ReadActivityByURI(activityURI); err == nil && existing != nil {
+		if existing.Local || username == "" || existing.InboxUsername == username {
+			log.Printf("Inbox: Activity %s already stored", activityURI)
+			return nil
+		}
+		log.Printf("Inbox: Activity %s arrived at the inbox of %s, processing it for them too", activityURI, username)
+		return database.AddActivityRecipient(existing.Id, username)
+	}
+
+	activityRecord := &domain.Activity{
+		Id:            uuid.New(),
+		ActivityURI:   activityURI,
+		ActivityType:  activity.Type,
+		ActorURI:      activity.Actor,
+		ObjectURI:     objectURI,
+		RawJSON:       string(body),
+		Processed:     false,
+		Local:         false,
+		CreatedAt:     time.Now(),
+		InboxUsername: username,
+	}
+
+	return database.CreateActivity(activityRecord)
+}
 
+// activityURIs returns the URI an activity is stored under and the URI of its object
+// Activities without an ID are stored under the ID of their object
+func activityURIs(activity *Activity) (string, string) {
 	// Extract ObjectURI from the activity's object field
 	objectURI := ""
 	if activity.Object != nil {
@@ -95,77 +273,76 @@ func HandleInbox(w http.ResponseWriter, r *http.Request, username string, conf *
 		}
 	}
 
-	activityRecord := &domain.Activity{
-		Id:           uuid.New(),
-		ActivityURI:  activity.ID,
-		ActivityType: activity.Type,
-		ActorURI:     activity.Actor,
-		ObjectURI:    objectURI,
-		RawJSON:      string(body),
-		Processed:    false,
-		Local:        false,
-		CreatedAt:    time.Now(),
-	}
-
-	if err := database.CreateActivity(activityRecord); err != nil {
-		log.Printf("Inbox: Failed to store activity: %v", err)
-		// Don't fail the request, we'll process it anyway
+	// Fall back to the object ID if the activity ID is missing
+	activityURI := activity.ID
+	if activityURI == "" {
+		activityURI = objectURI
 	}
+	return activityURI, objectURI
+}
 
-	// Process activity based on type
+// processActivity handles a verified activity for one local recipient
+func processActivity(body []byte, activity *Activity, activityRecord *domain.Activity, username string, remoteActor *domain.RemoteAccount, conf *util.AppConfig) error {
+	var err error
 	switch activity.Type {
 	case "Follow":
-		if err := handleFollowActivity(body, username, remoteActor, conf); err != nil {
-			log.Printf("Inbox: Failed to handle Follow: %v", err)
-			http.Error(w, "Failed to process Follow", http.StatusInternalServerError)
-			return
-		}
+		err = handleFollowActivity(body, username, remoteActor, conf)
 	case "Undo":
-		if err := handleUndoActivity(body, username, remoteActor); err != nil {
-			log.Printf("Inbox: Failed to handle Undo: %v", err)
-			http.Error(w, "Failed to process Undo", http.StatusInternalServerError)
-			return
-		}
+		err = handleUndoActivity(body, username, remoteActor)
 	case "Create":
-		if err := handleCreateActivity(body, username); err != nil {
-			log.Printf("Inbox: Failed to handle Create: %v", err)
-			http.Error(w, "Failed to process Create", http.StatusInternalServerError)
-			return
-		}
+		err = handleCreateActivity(body, username, activityRecord, conf)
 	case "Like":
-		if err := handleLikeActivity(body, username); err != nil {
-			log.Printf("Inbox: Failed to handle Like: %v", err)
-			http.Error(w, "Failed to process Like", http.StatusInternalServerError)
-			return
-		}
+		err = handleLikeActivity(body, remoteActor, conf)
+	case "Announce":
+		err = handleAnnounceActivity(body, username, activityRecord, remoteActor, conf)
 	case "Accept":
 		// Accept activities are confirmations of Follow requests
-		if err := handleAcceptActivity(body, username); err != nil {
+		if err := handleAcceptActivity(body, username, remoteActor); err != nil {
 			log.Printf("Inbox: Failed to handle Accept: %v", err)
 			// Don't fail the request
 		}
-	case "Update":
-		if err := handleUpdateActivity(body, username); err != nil {
-			log.Printf("Inbox: Failed to handle Update: %v", err)
-			http.Error(w, "Failed to process Update", http.StatusInternalServerError)
-			return
+	case "Reject":
+		// Reject activities decline our Follow requests
+		if err := handleRejectActivity(body, username, remoteActor); err != nil {
+			log.Printf("Inbox: Failed to handle Reject: %v", err)
+			// Don't fail the request
 		}
+	case "Update":
+		err = handleUpdateActivity(body, username)
 	case "Delete":
-		if err := handleDeleteActivity(body, username); err != nil {
-			log.Printf("Inbox: Failed to handle Delete: %v", err)
-			http.Error(w, "Failed to process Delete", http.StatusInternalServerError)
-			return
-		}
+		err = handleDeleteActivity(body, username)
+	case "Move":
+		err = handleMoveActivity(body, username, remoteActor, conf)
 	default:
 		log.Printf("Inbox: Unsupported activity type: %s", activity.Type)
 	}
 
-	// Mark activity as processed
-	activityRecord.Processed = true
-	database.UpdateActivity(activityRecord)
+	if err != nil {
+		log.Printf("Inbox: Failed to handle %s: %v", activity.Type, err)
+		return err
+	}
+	return nil
+}
+
+// isBlockedByUser reports whether the local user blocked the actor or its domain
+func isBlockedByUser(username string, actorURI string) bool {
+	database := db.GetDB()
+	err, localAccount := database.ReadAccByUsername(username)
+	if err != nil || localAccount == nil {
+		return false
+	}
 
-	// Return 202 Accepted
-	w.WriteHeader(http.StatusAccepted)
+	parsed, err := url.Parse(actorURI)
+	if err != nil {
+		return false
+	}
+
+	blocked, err := database.IsBlocked(localAccount.Id, actorURI, parsed.Hostname())
+	if err != nil {
+		log.Printf("Inbox: Failed to check blocks of %s: %v", username, err)
+		return false
+	}
+	return blocked
 }
 
 // handleFollowActivity processes a Follow activity
@@ -193,7 +370,7 @@ func handleFollowActivity(body []byte, username string, remoteActor *domain.Remo
 		AccountId:       remoteActor.Id,  // The follower
 		TargetAccountId: localAccount.Id, // The target being followed
 		URI:             follow.ID,
-		Accepted:        true, // Auto-accept for now
+		Accepted:        !localAccount.Locked, // Locked accounts approve follows manually
 		CreatedAt:       time.Now(),
 	}
 
@@ -201,6 +378,12 @@ func handleFollowActivity(body []byte, username string, remoteActor *domain.Remo
 		return fmt.Errorf("failed to create follow: %w", err)
 	}
 
+	if localAccount.Locked {
+		// Accept or Reject is sent once the user answers the request
+		log.Printf("Inbox: Follow from %s@%s is pending approval", remoteActor.Username, remoteActor.Domain)
+		return nil
+	}
+
 	// Send Accept activity
 	if err := SendAccept(localAccount, remoteActor, follow.ID, conf); err != nil {
 		return fmt.Errorf("failed to send Accept: %w", err)
@@ -240,21 +423,60 @@ func handleUndoActivity(body []byte, username string, remoteActor *domain.Remote
 		log.Printf("Inbox: Removed follow from %s@%s", remoteActor.Username, remoteActor.Domain)
 	}
 
+	if obj.Type == "Like" {
+		// Remove the stored like, only the liker may undo it
+		database := db.GetDB()
+		err, like := database.ReadLikeByURI(obj.ID)
+		if err == nil && like != nil && like.AccountId != remoteActor.Id {
+			return fmt.Errorf("%w: %s can't undo the like %s of another actor", errActivityRejected, remoteActor.ActorURI, obj.ID)
+		}
+		if err := database.DeleteLikeByURI(obj.ID); err != nil {
+			return fmt.Errorf("failed to delete like: %w", err)
+		}
+		log.Printf("Inbox: Removed like %s from %s@%s", obj.ID, remoteActor.Username, remoteActor.Domain)
+	}
+
+	if obj.Type == "Announce" {
+		// Remove the stored share and the boost from the timeline, only the booster may undo them
+		database := db.GetDB()
+		err, share := database.ReadShareByURI(obj.ID)
+		if err == nil && share != nil && share.AccountId != remoteActor.Id {
+			return fmt.Errorf("%w: %s can't undo the boost %s of another actor", errActivityRejected, remoteActor.ActorURI, obj.ID)
+		}
+		err, announce := database.ReadActivityByURI(obj.ID)
+		if err == nil && announce != nil && announce.ActorURI != remoteActor.ActorURI {
+			return fmt.Errorf("%w: %s can't undo the boost %s by %s", errActivityRejected, remoteActor.ActorURI, obj.ID, announce.ActorURI)
+		}
+
+		if err := database.DeleteShareByURI(obj.ID); err != nil {
+			return fmt.Errorf("failed to delete share: %w", err)
+		}
+		if announce != nil {
+			if err := database.DeleteActivity(announce.Id); err != nil {
+				return fmt.Errorf("failed to delete announce: %w", err)
+			}
+		}
+		log.Printf("Inbox: Removed boost %s from %s@%s", obj.ID, remoteActor.Username, remoteActor.Domain)
+	}
+
 	return nil
 }
 
 // handleCreateActivity processes a Create activity (incoming post/note)
-func handleCreateActivity(body []byte, username string) error {
+// Posts are accepted from followed actors, and replies to local notes from anyone
+func handleCreateActivity(body []byte, username string, activityRecord *domain.Activity, conf *util.AppConfig) error {
 	var create struct {
 		ID     string `json:"id"`
 		Type   string `json:"type"`
 		Actor  string `json:"actor"`
 		Object struct {
-			ID           string `json:"id"`
-			Type         string `json:"type"`
-			Content      string `json:"content"`
-			Published    string `json:"published"`
-			AttributedTo string `json:"attributedTo"`
+			ID           string        `json:"id"`
+			Type         string        `json:"type"`
+			Content      string        `json:"content"`
+			Published    string        `json:"published"`
+			AttributedTo string        `json:"attributedTo"`
+			InReplyTo    string        `json:"inReplyTo"`
+			Tag          []ActivityTag `json:"tag"`
 		} `json:"object"`
 	}
 
@@ -286,56 +508,202 @@ func handleCreateActivity(body []byte, username string) error {
 	// Check if we follow this actor
 	err, follow := database.ReadFollowByAccountIds(localAccount.Id, remoteActor.Id)
 	if err != nil || follow == nil {
-		log.Printf("Inbox: Rejecting Create from %s - not following (err: %v, follow: %v)", create.Actor, err, follow)
-		return fmt.Errorf("not following this actor")
+		// Replies to our own notes and posts mentioning the local user are accepted from anyone
+		localActorURI := fmt.Sprintf("https://%s/users/%s", conf.Conf.SslDomain, localAccount.Username)
+		if _, isLocalReply := parseLocalNoteId(create.Object.InReplyTo, conf); isLocalReply {
+			log.Printf("Inbox: Accepted reply to %s from %s@%s", create.Object.InReplyTo, remoteActor.Username, remoteActor.Domain)
+		} else if mentionsActor(create.Object.Tag, localActorURI) {
+			log.Printf("Inbox: Accepted mention of %s from %s@%s", localAccount.Username, remoteActor.Username, remoteActor.Domain)
+		} else {
+			log.Printf("Inbox: Rejecting Create from %s - not following (err: %v, follow: %v)", create.Actor, err, follow)
+			return fmt.Errorf("%w: not following this actor", errActivityRejected)
+		}
+	} else {
+		log.Printf("Inbox: Accepted post from followed user %s@%s (follow accepted: %v)", remoteActor.Username, remoteActor.Domain, follow.Accepted)
 	}
 
-	log.Printf("Inbox: Accepted post from followed user %s@%s (follow accepted: %v)", remoteActor.Username, remoteActor.Domain, follow.Accepted)
+	// Index hashtags so the post shows up on tag views
+	if tags := hashtagNames(create.Object.Tag); len(tags) > 0 {
+		if err := database.CreateActivityTags(activityRecord.Id, tags); err != nil {
+			log.Printf("Inbox: Failed to index hashtags of %s: %v", activityRecord.ActivityURI, err)
+		}
+	}
 
-	// Use the activity ID, not the object ID
-	activityURI := create.ID
-	if activityURI == "" {
-		// Fallback to object ID if activity ID is missing
-		activityURI = create.Object.ID
+	return nil
+}
+
+// handleLikeActivity processes a Like activity on one of our notes
+func handleLikeActivity(body []byte, remoteActor *domain.RemoteAccount, conf *util.AppConfig) error {
+	var like struct {
+		ID     string      `json:"id"`
+		Type   string      `json:"type"`
+		Actor  string      `json:"actor"`
+		Object interface{} `json:"object"`
+	}
+	if err := json.Unmarshal(body, &like); err != nil {
+		return fmt.Errorf("failed to parse Like activity: %w", err)
 	}
 
-	// Check if we already have this activity
-	err, existingActivity := database.ReadActivityByURI(activityURI)
-	if err == nil && existingActivity != nil {
-		log.Printf("Inbox: Activity %s already exists, skipping", activityURI)
+	// Object is usually the note URI, but some servers embed the note
+	var objectURI string
+	switch obj := like.Object.(type) {
+	case string:
+		objectURI = obj
+	case map[string]interface{}:
+		if id, ok := obj["id"].(string); ok {
+			objectURI = id
+		}
+	}
+
+	noteId, ok := parseLocalNoteId(objectURI, conf)
+	if !ok {
+		log.Printf("Inbox: Ignoring Like for non-local object %s", objectURI)
 		return nil
 	}
 
-	// Store the incoming post activity
-	activity := &domain.Activity{
-		Id:           uuid.New(),
-		ActivityURI:  activityURI,
-		ActivityType: "Create",
-		ActorURI:     create.Actor,
-		ObjectURI:    create.Object.ID,
-		RawJSON:      string(body),
-		Processed:    true,
-		Local:        false,
-		CreatedAt:    time.Now(),
+	database := db.GetDB()
+	err, note := database.ReadNoteId(noteId)
+	if err != nil || note == nil {
+		log.Printf("Inbox: Liked note %s not found, ignoring", noteId)
+		return nil
 	}
 
-	if err := database.CreateActivity(activity); err != nil {
-		log.Printf("Inbox: Failed to store Create activity: %v", err)
-		// Don't fail the request
+	likeRecord := &domain.Like{
+		Id:        uuid.New(),
+		AccountId: remoteActor.Id,
+		NoteId:    note.Id,
+		URI:       like.ID,
+		CreatedAt: time.Now(),
+	}
+	if err := database.CreateLike(likeRecord); err != nil {
+		return fmt.Errorf("failed to store like: %w", err)
 	}
 
+	log.Printf("Inbox: %s@%s liked note %s", remoteActor.Username, remoteActor.Domain, note.Id)
 	return nil
 }
 
-// handleLikeActivity processes a Like activity
-func handleLikeActivity(body []byte, username string) error {
-	log.Printf("Inbox: Processing Like activity for %s", username)
-	// TODO: Store like in likes table
+// handleAnnounceActivity processes an Announce (boost) activity
+// Boosts of local notes are recorded as shares. Boosts by followed actors are kept
+// in the stored activity with the original object embedded, so the timeline can show them.
+func handleAnnounceActivity(body []byte, username string, activityRecord *domain.Activity, remoteActor *domain.RemoteAccount, conf *util.AppConfig) error {
+	var announce struct {
+		ID     string      `json:"id"`
+		Type   string      `json:"type"`
+		Actor  string      `json:"actor"`
+		Object interface{} `json:"object"`
+	}
+	if err := json.Unmarshal(body, &announce); err != nil {
+		return fmt.Errorf("failed to parse Announce activity: %w", err)
+	}
+
+	// Object is usually just the URI of the boosted post
+	var objectURI string
+	var object map[string]interface{}
+	switch obj := announce.Object.(type) {
+	case string:
+		objectURI = obj
+	case map[string]interface{}:
+		object = obj
+		if id, ok := obj["id"].(string); ok {
+			objectURI = id
+		}
+	}
+	if objectURI == "" {
+		return fmt.Errorf("%w: Announce without object", errActivityRejected)
+	}
+
+	database := db.GetDB()
+
+	// Record boosts of our own notes
+	if noteId, ok := parseLocalNoteId(objectURI, conf); ok {
+		err, note := database.ReadNoteId(noteId)
+		if err != nil || note == nil {
+			log.Printf("Inbox: Boosted note %s not found, ignoring", noteId)
+			return nil
+		}
+
+		shareRecord := &domain.Share{
+			Id:        uuid.New(),
+			AccountId: remoteActor.Id,
+			NoteId:    note.Id,
+			URI:       announce.ID,
+			ObjectURI: objectURI,
+			CreatedAt: time.Now(),
+		}
+		if err := database.CreateShare(shareRecord); err != nil {
+			return fmt.Errorf("failed to store share: %w", err)
+		}
+		log.Printf("Inbox: %s@%s boosted note %s", remoteActor.Username, remoteActor.Domain, note.Id)
+
+		if object == nil {
+			object = localNoteObject(note, conf)
+		}
+	}
+
+	// Only boosts by followed actors show up in the timeline
+	err, localAccount := database.ReadAccByUsername(username)
+	if err != nil {
+		return fmt.Errorf("local account not found: %w", err)
+	}
+	err, follow := database.ReadFollowByAccountIds(localAccount.Id, remoteActor.Id)
+	if err != nil || follow == nil {
+		return nil
+	}
+
+	// Fetch the original object when only a URI was given
+	if object == nil {
+		fetched, err := FetchRemoteObject(objectURI)
+		if err != nil {
+			log.Printf("Inbox: Failed to fetch boosted object %s: %v", objectURI, err)
+			return nil
+		}
+		object = fetched
+	}
+
+	embedded := map[string]interface{}{
+		"id":     announce.ID,
+		"type":   "Announce",
+		"actor":  announce.Actor,
+		"object": object,
+	}
+	activityRecord.RawJSON = mustMarshal(embedded)
+	activityRecord.ObjectURI = objectURI
+	if err := database.UpdateActivity(activityRecord); err != nil {
+		return fmt.Errorf("failed to store boosted object: %w", err)
+	}
+
+	log.Printf("Inbox: Stored boost of %s by followed user %s@%s", objectURI, remoteActor.Username, remoteActor.Domain)
 	return nil
 }
 
+// localNoteObject builds a minimal Note object for one of our notes
+func localNoteObject(note *domain.Note, conf *util.AppConfig) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           fmt.Sprintf("https://%s/notes/%s", conf.Conf.SslDomain, note.Id.String()),
+		"type":         "Note",
+		"attributedTo": fmt.Sprintf("https://%s/users/%s", conf.Conf.SslDomain, note.CreatedBy),
+		"content":      util.MarkdownLinksToHTML(note.Message),
+		"published":    note.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// parseLocalNoteId extracts the note ID from one of our note URIs
+// Example: "https://example.com/notes/<uuid>" -> <uuid>
+func parseLocalNoteId(objectURI string, conf *util.AppConfig) (uuid.UUID, bool) {
+	prefix := fmt.Sprintf("https://%s/notes/", conf.Conf.SslDomain)
+	if !strings.HasPrefix(objectURI, prefix) {
+		return uuid.Nil, false
+	}
+	noteId, err := uuid.Parse(strings.TrimPrefix(objectURI, prefix))
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return noteId, true
+}
+
 // handleAcceptActivity processes an Accept activity (response to Follow)
-func handleAcceptActivity(body []byte, username string) error {
+func handleAcceptActivity(body []byte, username string, remoteActor *domain.RemoteAccount) error {
 	var accept struct {
 		Type   string          `json:"type"`
 		Actor  string          `json:"actor"`
@@ -354,6 +722,11 @@ func handleAcceptActivity(body []byte, username string) error {
 		return fmt.Errorf("failed to parse Accept object: %w", err)
 	}
 
+	follow, err := answeredFollow(followObj.ID, accept.Actor, remoteActor)
+	if err != nil || follow == nil {
+		return err
+	}
+
 	// Update the follow to accepted=true
 	database := db.GetDB()
 	if err := database.AcceptFollowByURI(followObj.ID); err != nil {
@@ -364,6 +737,55 @@ func handleAcceptActivity(body []byte, username string) error {
 	return nil
 }
 
+// handleRejectActivity processes a Reject activity (response to our Follow)
+func handleRejectActivity(body []byte, username string, remoteActor *domain.RemoteAccount) error {
+	var reject struct {
+		Type   string          `json:"type"`
+		Actor  string          `json:"actor"`
+		Object json.RawMessage `json:"object"`
+	}
+
+	if err := json.Unmarshal(body, &reject); err != nil {
+		return fmt.Errorf("failed to parse Reject activity: %w", err)
+	}
+
+	// Parse the embedded Follow object to get the follow ID
+	var followObj struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(reject.Object, &followObj); err != nil {
+		return fmt.Errorf("failed to parse Reject object: %w", err)
+	}
+
+	follow, err := answeredFollow(followObj.ID, reject.Actor, remoteActor)
+	if err != nil || follow == nil {
+		return err
+	}
+
+	// A rejected follow is dropped entirely
+	database := db.GetDB()
+	if err := database.DeleteFollowByURI(followObj.ID); err != nil {
+		return fmt.Errorf("failed to delete follow: %w", err)
+	}
+
+	log.Printf("Inbox: Follow %s was rejected by %s", followObj.ID, reject.Actor)
+	return nil
+}
+
+// answeredFollow returns the follow an Accept or Reject by actor answers, nil if it's unknown
+// Only the followed actor may answer a follow, so a follow of someone else is rejected
+func answeredFollow(followURI string, actor string, remoteActor *domain.RemoteAccount) (*domain.Follow, error) {
+	err, follow := db.GetDB().ReadFollowByURI(followURI)
+	if err != nil || follow == nil {
+		log.Printf("Inbox: Unknown follow %s answered by %s", followURI, actor)
+		return nil, nil
+	}
+	if actor != remoteActor.ActorURI || follow.TargetAccountId != remoteActor.Id {
+		return nil, fmt.Errorf("%w: %s can't answer the follow %s of another actor", errActivityRejected, remoteActor.ActorURI, followURI)
+	}
+	return follow, nil
+}
+
 // handleUpdateActivity processes an Update activity (e.g., profile updates, post edits)
 func handleUpdateActivity(body []byte, username string) error {
 	var update struct {
@@ -392,6 +814,9 @@ func handleUpdateActivity(body []byte, username string) error {
 
 	switch objectType.Type {
 	case "Person":
+		if objectType.ID != update.Actor {
+			return fmt.Errorf("%w: %s can't update the profile of %s", errActivityRejected, update.Actor, objectType.ID)
+		}
 		// Profile update - re-fetch and update cached actor
 		remoteActor, err := GetOrFetchActor(update.Actor)
 		if err != nil {
@@ -407,6 +832,9 @@ func handleUpdateActivity(body []byte, username string) error {
 			log.Printf("Inbox: Note/Article %s not found for update, ignoring", objectType.ID)
 			return nil
 		}
+		if existingActivity.ActorURI != update.Actor {
+			return fmt.Errorf("%w: %s can't update %s by %s", errActivityRejected, update.Actor, objectType.ID, existingActivity.ActorURI)
+		}
 
 		// Update the stored activity with new content but keep activity_type as 'Create'
 		// so it still shows up in the timeline
@@ -457,7 +885,7 @@ func handleDeleteActivity(body []byte, username string) error {
 	}
 
 	if objectURI == "" {
-		return fmt.Errorf("could not determine object URI from Delete activity")
+		return fmt.Errorf("%w: could not determine object URI from Delete activity", errActivityRejected)
 	}
 
 	log.Printf("Inbox: Processing Delete for %s from %s", objectURI, delete.Actor)
@@ -484,6 +912,9 @@ func handleDeleteActivity(body []byte, username string) error {
 			log.Printf("Inbox: Activity with object %s not found for deletion, ignoring", objectURI)
 			return nil
 		}
+		if activity.ActorURI != delete.Actor {
+			return fmt.Errorf("%w: %s can't delete %s by %s", errActivityRejected, delete.Actor, objectURI, activity.ActorURI)
+		}
 
 		// Delete the activity from the database
 		if err := database.DeleteActivity(activity.Id); err != nil {
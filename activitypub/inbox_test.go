@@ -2,8 +2,17 @@ package activitypub
 
 import (
 	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/util"
+	"github.com/google/uuid"
 )
 
 func TestActivityUnmarshal(t *testing.T) {
@@ -665,3 +674,211 @@ func TestHTMLContentInNote(t *testing.T) {
 		t.Error("Content should preserve HTML formatting")
 	}
 }
+
+func TestParseLocalNoteId(t *testing.T) {
+	conf := &util.AppConfig{}
+	conf.Conf.SslDomain = "example.com"
+
+	noteId := uuid.New()
+
+	tests := []struct {
+		name      string
+		objectURI string
+		wantId    uuid.UUID
+		wantOk    bool
+	}{
+		{"local note", "https://example.com/notes/" + noteId.String(), noteId, true},
+		{"remote note", "https://other.com/notes/" + noteId.String(), uuid.Nil, false},
+		{"invalid id", "https://example.com/notes/not-a-uuid", uuid.Nil, false},
+		{"empty", "", uuid.Nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok := parseLocalNoteId(tt.objectURI, conf)
+			if ok != tt.wantOk {
+				t.Errorf("Expected ok=%v, got %v", tt.wantOk, ok)
+			}
+			if id != tt.wantId {
+				t.Errorf("Expected id %s, got %s", tt.wantId, id)
+			}
+		})
+	}
+}
+
+func TestLocalNoteObject(t *testing.T) {
+	conf := &util.AppConfig{}
+	conf.Conf.SslDomain = "example.com"
+
+	note := &domain.Note{
+		Id:        uuid.New(),
+		CreatedBy: "alice",
+		Message:   "Check [this](https://example.org)",
+		CreatedAt: time.Now(),
+	}
+
+	obj := localNoteObject(note, conf)
+
+	if obj["id"] != "https://example.com/notes/"+note.Id.String() {
+		t.Errorf("Unexpected id: %v", obj["id"])
+	}
+	if obj["attributedTo"] != "https://example.com/users/alice" {
+		t.Errorf("Unexpected attributedTo: %v", obj["attributedTo"])
+	}
+	if obj["type"] != "Note" {
+		t.Errorf("Expected type Note, got %v", obj["type"])
+	}
+	if !strings.Contains(obj["content"].(string), `<a href="https://example.org"`) {
+		t.Errorf("Expected Markdown link converted to HTML, got %v", obj["content"])
+	}
+}
+
+func TestRejectReplayPerRecipient(t *testing.T) {
+	body := `{"id":"https://remote.example/follows/replay","type":"Follow","actor":"https://remote.example/users/bob","object":"https://example.com/users/alice"}`
+	record := storeTestActivity(t, body, "https://remote.example/follows/replay", "alice")
+	activity := &Activity{ID: record.ActivityURI, Type: "Follow", Actor: record.ActorURI}
+
+	rejected := func(username string) bool {
+		return rejectReplay(httptest.NewRecorder(), activity, username)
+	}
+
+	if !rejected("alice") {
+		t.Error("Expected a replay to the same inbox to be rejected")
+	}
+	if !rejected("") {
+		t.Error("Expected a replay to the shared inbox to be rejected")
+	}
+	if rejected("carol") {
+		t.Error("Expected a first delivery to another inbox to be accepted")
+	}
+
+	// The extra recipient is recorded without resetting the stored activity
+	if err := db.GetDB().MarkActivityProcessed(record.Id, ""); err != nil {
+		t.Fatalf("MarkActivityProcessed failed: %v", err)
+	}
+	if err := storeActivity([]byte(body), activity, "carol"); err != nil {
+		t.Fatalf("storeActivity failed: %v", err)
+	}
+	if !rejected("carol") {
+		t.Error("Expected a second delivery to the same inbox to be rejected")
+	}
+
+	err, recipients := db.GetDB().ReadActivityRecipients(record.Id)
+	if err != nil {
+		t.Fatalf("ReadActivityRecipients failed: %v", err)
+	}
+	if len(*recipients) != 1 || (*recipients)[0].Username != "carol" || (*recipients)[0].Processed {
+		t.Errorf("Expected carol as the only pending recipient, got %+v", *recipients)
+	}
+
+	w := httptest.NewRecorder()
+	rejectReplay(w, activity, "alice")
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+func TestUndoLikeOfAnotherActor(t *testing.T) {
+	bob := cacheTestActor(t)
+	mallory := &domain.RemoteAccount{Id: uuid.New(), Username: "mallory", Domain: "remote.example", ActorURI: "https://remote.example/users/mallory"}
+
+	database := db.GetDB()
+	likeURI := "https://remote.example/users/bob#likes/1"
+	if err := database.CreateLike(&domain.Like{Id: uuid.New(), AccountId: bob.Id, NoteId: uuid.New(), URI: likeURI, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("CreateLike failed: %v", err)
+	}
+
+	undo := func(actor *domain.RemoteAccount) error {
+		body := `{"type":"Undo","actor":"` + actor.ActorURI + `","object":{"id":"` + likeURI + `","type":"Like"}}`
+		return handleUndoActivity([]byte(body), "alice", actor)
+	}
+
+	if err := undo(mallory); !errors.Is(err, errActivityRejected) {
+		t.Errorf("Expected the Undo of another actor's like to be rejected, got %v", err)
+	}
+	if err, like := database.ReadLikeByURI(likeURI); err != nil || like == nil {
+		t.Fatal("Expected the like to be kept")
+	}
+
+	if err := undo(bob); err != nil {
+		t.Fatalf("Undo by the liker failed: %v", err)
+	}
+	if err, like := database.ReadLikeByURI(likeURI); err == nil && like != nil {
+		t.Error("Expected the like to be removed by its liker")
+	}
+}
+
+func TestUndoAnnounceOfAnotherActor(t *testing.T) {
+	boostURI := "https://remote.example/users/bob/statuses/9/activity"
+	body := `{"id":"` + boostURI + `","type":"Announce","actor":"https://remote.example/users/bob","object":"https://elsewhere.example/notes/1"}`
+	record := storeTestActivity(t, body, boostURI, "alice")
+
+	database := db.GetDB()
+	err, bob := database.ReadRemoteAccountByURI("https://remote.example/users/bob")
+	if err != nil {
+		t.Fatalf("ReadRemoteAccountByURI failed: %v", err)
+	}
+	mallory := &domain.RemoteAccount{Id: uuid.New(), Username: "mallory", Domain: "remote.example", ActorURI: "https://remote.example/users/mallory"}
+
+	undo := func(actor *domain.RemoteAccount) error {
+		body := `{"type":"Undo","actor":"` + actor.ActorURI + `","object":{"id":"` + boostURI + `","type":"Announce"}}`
+		return handleUndoActivity([]byte(body), "alice", actor)
+	}
+
+	if err := undo(mallory); !errors.Is(err, errActivityRejected) {
+		t.Errorf("Expected the Undo of another actor's boost to be rejected, got %v", err)
+	}
+	if err, stored := database.ReadActivityByURI(boostURI); err != nil || stored == nil {
+		t.Fatal("Expected the boost to be kept")
+	}
+
+	if err := undo(bob); err != nil {
+		t.Fatalf("Undo by the booster failed: %v", err)
+	}
+	if err, stored := database.ReadActivityByURI(record.ActivityURI); err == nil && stored != nil {
+		t.Error("Expected the boost to be removed by its booster")
+	}
+}
+
+func TestAnswerFollowOfAnotherActor(t *testing.T) {
+	bob := cacheTestActor(t)
+	mallory := &domain.RemoteAccount{Id: uuid.New(), Username: "mallory", Domain: "remote.example", ActorURI: "https://remote.example/users/mallory"}
+
+	database := db.GetDB()
+	followURI := "https://example.com/activities/" + uuid.New().String()
+	if err := database.CreateFollow(&domain.Follow{Id: uuid.New(), AccountId: uuid.New(), TargetAccountId: bob.Id, URI: followURI, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("CreateFollow failed: %v", err)
+	}
+	answer := func(activityType string, actorURI string, actor *domain.RemoteAccount) error {
+		body := []byte(`{"type":"` + activityType + `","actor":"` + actorURI + `","object":{"id":"` + followURI + `","type":"Follow"}}`)
+		if activityType == "Accept" {
+			return handleAcceptActivity(body, "alice", actor)
+		}
+		return handleRejectActivity(body, "alice", actor)
+	}
+
+	for _, activityType := range []string{"Accept", "Reject"} {
+		if err := answer(activityType, mallory.ActorURI, mallory); !errors.Is(err, errActivityRejected) {
+			t.Errorf("Expected the %s of another actor's follow to be rejected, got %v", activityType, err)
+		}
+		if err := answer(activityType, mallory.ActorURI, bob); !errors.Is(err, errActivityRejected) {
+			t.Errorf("Expected a %s naming another actor to be rejected, got %v", activityType, err)
+		}
+	}
+	if err, follow := database.ReadFollowByURI(followURI); err != nil || follow == nil || follow.Accepted {
+		t.Fatalf("Expected the follow to be kept pending, got %+v (%v)", follow, err)
+	}
+
+	if err := answer("Accept", bob.ActorURI, bob); err != nil {
+		t.Fatalf("Accept by the followed actor failed: %v", err)
+	}
+	if err, follow := database.ReadFollowByURI(followURI); err != nil || follow == nil || !follow.Accepted {
+		t.Errorf("Expected the follow to be accepted, got %+v (%v)", follow, err)
+	}
+	if err := answer("Reject", bob.ActorURI, bob); err != nil {
+		t.Fatalf("Reject by the followed actor failed: %v", err)
+	}
+	if err, follow := database.ReadFollowByURI(followURI); err == nil && follow != nil {
+		t.Error("Expected the follow to be removed")
+	}
+}
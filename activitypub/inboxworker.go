@@ -0,0 +1,232 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/util"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultInboxWorkers = 4
+	inboxBatchSize      = 100
+	inboxPollInterval   = 5 * time.Second
+	inboxMaxAttempts    = 6
+)
+
+// Pause before the next attempt to process an activity, by failed attempts so far
+var inboxBackoff = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour, 4 * time.Hour}
+
+// errActivityRejected marks activities that are refused for good, they aren't retried
+var errActivityRejected = errors.New("activity rejected")
+
+// inboxQueue processes received activities stored with processed = 0 in the background
+type inboxQueue struct {
+	conf *util.AppConfig
+	jobs chan domain.Activity
+	wake chan struct{}
+
+	mu       sync.Mutex
+	inFlight map[uuid.UUID]bool
+}
+
+// receivedActivities is the running inbox queue, nil until StartInboxWorker is called
+var receivedActivities *inboxQueue
+
+// StartInboxWorker starts the background workers that process received activities
+// Activities left unprocessed by a previous run are picked up right away
+func StartInboxWorker(conf *util.AppConfig) {
+	workers := conf.Conf.InboxWorkers
+	if workers <= 0 {
+		workers = defaultInboxWorkers
+	}
+	log.Printf("Starting ActivityPub inbox worker (%d workers)...", workers)
+
+	if count, err := db.GetDB().CountUnprocessedActivities(); err == nil && count > 0 {
+		log.Printf("InboxWorker: Recovering %d unprocessed activities", count)
+	}
+
+	queue := &inboxQueue{
+		conf:     conf,
+		jobs:     make(chan domain.Activity),
+		wake:     make(chan struct{}, 1),
+		inFlight: make(map[uuid.UUID]bool),
+	}
+	for i := 0; i < workers; i++ {
+		go queue.work()
+	}
+
+	go func() {
+		ticker := time.NewTicker(inboxPollInterval)
+		for {
+			queue.dispatch()
+			select {
+			case <-ticker.C:
+			case <-queue.wake:
+			}
+		}
+	}()
+	receivedActivities = queue
+}
+
+// wakeInboxWorker asks the inbox queue to look for new activities without waiting for the next poll
+func wakeInboxWorker() {
+	if receivedActivities == nil {
+		return
+	}
+	select {
+	case receivedActivities.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch hands due activities to the workers, skipping those still in flight
+func (q *inboxQueue) dispatch() {
+	err, activities := db.GetDB().ReadUnprocessedActivities(inboxBatchSize)
+	if err != nil {
+		log.Printf("InboxWorker: Failed to read unprocessed activities: %v", err)
+		return
+	}
+	if activities == nil {
+		return
+	}
+
+	for _, activity := range *activities {
+		if !q.acquire(activity.Id) {
+			continue
+		}
+		q.jobs <- activity
+	}
+}
+
+func (q *inboxQueue) acquire(id uuid.UUID) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.inFlight[id] {
+		return false
+	}
+	q.inFlight[id] = true
+	return true
+}
+
+func (q *inboxQueue) release(id uuid.UUID) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.inFlight, id)
+}
+
+// work processes the activities handed out by dispatch
+func (q *inboxQueue) work() {
+	for activity := range q.jobs {
+		q.process(activity)
+		q.release(activity.Id)
+	}
+}
+
+// process runs the handlers of a received activity and records the outcome
+// Failed activities are retried with backoff until they are given up on
+func (q *inboxQueue) process(record domain.Activity) {
+	database := db.GetDB()
+
+	err := processReceivedActivity(&record, q.conf)
+	if err == nil {
+		if err := database.MarkActivityProcessed(record.Id, ""); err != nil {
+			log.Printf("InboxWorker: Failed to mark %s as processed: %v", record.ActivityURI, err)
+		}
+		return
+	}
+
+	record.Attempts++
+	if permanentFailure(err) || record.Attempts >= inboxMaxAttempts {
+		log.Printf("InboxWorker: Giving up on %s %s after %d attempts: %v", record.ActivityType, record.ActivityURI, record.Attempts, err)
+		database.MarkActivityProcessed(record.Id, err.Error())
+		return
+	}
+
+	backoff := inboxBackoff[min(record.Attempts-1, len(inboxBackoff)-1)]
+	log.Printf("InboxWorker: Processing %s %s failed (attempt %d), retry in %s: %v",
+		record.ActivityType, record.ActivityURI, record.Attempts, backoff, err)
+	database.UpdateActivityAttempt(record.Id, record.Attempts, time.Now().Add(backoff), err.Error())
+}
+
+// permanentFailure reports whether processing an activity again can't succeed
+func permanentFailure(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.Is(err, errActivityRejected) || errors.As(err, &syntaxErr) || errors.As(err, &typeErr)
+}
+
+// processReceivedActivity handles a stored activity for its recipients:
+// the owner of the personal inbox it was delivered to, or everyone it is addressed to for the shared inbox,
+// and the users whose personal inboxes it was delivered to later.
+// Each recipient is recorded once handled, so a retry only runs the handlers of recipients that failed;
+// handlers like Follow and Create have side effects that must not be repeated
+func processReceivedActivity(record *domain.Activity, conf *util.AppConfig) error {
+	body := []byte(record.RawJSON)
+	var activity Activity
+	if err := json.Unmarshal(body, &activity); err != nil {
+		return err
+	}
+
+	remoteActor, err := GetOrFetchActor(record.ActorURI)
+	if err != nil {
+		return err
+	}
+
+	recipients := []string{record.InboxUsername}
+	if record.InboxUsername == "" {
+		recipients = sharedInboxRecipients(body, remoteActor, conf)
+		if len(recipients) == 0 {
+			log.Printf("InboxWorker: No local recipients for %s %s", activity.Type, activity.ID)
+		}
+	}
+
+	database := db.GetDB()
+	err, recorded := database.ReadActivityRecipients(record.Id)
+	if err != nil {
+		return err
+	}
+	handled := make(map[string]bool)
+	for _, recipient := range *recorded {
+		if _, known := handled[recipient.Username]; !known && !slices.Contains(recipients, recipient.Username) {
+			recipients = append(recipients, recipient.Username)
+		}
+		handled[recipient.Username] = recipient.Processed
+	}
+
+	// A recipient that can't be handled doesn't stop the others, the activity is retried if one may succeed later
+	var failed, rejected error
+	for _, username := range recipients {
+		if handled[username] {
+			continue
+		}
+		if isBlockedByUser(username, activity.Actor) {
+			log.Printf("InboxWorker: Dropping %s from %s blocked by %s", activity.Type, activity.Actor, username)
+		} else if err := processActivity(body, &activity, record, username, remoteActor, conf); err != nil {
+			if !permanentFailure(err) {
+				failed = err
+				database.SetActivityRecipient(record.Id, username, false)
+				continue
+			}
+			// Only a personal inbox delivery fails as a whole when it is rejected
+			if record.InboxUsername != "" {
+				rejected = err
+			}
+		}
+		if err := database.SetActivityRecipient(record.Id, username, true); err != nil {
+			log.Printf("InboxWorker: Failed to record %s as handled for %s: %v", record.ActivityURI, username, err)
+		}
+	}
+	if failed != nil {
+		return failed
+	}
+	return rejected
+}
@@ -0,0 +1,117 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/util"
+	"github.com/google/uuid"
+)
+
+func TestPermanentFailure(t *testing.T) {
+	var parsed map[string]interface{}
+	syntaxErr := json.Unmarshal([]byte(`{"type":`), &parsed)
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"rejected", fmt.Errorf("%w: not following this actor", errActivityRejected), true},
+		{"invalid JSON", fmt.Errorf("failed to parse Follow activity: %w", syntaxErr), true},
+		{"database", errors.New("database is locked"), false},
+		{"remote fetch", fmt.Errorf("failed to fetch updated actor: %w", errors.New("timeout")), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := permanentFailure(tt.err); got != tt.want {
+				t.Errorf("permanentFailure() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWakeInboxWorker(t *testing.T) {
+	// Nothing to wake before the worker is started
+	wakeInboxWorker()
+
+	receivedActivities = &inboxQueue{wake: make(chan struct{}, 1)}
+	defer func() { receivedActivities = nil }()
+
+	// Wake-ups don't block while one is pending
+	wakeInboxWorker()
+	wakeInboxWorker()
+	if len(receivedActivities.wake) != 1 {
+		t.Errorf("Expected a single pending wake-up, got %d", len(receivedActivities.wake))
+	}
+}
+
+// cacheTestActor caches the remote actor https://remote.example/users/bob and returns it
+func cacheTestActor(t *testing.T) *domain.RemoteAccount {
+	t.Helper()
+	database := db.GetDB()
+
+	actorURI := "https://remote.example/users/bob"
+	if err, cached := database.ReadRemoteAccountByURI(actorURI); err == nil && cached != nil {
+		return cached
+	}
+	actor := &domain.RemoteAccount{
+		Id:            uuid.New(),
+		Username:      "bob",
+		Domain:        "remote.example",
+		ActorURI:      actorURI,
+		InboxURI:      actorURI + "/inbox",
+		PublicKeyPem:  testKeyPem,
+		LastFetchedAt: time.Now(),
+	}
+	if err := database.CreateRemoteAccount(actor); err != nil {
+		t.Fatalf("CreateRemoteAccount failed: %v", err)
+	}
+	return actor
+}
+
+// storeTestActivity stores a received activity from a cached remote actor, delivered to a personal inbox
+func storeTestActivity(t *testing.T, body string, activityURI string, inboxUsername string) *domain.Activity {
+	t.Helper()
+	database := db.GetDB()
+	actor := cacheTestActor(t)
+
+	record := &domain.Activity{
+		Id:            uuid.New(),
+		ActivityURI:   activityURI,
+		ActivityType:  "Follow",
+		ActorURI:      actor.ActorURI,
+		RawJSON:       body,
+		CreatedAt:     time.Now(),
+		InboxUsername: inboxUsername,
+	}
+	if err := database.CreateActivity(record); err != nil {
+		t.Fatalf("CreateActivity failed: %v", err)
+	}
+	return record
+}
+
+func TestProcessReceivedActivitySkipsHandledRecipients(t *testing.T) {
+	conf := &util.AppConfig{}
+	body := `{"id":"https://remote.example/follows/1","type":"Follow","actor":"https://remote.example/users/bob","object":"https://example.com/users/ghost"}`
+	record := storeTestActivity(t, body, "https://remote.example/follows/1", "ghost")
+
+	// ghost has no account, so handling the Follow fails
+	if err := processReceivedActivity(record, conf); err == nil {
+		t.Fatal("Expected the Follow for a missing account to fail")
+	}
+
+	// A recipient handled by an earlier attempt is skipped on retries
+	if err := db.GetDB().SetActivityRecipient(record.Id, "ghost", true); err != nil {
+		t.Fatalf("SetActivityRecipient failed: %v", err)
+	}
+	if err := processReceivedActivity(record, conf); err != nil {
+		t.Errorf("Expected the handled recipient to be skipped, got %v", err)
+	}
+}
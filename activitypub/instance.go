@@ -0,0 +1,83 @@
+package activitypub
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/util"
+)
+
+// InstanceActorPath is where the server-wide instance actor is published
+const InstanceActorPath = "/actor"
+
+// instanceSigner holds the key used to sign outgoing fetches
+var instanceSigner struct {
+	sync.RWMutex
+	privateKey *rsa.PrivateKey
+	keyId      string
+}
+
+// InstanceActorURI returns the id of the instance actor
+func InstanceActorURI(conf *util.AppConfig) string {
+	return fmt.Sprintf("https://%s%s", conf.Conf.SslDomain, InstanceActorPath)
+}
+
+// InitInstanceActor loads the instance actor's keypair, generating it on first start
+// Once loaded, all fetches of remote actors and objects are signed with it
+func InitInstanceActor(conf *util.AppConfig) error {
+	err, keypair := db.GetDB().ReadOrCreateInstanceKeys()
+	if err != nil {
+		return fmt.Errorf("failed to read instance actor keys: %w", err)
+	}
+
+	privateKey, err := ParsePrivateKey(keypair.Private)
+	if err != nil {
+		return fmt.Errorf("failed to parse instance actor key: %w", err)
+	}
+
+	instanceSigner.Lock()
+	defer instanceSigner.Unlock()
+	instanceSigner.privateKey = privateKey
+	instanceSigner.keyId = InstanceActorURI(conf) + "#main-key"
+
+	log.Printf("Instance actor ready at %s", InstanceActorURI(conf))
+	return nil
+}
+
+// fetchSigned GETs ActivityPub JSON, signed by the instance actor with the scheme negotiated for the server
+func fetchSigned(uri string) (*http.Response, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	return sendSigned(client, uri, func(scheme signatureScheme) (*http.Request, error) {
+		return newSignedGet(uri, scheme)
+	})
+}
+
+// newSignedGet creates a GET request for ActivityPub JSON
+// The request is signed by the instance actor if it was initialized, so servers in
+// authorized fetch mode answer it
+func newSignedGet(uri string, scheme signatureScheme) (*http.Request, error) {
+	req, err := http.NewRequest("GET", uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/activity+json")
+	req.Header.Set("User-Agent", "stegodon/1.0 ActivityPub")
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("Host", req.URL.Host)
+
+	instanceSigner.RLock()
+	defer instanceSigner.RUnlock()
+	if instanceSigner.privateKey != nil {
+		if err := signRequest(req, instanceSigner.privateKey, instanceSigner.keyId, scheme); err != nil {
+			return nil, fmt.Errorf("failed to sign request: %w", err)
+		}
+	}
+
+	return req, nil
+}
@@ -0,0 +1,65 @@
+package activitypub
+
+import "testing"
+
+func TestNewSignedGet(t *testing.T) {
+	privateKey, publicKey, err := generateTestKeyPair()
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	publicPEM, err := publicKeyToPEM(publicKey)
+	if err != nil {
+		t.Fatalf("Failed to convert public key to PEM: %v", err)
+	}
+
+	// Without an instance actor the request stays unsigned
+	req, err := newSignedGet("https://remote.example/users/bob", schemeCavage)
+	if err != nil {
+		t.Fatalf("newSignedGet failed: %v", err)
+	}
+	if req.Header.Get("Signature") != "" {
+		t.Error("Expected an unsigned request")
+	}
+
+	instanceSigner.Lock()
+	instanceSigner.privateKey = privateKey
+	instanceSigner.keyId = "https://example.com/actor#main-key"
+	instanceSigner.Unlock()
+	defer func() {
+		instanceSigner.Lock()
+		instanceSigner.privateKey = nil
+		instanceSigner.keyId = ""
+		instanceSigner.Unlock()
+	}()
+
+	req, err = newSignedGet("https://remote.example/users/bob", schemeCavage)
+	if err != nil {
+		t.Fatalf("newSignedGet failed: %v", err)
+	}
+	if req.Header.Get("Accept") != "application/activity+json" {
+		t.Errorf("Expected ActivityPub Accept header, got %q", req.Header.Get("Accept"))
+	}
+
+	actorURI, err := VerifyRequest(req, publicPEM)
+	if err != nil {
+		t.Fatalf("Signed GET did not verify: %v", err)
+	}
+	if actorURI != "https://example.com/actor" {
+		t.Errorf("Expected instance actor, got %s", actorURI)
+	}
+	// Servers accepting RFC 9421 get a Signature-Input header
+	req, err = newSignedGet("https://remote.example/users/bob", schemeMessageSignature)
+	if err != nil {
+		t.Fatalf("newSignedGet failed: %v", err)
+	}
+	if !isMessageSignature(req) {
+		t.Fatal("Expected an RFC 9421 signature")
+	}
+	actorURI, err = VerifyRequest(req, publicPEM)
+	if err != nil {
+		t.Fatalf("RFC 9421 signed GET did not verify: %v", err)
+	}
+	if actorURI != "https://example.com/actor" {
+		t.Errorf("Expected instance actor, got %s", actorURI)
+	}
+}
@@ -0,0 +1,112 @@
+package activitypub
+
+import (
+	"crypto/sha256"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/google/uuid"
+)
+
+// Minimum time between two refetches of the same actor after failed signature checks
+const keyRefreshInterval = 10 * time.Minute
+
+// keyRefreshLimiter allows refetching an actor's key once per interval,
+// so forged requests can't make us hammer a remote server
+type keyRefreshLimiter struct {
+	mu       sync.Mutex
+	last     map[string]time.Time
+	interval time.Duration
+	now      func() time.Time
+}
+
+var keyRefreshes = newKeyRefreshLimiter(keyRefreshInterval)
+
+func newKeyRefreshLimiter(interval time.Duration) *keyRefreshLimiter {
+	return &keyRefreshLimiter{
+		last:     make(map[string]time.Time),
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// allow reports whether the key of an actor may be refetched now, and if so counts the refetch
+func (l *keyRefreshLimiter) allow(actorURI string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if last, ok := l.last[actorURI]; ok && now.Sub(last) < l.interval {
+		return false
+	}
+
+	// Forget refetches that no longer limit anything
+	for uri, last := range l.last {
+		if now.Sub(last) >= l.interval {
+			delete(l.last, uri)
+		}
+	}
+	l.last[actorURI] = now
+	return true
+}
+
+// refreshActorKey refetches an actor whose cached key didn't verify a signature
+// ok is false when the actor was refetched recently, couldn't be fetched, was served with another id
+// or still has the same key; keys of documents that don't belong to the actor are never recorded as key changes
+func refreshActorKey(cached *domain.RemoteAccount) (*domain.RemoteAccount, bool) {
+	if !keyRefreshes.allow(cached.ActorURI) {
+		log.Printf("Inbox: Key of %s was refreshed recently, not refetching", cached.ActorURI)
+		return nil, false
+	}
+
+	log.Printf("Inbox: Refetching %s after a failed signature check", cached.ActorURI)
+	fetched, err := FetchRemoteActor(cached.ActorURI)
+	if err != nil {
+		log.Printf("Inbox: Failed to refetch actor %s: %v", cached.ActorURI, err)
+		return nil, false
+	}
+	// A document of another actor, even on the same server, doesn't change this actor's key
+	if fetched.ActorURI != cached.ActorURI {
+		log.Printf("Inbox: Refetching %s returned actor %s, keeping the cached key", cached.ActorURI, fetched.ActorURI)
+		return nil, false
+	}
+	if fetched.PublicKeyPem == cached.PublicKeyPem && fetched.Ed25519KeyPem == cached.Ed25519KeyPem {
+		return nil, false
+	}
+	return fetched, true
+}
+
+// recordKeyChange keeps the replaced key of a remote actor in the key history
+func recordKeyChange(actorURI string, oldKeyPem string, newKeyPem string) {
+	log.Printf("Key of %s changed from %s to %s", actorURI, KeyFingerprint(oldKeyPem), KeyFingerprint(newKeyPem))
+
+	change := &domain.KeyChange{
+		Id:        uuid.New(),
+		ActorURI:  actorURI,
+		OldKeyPem: oldKeyPem,
+		NewKeyPem: newKeyPem,
+		ChangedAt: time.Now(),
+	}
+	if err := db.GetDB().CreateKeyChange(change); err != nil {
+		log.Printf("Failed to record key change of %s: %v", actorURI, err)
+	}
+}
+
+// KeyFingerprint returns a short SHA-256 fingerprint of a PEM encoded public key, e.g. "SHA256:3f2a9c01b7e4d6a8"
+// An Ed25519 key removed by its actor has no PEM and is shown as "none"
+func KeyFingerprint(publicKeyPem string) string {
+	if publicKeyPem == "" {
+		return "none"
+	}
+	block, _ := pem.Decode([]byte(publicKeyPem))
+	if block == nil {
+		return "invalid key"
+	}
+	sum := sha256.Sum256(block.Bytes)
+	return fmt.Sprintf("SHA256:%x", sum[:8])
+}
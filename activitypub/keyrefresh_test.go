@@ -0,0 +1,125 @@
+package activitypub
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deemkeen/stegodon/db"
+)
+
+func TestKeyRefreshLimiter(t *testing.T) {
+	limiter := newKeyRefreshLimiter(10 * time.Minute)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	alice := "https://mastodon.example/users/alice"
+	bob := "https://mastodon.example/users/bob"
+
+	if !limiter.allow(alice) {
+		t.Fatal("Expected the first refresh to be allowed")
+	}
+	if limiter.allow(alice) {
+		t.Error("Expected a second refresh within the interval to be refused")
+	}
+	if !limiter.allow(bob) {
+		t.Error("Expected refreshes to be limited per actor")
+	}
+
+	now = now.Add(10 * time.Minute)
+	if !limiter.allow(alice) {
+		t.Error("Expected a refresh after the interval to be allowed")
+	}
+	if len(limiter.last) != 1 {
+		t.Errorf("Expected expired refreshes to be forgotten, got %v", limiter.last)
+	}
+}
+
+func TestKeyFingerprint(t *testing.T) {
+	_, publicKey, err := generateTestKeyPair()
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	publicKeyPem, err := publicKeyToPEM(publicKey)
+	if err != nil {
+		t.Fatalf("Failed to encode public key: %v", err)
+	}
+
+	fingerprint := KeyFingerprint(publicKeyPem)
+	if !strings.HasPrefix(fingerprint, "SHA256:") || len(fingerprint) != len("SHA256:")+16 {
+		t.Errorf("Unexpected fingerprint %q", fingerprint)
+	}
+	if KeyFingerprint(publicKeyPem) != fingerprint {
+		t.Error("Expected the fingerprint to be stable")
+	}
+
+	_, otherKey, _ := generateTestKeyPair()
+	otherPem, _ := publicKeyToPEM(otherKey)
+	if KeyFingerprint(otherPem) == fingerprint {
+		t.Error("Expected different keys to have different fingerprints")
+	}
+
+	if KeyFingerprint("not a key") != "invalid key" {
+		t.Errorf("Expected an invalid key, got %q", KeyFingerprint("not a key"))
+	}
+}
+
+// keyChangesOf returns the recorded key changes of an actor
+func keyChangesOf(t *testing.T, actorURI string) []string {
+	t.Helper()
+	err, changes := db.GetDB().ReadKeyChanges(1000)
+	if err != nil {
+		t.Fatalf("ReadKeyChanges failed: %v", err)
+	}
+	var newKeys []string
+	for _, change := range *changes {
+		if change.ActorURI == actorURI {
+			newKeys = append(newKeys, change.NewKeyPem)
+		}
+	}
+	return newKeys
+}
+
+func TestRefreshActorKey(t *testing.T) {
+	servedKey := "old-key"
+	server := newActorServer(t, func(serverURL string, path string) map[string]interface{} {
+		switch path {
+		case "/users/alice":
+			return testActor(serverURL+path, serverURL+path, servedKey)
+		case "/users/bob":
+			// Another actor of the same server
+			return testActor(serverURL+"/users/carol", serverURL+"/users/carol", "carol-key")
+		case "/users/dave":
+			// An actor of another server
+			return testActor("https://victim.example/users/dave", "https://victim.example/users/dave", "spoofed-key")
+		}
+		return nil
+	})
+
+	alice, err := FetchRemoteActor(server.URL + "/users/alice")
+	if err != nil {
+		t.Fatalf("FetchRemoteActor failed: %v", err)
+	}
+	servedKey = "new-key"
+	refreshed, ok := refreshActorKey(alice)
+	if !ok || refreshed.PublicKeyPem != "new-key" {
+		t.Fatalf("Expected the new key of alice, got %v", refreshed)
+	}
+	if changes := keyChangesOf(t, alice.ActorURI); len(changes) != 1 || changes[0] != "new-key" {
+		t.Errorf("Expected the key change of alice to be recorded, got %v", changes)
+	}
+
+	for _, username := range []string{"bob", "dave"} {
+		cached := *alice
+		cached.ActorURI = server.URL + "/users/" + username
+		if _, ok := refreshActorKey(&cached); ok {
+			t.Errorf("Expected the refresh of %s to be rejected", username)
+		}
+		if changes := keyChangesOf(t, cached.ActorURI); len(changes) != 0 {
+			t.Errorf("Expected no key change of %s, got %v", username, changes)
+		}
+	}
+	if changes := keyChangesOf(t, "https://victim.example/users/dave"); len(changes) != 0 {
+		t.Errorf("Expected no key change of the spoofed actor, got %v", changes)
+	}
+}
@@ -0,0 +1,122 @@
+package activitypub
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/deemkeen/stegodon/domain"
+)
+
+// Multicodec prefix of Ed25519 public keys in a publicKeyMultibase
+var ed25519MulticodecPrefix = []byte{0xed, 0x01}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// parseVerificationKey converts a PEM encoded RSA or Ed25519 public key
+func parseVerificationKey(publicKeyPem string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKeyPem))
+	if block == nil {
+		return nil, fmt.Errorf("failed to parse PEM block")
+	}
+
+	pubKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	switch key := pubKey.(type) {
+	case *rsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", pubKey)
+	}
+}
+
+// actorKeyPem returns the key of an actor a keyId refers to:
+// its Ed25519 key when it published one under that id, its main key otherwise
+func actorKeyPem(actor *domain.RemoteAccount, keyId string) string {
+	if actor.Ed25519KeyPem != "" && keyId == actor.Ed25519KeyId {
+		return actor.Ed25519KeyPem
+	}
+	return actor.PublicKeyPem
+}
+
+// ed25519AssertionKey finds an Ed25519 Multikey in the assertionMethod of an actor (FEP-521a)
+// Returns the key id and PEM encoded key, empty strings if the actor publishes none
+func ed25519AssertionKey(actorURI string, assertionMethod interface{}) (string, string) {
+	var methods []interface{}
+	switch v := assertionMethod.(type) {
+	case []interface{}:
+		methods = v
+	case map[string]interface{}:
+		methods = []interface{}{v}
+	}
+
+	for _, method := range methods {
+		key, ok := method.(map[string]interface{})
+		if !ok || key["type"] != "Multikey" {
+			continue
+		}
+		id, _ := key["id"].(string)
+		controller, _ := key["controller"].(string)
+		multibase, _ := key["publicKeyMultibase"].(string)
+		if id == "" || controller != actorURI || !keyOwnedBy(id, actorURI) {
+			continue
+		}
+
+		publicKey, err := parseMultikey(multibase)
+		if err != nil {
+			continue
+		}
+		der, err := x509.MarshalPKIXPublicKey(publicKey)
+		if err != nil {
+			continue
+		}
+		return id, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	}
+	return "", ""
+}
+
+// parseMultikey decodes the publicKeyMultibase of an Ed25519 Multikey, e.g. "z6Mk..."
+func parseMultikey(multibase string) (ed25519.PublicKey, error) {
+	if !strings.HasPrefix(multibase, "z") {
+		return nil, errors.New("multibase is not base58btc")
+	}
+	decoded, err := decodeBase58(multibase[1:])
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded) != len(ed25519MulticodecPrefix)+ed25519.PublicKeySize ||
+		decoded[0] != ed25519MulticodecPrefix[0] || decoded[1] != ed25519MulticodecPrefix[1] {
+		return nil, errors.New("not an Ed25519 public key")
+	}
+	return ed25519.PublicKey(decoded[len(ed25519MulticodecPrefix):]), nil
+}
+
+// decodeBase58 decodes base58 with the Bitcoin alphabet
+func decodeBase58(encoded string) ([]byte, error) {
+	value := new(big.Int)
+	radix := big.NewInt(58)
+	for _, r := range encoded {
+		digit := strings.IndexRune(base58Alphabet, r)
+		if digit < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", r)
+		}
+		value.Mul(value, radix)
+		value.Add(value, big.NewInt(int64(digit)))
+	}
+
+	// Leading ones encode leading zero bytes
+	zeros := 0
+	for zeros < len(encoded) && encoded[zeros] == '1' {
+		zeros++
+	}
+	return append(make([]byte, zeros), value.Bytes()...), nil
+}
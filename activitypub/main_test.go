@@ -0,0 +1,25 @@
+package activitypub
+
+import (
+	"os"
+	"testing"
+
+	"github.com/deemkeen/stegodon/db"
+)
+
+// TestMain points the database at a temporary home directory,
+// so tests that store actors or activities never touch a real instance's database
+func TestMain(m *testing.M) {
+	home, err := os.MkdirTemp("", "stegodon-test")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("HOME", home)
+	if err := db.GetDB().RunActivityPubMigrations(); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	os.RemoveAll(home)
+	os.Exit(code)
+}
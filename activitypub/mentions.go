@@ -0,0 +1,193 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/util"
+	"github.com/google/uuid"
+)
+
+// ResolveWebFinger resolves a user@domain to an ActivityPub actor URI
+// Example: ResolveWebFinger("alice", "mastodon.social") -> "https://mastodon.social/users/alice"
+func ResolveWebFinger(username, domain string) (string, error) {
+	webfingerURL := fmt.Sprintf("https://%s/.well-known/webfinger?resource=acct:%s@%s",
+		domain, username, domain)
+
+	req, err := http.NewRequest("GET", webfingerURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/jrd+json")
+	req.Header.Set("User-Agent", "stegodon/1.0 ActivityPub")
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("webfinger request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("webfinger failed with status: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var result struct {
+		Links []struct {
+			Rel  string `json:"rel"`
+			Type string `json:"type"`
+			Href string `json:"href"`
+		} `json:"links"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to parse webfinger response: %w", err)
+	}
+
+	// Find self link with type application/activity+json
+	for _, link := range result.Links {
+		if link.Rel == "self" && link.Type == "application/activity+json" {
+			return link.Href, nil
+		}
+	}
+
+	return "", fmt.Errorf("no ActivityPub actor found in webfinger response")
+}
+
+// ResolveMentions resolves the @user and @user@domain mentions in a note to actors
+// Local users are looked up in the accounts table, remote users via WebFinger
+// Mentions that can't be resolved are skipped
+func ResolveMentions(text string, conf *util.AppConfig) []domain.Mention {
+	database := db.GetDB()
+	var mentions []domain.Mention
+
+	for _, mention := range util.ExtractMentions(text) {
+		var actorURI string
+
+		if mention.Domain == "" || mention.Domain == strings.ToLower(conf.Conf.SslDomain) {
+			err, acc := database.ReadAccByUsername(mention.Username)
+			if err != nil || acc == nil {
+				continue
+			}
+			actorURI = fmt.Sprintf("https://%s/users/%s", conf.Conf.SslDomain, acc.Username)
+		} else {
+			uri, err := ResolveWebFinger(mention.Username, mention.Domain)
+			if err != nil {
+				log.Printf("Outbox: Failed to resolve mention %s: %v", mention.Handle(), err)
+				continue
+			}
+			actorURI = uri
+		}
+
+		mentions = append(mentions, domain.Mention{
+			Id:        uuid.New(),
+			Handle:    mention.Handle(),
+			ActorURI:  actorURI,
+			CreatedAt: time.Now(),
+		})
+	}
+
+	return mentions
+}
+
+// NoteContentHTML renders a note message as HTML, converting Markdown links, hashtags and mentions
+func NoteContentHTML(message string, mentions []domain.Mention, conf *util.AppConfig) string {
+	contentHTML := util.MarkdownLinksToHTML(message)
+	contentHTML = util.HashtagsToHTML(contentHTML, "https://"+conf.Conf.SslDomain)
+	if len(mentions) == 0 {
+		return contentHTML
+	}
+
+	hrefs := make(map[string]string, len(mentions))
+	for _, mention := range mentions {
+		hrefs[mention.Handle] = mention.ActorURI
+	}
+	return util.MentionsToHTML(contentHTML, hrefs)
+}
+
+// storeNoteMentions resolves the mentions of a note and stores them for later rendering
+func storeNoteMentions(note *domain.Note, conf *util.AppConfig) []domain.Mention {
+	mentions := ResolveMentions(note.Message, conf)
+	if err := db.GetDB().ReplaceNoteMentions(note.Id, mentions); err != nil {
+		log.Printf("Outbox: Failed to store mentions for note %s: %v", note.Id, err)
+	}
+	return mentions
+}
+
+// MentionTags builds the ActivityPub Mention tags for a note
+// Local mentions are named with the full @user@domain handle
+func MentionTags(mentions []domain.Mention, conf *util.AppConfig) []map[string]interface{} {
+	tags := make([]map[string]interface{}, 0, len(mentions))
+	for _, mention := range mentions {
+		name := mention.Handle
+		if strings.Count(name, "@") == 1 {
+			name = name + "@" + conf.Conf.SslDomain
+		}
+		tags = append(tags, map[string]interface{}{
+			"type": "Mention",
+			"href": mention.ActorURI,
+			"name": name,
+		})
+	}
+	return tags
+}
+
+// addressMentions adds the mentioned actors to the cc of an activity and its object
+// Returns the inboxes to deliver to for mentioned remote actors
+func addressMentions(activity map[string]interface{}, mentions []domain.Mention, conf *util.AppConfig) []string {
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	object := activity["object"].(map[string]interface{})
+
+	localPrefix := fmt.Sprintf("https://%s/", conf.Conf.SslDomain)
+	var inboxes []string
+	for _, mention := range mentions {
+		activity["cc"] = append(activity["cc"].([]string), mention.ActorURI)
+		object["cc"] = append(object["cc"].([]string), mention.ActorURI)
+
+		// Local users see the note without delivery
+		if strings.HasPrefix(mention.ActorURI, localPrefix) {
+			continue
+		}
+
+		remoteActor, err := GetOrFetchActor(mention.ActorURI)
+		if err != nil {
+			log.Printf("Outbox: Failed to fetch mentioned actor %s: %v", mention.ActorURI, err)
+			continue
+		}
+		inboxes = append(inboxes, deliveryInbox(remoteActor))
+	}
+
+	return inboxes
+}
+
+// ActivityTag represents an entry in an object's tag array (Mention, Hashtag, ...)
+type ActivityTag struct {
+	Type string `json:"type"`
+	Href string `json:"href"`
+	Name string `json:"name"`
+}
+
+// mentionsActor reports whether a list of ActivityPub tags contains a Mention of the given actor
+func mentionsActor(tags []ActivityTag, actorURI string) bool {
+	for _, tag := range tags {
+		if tag.Type == "Mention" && tag.Href == actorURI {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package activitypub
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/util"
+)
+
+func TestMentionTags(t *testing.T) {
+	conf := &util.AppConfig{}
+	conf.Conf.SslDomain = "example.com"
+
+	mentions := []domain.Mention{
+		{Handle: "@bob", ActorURI: "https://example.com/users/bob"},
+		{Handle: "@carol@remote.example", ActorURI: "https://remote.example/users/carol"},
+	}
+
+	tags := MentionTags(mentions, conf)
+	if len(tags) != 2 {
+		t.Fatalf("Expected 2 tags, got %d", len(tags))
+	}
+
+	if tags[0]["type"] != "Mention" {
+		t.Errorf("Expected type Mention, got %v", tags[0]["type"])
+	}
+	if tags[0]["name"] != "@bob@example.com" {
+		t.Errorf("Expected local mention to be named @bob@example.com, got %v", tags[0]["name"])
+	}
+	if tags[1]["name"] != "@carol@remote.example" {
+		t.Errorf("Expected remote mention to keep its handle, got %v", tags[1]["name"])
+	}
+	if tags[1]["href"] != "https://remote.example/users/carol" {
+		t.Errorf("Expected href of carol, got %v", tags[1]["href"])
+	}
+}
+
+func TestMentionsActor(t *testing.T) {
+	tags := []ActivityTag{
+		{Type: "Hashtag", Href: "https://remote.example/tags/go", Name: "#go"},
+		{Type: "Mention", Href: "https://example.com/users/alice", Name: "@alice@example.com"},
+	}
+
+	if !mentionsActor(tags, "https://example.com/users/alice") {
+		t.Error("Expected alice to be mentioned")
+	}
+	if mentionsActor(tags, "https://example.com/users/bob") {
+		t.Error("Expected bob not to be mentioned")
+	}
+	if mentionsActor(tags, "https://remote.example/tags/go") {
+		t.Error("Hashtags should not count as mentions")
+	}
+	if mentionsActor(nil, "https://example.com/users/alice") {
+		t.Error("Expected no mention without tags")
+	}
+}
+
+func TestNoteContentHTML(t *testing.T) {
+	conf := &util.AppConfig{}
+	conf.Conf.SslDomain = "example.com"
+
+	mentions := []domain.Mention{
+		{Handle: "@carol@remote.example", ActorURI: "https://remote.example/users/carol"},
+	}
+
+	html := NoteContentHTML("Hi @carol@remote.example, see [docs](https://example.com) #Release", mentions, conf)
+	if !strings.Contains(html, `href="https://remote.example/users/carol"`) {
+		t.Errorf("Expected mention link in %s", html)
+	}
+	if !strings.Contains(html, `href="https://example.com"`) {
+		t.Errorf("Expected Markdown link in %s", html)
+	}
+	if !strings.Contains(html, `href="https://example.com/tags/release"`) {
+		t.Errorf("Expected hashtag link in %s", html)
+	}
+
+	plain := NoteContentHTML("Hi @nobody", nil, conf)
+	if strings.Contains(plain, "mention") {
+		t.Errorf("Unresolved mentions should stay plain text, got %s", plain)
+	}
+}
@@ -0,0 +1,204 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/util"
+	"github.com/google/uuid"
+)
+
+// ErrAliasMissing is returned when the account moved to doesn't list the moving account in its alsoKnownAs
+var ErrAliasMissing = errors.New("target account doesn't list the moving account as an alias")
+
+// ResolveActorHandle resolves user@domain, @user@domain or an actor URI to an actor URI
+func ResolveActorHandle(handle string) (string, error) {
+	handle = strings.TrimSpace(handle)
+	if strings.HasPrefix(handle, "https://") {
+		return handle, nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(handle, "@"), "@")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("invalid handle %q, expected user@domain", handle)
+	}
+	return ResolveWebFinger(parts[0], parts[1])
+}
+
+// AddAlias adds another account of the same person to the alsoKnownAs of a local account,
+// so that account can move its followers here. Returns the actor URI of the alias
+func AddAlias(localAccount *domain.Account, handle string) (string, error) {
+	aliasURI, err := ResolveActorHandle(handle)
+	if err != nil {
+		return "", err
+	}
+	if slices.Contains(localAccount.AlsoKnownAs, aliasURI) {
+		return aliasURI, nil
+	}
+
+	aliases := append(slices.Clone(localAccount.AlsoKnownAs), aliasURI)
+	if err := db.GetDB().UpdateAlsoKnownAs(localAccount.Id, aliases); err != nil {
+		return "", fmt.Errorf("failed to store alias: %w", err)
+	}
+	localAccount.AlsoKnownAs = aliases
+	return aliasURI, nil
+}
+
+// RemoveAlias removes an actor URI from the alsoKnownAs of a local account
+func RemoveAlias(localAccount *domain.Account, aliasURI string) error {
+	aliases := slices.DeleteFunc(slices.Clone(localAccount.AlsoKnownAs), func(uri string) bool {
+		return uri == aliasURI
+	})
+	if err := db.GetDB().UpdateAlsoKnownAs(localAccount.Id, aliases); err != nil {
+		return fmt.Errorf("failed to remove alias: %w", err)
+	}
+	localAccount.AlsoKnownAs = aliases
+	return nil
+}
+
+// ResolveMoveTarget resolves the account a local account wants to move to
+// and checks that it lists the local account in its alsoKnownAs
+func ResolveMoveTarget(localAccount *domain.Account, handle string, conf *util.AppConfig) (string, error) {
+	targetURI, err := ResolveActorHandle(handle)
+	if err != nil {
+		return "", err
+	}
+	if sameOrigin(targetURI, "https://"+conf.Conf.SslDomain) {
+		return "", fmt.Errorf("moving to an account on this server is not supported")
+	}
+
+	target, err := FetchRemoteObject(targetURI)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch target account: %w", err)
+	}
+	actorURI := fmt.Sprintf("https://%s/users/%s", conf.Conf.SslDomain, localAccount.Username)
+	if !listsAlias(target, actorURI) {
+		return "", fmt.Errorf("%w: add %s to the aliases of %s first", ErrAliasMissing, actorURI, targetURI)
+	}
+	return targetURI, nil
+}
+
+// SendMove moves a local account to another account: it marks the account as moved,
+// sends a Move to its remote followers and makes its local followers follow the new account
+func SendMove(localAccount *domain.Account, targetURI string, conf *util.AppConfig) error {
+	database := db.GetDB()
+	if err := database.UpdateMovedTo(localAccount.Id, targetURI); err != nil {
+		return fmt.Errorf("failed to store move: %w", err)
+	}
+	localAccount.MovedTo = targetURI
+
+	actorURI := fmt.Sprintf("https://%s/users/%s", conf.Conf.SslDomain, localAccount.Username)
+	move := map[string]interface{}{
+		"@context": "https://www.w3.org/ns/activitystreams",
+		"id":       fmt.Sprintf("https://%s/activities/%s", conf.Conf.SslDomain, uuid.New().String()),
+		"type":     "Move",
+		"actor":    actorURI,
+		"object":   actorURI,
+		"target":   targetURI,
+		"to":       []string{actorURI + "/followers"},
+	}
+	queued := queueToFollowers(move, localAccount, nil)
+	log.Printf("Outbox: Queued Move of %s to %s for %d inboxes", actorURI, targetURI, queued)
+
+	moveLocalFollowers(localAccount, targetURI, conf)
+	return nil
+}
+
+// moveLocalFollowers makes local followers of a moved account follow the account it moved to
+func moveLocalFollowers(localAccount *domain.Account, targetURI string, conf *util.AppConfig) {
+	database := db.GetDB()
+	err, followers := database.ReadFollowersByAccountId(localAccount.Id)
+	if err != nil || followers == nil {
+		return
+	}
+
+	for _, follow := range *followers {
+		if !follow.IsLocal {
+			continue
+		}
+		err, follower := database.ReadAccById(follow.AccountId)
+		if err != nil || follower == nil {
+			continue
+		}
+		if err := SendFollow(follower, targetURI, conf); err != nil {
+			log.Printf("Outbox: Failed to move %s to %s: %v", follower.Username, targetURI, err)
+			continue
+		}
+		if err := database.DeleteFollowByAccountIds(follower.Id, localAccount.Id); err != nil {
+			log.Printf("Outbox: Failed to remove follow of %s by %s: %v", localAccount.Username, follower.Username, err)
+		}
+	}
+}
+
+// handleMoveActivity follows the new account of a followed actor that moved
+// The new account has to list the old one in its alsoKnownAs, so an actor can't hand its followers to anyone
+func handleMoveActivity(body []byte, username string, remoteActor *domain.RemoteAccount, conf *util.AppConfig) error {
+	var move struct {
+		Actor  string      `json:"actor"`
+		Target interface{} `json:"target"`
+	}
+	if err := json.Unmarshal(body, &move); err != nil {
+		return fmt.Errorf("failed to parse Move activity: %w", err)
+	}
+
+	targets := uriList(move.Target)
+	if len(targets) != 1 || targets[0] == move.Actor {
+		return fmt.Errorf("%w: Move by %s without a target", errActivityRejected, move.Actor)
+	}
+	targetURI := targets[0]
+
+	database := db.GetDB()
+	err, localAccount := database.ReadAccByUsername(username)
+	if err != nil || localAccount == nil {
+		return fmt.Errorf("local account not found: %s", username)
+	}
+	err, follow := database.ReadFollowByAccountIds(localAccount.Id, remoteActor.Id)
+	if err != nil || follow == nil {
+		log.Printf("Inbox: %s doesn't follow %s, ignoring Move", username, move.Actor)
+		return nil
+	}
+
+	target, err := FetchRemoteObject(targetURI)
+	if err != nil {
+		return fmt.Errorf("failed to fetch Move target: %w", err)
+	}
+	if !listsAlias(target, move.Actor) {
+		return fmt.Errorf("%w: %s doesn't list %s in its alsoKnownAs", errActivityRejected, targetURI, move.Actor)
+	}
+
+	if isBlockedByUser(username, targetURI) {
+		log.Printf("Inbox: %s blocked %s, not following the moved account", username, targetURI)
+	} else if !followsActor(localAccount, targetURI) {
+		if err := SendFollow(localAccount, targetURI, conf); err != nil {
+			return fmt.Errorf("failed to follow moved account: %w", err)
+		}
+	}
+
+	if err := database.DeleteFollowByURI(follow.URI); err != nil {
+		return fmt.Errorf("failed to remove follow of moved account: %w", err)
+	}
+	log.Printf("Inbox: %s moved to %s, %s follows the new account", move.Actor, targetURI, username)
+	return nil
+}
+
+// followsActor reports whether a local account already follows (or requested to follow) a remote actor
+func followsActor(localAccount *domain.Account, actorURI string) bool {
+	database := db.GetDB()
+	err, remoteActor := database.ReadRemoteAccountByURI(actorURI)
+	if err != nil || remoteActor == nil {
+		return false
+	}
+	following, err := database.IsFollowingOrRequested(localAccount.Id, remoteActor.Id)
+	return err == nil && following
+}
+
+// listsAlias reports whether an actor document lists the given actor URI in its alsoKnownAs
+func listsAlias(actor map[string]interface{}, actorURI string) bool {
+	return slices.Contains(uriList(actor["alsoKnownAs"]), actorURI)
+}
@@ -0,0 +1,251 @@
+package activitypub
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/util"
+	"github.com/google/uuid"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// testSession is an SSH session that only knows its public key, enough to create an account
+type testSession struct {
+	ssh.Session
+	publicKey ssh.PublicKey
+}
+
+func (s testSession) PublicKey() ssh.PublicKey {
+	return s.publicKey
+}
+
+// createTestAccount creates a local account with a new SSH key
+func createTestAccount(t *testing.T, username string) *domain.Account {
+	t.Helper()
+	sshPublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate SSH key: %v", err)
+	}
+	publicKey, err := gossh.NewPublicKey(sshPublicKey)
+	if err != nil {
+		t.Fatalf("Failed to convert SSH key: %v", err)
+	}
+	privateKey, rsaPublicKey, err := generateTestKeyPair()
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	publicPem, _ := publicKeyToPEM(rsaPublicKey)
+
+	database := db.GetDB()
+	keyPair := &util.RsaKeyPair{Private: privateKeyToPEM(privateKey), Public: publicPem}
+	if err := database.CreateAccByUsername(testSession{publicKey: publicKey}, username, keyPair); err != nil {
+		t.Fatalf("Failed to create account %s: %v", username, err)
+	}
+	err, account := database.ReadAccByUsername(username)
+	if err != nil || account == nil {
+		t.Fatalf("Failed to read account %s: %v", username, err)
+	}
+	return account
+}
+
+// moveServer serves a moved actor, the account it moved to and one that doesn't list it as an alias
+// It returns the activity types posted to the inbox of the new account
+func moveServer(t *testing.T) (string, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var posted []string
+	server := newActorServer(t, func(serverURL string, path string) map[string]interface{} {
+		switch path {
+		case "/users/old", "/users/stranger":
+			return testActor(serverURL+path, serverURL+path, testKeyPem)
+		case "/users/new":
+			actor := testActor(serverURL+path, serverURL+path, testKeyPem)
+			actor["alsoKnownAs"] = []string{serverURL + "/users/old"}
+			return actor
+		case "/users/new/inbox":
+			mu.Lock()
+			posted = append(posted, "Follow")
+			mu.Unlock()
+			return map[string]interface{}{}
+		}
+		return nil
+	})
+	return server.URL, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), posted...)
+	}
+}
+
+// followRemote stores an accepted follow of a remote actor by a local account
+func followRemote(t *testing.T, localAccount *domain.Account, actorURI string) *domain.RemoteAccount {
+	t.Helper()
+	remoteActor, err := GetOrFetchActor(actorURI)
+	if err != nil {
+		t.Fatalf("Failed to fetch %s: %v", actorURI, err)
+	}
+	if err := db.GetDB().CreateFollow(&domain.Follow{
+		Id:              uuid.New(),
+		AccountId:       localAccount.Id,
+		TargetAccountId: remoteActor.Id,
+		URI:             "https://example.com/activities/" + uuid.New().String(),
+		Accepted:        true,
+		CreatedAt:       time.Now(),
+	}); err != nil {
+		t.Fatalf("CreateFollow failed: %v", err)
+	}
+	return remoteActor
+}
+
+// moveActivity returns a Move of an actor to a target
+func moveActivity(actorURI string, targetURI string) []byte {
+	body, _ := json.Marshal(map[string]interface{}{
+		"id":     actorURI + "#moves/1",
+		"type":   "Move",
+		"actor":  actorURI,
+		"object": actorURI,
+		"target": targetURI,
+	})
+	return body
+}
+
+func TestListsAlias(t *testing.T) {
+	oldURI := "https://old.example/users/alice"
+	tests := []struct {
+		name  string
+		actor map[string]interface{}
+		want  bool
+	}{
+		{"no aliases", map[string]interface{}{"id": "https://new.example/users/alice"}, false},
+		{"single alias", map[string]interface{}{"alsoKnownAs": oldURI}, true},
+		{"alias list", map[string]interface{}{"alsoKnownAs": []interface{}{"https://other.example/users/alice", oldURI}}, true},
+		{"other aliases", map[string]interface{}{"alsoKnownAs": []interface{}{"https://other.example/users/alice"}}, false},
+	}
+	for _, tt := range tests {
+		if got := listsAlias(tt.actor, oldURI); got != tt.want {
+			t.Errorf("%s: listsAlias = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResolveActorHandle(t *testing.T) {
+	uri, err := ResolveActorHandle(" https://new.example/users/alice ")
+	if err != nil || uri != "https://new.example/users/alice" {
+		t.Errorf("Expected actor URIs to be used as they are, got %q (%v)", uri, err)
+	}
+
+	for _, handle := range []string{"alice", "@alice", "alice@", "@alice@new.example@extra", ""} {
+		if _, err := ResolveActorHandle(handle); err == nil || !strings.Contains(err.Error(), "invalid handle") {
+			t.Errorf("Expected %q to be an invalid handle, got %v", handle, err)
+		}
+	}
+}
+
+func TestHandleMoveActivity(t *testing.T) {
+	conf := &util.AppConfig{}
+	conf.Conf.SslDomain = "example.com"
+	serverURL, posted := moveServer(t)
+	oldURI := serverURL + "/users/old"
+	newURI := serverURL + "/users/new"
+	database := db.GetDB()
+
+	// The account moved to has to list the moved one as an alias
+	follower := createTestAccount(t, "move_follower")
+	oldActor := followRemote(t, follower, oldURI)
+	err := handleMoveActivity(moveActivity(oldURI, serverURL+"/users/stranger"), follower.Username, oldActor, conf)
+	if !errors.Is(err, errActivityRejected) {
+		t.Errorf("Expected a Move to an account without the alias to be rejected, got %v", err)
+	}
+	if err, follow := database.ReadFollowByAccountIds(follower.Id, oldActor.Id); err != nil || follow == nil {
+		t.Error("Expected the follow to be kept after a rejected Move")
+	}
+
+	// Accounts that don't follow the moved actor ignore the Move
+	bystander := createTestAccount(t, "move_bystander")
+	if err := handleMoveActivity(moveActivity(oldURI, newURI), bystander.Username, oldActor, conf); err != nil {
+		t.Errorf("Expected a Move to an account that doesn't follow the actor to be ignored, got %v", err)
+	}
+	if followsActor(bystander, newURI) {
+		t.Error("Expected no follow of the new account by an account that didn't follow the old one")
+	}
+	if got := posted(); len(got) != 0 {
+		t.Errorf("Expected nothing posted to the new account yet, got %v", got)
+	}
+
+	// Followers follow the new account instead of the old one
+	if err := handleMoveActivity(moveActivity(oldURI, newURI), follower.Username, oldActor, conf); err != nil {
+		t.Fatalf("handleMoveActivity failed: %v", err)
+	}
+	if err, follow := database.ReadFollowByAccountIds(follower.Id, oldActor.Id); err == nil && follow != nil {
+		t.Error("Expected the follow of the old account to be removed")
+	}
+	if !followsActor(follower, newURI) {
+		t.Error("Expected a follow of the new account")
+	}
+	if got := posted(); len(got) != 1 || got[0] != "Follow" {
+		t.Errorf("Expected a Follow posted to the new account, got %v", got)
+	}
+}
+
+func TestSendMove(t *testing.T) {
+	conf := &util.AppConfig{}
+	conf.Conf.SslDomain = "example.com"
+	serverURL, _ := moveServer(t)
+	newURI := serverURL + "/users/new"
+	database := db.GetDB()
+
+	mover := createTestAccount(t, "move_mover")
+	localFollower := createTestAccount(t, "move_local_follower")
+	if err := database.CreateLocalFollow(localFollower.Id, mover.Id); err != nil {
+		t.Fatalf("CreateLocalFollow failed: %v", err)
+	}
+	remoteFollower, err := GetOrFetchActor(serverURL + "/users/stranger")
+	if err != nil {
+		t.Fatalf("Failed to fetch remote follower: %v", err)
+	}
+	if err := database.CreateFollow(&domain.Follow{
+		Id:              uuid.New(),
+		AccountId:       remoteFollower.Id,
+		TargetAccountId: mover.Id,
+		URI:             serverURL + "/activities/" + uuid.New().String(),
+		Accepted:        true,
+		CreatedAt:       time.Now(),
+	}); err != nil {
+		t.Fatalf("CreateFollow failed: %v", err)
+	}
+
+	if err := SendMove(mover, newURI, conf); err != nil {
+		t.Fatalf("SendMove failed: %v", err)
+	}
+
+	if err, moved := database.ReadAccById(mover.Id); err != nil || moved.MovedTo != newURI {
+		t.Errorf("Expected moved_to to be %s, got %+v (%v)", newURI, moved, err)
+	}
+
+	moves := queuedActivities(t, mover.Id, "Move")
+	if len(moves) != 1 || moves[0].InboxURI != remoteFollower.InboxURI {
+		t.Fatalf("Expected one Move queued for %s, got %+v", remoteFollower.InboxURI, moves)
+	}
+	var move map[string]interface{}
+	json.Unmarshal([]byte(moves[0].ActivityJSON), &move)
+	if move["target"] != newURI || move["object"] != "https://example.com/users/move_mover" {
+		t.Errorf("Unexpected Move: %v", move)
+	}
+
+	// Local followers follow the new account
+	if !followsActor(localFollower, newURI) {
+		t.Error("Expected the local follower to follow the new account")
+	}
+	if following, _ := database.IsFollowingLocal(localFollower.Id, mover.Id); following {
+		t.Error("Expected the local follow of the moved account to be removed")
+	}
+}
@@ -0,0 +1,147 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Errors returned when a signed activity doesn't belong to its signer
+var (
+	ErrKeyOwnerMismatch = errors.New("signing key is not owned by the actor")
+	ErrCrossOrigin      = errors.New("activity touches objects of another origin")
+	ErrActorMismatch    = errors.New("actor document is not the requested actor")
+)
+
+// checkKeyOwner checks that the key an inbox request is signed with belongs to the claimed actor
+// Keys are expected at <actor>#fragment (e.g. Mastodon) or <actor>/path (e.g. GoToSocial)
+func checkKeyOwner(req *http.Request, actorURI string) error {
+	keyId, err := signatureKeyId(req)
+	if err != nil {
+		return fmt.Errorf("failed to read signature: %w", err)
+	}
+
+	if !keyOwnedBy(keyId, actorURI) {
+		return fmt.Errorf("%w: key %s, actor %s", ErrKeyOwnerMismatch, keyId, actorURI)
+	}
+	return nil
+}
+
+// keyOwnedBy reports whether a keyId is one of the actor's keys
+func keyOwnedBy(keyId string, actorURI string) bool {
+	if actorURI == "" {
+		return false
+	}
+	owner := strings.SplitN(keyId, "#", 2)[0]
+	return owner == actorURI || strings.HasPrefix(owner, strings.TrimSuffix(actorURI, "/")+"/")
+}
+
+// checkOrigin applies same-origin rules to a received activity:
+// its id lives on the actor's server, objects it creates, updates or deletes are the actor's own,
+// and undone activities were done by the actor
+func checkOrigin(body []byte, activity *Activity) error {
+	if _, err := originOf(activity.Actor); err != nil {
+		return fmt.Errorf("%w: invalid actor %q", ErrCrossOrigin, activity.Actor)
+	}
+	if activity.ID != "" && !sameOrigin(activity.ID, activity.Actor) {
+		return fmt.Errorf("%w: id %s is not on the server of %s", ErrCrossOrigin, activity.ID, activity.Actor)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return err
+	}
+
+	switch activity.Type {
+	case "Create", "Update", "Delete":
+		for _, objectURI := range uriList(raw["object"]) {
+			if !sameOrigin(objectURI, activity.Actor) {
+				return fmt.Errorf("%w: %s of %s by %s", ErrCrossOrigin, activity.Type, objectURI, activity.Actor)
+			}
+		}
+		if object, ok := raw["object"].(map[string]interface{}); ok {
+			for _, author := range uriList(object["attributedTo"]) {
+				if author != activity.Actor {
+					return fmt.Errorf("%w: %s of an object attributed to %s by %s", ErrCrossOrigin, activity.Type, author, activity.Actor)
+				}
+			}
+		}
+	case "Undo":
+		switch object := raw["object"].(type) {
+		case string:
+			if !sameOrigin(object, activity.Actor) {
+				return fmt.Errorf("%w: Undo of %s by %s", ErrCrossOrigin, object, activity.Actor)
+			}
+		case map[string]interface{}:
+			for _, actor := range uriList(object["actor"]) {
+				if actor != activity.Actor {
+					return fmt.Errorf("%w: Undo of an activity by %s by %s", ErrCrossOrigin, actor, activity.Actor)
+				}
+			}
+		}
+	case "Move":
+		// Actors can only move themselves
+		for _, objectURI := range uriList(raw["object"]) {
+			if objectURI != activity.Actor {
+				return fmt.Errorf("%w: Move of %s by %s", ErrCrossOrigin, objectURI, activity.Actor)
+			}
+		}
+	}
+	return nil
+}
+
+// stripForeignObject replaces an object embedded in an Announce with its URI when it comes from another server,
+// so the boosted post is fetched from its origin instead of trusting the booster's copy
+func stripForeignObject(body []byte, activity *Activity) []byte {
+	if activity.Type != "Announce" {
+		return body
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return body
+	}
+	object, ok := raw["object"].(map[string]interface{})
+	if !ok {
+		return body
+	}
+	objectURI, _ := object["id"].(string)
+	if objectURI == "" || sameOrigin(objectURI, activity.Actor) {
+		return body
+	}
+
+	raw["object"] = objectURI
+	stripped, err := json.Marshal(raw)
+	if err != nil {
+		return body
+	}
+	return stripped
+}
+
+// originOf returns the scheme and host of a URI
+func originOf(uri string) (string, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return "", fmt.Errorf("not an absolute URI: %s", uri)
+	}
+	return strings.ToLower(parsed.Scheme + "://" + parsed.Host), nil
+}
+
+// sameOrigin reports whether two URIs live on the same server
+func sameOrigin(a string, b string) bool {
+	originA, err := originOf(a)
+	if err != nil {
+		return false
+	}
+	originB, err := originOf(b)
+	if err != nil {
+		return false
+	}
+	return originA == originB
+}
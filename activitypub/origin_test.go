@@ -0,0 +1,148 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestKeyOwnedBy(t *testing.T) {
+	actor := "https://mastodon.example/users/alice"
+	tests := []struct {
+		keyId string
+		want  bool
+	}{
+		{"https://mastodon.example/users/alice#main-key", true},
+		{"https://mastodon.example/users/alice/main-key", true},
+		{"https://mastodon.example/users/alice", true},
+		{"https://mastodon.example/users/alicebob#main-key", false},
+		{"https://mastodon.example/users/bob#main-key", false},
+		{"https://evil.example/users/alice#main-key", false},
+		{"https://mastodon.example/actor#main-key", false},
+	}
+	for _, tt := range tests {
+		if got := keyOwnedBy(tt.keyId, actor); got != tt.want {
+			t.Errorf("keyOwnedBy(%q) = %v, want %v", tt.keyId, got, tt.want)
+		}
+	}
+	if keyOwnedBy("https://mastodon.example/users/alice#main-key", "") {
+		t.Error("Expected no key to belong to an empty actor")
+	}
+}
+
+func TestCheckKeyOwner(t *testing.T) {
+	req, _ := http.NewRequest("POST", "https://example.com/inbox", nil)
+	req.Header.Set("Signature", `keyId="https://mastodon.example/users/bob#main-key",algorithm="rsa-sha256",headers="(request-target) host date digest",signature="c2lnbmF0dXJl"`)
+
+	if err := checkKeyOwner(req, "https://mastodon.example/users/bob"); err != nil {
+		t.Errorf("Expected the key to belong to its actor, got %v", err)
+	}
+	if err := checkKeyOwner(req, "https://mastodon.example/users/alice"); !errors.Is(err, ErrKeyOwnerMismatch) {
+		t.Errorf("Expected ErrKeyOwnerMismatch, got %v", err)
+	}
+
+	unsigned, _ := http.NewRequest("POST", "https://example.com/inbox", nil)
+	if err := checkKeyOwner(unsigned, "https://mastodon.example/users/bob"); err == nil {
+		t.Error("Expected an error for a request without signature")
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		ok   bool
+	}{
+		{"create own note", `{"id":"https://a.example/activities/1","type":"Create","actor":"https://a.example/users/alice",
+			"object":{"id":"https://a.example/notes/1","type":"Note","attributedTo":"https://a.example/users/alice"}}`, true},
+		{"create foreign note", `{"id":"https://a.example/activities/1","type":"Create","actor":"https://a.example/users/alice",
+			"object":{"id":"https://b.example/notes/1","type":"Note","attributedTo":"https://a.example/users/alice"}}`, false},
+		{"create note attributed to someone else", `{"id":"https://a.example/activities/1","type":"Create","actor":"https://a.example/users/alice",
+			"object":{"id":"https://a.example/notes/1","type":"Note","attributedTo":"https://a.example/users/bob"}}`, false},
+		{"activity id on another server", `{"id":"https://b.example/activities/1","type":"Like","actor":"https://a.example/users/alice",
+			"object":"https://b.example/notes/1"}`, false},
+		{"like foreign note", `{"id":"https://a.example/activities/1","type":"Like","actor":"https://a.example/users/alice",
+			"object":"https://b.example/notes/1"}`, true},
+		{"update own profile", `{"id":"https://a.example/activities/1","type":"Update","actor":"https://a.example/users/alice",
+			"object":{"id":"https://a.example/users/alice","type":"Person"}}`, true},
+		{"update foreign profile", `{"id":"https://a.example/activities/1","type":"Update","actor":"https://a.example/users/alice",
+			"object":{"id":"https://b.example/users/alice","type":"Person"}}`, false},
+		{"delete own note", `{"id":"https://a.example/activities/1","type":"Delete","actor":"https://a.example/users/alice",
+			"object":{"id":"https://a.example/notes/1","type":"Tombstone"}}`, true},
+		{"delete foreign note", `{"id":"https://a.example/activities/1","type":"Delete","actor":"https://a.example/users/alice",
+			"object":"https://b.example/notes/1"}`, false},
+		{"undo own follow", `{"id":"https://a.example/activities/2","type":"Undo","actor":"https://a.example/users/alice",
+			"object":{"id":"https://a.example/activities/1","type":"Follow","actor":"https://a.example/users/alice"}}`, true},
+		{"undo follow of someone else", `{"id":"https://a.example/activities/2","type":"Undo","actor":"https://a.example/users/alice",
+			"object":{"id":"https://a.example/activities/1","type":"Follow","actor":"https://a.example/users/bob"}}`, false},
+		{"undo foreign activity", `{"id":"https://a.example/activities/2","type":"Undo","actor":"https://a.example/users/alice",
+			"object":"https://b.example/activities/1"}`, false},
+		{"announce foreign note", `{"id":"https://a.example/activities/1","type":"Announce","actor":"https://a.example/users/alice",
+			"object":{"id":"https://b.example/notes/1","type":"Note"}}`, true},
+		{"move own account", `{"id":"https://a.example/activities/1","type":"Move","actor":"https://a.example/users/alice",
+			"object":"https://a.example/users/alice","target":"https://b.example/users/alice"}`, true},
+		{"move someone else", `{"id":"https://a.example/activities/1","type":"Move","actor":"https://a.example/users/alice",
+			"object":"https://a.example/users/bob","target":"https://b.example/users/alice"}`, false},
+		{"relative actor", `{"id":"https://a.example/activities/1","type":"Like","actor":"/users/alice","object":"https://a.example/notes/1"}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var activity Activity
+			if err := json.Unmarshal([]byte(tt.body), &activity); err != nil {
+				t.Fatalf("Failed to parse activity: %v", err)
+			}
+			err := checkOrigin([]byte(tt.body), &activity)
+			if tt.ok && err != nil {
+				t.Errorf("Expected the activity to pass, got %v", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrCrossOrigin) {
+				t.Errorf("Expected ErrCrossOrigin, got %v", err)
+			}
+		})
+	}
+}
+
+func TestStripForeignObject(t *testing.T) {
+	foreign := []byte(`{"id":"https://a.example/activities/1","type":"Announce","actor":"https://a.example/users/alice",
+		"object":{"id":"https://b.example/notes/1","type":"Note","content":"forged"}}`)
+	var activity Activity
+	json.Unmarshal(foreign, &activity)
+
+	var stripped map[string]interface{}
+	if err := json.Unmarshal(stripForeignObject(foreign, &activity), &stripped); err != nil {
+		t.Fatalf("Failed to parse stripped activity: %v", err)
+	}
+	if stripped["object"] != "https://b.example/notes/1" {
+		t.Errorf("Expected the foreign object to be replaced by its URI, got %v", stripped["object"])
+	}
+
+	own := []byte(`{"id":"https://a.example/activities/1","type":"Announce","actor":"https://a.example/users/alice",
+		"object":{"id":"https://a.example/notes/1","type":"Note"}}`)
+	if string(stripForeignObject(own, &activity)) != string(own) {
+		t.Error("Expected an object from the actor's server to be kept")
+	}
+
+	create := []byte(`{"id":"https://a.example/activities/1","type":"Create","actor":"https://a.example/users/alice",
+		"object":{"id":"https://b.example/notes/1","type":"Note"}}`)
+	json.Unmarshal(create, &activity)
+	if string(stripForeignObject(create, &activity)) != string(create) {
+		t.Error("Expected only Announce activities to be changed")
+	}
+}
+
+func TestSameOrigin(t *testing.T) {
+	if !sameOrigin("https://A.example/users/alice", "https://a.example/notes/1") {
+		t.Error("Expected hosts to be compared case-insensitively")
+	}
+	if sameOrigin("https://a.example/notes/1", "http://a.example/notes/1") {
+		t.Error("Expected different schemes to be different origins")
+	}
+	if sameOrigin("https://a.example:8443/notes/1", "https://a.example/notes/1") {
+		t.Error("Expected different ports to be different origins")
+	}
+	if sameOrigin("/notes/1", "/notes/1") {
+		t.Error("Expected relative URIs to have no origin")
+	}
+}
@@ -1,13 +1,12 @@
 package activitypub
 
 import (
-	"bytes"
-	"crypto/sha256"
-	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/deemkeen/stegodon/db"
@@ -18,44 +17,27 @@ import (
 
 // SendActivity sends an activity to a remote inbox
 func SendActivity(activity interface{}, inboxURI string, localAccount *domain.Account, conf *util.AppConfig) error {
+	if IsDomainSuspended(inboxURI) {
+		return fmt.Errorf("domain of %s is suspended", inboxURI)
+	}
+
 	// Marshal activity to JSON
 	activityJSON, err := json.Marshal(activity)
 	if err != nil {
 		return fmt.Errorf("failed to marshal activity: %w", err)
 	}
 
-	// Calculate digest for HTTP signature
-	hash := sha256.Sum256(activityJSON)
-	digest := "SHA-256=" + base64.StdEncoding.EncodeToString(hash[:])
-
-	// Create HTTP request
-	req, err := http.NewRequest("POST", inboxURI, bytes.NewReader(activityJSON))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/activity+json")
-	req.Header.Set("Accept", "application/activity+json")
-	req.Header.Set("User-Agent", "stegodon/1.0 ActivityPub")
-	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
-	req.Header.Set("Host", req.URL.Host)
-	req.Header.Set("Digest", digest)
-
 	// Parse private key for signing
 	privateKey, err := ParsePrivateKey(localAccount.WebPrivateKey)
 	if err != nil {
 		return fmt.Errorf("failed to parse private key: %w", err)
 	}
 
-	// Sign request
+	// Send the signed request
 	keyID := fmt.Sprintf("https://%s/users/%s#main-key", conf.Conf.SslDomain, localAccount.Username)
-	if err := SignRequest(req, privateKey, keyID); err != nil {
-		return fmt.Errorf("failed to sign request: %w", err)
-	}
-
-	// Send request
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := sendSigned(deliveryClient, inboxURI, func(scheme signatureScheme) (*http.Request, error) {
+		return newSignedPost(inboxURI, activityJSON, privateKey, keyID, scheme)
+	})
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
@@ -90,14 +72,36 @@ func SendAccept(localAccount *domain.Account, remoteActor *domain.RemoteAccount,
 	return SendActivity(accept, remoteActor.InboxURI, localAccount, conf)
 }
 
+// SendReject sends a Reject activity in response to a Follow
+func SendReject(localAccount *domain.Account, remoteActor *domain.RemoteAccount, followID string, conf *util.AppConfig) error {
+	rejectID := fmt.Sprintf("https://%s/activities/%s", conf.Conf.SslDomain, uuid.New().String())
+	actorURI := fmt.Sprintf("https://%s/users/%s", conf.Conf.SslDomain, localAccount.Username)
+
+	reject := map[string]interface{}{
+		"@context": "https://www.w3.org/ns/activitystreams",
+		"id":       rejectID,
+		"type":     "Reject",
+		"actor":    actorURI,
+		"object": map[string]interface{}{
+			"id":     followID,
+			"type":   "Follow",
+			"actor":  remoteActor.ActorURI,
+			"object": actorURI,
+		},
+	}
+
+	return SendActivity(reject, remoteActor.InboxURI, localAccount, conf)
+}
+
 // SendCreate sends a Create activity for a new note
 func SendCreate(note *domain.Note, localAccount *domain.Account, conf *util.AppConfig) error {
 	actorURI := fmt.Sprintf("https://%s/users/%s", conf.Conf.SslDomain, localAccount.Username)
 	noteURI := fmt.Sprintf("https://%s/notes/%s", conf.Conf.SslDomain, note.Id.String())
 	createID := fmt.Sprintf("https://%s/activities/%s", conf.Conf.SslDomain, uuid.New().String())
 
-	// Convert Markdown links to HTML for ActivityPub content
-	contentHTML := util.MarkdownLinksToHTML(note.Message)
+	// Resolve and store mentions, then convert Markdown links and mentions to HTML
+	mentions := storeNoteMentions(note, conf)
+	contentHTML := NoteContentHTML(note.Message, mentions, conf)
 
 	create := map[string]interface{}{
 		"@context":  "https://www.w3.org/ns/activitystreams",
@@ -125,46 +129,56 @@ func SendCreate(note *domain.Note, localAccount *domain.Account, conf *util.AppC
 			},
 		},
 	}
+	object := create["object"].(map[string]interface{})
 
-	// Get all followers and queue delivery to their inboxes
-	database := db.GetDB()
-	err, followers := database.ReadFollowersByAccountId(localAccount.Id)
-	if err != nil {
-		log.Printf("Outbox: Failed to get followers: %v", err)
-		return nil // Don't fail if we can't get followers
+	// Mentioned actors are tagged, addressed and delivered to directly
+	if tags := NoteTags(note.Message, mentions, conf); len(tags) > 0 {
+		object["tag"] = tags
 	}
-
-	if followers == nil || len(*followers) == 0 {
-		log.Printf("Outbox: No followers to deliver to")
-		return nil
+	extraInboxes := addressMentions(create, mentions, conf)
+
+	// Replies carry inReplyTo and are also delivered to the author of the parent post
+	if note.InReplyToURI != "" {
+		object["inReplyTo"] = note.InReplyToURI
+		if author := replyAuthor(note.InReplyToURI, conf); author != nil {
+			create["cc"] = append(create["cc"].([]string), author.ActorURI)
+			object["cc"] = append(object["cc"].([]string), author.ActorURI)
+			extraInboxes = append(extraInboxes, deliveryInbox(author))
+		}
 	}
 
-	// Queue delivery to each follower's inbox
-	for _, follower := range *followers {
-		// AccountId is the follower (remote actor we need to deliver to)
-		err, remoteActor := database.ReadRemoteAccountById(follower.AccountId)
-		if err != nil {
-			log.Printf("Outbox: Failed to get remote actor %s: %v", follower.AccountId, err)
-			continue
-		}
+	queued := queueToFollowers(create, localAccount, extraInboxes)
 
-		// Queue for delivery
-		queueItem := &domain.DeliveryQueueItem{
-			Id:           uuid.New(),
-			InboxURI:     remoteActor.InboxURI,
-			ActivityJSON: mustMarshal(create),
-			Attempts:     0,
-			NextRetryAt:  time.Now(),
-			CreatedAt:    time.Now(),
-		}
+	log.Printf("Outbox: Queued Create activity for note %s to %d inboxes", note.Id, queued)
+	return nil
+}
 
-		if err := database.EnqueueDelivery(queueItem); err != nil {
-			log.Printf("Outbox: Failed to queue delivery to %s: %v", remoteActor.InboxURI, err)
-		}
+// replyAuthor returns the remote author of the post a reply answers, or nil for local
+// or unknown posts
+func replyAuthor(inReplyToURI string, conf *util.AppConfig) *domain.RemoteAccount {
+	if _, isLocal := parseLocalNoteId(inReplyToURI, conf); isLocal {
+		return nil
 	}
 
-	log.Printf("Outbox: Queued Create activity for note %s to %d followers", note.Id, len(*followers))
-	return nil
+	// Look up the stored post first, fall back to fetching it (e.g. for boosted posts)
+	authorURI := ""
+	err, parent := db.GetDB().ReadActivityByObjectURI(inReplyToURI)
+	if err == nil && parent != nil {
+		authorURI = parent.ActorURI
+	} else if object, err := FetchRemoteObject(inReplyToURI); err == nil {
+		authorURI, _ = object["attributedTo"].(string)
+	}
+	if authorURI == "" {
+		log.Printf("Outbox: Author of parent post %s not found, not addressing it", inReplyToURI)
+		return nil
+	}
+
+	author, err := GetOrFetchActor(authorURI)
+	if err != nil {
+		log.Printf("Outbox: Failed to fetch parent author %s: %v", authorURI, err)
+		return nil
+	}
+	return author
 }
 
 // SendUpdate sends an Update activity to all followers when a note is edited
@@ -179,8 +193,9 @@ func SendUpdate(note *domain.Note, localAccount *domain.Account, conf *util.AppC
 		updatedTime = *note.EditedAt
 	}
 
-	// Convert Markdown links to HTML for ActivityPub content
-	contentHTML := util.MarkdownLinksToHTML(note.Message)
+	// Mentions may have changed with the edit, so resolve them again
+	mentions := storeNoteMentions(note, conf)
+	contentHTML := NoteContentHTML(note.Message, mentions, conf)
 
 	update := map[string]interface{}{
 		"@context": "https://www.w3.org/ns/activitystreams",
@@ -209,42 +224,17 @@ func SendUpdate(note *domain.Note, localAccount *domain.Account, conf *util.AppC
 		},
 	}
 
-	// Get all followers and queue delivery to their inboxes
-	database := db.GetDB()
-	err, followers := database.ReadFollowersByAccountId(localAccount.Id)
-	if err != nil {
-		log.Printf("Outbox: Failed to get followers for Update: %v", err)
-		return nil
+	if note.InReplyToURI != "" {
+		update["object"].(map[string]interface{})["inReplyTo"] = note.InReplyToURI
 	}
 
-	if followers == nil || len(*followers) == 0 {
-		log.Printf("Outbox: No followers to deliver Update to")
-		return nil
-	}
-
-	// Queue delivery to each follower's inbox
-	for _, follower := range *followers {
-		err, remoteActor := database.ReadRemoteAccountById(follower.AccountId)
-		if err != nil {
-			log.Printf("Outbox: Failed to get remote actor %s: %v", follower.AccountId, err)
-			continue
-		}
-
-		queueItem := &domain.DeliveryQueueItem{
-			Id:           uuid.New(),
-			InboxURI:     remoteActor.InboxURI,
-			ActivityJSON: mustMarshal(update),
-			Attempts:     0,
-			NextRetryAt:  time.Now(),
-			CreatedAt:    time.Now(),
-		}
-
-		if err := database.EnqueueDelivery(queueItem); err != nil {
-			log.Printf("Outbox: Failed to queue Update delivery to %s: %v", remoteActor.InboxURI, err)
-		}
+	if tags := NoteTags(note.Message, mentions, conf); len(tags) > 0 {
+		update["object"].(map[string]interface{})["tag"] = tags
 	}
+	extraInboxes := addressMentions(update, mentions, conf)
+	queued := queueToFollowers(update, localAccount, extraInboxes)
 
-	log.Printf("Outbox: Queued Update activity for note %s to %d followers", note.Id, len(*followers))
+	log.Printf("Outbox: Queued Update activity for note %s to %d inboxes", note.Id, queued)
 	return nil
 }
 
@@ -269,42 +259,9 @@ func SendDelete(noteId uuid.UUID, localAccount *domain.Account, conf *util.AppCo
 		"object": noteURI,
 	}
 
-	// Get all followers and queue delivery to their inboxes
-	database := db.GetDB()
-	err, followers := database.ReadFollowersByAccountId(localAccount.Id)
-	if err != nil {
-		log.Printf("Outbox: Failed to get followers for Delete: %v", err)
-		return nil
-	}
-
-	if followers == nil || len(*followers) == 0 {
-		log.Printf("Outbox: No followers to deliver Delete to")
-		return nil
-	}
-
-	// Queue delivery to each follower's inbox
-	for _, follower := range *followers {
-		err, remoteActor := database.ReadRemoteAccountById(follower.AccountId)
-		if err != nil {
-			log.Printf("Outbox: Failed to get remote actor %s: %v", follower.AccountId, err)
-			continue
-		}
-
-		queueItem := &domain.DeliveryQueueItem{
-			Id:           uuid.New(),
-			InboxURI:     remoteActor.InboxURI,
-			ActivityJSON: mustMarshal(deleteActivity),
-			Attempts:     0,
-			NextRetryAt:  time.Now(),
-			CreatedAt:    time.Now(),
-		}
-
-		if err := database.EnqueueDelivery(queueItem); err != nil {
-			log.Printf("Outbox: Failed to queue Delete delivery to %s: %v", remoteActor.InboxURI, err)
-		}
-	}
+	queued := queueToFollowers(deleteActivity, localAccount, nil)
 
-	log.Printf("Outbox: Queued Delete activity for note %s to %d followers", noteId, len(*followers))
+	log.Printf("Outbox: Queued Delete activity for note %s to %d inboxes", noteId, queued)
 	return nil
 }
 
@@ -346,6 +303,396 @@ func SendFollow(localAccount *domain.Account, remoteActorURI string, conf *util.
 	return SendActivity(follow, remoteActor.InboxURI, localAccount, conf)
 }
 
+// SendLike queues a Like activity for a remote post to its author and stores it locally
+// The like is keyed by the post's object URI
+func SendLike(localAccount *domain.Account, objectURI string, authorURI string, conf *util.AppConfig) error {
+	database := db.GetDB()
+
+	// Don't like the same post twice
+	err, existing := database.ReadLikeByAccountAndObjectURI(localAccount.Id, objectURI)
+	if err == nil && existing != nil {
+		return fmt.Errorf("post already liked")
+	}
+
+	author, err := GetOrFetchActor(authorURI)
+	if err != nil {
+		return fmt.Errorf("failed to fetch post author: %w", err)
+	}
+
+	likeID := fmt.Sprintf("https://%s/activities/%s", conf.Conf.SslDomain, uuid.New().String())
+	actorURI := fmt.Sprintf("https://%s/users/%s", conf.Conf.SslDomain, localAccount.Username)
+
+	like := map[string]interface{}{
+		"@context": "https://www.w3.org/ns/activitystreams",
+		"id":       likeID,
+		"type":     "Like",
+		"actor":    actorURI,
+		"object":   objectURI,
+		"to":       []string{author.ActorURI},
+	}
+
+	likeRecord := &domain.Like{
+		Id:        uuid.New(),
+		AccountId: localAccount.Id,
+		URI:       likeID,
+		ObjectURI: objectURI,
+		CreatedAt: time.Now(),
+	}
+	if err := database.CreateLike(likeRecord); err != nil {
+		return fmt.Errorf("failed to store like: %w", err)
+	}
+
+	if err := queueDelivery(like, author.InboxURI, localAccount); err != nil {
+		if delErr := database.DeleteLikeByURI(likeID); delErr != nil {
+			log.Printf("Outbox: Failed to remove unqueued like %s: %v", likeID, delErr)
+		}
+		return fmt.Errorf("failed to queue Like: %w", err)
+	}
+
+	log.Printf("Outbox: Queued Like of %s from %s", objectURI, localAccount.Username)
+	return nil
+}
+
+// SendUndoLike retracts a previously sent Like and removes it locally
+func SendUndoLike(localAccount *domain.Account, objectURI string, authorURI string, conf *util.AppConfig) error {
+	database := db.GetDB()
+
+	err, like := database.ReadLikeByAccountAndObjectURI(localAccount.Id, objectURI)
+	if err != nil || like == nil {
+		return fmt.Errorf("post is not liked")
+	}
+
+	author, err := GetOrFetchActor(authorURI)
+	if err != nil {
+		return fmt.Errorf("failed to fetch post author: %w", err)
+	}
+
+	undoID := fmt.Sprintf("https://%s/activities/%s", conf.Conf.SslDomain, uuid.New().String())
+	actorURI := fmt.Sprintf("https://%s/users/%s", conf.Conf.SslDomain, localAccount.Username)
+
+	undo := map[string]interface{}{
+		"@context": "https://www.w3.org/ns/activitystreams",
+		"id":       undoID,
+		"type":     "Undo",
+		"actor":    actorURI,
+		"object": map[string]interface{}{
+			"id":     like.URI,
+			"type":   "Like",
+			"actor":  actorURI,
+			"object": objectURI,
+		},
+		"to": []string{author.ActorURI},
+	}
+
+	if err := database.DeleteLikeByURI(like.URI); err != nil {
+		return fmt.Errorf("failed to delete like: %w", err)
+	}
+
+	if err := queueDelivery(undo, author.InboxURI, localAccount); err != nil {
+		return fmt.Errorf("failed to queue Undo: %w", err)
+	}
+
+	log.Printf("Outbox: Queued Undo Like of %s from %s", objectURI, localAccount.Username)
+	return nil
+}
+
+// SendAnnounce boosts a local or remote post to the local account's followers
+func SendAnnounce(localAccount *domain.Account, objectURI string, authorURI string, conf *util.AppConfig) error {
+	database := db.GetDB()
+
+	// Don't boost the same post twice
+	err, existing := database.ReadShareByAccountAndObjectURI(localAccount.Id, objectURI)
+	if err == nil && existing != nil {
+		return fmt.Errorf("post already boosted")
+	}
+
+	actorURI := fmt.Sprintf("https://%s/users/%s", conf.Conf.SslDomain, localAccount.Username)
+	announceID := fmt.Sprintf("https://%s/activities/%s", conf.Conf.SslDomain, uuid.New().String())
+
+	announce := map[string]interface{}{
+		"@context":  "https://www.w3.org/ns/activitystreams",
+		"id":        announceID,
+		"type":      "Announce",
+		"actor":     actorURI,
+		"published": time.Now().Format(time.RFC3339),
+		"to": []string{
+			"https://www.w3.org/ns/activitystreams#Public",
+		},
+		"cc": []string{
+			fmt.Sprintf("https://%s/users/%s/followers", conf.Conf.SslDomain, localAccount.Username),
+			authorURI,
+		},
+		"object": objectURI,
+	}
+
+	// Boosts of local notes count towards the note's shares, boosts of remote posts are keyed by object URI
+	noteId, _ := parseLocalNoteId(objectURI, conf)
+
+	shareRecord := &domain.Share{
+		Id:        uuid.New(),
+		AccountId: localAccount.Id,
+		NoteId:    noteId,
+		URI:       announceID,
+		ObjectURI: objectURI,
+		CreatedAt: time.Now(),
+	}
+	if err := database.CreateShare(shareRecord); err != nil {
+		return fmt.Errorf("failed to store share: %w", err)
+	}
+
+	queued := queueToFollowers(announce, localAccount, authorInbox(objectURI, authorURI, conf))
+
+	log.Printf("Outbox: Queued Announce of %s from %s to %d inboxes", objectURI, localAccount.Username, queued)
+	return nil
+}
+
+// SendUndoAnnounce retracts a previously sent boost and removes it locally
+func SendUndoAnnounce(localAccount *domain.Account, objectURI string, authorURI string, conf *util.AppConfig) error {
+	database := db.GetDB()
+
+	err, share := database.ReadShareByAccountAndObjectURI(localAccount.Id, objectURI)
+	if err != nil || share == nil {
+		return fmt.Errorf("post is not boosted")
+	}
+
+	actorURI := fmt.Sprintf("https://%s/users/%s", conf.Conf.SslDomain, localAccount.Username)
+	undoID := fmt.Sprintf("https://%s/activities/%s", conf.Conf.SslDomain, uuid.New().String())
+
+	undo := map[string]interface{}{
+		"@context": "https://www.w3.org/ns/activitystreams",
+		"id":       undoID,
+		"type":     "Undo",
+		"actor":    actorURI,
+		"to": []string{
+			"https://www.w3.org/ns/activitystreams#Public",
+		},
+		"cc": []string{
+			fmt.Sprintf("https://%s/users/%s/followers", conf.Conf.SslDomain, localAccount.Username),
+			authorURI,
+		},
+		"object": map[string]interface{}{
+			"id":     share.URI,
+			"type":   "Announce",
+			"actor":  actorURI,
+			"object": objectURI,
+		},
+	}
+
+	if err := database.DeleteShareByURI(share.URI); err != nil {
+		return fmt.Errorf("failed to delete share: %w", err)
+	}
+
+	queued := queueToFollowers(undo, localAccount, authorInbox(objectURI, authorURI, conf))
+
+	log.Printf("Outbox: Queued Undo Announce of %s from %s to %d inboxes", objectURI, localAccount.Username, queued)
+	return nil
+}
+
+// SendBlock blocks a remote actor: the block is stored, follows in both directions are
+// removed and a Block activity is sent to the actor
+func SendBlock(localAccount *domain.Account, remoteActorURI string, conf *util.AppConfig) error {
+	database := db.GetDB()
+
+	blocked, err := database.IsBlocked(localAccount.Id, remoteActorURI, "")
+	if err == nil && blocked {
+		return fmt.Errorf("actor already blocked")
+	}
+
+	remoteActor, err := GetOrFetchActor(remoteActorURI)
+	if err != nil {
+		return fmt.Errorf("failed to fetch actor: %w", err)
+	}
+
+	blockID := fmt.Sprintf("https://%s/activities/%s", conf.Conf.SslDomain, uuid.New().String())
+	actorURI := fmt.Sprintf("https://%s/users/%s", conf.Conf.SslDomain, localAccount.Username)
+
+	block := map[string]interface{}{
+		"@context": "https://www.w3.org/ns/activitystreams",
+		"id":       blockID,
+		"type":     "Block",
+		"actor":    actorURI,
+		"object":   remoteActor.ActorURI,
+		"to":       []string{remoteActor.ActorURI},
+	}
+
+	blockRecord := &domain.Block{
+		Id:        uuid.New(),
+		AccountId: localAccount.Id,
+		Target:    remoteActor.ActorURI,
+		URI:       blockID,
+		CreatedAt: time.Now(),
+	}
+	if err := database.CreateBlock(blockRecord); err != nil {
+		return fmt.Errorf("failed to store block: %w", err)
+	}
+
+	if err := queueDelivery(block, remoteActor.InboxURI, localAccount); err != nil {
+		return fmt.Errorf("failed to queue Block: %w", err)
+	}
+
+	log.Printf("Outbox: Queued Block of %s from %s", remoteActor.ActorURI, localAccount.Username)
+	return nil
+}
+
+// ErrInvalidDomain is returned by BlockDomain for input that isn't a domain name
+var ErrInvalidDomain = errors.New("invalid domain")
+
+// ErrDomainAlreadyBlocked is returned by BlockDomain for a domain the account blocked before
+var ErrDomainAlreadyBlocked = errors.New("domain already blocked")
+
+// BlockDomain blocks a whole domain for a local account, e.g. "example.com"
+// A domain block only affects this user and is not announced
+func BlockDomain(localAccount *domain.Account, domainName string) error {
+	domainName = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domainName), "/"))
+	if !strings.Contains(domainName, ".") || strings.ContainsAny(domainName, "/@ ") {
+		return fmt.Errorf("%w: %q", ErrInvalidDomain, domainName)
+	}
+
+	database := db.GetDB()
+	if blocked, err := database.IsBlocked(localAccount.Id, "", domainName); err == nil && blocked {
+		return ErrDomainAlreadyBlocked
+	}
+	if err := database.CreateBlock(&domain.Block{
+		Id:        uuid.New(),
+		AccountId: localAccount.Id,
+		Target:    domainName,
+		IsDomain:  true,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		return fmt.Errorf("failed to store domain block: %w", err)
+	}
+
+	log.Printf("Outbox: %s blocked the domain %s", localAccount.Username, domainName)
+	return nil
+}
+
+// SendUndoBlock lifts a block; actor blocks are retracted with an Undo Block activity
+func SendUndoBlock(localAccount *domain.Account, blockId uuid.UUID, conf *util.AppConfig) error {
+	database := db.GetDB()
+
+	err, block := database.ReadBlockById(blockId)
+	if err != nil || block == nil || block.AccountId != localAccount.Id {
+		return fmt.Errorf("block not found")
+	}
+
+	if err := database.DeleteBlock(localAccount.Id, block.Id); err != nil {
+		return fmt.Errorf("failed to delete block: %w", err)
+	}
+
+	// Domain blocks are never announced to the blocked servers
+	if block.IsDomain || block.URI == "" {
+		return nil
+	}
+
+	remoteActor, err := GetOrFetchActor(block.Target)
+	if err != nil {
+		return fmt.Errorf("failed to fetch actor: %w", err)
+	}
+
+	undoID := fmt.Sprintf("https://%s/activities/%s", conf.Conf.SslDomain, uuid.New().String())
+	actorURI := fmt.Sprintf("https://%s/users/%s", conf.Conf.SslDomain, localAccount.Username)
+
+	undo := map[string]interface{}{
+		"@context": "https://www.w3.org/ns/activitystreams",
+		"id":       undoID,
+		"type":     "Undo",
+		"actor":    actorURI,
+		"object": map[string]interface{}{
+			"id":     block.URI,
+			"type":   "Block",
+			"actor":  actorURI,
+			"object": block.Target,
+		},
+		"to": []string{remoteActor.ActorURI},
+	}
+
+	if err := queueDelivery(undo, remoteActor.InboxURI, localAccount); err != nil {
+		return fmt.Errorf("failed to queue Undo: %w", err)
+	}
+
+	log.Printf("Outbox: Queued Undo Block of %s from %s", block.Target, localAccount.Username)
+	return nil
+}
+
+// authorInbox returns the inbox of the author of a boosted post when it lives on another server
+func authorInbox(objectURI string, authorURI string, conf *util.AppConfig) []string {
+	if _, isLocal := parseLocalNoteId(objectURI, conf); isLocal || authorURI == "" {
+		return nil
+	}
+
+	author, err := GetOrFetchActor(authorURI)
+	if err != nil {
+		log.Printf("Outbox: Failed to fetch post author %s: %v", authorURI, err)
+		return nil
+	}
+	return []string{deliveryInbox(author)}
+}
+
+// queueToFollowers queues an activity for every remote follower of localAccount and
+// for the given extra inboxes, delivering at most once per inbox
+// Followers on servers with a shared inbox are collapsed onto a single delivery
+// Returns the number of queued deliveries
+func queueToFollowers(activity interface{}, localAccount *domain.Account, extraInboxes []string) int {
+	database := db.GetDB()
+	seen := make(map[string]bool)
+	queued := 0
+
+	queue := func(inboxURI string) {
+		if inboxURI == "" || seen[inboxURI] {
+			return
+		}
+		seen[inboxURI] = true
+		if err := queueDelivery(activity, inboxURI, localAccount); err != nil {
+			log.Printf("Outbox: Failed to queue delivery to %s: %v", inboxURI, err)
+			return
+		}
+		queued++
+	}
+
+	err, followers := database.ReadFollowersByAccountId(localAccount.Id)
+	if err != nil {
+		log.Printf("Outbox: Failed to get followers: %v", err)
+	} else if followers != nil {
+		for _, follower := range *followers {
+			// AccountId is the follower (remote actor we need to deliver to)
+			err, remoteActor := database.ReadRemoteAccountById(follower.AccountId)
+			if err != nil {
+				continue // Local follower, nothing to deliver
+			}
+			queue(deliveryInbox(remoteActor))
+		}
+	}
+
+	for _, inboxURI := range extraInboxes {
+		queue(inboxURI)
+	}
+
+	return queued
+}
+
+// deliveryInbox returns the inbox to deliver public activities to for a remote actor,
+// preferring the shared inbox of its server
+func deliveryInbox(remoteActor *domain.RemoteAccount) string {
+	if remoteActor.SharedInboxURI != "" {
+		return remoteActor.SharedInboxURI
+	}
+	return remoteActor.InboxURI
+}
+
+// queueDelivery adds an activity of localAccount to the delivery queue for a single inbox
+func queueDelivery(activity interface{}, inboxURI string, localAccount *domain.Account) error {
+	queueItem := &domain.DeliveryQueueItem{
+		Id:           uuid.New(),
+		AccountId:    localAccount.Id,
+		InboxURI:     inboxURI,
+		ActivityJSON: mustMarshal(activity),
+		Attempts:     0,
+		NextRetryAt:  time.Now(),
+		CreatedAt:    time.Now(),
+	}
+	return db.GetDB().EnqueueDelivery(queueItem)
+}
+
 // mustMarshal marshals v to JSON, panicking on error
 func mustMarshal(v interface{}) string {
 	b, err := json.Marshal(v)
@@ -4,11 +4,15 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/deemkeen/stegodon/db"
 	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/util"
 	"github.com/google/uuid"
 )
 
@@ -568,3 +572,104 @@ func TestContextURI(t *testing.T) {
 		t.Error("Context should be from W3C")
 	}
 }
+
+func TestDeliveryInbox(t *testing.T) {
+	actor := &domain.RemoteAccount{InboxURI: "https://example.com/users/bob/inbox"}
+	if got := deliveryInbox(actor); got != actor.InboxURI {
+		t.Errorf("Expected personal inbox without shared inbox, got %s", got)
+	}
+
+	actor.SharedInboxURI = "https://example.com/inbox"
+	if got := deliveryInbox(actor); got != actor.SharedInboxURI {
+		t.Errorf("Expected shared inbox, got %s", got)
+	}
+}
+
+// queuedActivities returns the queued deliveries of an activity type sent for a local account
+func queuedActivities(t *testing.T, accountId uuid.UUID, activityType string) []domain.DeliveryQueueItem {
+	t.Helper()
+	err, deliveries := db.GetDB().ReadPendingDeliveries(1000)
+	if err != nil {
+		t.Fatalf("ReadPendingDeliveries failed: %v", err)
+	}
+
+	var queued []domain.DeliveryQueueItem
+	for _, item := range *deliveries {
+		var activity map[string]interface{}
+		json.Unmarshal([]byte(item.ActivityJSON), &activity)
+		if item.AccountId == accountId && activity["type"] == activityType {
+			queued = append(queued, item)
+		}
+	}
+	return queued
+}
+
+func TestSendLike(t *testing.T) {
+	account := &domain.Account{Id: uuid.New(), Username: "alice"}
+	conf := &util.AppConfig{}
+	conf.Conf.SslDomain = "example.com"
+
+	server := newActorServer(t, func(serverURL string, path string) map[string]interface{} {
+		if path == "/users/dave" {
+			return testActor(serverURL+path, serverURL+path, testKeyPem)
+		}
+		return nil
+	})
+	authorURI := server.URL + "/users/dave"
+	inboxURI := authorURI + "/inbox"
+
+	database := db.GetDB()
+	for i := 0; i < 2; i++ {
+		objectURI := fmt.Sprintf("%s/statuses/%d", authorURI, i)
+		if err := SendLike(account, objectURI, authorURI, conf); err != nil {
+			t.Fatalf("SendLike of %s failed: %v", objectURI, err)
+		}
+		err, like := database.ReadLikeByAccountAndObjectURI(account.Id, objectURI)
+		if err != nil || like == nil {
+			t.Fatalf("Expected the like of %s to be stored, got %v", objectURI, err)
+		}
+		if like.NoteId != uuid.Nil {
+			t.Errorf("Expected a like of a remote post without note id, got %s", like.NoteId)
+		}
+	}
+	if err := SendLike(account, authorURI+"/statuses/0", authorURI, conf); err == nil {
+		t.Error("Expected a second like of the same post to be refused")
+	}
+
+	likes := queuedActivities(t, account.Id, "Like")
+	if len(likes) != 2 || likes[0].InboxURI != inboxURI || likes[1].InboxURI != inboxURI {
+		t.Fatalf("Expected two Likes queued for %s, got %+v", inboxURI, likes)
+	}
+
+	// Undoing a like goes the same way
+	objectURI := authorURI + "/statuses/0"
+	if err := SendUndoLike(account, objectURI, authorURI, conf); err != nil {
+		t.Fatalf("SendUndoLike failed: %v", err)
+	}
+	if err, like := database.ReadLikeByAccountAndObjectURI(account.Id, objectURI); err == nil && like != nil {
+		t.Error("Expected the like to be removed")
+	}
+	if undos := queuedActivities(t, account.Id, "Undo"); len(undos) != 1 || undos[0].InboxURI != inboxURI {
+		t.Errorf("Expected an Undo queued for %s, got %+v", inboxURI, undos)
+	}
+}
+
+func TestBlockDomain(t *testing.T) {
+	account := createTestAccount(t, "domain_blocker")
+
+	for _, input := range []string{"localhost", "bad.example/path", "user@bad.example", "bad .example", ""} {
+		if err := BlockDomain(account, input); !errors.Is(err, ErrInvalidDomain) {
+			t.Errorf("Expected %q to be an invalid domain, got %v", input, err)
+		}
+	}
+
+	if err := BlockDomain(account, " Spam.Example/ "); err != nil {
+		t.Fatalf("BlockDomain failed: %v", err)
+	}
+	if blocked, err := db.GetDB().IsBlocked(account.Id, "", "spam.example"); err != nil || !blocked {
+		t.Errorf("Expected spam.example to be blocked, got %v (%v)", blocked, err)
+	}
+	if err := BlockDomain(account, "spam.example"); !errors.Is(err, ErrDomainAlreadyBlocked) {
+		t.Errorf("Expected a second block to be refused, got %v", err)
+	}
+}
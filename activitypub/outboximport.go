@@ -0,0 +1,127 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/util"
+)
+
+// OutboxFile is the name of the outbox in Mastodon's archive
+const OutboxFile = "outbox.json"
+
+// OutboxImport counts the posts of an imported outbox
+type OutboxImport struct {
+	Total    int // Activities in the outbox
+	Imported int
+	Skipped  int // Posts imported before
+	Ignored  int // Activities that are not public posts, e.g. boosts or followers-only posts
+}
+
+// outboxPost is a public post found in an outbox
+type outboxPost struct {
+	URI       string // ActivityPub id of the original post
+	URL       string // Link to the original post
+	Message   string
+	Published time.Time
+}
+
+// ImportOutbox imports the public posts of an outbox, e.g. outbox.json of a Mastodon archive, as notes
+// The notes keep their original creation time and link to the original post; they are not federated
+// Posts imported before are skipped, so an import can be repeated
+func ImportOutbox(localAccount *domain.Account, r io.Reader) (OutboxImport, error) {
+	var result OutboxImport
+
+	posts, total, err := parseOutboxPosts(r)
+	if err != nil {
+		return result, err
+	}
+	result.Total = total
+	result.Ignored = total - len(posts)
+
+	database := db.GetDB()
+	for _, post := range posts {
+		message := fmt.Sprintf("%s\n\n[Original post](%s)", post.Message, post.URL)
+		created, err := database.CreateImportedNote(localAccount.Id, message, post.Published, post.URI)
+		if err != nil {
+			return result, fmt.Errorf("failed to import %s: %w", post.URI, err)
+		}
+		if created {
+			result.Imported++
+		} else {
+			result.Skipped++
+		}
+	}
+
+	log.Printf("Imported %d of %d outbox activities for %s (%d skipped, %d ignored)",
+		result.Imported, result.Total, localAccount.Username, result.Skipped, result.Ignored)
+	return result, nil
+}
+
+// parseOutboxPosts returns the public posts created in an outbox and the number of activities in it
+func parseOutboxPosts(r io.Reader) ([]outboxPost, int, error) {
+	var outbox struct {
+		OrderedItems []map[string]interface{} `json:"orderedItems"`
+	}
+	if err := json.NewDecoder(r).Decode(&outbox); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse outbox: %w", err)
+	}
+
+	posts := make([]outboxPost, 0, len(outbox.OrderedItems))
+	for _, activity := range outbox.OrderedItems {
+		if post, ok := outboxPostFromActivity(activity); ok {
+			posts = append(posts, post)
+		}
+	}
+	return posts, len(outbox.OrderedItems), nil
+}
+
+// outboxPostFromActivity returns the post of a public Create activity
+func outboxPostFromActivity(activity map[string]interface{}) (outboxPost, bool) {
+	var post outboxPost
+
+	if activityType, _ := activity["type"].(string); activityType != "Create" {
+		return post, false
+	}
+	object, ok := activity["object"].(map[string]interface{})
+	if !ok || !addressedToPublic(object["to"]) && !addressedToPublic(activity["to"]) {
+		return post, false
+	}
+
+	post.URI, _ = object["id"].(string)
+	post.URL = post.URI
+	if url, ok := object["url"].(string); ok && url != "" {
+		post.URL = url
+	}
+	content, _ := object["content"].(string)
+	post.Message = util.HTMLToMarkdown(content)
+	if post.URI == "" || post.Message == "" {
+		return post, false
+	}
+
+	published, _ := object["published"].(string)
+	if published == "" {
+		published, _ = activity["published"].(string)
+	}
+	t, err := time.Parse(time.RFC3339, published)
+	if err != nil {
+		return post, false
+	}
+	post.Published = t
+	return post, true
+}
+
+// addressedToPublic reports whether an addressing field (to) contains the public collection
+func addressedToPublic(addressing interface{}) bool {
+	for _, uri := range uriList(addressing) {
+		if isPublicAddress(uri) {
+			return true
+		}
+	}
+	return false
+}
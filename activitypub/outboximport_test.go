@@ -0,0 +1,76 @@
+package activitypub
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseOutboxPosts(t *testing.T) {
+	outbox := `{
+		"@context": "https://www.w3.org/ns/activitystreams",
+		"type": "OrderedCollection",
+		"orderedItems": [
+			{
+				"type": "Create",
+				"published": "2022-11-05T10:00:00Z",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://mastodon.example/users/alice/statuses/1",
+					"url": "https://mastodon.example/@alice/1",
+					"type": "Note",
+					"published": "2022-11-05T10:00:00Z",
+					"to": ["https://www.w3.org/ns/activitystreams#Public"],
+					"content": "<p>Hello <a href=\"https://mastodon.example/tags/fediverse\" class=\"mention hashtag\" rel=\"tag\">#<span>fediverse</span></a></p>"
+				}
+			},
+			{
+				"type": "Create",
+				"published": "2022-11-06T10:00:00Z",
+				"to": ["https://mastodon.example/users/alice/followers"],
+				"object": {
+					"id": "https://mastodon.example/users/alice/statuses/2",
+					"type": "Note",
+					"to": ["https://mastodon.example/users/alice/followers"],
+					"content": "<p>followers only</p>"
+				}
+			},
+			{
+				"type": "Announce",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": "https://other.example/notes/1"
+			}
+		]
+	}`
+
+	posts, total, err := parseOutboxPosts(strings.NewReader(outbox))
+	if err != nil {
+		t.Fatalf("parseOutboxPosts failed: %v", err)
+	}
+	if total != 3 {
+		t.Errorf("Expected 3 activities, got %d", total)
+	}
+	if len(posts) != 1 {
+		t.Fatalf("Expected only the public post, got %d posts", len(posts))
+	}
+
+	post := posts[0]
+	if post.URI != "https://mastodon.example/users/alice/statuses/1" {
+		t.Errorf("Expected the object id as URI, got %s", post.URI)
+	}
+	if post.URL != "https://mastodon.example/@alice/1" {
+		t.Errorf("Expected the object url as link, got %s", post.URL)
+	}
+	if post.Message != "Hello #fediverse" {
+		t.Errorf("Expected the content as Markdown, got %q", post.Message)
+	}
+	if !post.Published.Equal(time.Date(2022, 11, 5, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the original published time, got %v", post.Published)
+	}
+}
+
+func TestParseOutboxPostsInvalid(t *testing.T) {
+	if _, _, err := parseOutboxPosts(strings.NewReader("not json")); err == nil {
+		t.Error("Expected an error for an invalid outbox")
+	}
+}
@@ -0,0 +1,169 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/util"
+)
+
+// Addressing fields of activities and objects
+var addressingFields = []string{"to", "cc", "bto", "bcc", "audience"}
+
+// sharedInboxRecipients returns the usernames of the local users a shared inbox delivery is meant for
+// Recipients are users addressed or mentioned directly, authors of local notes the activity refers to,
+// and local followers of the actor for public and followers-only activities
+func sharedInboxRecipients(body []byte, remoteActor *domain.RemoteAccount, conf *util.AppConfig) []string {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil
+	}
+
+	database := db.GetDB()
+	var recipients []string
+	seen := make(map[string]bool)
+	add := func(username string) {
+		if username != "" && !seen[username] {
+			seen[username] = true
+			recipients = append(recipients, username)
+		}
+	}
+
+	includeFollowers := false
+	for _, uri := range addressedURIs(raw) {
+		if username := localUsername(uri, conf); username != "" {
+			add(username)
+			continue
+		}
+		if noteId, ok := parseLocalNoteId(uri, conf); ok {
+			if err, note := database.ReadNoteId(noteId); err == nil && note != nil {
+				add(note.CreatedBy)
+			}
+			continue
+		}
+		if isPublicAddress(uri) || isFollowersCollection(uri, remoteActor.ActorURI) {
+			includeFollowers = true
+		}
+	}
+
+	if includeFollowers {
+		for _, username := range localFollowersOf(remoteActor) {
+			add(username)
+		}
+	}
+
+	return recipients
+}
+
+// addressedURIs collects the URIs an activity is addressed to or refers to:
+// the addressing fields and mentions of the activity and its object, and the actors
+// and objects referenced by the object (e.g. the followed actor of an undone Follow)
+func addressedURIs(raw map[string]interface{}) []string {
+	var uris []string
+	collect := func(obj map[string]interface{}) {
+		for _, field := range addressingFields {
+			uris = append(uris, uriList(obj[field])...)
+		}
+		if tags, ok := obj["tag"].([]interface{}); ok {
+			for _, tag := range tags {
+				if t, ok := tag.(map[string]interface{}); ok && t["type"] == "Mention" {
+					uris = append(uris, uriList(t["href"])...)
+				}
+			}
+		}
+	}
+
+	collect(raw)
+	switch obj := raw["object"].(type) {
+	case string:
+		uris = append(uris, obj)
+	case map[string]interface{}:
+		collect(obj)
+		for _, field := range []string{"id", "actor", "object", "inReplyTo"} {
+			uris = append(uris, uriList(obj[field])...)
+		}
+	}
+	return uris
+}
+
+// uriList reads a JSON-LD value that is a URI, an object with an id, or a list of them
+func uriList(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case map[string]interface{}:
+		if id, ok := v["id"].(string); ok {
+			return []string{id}
+		}
+	case []interface{}:
+		var uris []string
+		for _, item := range v {
+			uris = append(uris, uriList(item)...)
+		}
+		return uris
+	}
+	return nil
+}
+
+// isPublicAddress reports whether a URI is the special public collection
+func isPublicAddress(uri string) bool {
+	return uri == "https://www.w3.org/ns/activitystreams#Public" || uri == "as:Public" || uri == "Public"
+}
+
+// isFollowersCollection reports whether a URI looks like the followers collection of the actor
+// Remote actors don't always use <actor>/followers, so any followers collection on the actor's server counts
+func isFollowersCollection(uri string, actorURI string) bool {
+	if !strings.HasSuffix(uri, "/followers") {
+		return false
+	}
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+	actor, err := url.Parse(actorURI)
+	if err != nil {
+		return false
+	}
+	return parsed.Host == actor.Host
+}
+
+// localUsername returns the username of a local actor URI, or "" if it isn't one of our users
+func localUsername(uri string, conf *util.AppConfig) string {
+	prefix := fmt.Sprintf("https://%s/users/", conf.Conf.SslDomain)
+	if !strings.HasPrefix(uri, prefix) {
+		return ""
+	}
+	username := strings.TrimPrefix(uri, prefix)
+	if username == "" || strings.Contains(username, "/") {
+		return ""
+	}
+	if err, acc := db.GetDB().ReadAccByUsername(username); err != nil || acc == nil {
+		return ""
+	}
+	return username
+}
+
+// localFollowersOf returns the usernames of the local users following a remote actor
+func localFollowersOf(remoteActor *domain.RemoteAccount) []string {
+	database := db.GetDB()
+	err, follows := database.ReadFollowersByAccountId(remoteActor.Id)
+	if err != nil || follows == nil {
+		if err != nil {
+			log.Printf("Inbox: Failed to read local followers of %s: %v", remoteActor.ActorURI, err)
+		}
+		return nil
+	}
+
+	var usernames []string
+	for _, follow := range *follows {
+		if err, acc := database.ReadAccById(follow.AccountId); err == nil && acc != nil {
+			usernames = append(usernames, acc.Username)
+		}
+	}
+	return usernames
+}
@@ -0,0 +1,104 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAddressedURIs(t *testing.T) {
+	body := `{
+		"type": "Create",
+		"actor": "https://remote.example/users/bob",
+		"to": ["https://www.w3.org/ns/activitystreams#Public"],
+		"cc": ["https://remote.example/users/bob/followers"],
+		"object": {
+			"id": "https://remote.example/notes/1",
+			"inReplyTo": "https://example.com/notes/abc",
+			"to": "https://example.com/users/alice",
+			"tag": [
+				{"type": "Mention", "href": "https://example.com/users/carol"},
+				{"type": "Hashtag", "href": "https://remote.example/tags/go"}
+			]
+		}
+	}`
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &raw); err != nil {
+		t.Fatalf("Failed to parse activity: %v", err)
+	}
+
+	uris := addressedURIs(raw)
+	for _, expected := range []string{
+		"https://www.w3.org/ns/activitystreams#Public",
+		"https://remote.example/users/bob/followers",
+		"https://example.com/users/alice",
+		"https://example.com/users/carol",
+		"https://example.com/notes/abc",
+	} {
+		if !containsString(uris, expected) {
+			t.Errorf("Expected %s in %v", expected, uris)
+		}
+	}
+	if containsString(uris, "https://remote.example/tags/go") {
+		t.Error("Hashtags should not count as addressed")
+	}
+}
+
+func TestAddressedURIsUndoFollow(t *testing.T) {
+	body := `{
+		"type": "Undo",
+		"actor": "https://remote.example/users/bob",
+		"object": {
+			"type": "Follow",
+			"actor": "https://remote.example/users/bob",
+			"object": "https://example.com/users/alice"
+		}
+	}`
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &raw); err != nil {
+		t.Fatalf("Failed to parse activity: %v", err)
+	}
+
+	if !containsString(addressedURIs(raw), "https://example.com/users/alice") {
+		t.Error("Expected the followed actor of the undone Follow")
+	}
+}
+
+func TestIsFollowersCollection(t *testing.T) {
+	actor := "https://remote.example/users/bob"
+
+	tests := []struct {
+		uri      string
+		expected bool
+	}{
+		{"https://remote.example/users/bob/followers", true},
+		{"https://remote.example/ap/bob/followers", true},
+		{"https://other.example/users/bob/followers", false},
+		{"https://remote.example/users/bob/following", false},
+	}
+	for _, tt := range tests {
+		if got := isFollowersCollection(tt.uri, actor); got != tt.expected {
+			t.Errorf("isFollowersCollection(%q) = %v, want %v", tt.uri, got, tt.expected)
+		}
+	}
+}
+
+func TestIsPublicAddress(t *testing.T) {
+	for _, uri := range []string{"https://www.w3.org/ns/activitystreams#Public", "as:Public", "Public"} {
+		if !isPublicAddress(uri) {
+			t.Errorf("Expected %s to be public", uri)
+		}
+	}
+	if isPublicAddress("https://remote.example/users/bob/followers") {
+		t.Error("Followers collection is not public")
+	}
+}
+
+// containsString reports whether a slice contains the given string
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
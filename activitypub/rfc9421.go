@@ -0,0 +1,411 @@
+package activitypub
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RFC 9421 HTTP Message Signatures, accepted next to the draft-cavage signatures of SignRequest and VerifyRequest
+
+// Label of the signatures we create
+const messageSignatureLabel = "sig1"
+
+// Signature algorithms of RFC 9421 section 6.2 that keys of remote actors can be used with
+const (
+	algRSAv15SHA256 = "rsa-v1_5-sha256"
+	algRSAPSSSHA512 = "rsa-pss-sha512"
+	algEd25519      = "ed25519"
+)
+
+// Components we sign, and the components the signature of an inbox POST must cover
+var (
+	messageGetComponents  = []string{"@method", "@target-uri"}
+	messagePostComponents = []string{"@method", "@target-uri", "content-digest"}
+)
+
+// ErrMalformedSignature is returned for Signature-Input and Signature headers that can't be parsed
+var ErrMalformedSignature = errors.New("malformed message signature")
+
+// messageSignature is a signature from the Signature-Input and Signature headers of a request
+type messageSignature struct {
+	Label      string
+	Components []string
+	Params     string // Signature parameters as received, they are part of the signed data
+	KeyId      string
+	Alg        string
+	Created    int64 // Unix time, 0 when not given
+	Expires    int64 // Unix time, 0 when not given
+	Signature  []byte
+}
+
+// isMessageSignature reports whether a request is signed the RFC 9421 way
+func isMessageSignature(req *http.Request) bool {
+	return req.Header.Get("Signature-Input") != ""
+}
+
+// signMessage signs an outgoing request with an RFC 9421 signature
+// The Content-Digest is signed for requests carrying one, GETs have none
+func signMessage(req *http.Request, privateKey *rsa.PrivateKey, keyId string, now time.Time) error {
+	components := messageGetComponents
+	if req.Header.Get("Content-Digest") != "" {
+		components = messagePostComponents
+	}
+
+	quoted := make([]string, len(components))
+	for i, component := range components {
+		quoted[i] = strconv.Quote(component)
+	}
+	params := fmt.Sprintf(`(%s);created=%d;keyid=%s;alg=%s`,
+		strings.Join(quoted, " "), now.Unix(), strconv.Quote(keyId), strconv.Quote(algRSAv15SHA256))
+
+	base, err := signatureBase(req, components, params)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256([]byte(base))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, hash[:])
+	if err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	req.Header.Set("Signature-Input", messageSignatureLabel+"="+params)
+	req.Header.Set("Signature", messageSignatureLabel+"=:"+base64.StdEncoding.EncodeToString(signature)+":")
+	return nil
+}
+
+// verifyMessageSignature checks an RFC 9421 signature with the public key of its keyid
+func verifyMessageSignature(req *http.Request, sig *messageSignature, publicKey crypto.PublicKey) error {
+	base, err := signatureBase(req, sig.Components, sig.Params)
+	if err != nil {
+		return err
+	}
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		switch sig.Alg {
+		case "", algRSAv15SHA256:
+			hash := sha256.Sum256([]byte(base))
+			return rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], sig.Signature)
+		case algRSAPSSSHA512:
+			hash := sha512.Sum512([]byte(base))
+			return rsa.VerifyPSS(key, crypto.SHA512, hash[:], sig.Signature, &rsa.PSSOptions{SaltLength: 64, Hash: crypto.SHA512})
+		}
+	case ed25519.PublicKey:
+		if sig.Alg == "" || sig.Alg == algEd25519 {
+			if !ed25519.Verify(key, []byte(base), sig.Signature) {
+				return errors.New("ed25519 signature does not match")
+			}
+			return nil
+		}
+	default:
+		return fmt.Errorf("unsupported key type %T", publicKey)
+	}
+	return fmt.Errorf("algorithm %q does not match the key type %T", sig.Alg, publicKey)
+}
+
+// checkMessageSignedPost checks an RFC 9421 signed inbox POST like checkSignedPost does a draft-cavage one:
+// the signature covers the request and body digest, the digest matches the body and the signature is recent
+func checkMessageSignedPost(req *http.Request, body []byte, now time.Time) error {
+	sig, err := parseMessageSignature(req)
+	if err != nil {
+		return err
+	}
+	if err := requireComponents(sig.Components, messagePostComponents); err != nil {
+		return err
+	}
+	if err := VerifyContentDigest(req.Header.Get("Content-Digest"), body); err != nil {
+		return err
+	}
+	return checkMessageSignatureAge(req, sig, now)
+}
+
+// checkMessageSignedGet checks that the RFC 9421 signature of a fetch covers the request and is recent
+func checkMessageSignedGet(req *http.Request, now time.Time) error {
+	sig, err := parseMessageSignature(req)
+	if err != nil {
+		return err
+	}
+	if err := requireComponents(sig.Components, messageGetComponents); err != nil {
+		return err
+	}
+	return checkMessageSignatureAge(req, sig, now)
+}
+
+// checkMessageSignatureAge checks that an RFC 9421 signature hasn't expired and was created recently,
+// or covers a recent Date if it has no creation time
+func checkMessageSignatureAge(req *http.Request, sig *messageSignature, now time.Time) error {
+	if sig.Expires != 0 && now.After(time.Unix(sig.Expires, 0)) {
+		return fmt.Errorf("%w: signature expired at %d", ErrRequestExpired, sig.Expires)
+	}
+	if sig.Created != 0 {
+		created := time.Unix(sig.Created, 0)
+		if created.Before(now.Add(-signatureMaxAge)) || created.After(now.Add(signatureClockSkew)) {
+			return fmt.Errorf("%w: created %s", ErrRequestExpired, created.UTC().Format(time.RFC3339))
+		}
+		return nil
+	}
+
+	// Without a creation time the signed Date has to be recent
+	if err := requireComponents(sig.Components, []string{"date"}); err != nil {
+		return err
+	}
+	return CheckDate(req.Header.Get("Date"), now)
+}
+
+// requireComponents checks that a signature covers all the given components
+func requireComponents(components []string, required []string) error {
+	signed := make(map[string]bool)
+	for _, component := range components {
+		signed[component] = true
+	}
+	for _, component := range required {
+		if !signed[component] {
+			return fmt.Errorf("%w: %s is not signed", ErrUnsignedHeaders, component)
+		}
+	}
+	return nil
+}
+
+// VerifyContentDigest checks an RFC 9530 Content-Digest header against a request body
+// SHA-256 and SHA-512 digests are supported, every supported digest in the header must match
+func VerifyContentDigest(header string, body []byte) error {
+	if header == "" {
+		return fmt.Errorf("%w: missing Content-Digest header", ErrDigestMismatch)
+	}
+
+	checked := 0
+	for _, member := range splitStructured(header, ',') {
+		algorithm, value, ok := strings.Cut(member, "=")
+		if !ok {
+			continue
+		}
+
+		var sum []byte
+		switch strings.ToLower(strings.TrimSpace(algorithm)) {
+		case "sha-256":
+			hash := sha256.Sum256(body)
+			sum = hash[:]
+		case "sha-512":
+			hash := sha512.Sum512(body)
+			sum = hash[:]
+		default:
+			continue
+		}
+
+		expected, err := parseByteSequence(value)
+		if err != nil || subtle.ConstantTimeCompare(expected, sum) != 1 {
+			return fmt.Errorf("%w: %s", ErrDigestMismatch, algorithm)
+		}
+		checked++
+	}
+
+	if checked == 0 {
+		return fmt.Errorf("%w: no supported algorithm in %q", ErrDigestMismatch, header)
+	}
+	return nil
+}
+
+// contentDigest returns the RFC 9530 Content-Digest header of a body
+func contentDigest(body []byte) string {
+	hash := sha256.Sum256(body)
+	return "sha-256=:" + base64.StdEncoding.EncodeToString(hash[:]) + ":"
+}
+
+// parseMessageSignature reads the first signature of the Signature-Input header and its value from the Signature header
+func parseMessageSignature(req *http.Request) (*messageSignature, error) {
+	inputs := splitStructured(strings.Join(req.Header.Values("Signature-Input"), ","), ',')
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("%w: missing Signature-Input", ErrMalformedSignature)
+	}
+	label, input, ok := strings.Cut(inputs[0], "=")
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrMalformedSignature, inputs[0])
+	}
+	sig := &messageSignature{Label: strings.TrimSpace(label), Params: strings.TrimSpace(input)}
+
+	// The covered components, e.g. ("@method" "@target-uri" "content-digest")
+	if !strings.HasPrefix(sig.Params, "(") {
+		return nil, fmt.Errorf("%w: no component list", ErrMalformedSignature)
+	}
+	end := strings.Index(sig.Params, ")")
+	if end < 0 {
+		return nil, fmt.Errorf("%w: unterminated component list", ErrMalformedSignature)
+	}
+	for _, item := range strings.Fields(sig.Params[1:end]) {
+		component, err := parseString(item)
+		if err != nil {
+			return nil, fmt.Errorf("%w: component %s", ErrMalformedSignature, item)
+		}
+		sig.Components = append(sig.Components, component)
+	}
+
+	// The parameters, e.g. ;created=1700000000;keyid="https://example.com/users/alice#main-key"
+	for _, param := range splitStructured(sig.Params[end+1:], ';') {
+		if param == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(param, "=")
+		var err error
+		switch strings.TrimSpace(name) {
+		case "keyid":
+			sig.KeyId, err = parseString(value)
+		case "alg":
+			sig.Alg, err = parseString(value)
+		case "created":
+			sig.Created, err = strconv.ParseInt(value, 10, 64)
+		case "expires":
+			sig.Expires, err = strconv.ParseInt(value, 10, 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: parameter %s", ErrMalformedSignature, param)
+		}
+	}
+	if sig.KeyId == "" {
+		return nil, fmt.Errorf("%w: missing keyid", ErrMalformedSignature)
+	}
+
+	for _, member := range splitStructured(strings.Join(req.Header.Values("Signature"), ","), ',') {
+		name, value, ok := strings.Cut(member, "=")
+		if !ok || strings.TrimSpace(name) != sig.Label {
+			continue
+		}
+		signature, err := parseByteSequence(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: signature %s", ErrMalformedSignature, sig.Label)
+		}
+		sig.Signature = signature
+		return sig, nil
+	}
+	return nil, fmt.Errorf("%w: no signature for %s", ErrMalformedSignature, sig.Label)
+}
+
+// signatureBase builds the data a signature is computed over, RFC 9421 section 2.5
+func signatureBase(req *http.Request, components []string, params string) (string, error) {
+	var base strings.Builder
+	for _, component := range components {
+		value, err := componentValue(req, component)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&base, "%q: %s\n", component, value)
+	}
+	fmt.Fprintf(&base, "%q: %s", "@signature-params", params)
+	return base.String(), nil
+}
+
+// componentValue returns the value of a derived component or header of a request
+// Requests to our inbox arrive through a TLS terminating proxy, their target URI is https
+func componentValue(req *http.Request, component string) (string, error) {
+	authority := req.Host
+	if authority == "" {
+		authority = req.URL.Host
+	}
+
+	switch component {
+	case "@method":
+		return strings.ToUpper(req.Method), nil
+	case "@target-uri":
+		if req.URL.IsAbs() {
+			return req.URL.String(), nil
+		}
+		return "https://" + authority + req.URL.RequestURI(), nil
+	case "@authority":
+		return strings.ToLower(authority), nil
+	case "@scheme":
+		if req.URL.Scheme != "" {
+			return req.URL.Scheme, nil
+		}
+		return "https", nil
+	case "@request-target":
+		return req.URL.RequestURI(), nil
+	case "@path":
+		if path := req.URL.EscapedPath(); path != "" {
+			return path, nil
+		}
+		return "/", nil
+	case "@query":
+		return "?" + req.URL.RawQuery, nil
+	}
+	if strings.HasPrefix(component, "@") {
+		return "", fmt.Errorf("%w: unsupported component %s", ErrMalformedSignature, component)
+	}
+
+	values := req.Header.Values(component)
+	if len(values) == 0 {
+		return "", fmt.Errorf("%w: signed header %s is missing", ErrMalformedSignature, component)
+	}
+	for i, value := range values {
+		values[i] = strings.TrimSpace(value)
+	}
+	return strings.Join(values, ", "), nil
+}
+
+// splitStructured splits a structured header field at separators outside of strings and inner lists
+func splitStructured(value string, separator rune) []string {
+	var parts []string
+	var current strings.Builder
+	quoted, escaped, depth := false, false, 0
+	for _, r := range value {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case !quoted && r == '(':
+			depth++
+		case !quoted && r == ')':
+			depth--
+		case !quoted && depth == 0 && r == separator:
+			parts = append(parts, strings.TrimSpace(current.String()))
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" || len(parts) > 0 {
+		parts = append(parts, rest)
+	}
+	return parts
+}
+
+// parseString decodes a structured field string like "@method"
+func parseString(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return "", fmt.Errorf("not a string: %s", value)
+	}
+	var s strings.Builder
+	escaped := false
+	for _, r := range value[1 : len(value)-1] {
+		if !escaped && r == '\\' {
+			escaped = true
+			continue
+		}
+		escaped = false
+		s.WriteRune(r)
+	}
+	return s.String(), nil
+}
+
+// parseByteSequence decodes a structured field byte sequence like :c2lnbmF0dXJl:
+func parseByteSequence(value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 || value[0] != ':' || value[len(value)-1] != ':' {
+		return nil, fmt.Errorf("not a byte sequence: %s", value)
+	}
+	return base64.StdEncoding.DecodeString(value[1 : len(value)-1])
+}
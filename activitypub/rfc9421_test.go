@@ -0,0 +1,432 @@
+package activitypub
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deemkeen/stegodon/domain"
+)
+
+// inboundRequest turns a signed outgoing request into the request our inbox handler sees
+func inboundRequest(t *testing.T, out *http.Request, body []byte) *http.Request {
+	t.Helper()
+	in := httptest.NewRequest(out.Method, out.URL.RequestURI(), bytes.NewReader(body))
+	in.Host = out.URL.Host
+	for name, values := range out.Header {
+		in.Header[name] = values
+	}
+	return in
+}
+
+func TestMessageSignatureRoundtrip(t *testing.T) {
+	privateKey, publicKey, err := generateTestKeyPair()
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	publicPEM, _ := publicKeyToPEM(publicKey)
+
+	body := []byte(`{"type":"Create","actor":"https://example.com/users/alice"}`)
+	keyId := "https://example.com/users/alice#main-key"
+	out, err := newSignedPost("https://remote.example/users/bob/inbox", body, privateKey, keyId, schemeMessageSignature)
+	if err != nil {
+		t.Fatalf("newSignedPost failed: %v", err)
+	}
+	if !strings.HasPrefix(out.Header.Get("Signature-Input"), `sig1=("@method" "@target-uri" "content-digest");created=`) {
+		t.Errorf("Unexpected Signature-Input %q", out.Header.Get("Signature-Input"))
+	}
+
+	in := inboundRequest(t, out, body)
+	actorURI, err := VerifyRequest(in, publicPEM)
+	if err != nil {
+		t.Fatalf("VerifyRequest failed: %v", err)
+	}
+	if actorURI != "https://example.com/users/alice" {
+		t.Errorf("Expected actor from keyid, got %s", actorURI)
+	}
+	if err := checkSignedPost(in, body, time.Now()); err != nil {
+		t.Errorf("checkSignedPost failed: %v", err)
+	}
+	if got, _ := signatureKeyId(in); got != keyId {
+		t.Errorf("Expected keyId %s, got %s", keyId, got)
+	}
+
+	// A changed body doesn't match the signed digest
+	if err := checkSignedPost(in, []byte(`{"type":"Delete"}`), time.Now()); !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("Expected ErrDigestMismatch, got %v", err)
+	}
+
+	// The signature is bound to the target
+	other := inboundRequest(t, out, body)
+	other.URL.Path = "/users/carol/inbox"
+	if _, err := VerifyRequest(other, publicPEM); err == nil {
+		t.Error("Expected a request to another inbox not to verify")
+	}
+
+	// Old signatures are refused
+	if err := checkSignedPost(in, body, time.Now().Add(13*time.Hour)); !errors.Is(err, ErrRequestExpired) {
+		t.Errorf("Expected ErrRequestExpired, got %v", err)
+	}
+}
+
+func TestCheckMessageSignedPostComponents(t *testing.T) {
+	privateKey, _, _ := generateTestKeyPair()
+	body := []byte(`{"type":"Follow"}`)
+
+	// A signature without the body digest doesn't protect the body
+	out, _ := http.NewRequest("POST", "https://remote.example/inbox", bytes.NewReader(body))
+	out.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	if err := signMessage(out, privateKey, "https://example.com/users/alice#main-key", time.Now()); err != nil {
+		t.Fatalf("signMessage failed: %v", err)
+	}
+	out.Header.Set("Content-Digest", contentDigest(body))
+
+	err := checkSignedPost(inboundRequest(t, out, body), body, time.Now())
+	if !errors.Is(err, ErrUnsignedHeaders) {
+		t.Errorf("Expected ErrUnsignedHeaders, got %v", err)
+	}
+}
+
+// Test case B.2.6 of RFC 9421: signing a request with Ed25519
+func TestVerifyMessageSignatureRFCExample(t *testing.T) {
+	publicPEM := "-----BEGIN PUBLIC KEY-----\nMCowBQYDK2VwAyEAJrQLj5P/89iXES9+vFgrIy29clF9CC/oPPsw3c5D0bs=\n-----END PUBLIC KEY-----\n"
+
+	req := httptest.NewRequest("POST", "/foo?param=Value&Pet=dog", strings.NewReader(`{"hello": "world"}`))
+	req.Host = "example.com"
+	req.Header.Set("Date", "Tue, 20 Apr 2021 02:07:55 GMT")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Length", "18")
+	req.Header.Set("Signature-Input", `sig-b26=("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519"`)
+	req.Header.Set("Signature", `sig-b26=:wqcAqbmYJ2ji2glfAMaRy4gruYYnx2nEFN2HN6jrnDnQCK1u02Gb04v9EDgwUPiu4A0w6vuQv5lIp5WPpBKRCw==:`)
+
+	keyOwner, err := VerifyRequest(req, publicPEM)
+	if err != nil {
+		t.Fatalf("Expected the RFC example to verify, got %v", err)
+	}
+	if keyOwner != "test-key-ed25519" {
+		t.Errorf("Expected the keyid, got %s", keyOwner)
+	}
+
+	req.Header.Set("Content-Type", "text/plain")
+	if _, err := VerifyRequest(req, publicPEM); err == nil {
+		t.Error("Expected a changed signed header not to verify")
+	}
+}
+
+func TestVerifyContentDigest(t *testing.T) {
+	body := []byte(`{"hello": "world"}`)
+
+	// Example of RFC 9530 section 2
+	if err := VerifyContentDigest("sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:", body); err != nil {
+		t.Errorf("Expected the RFC example to match, got %v", err)
+	}
+	if err := VerifyContentDigest(contentDigest(body), body); err != nil {
+		t.Errorf("Expected our own digest to match, got %v", err)
+	}
+
+	tests := []string{
+		"",
+		"sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:, sha-512=:AAAA:",
+		"md5=:AAAA:",
+		"sha-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=",
+	}
+	for _, header := range tests {
+		if err := VerifyContentDigest(header, body); !errors.Is(err, ErrDigestMismatch) {
+			t.Errorf("VerifyContentDigest(%q): expected ErrDigestMismatch, got %v", header, err)
+		}
+	}
+}
+
+func TestParseMessageSignature(t *testing.T) {
+	req := httptest.NewRequest("POST", "/inbox", nil)
+	req.Header.Set("Signature-Input", `sig1=("@method" "@target-uri");created=1700000000;expires=1700000300;keyid="https://a.example/users/alice#main-key, really";alg="rsa-v1_5-sha256", sig2=("@method");keyid="other"`)
+	req.Header.Set("Signature", `sig2=:AAAA:, sig1=:c2lnbmF0dXJl:`)
+
+	sig, err := parseMessageSignature(req)
+	if err != nil {
+		t.Fatalf("parseMessageSignature failed: %v", err)
+	}
+	if sig.Label != "sig1" || sig.KeyId != "https://a.example/users/alice#main-key, really" || sig.Alg != algRSAv15SHA256 {
+		t.Errorf("Unexpected signature %+v", sig)
+	}
+	if sig.Created != 1700000000 || sig.Expires != 1700000300 {
+		t.Errorf("Unexpected times %d, %d", sig.Created, sig.Expires)
+	}
+	if len(sig.Components) != 2 || sig.Components[1] != "@target-uri" {
+		t.Errorf("Unexpected components %v", sig.Components)
+	}
+	if string(sig.Signature) != "signature" {
+		t.Errorf("Expected the signature of sig1, got %q", sig.Signature)
+	}
+
+	malformed := []struct{ input, signature string }{
+		{`sig1=("@method");created=1`, `sig1=:AAAA:`},
+		{`sig1=("@method";keyid="k"`, `sig1=:AAAA:`},
+		{`sig1=(@method);keyid="k"`, `sig1=:AAAA:`},
+		{`sig1=("@method");keyid="k"`, `sig2=:AAAA:`},
+		{`sig1=("@method");keyid="k"`, `sig1=AAAA`},
+	}
+	for _, tt := range malformed {
+		req.Header.Set("Signature-Input", tt.input)
+		req.Header.Set("Signature", tt.signature)
+		if _, err := parseMessageSignature(req); !errors.Is(err, ErrMalformedSignature) {
+			t.Errorf("%s / %s: expected ErrMalformedSignature, got %v", tt.input, tt.signature, err)
+		}
+	}
+}
+
+func TestVerifyActorRequestEd25519(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(publicKey)
+	ed25519PEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	_, rsaPublicKey, _ := generateTestKeyPair()
+	rsaPEM, _ := publicKeyToPEM(rsaPublicKey)
+
+	actor := &domain.RemoteAccount{
+		ActorURI:      "https://a.example/users/alice",
+		PublicKeyPem:  rsaPEM,
+		Ed25519KeyId:  "https://a.example/users/alice#ed25519-key",
+		Ed25519KeyPem: ed25519PEM,
+	}
+
+	req := httptest.NewRequest("GET", "/users/bob", nil)
+	req.Host = "example.com"
+	params := `("@method" "@target-uri");created=1700000000;keyid="https://a.example/users/alice#ed25519-key";alg="ed25519"`
+	base, err := signatureBase(req, messageGetComponents, params)
+	if err != nil {
+		t.Fatalf("signatureBase failed: %v", err)
+	}
+	if !strings.HasPrefix(base, "\"@method\": GET\n\"@target-uri\": https://example.com/users/bob\n") {
+		t.Errorf("Unexpected signature base %q", base)
+	}
+	req.Header.Set("Signature-Input", "sig1="+params)
+	req.Header.Set("Signature", "sig1=:"+base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte(base)))+":")
+
+	actorURI, err := verifyActorRequest(req, actor)
+	if err != nil {
+		t.Fatalf("Expected the Ed25519 key to verify, got %v", err)
+	}
+	if actorURI != actor.ActorURI {
+		t.Errorf("Expected %s, got %s", actor.ActorURI, actorURI)
+	}
+
+	// The RSA key can't verify an Ed25519 signature
+	if _, err := VerifyRequest(req, rsaPEM); err == nil {
+		t.Error("Expected the RSA key not to verify an Ed25519 signature")
+	}
+}
+
+// encodeBase58 encodes bytes with the Bitcoin alphabet
+func encodeBase58(data []byte) string {
+	value := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	var encoded []byte
+	for value.Sign() > 0 {
+		mod := new(big.Int)
+		value.DivMod(value, radix, mod)
+		encoded = append([]byte{base58Alphabet[mod.Int64()]}, encoded...)
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		encoded = append([]byte{'1'}, encoded...)
+	}
+	return string(encoded)
+}
+
+func TestEd25519AssertionKey(t *testing.T) {
+	publicKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	multibase := "z" + encodeBase58(append([]byte{0xed, 0x01}, publicKey...))
+	actorURI := "https://a.example/users/alice"
+
+	assertionMethod := []interface{}{
+		map[string]interface{}{"id": actorURI + "#other", "type": "JsonWebKey2020", "controller": actorURI},
+		map[string]interface{}{"id": actorURI + "#ed25519-key", "type": "Multikey", "controller": actorURI, "publicKeyMultibase": multibase},
+	}
+	keyId, keyPem := ed25519AssertionKey(actorURI, assertionMethod)
+	if keyId != actorURI+"#ed25519-key" {
+		t.Errorf("Expected the Multikey id, got %q", keyId)
+	}
+	parsed, err := parseVerificationKey(keyPem)
+	if err != nil {
+		t.Fatalf("Failed to parse the key PEM: %v", err)
+	}
+	if !publicKey.Equal(parsed) {
+		t.Error("Expected the published key")
+	}
+
+	// Keys controlled by someone else are ignored
+	if keyId, _ := ed25519AssertionKey("https://b.example/users/mallory", assertionMethod); keyId != "" {
+		t.Errorf("Expected no key for another controller, got %s", keyId)
+	}
+	if keyId, _ := ed25519AssertionKey(actorURI, nil); keyId != "" {
+		t.Errorf("Expected no key without assertionMethod, got %s", keyId)
+	}
+
+	if _, err := parseMultikey("z" + encodeBase58(append([]byte{0x12, 0x00}, publicKey...))); err == nil {
+		t.Error("Expected a key with another multicodec to be refused")
+	}
+	if _, err := parseMultikey("u" + multibase[1:]); err == nil {
+		t.Error("Expected another multibase encoding to be refused")
+	}
+}
+
+func TestSignatureSchemes(t *testing.T) {
+	schemes := newSignatureSchemes()
+	now := time.Now()
+	schemes.now = func() time.Time { return now }
+
+	if scheme, chosen := schemes.forHost("schemes.example"); scheme != schemeMessageSignature || chosen {
+		t.Error("Expected RFC 9421 signatures by default")
+	}
+	schemes.useCavage("schemes.example")
+	if scheme, chosen := schemes.forHost("schemes.example"); scheme != schemeCavage || !chosen {
+		t.Error("Expected draft-cavage signatures for a host that refused RFC 9421")
+	}
+	if scheme, _ := schemes.forHost("other.example"); scheme != schemeMessageSignature {
+		t.Error("Expected the choice to be per host")
+	}
+	if scheme, _ := newSignatureSchemes().forHost("schemes.example"); scheme != schemeCavage {
+		t.Error("Expected the choice to be stored")
+	}
+
+	now = now.Add(cavageRecheckInterval)
+	if scheme, chosen := schemes.forHost("schemes.example"); scheme != schemeMessageSignature || chosen {
+		t.Error("Expected RFC 9421 to be tried again after a while")
+	}
+	schemes.useMessageSignatures("schemes.example")
+	if scheme, chosen := schemes.forHost("schemes.example"); scheme != schemeMessageSignature || !chosen {
+		t.Error("Expected RFC 9421 signatures once the host took them")
+	}
+}
+
+// schemeServer is a server that records the signature scheme of each request
+// respond writes the response to a request signed with the scheme
+func schemeServer(t *testing.T, received *[]string, respond func(w http.ResponseWriter, scheme string)) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme := "cavage"
+		if isMessageSignature(r) {
+			scheme = "rfc9421"
+		}
+		*received = append(*received, scheme)
+		respond(w, scheme)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// sendSignedTo sends a signed POST to a server and returns the status of the response
+func sendSignedTo(t *testing.T, server *httptest.Server, privateKey *rsa.PrivateKey) int {
+	t.Helper()
+	inboxURI := server.URL + "/inbox"
+	resp, err := sendSigned(server.Client(), inboxURI, func(scheme signatureScheme) (*http.Request, error) {
+		return newSignedPost(inboxURI, []byte(`{}`), privateKey, "https://example.com/users/alice#main-key", scheme)
+	})
+	if err != nil {
+		t.Fatalf("sendSigned failed: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestSendSignedFallback(t *testing.T) {
+	privateKey, _, _ := generateTestKeyPair()
+	defer func() { hostSchemes = newSignatureSchemes() }()
+	hostSchemes = newSignatureSchemes()
+
+	// A server that only understands draft-cavage signatures
+	var received []string
+	server := schemeServer(t, &received, func(w http.ResponseWriter, scheme string) {
+		if scheme == "rfc9421" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	for i := 0; i < 2; i++ {
+		if status := sendSignedTo(t, server, privateKey); status != http.StatusAccepted {
+			t.Errorf("Expected request %d to be accepted, got %d", i+1, status)
+		}
+	}
+	if got := fmt.Sprint(received); got != "[rfc9421 cavage cavage]" {
+		t.Errorf("Expected one RFC 9421 attempt and draft-cavage afterwards, got %s", got)
+	}
+	if scheme, _ := newSignatureSchemes().forHost(deliveryHost(server.URL)); scheme != schemeCavage {
+		t.Error("Expected draft-cavage to be stored after RFC 9421 was refused")
+	}
+}
+
+func TestSendSignedRecheck(t *testing.T) {
+	privateKey, _, _ := generateTestKeyPair()
+	defer func() { hostSchemes = newSignatureSchemes() }()
+	hostSchemes = newSignatureSchemes()
+
+	// A server that refused RFC 9421 a while ago and takes it now
+	var received []string
+	server := schemeServer(t, &received, func(w http.ResponseWriter, scheme string) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	host := deliveryHost(server.URL)
+	hostSchemes.now = func() time.Time { return time.Now().Add(-cavageRecheckInterval) }
+	hostSchemes.useCavage(host)
+	hostSchemes.now = time.Now
+
+	for i := 0; i < 2; i++ {
+		if status := sendSignedTo(t, server, privateKey); status != http.StatusAccepted {
+			t.Errorf("Expected request %d to be accepted, got %d", i+1, status)
+		}
+	}
+	if got := fmt.Sprint(received); got != "[rfc9421 rfc9421]" {
+		t.Errorf("Expected RFC 9421 to be tried again, got %s", got)
+	}
+	if scheme, chosen := newSignatureSchemes().forHost(host); scheme != schemeMessageSignature || !chosen {
+		t.Error("Expected RFC 9421 to be stored once accepted")
+	}
+}
+
+func TestSendSignedNoRetry(t *testing.T) {
+	privateKey, _, _ := generateTestKeyPair()
+	defer func() { hostSchemes = newSignatureSchemes() }()
+	hostSchemes = newSignatureSchemes()
+
+	// A 403 Forbidden isn't about the signature
+	var forbidden []string
+	blocking := schemeServer(t, &forbidden, func(w http.ResponseWriter, scheme string) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	if status := sendSignedTo(t, blocking, privateKey); status != http.StatusForbidden {
+		t.Errorf("Expected the refusal, got %d", status)
+	}
+
+	// Draft-cavage signatures aren't retried either
+	var refused []string
+	server := schemeServer(t, &refused, func(w http.ResponseWriter, scheme string) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	hostSchemes.useCavage(deliveryHost(server.URL))
+	if status := sendSignedTo(t, server, privateKey); status != http.StatusUnauthorized {
+		t.Errorf("Expected the refusal, got %d", status)
+	}
+
+	if got := fmt.Sprint(forbidden, refused); got != "[rfc9421] [cavage]" {
+		t.Errorf("Expected no retries, got %s", got)
+	}
+}
@@ -0,0 +1,166 @@
+package activitypub
+
+import (
+	"crypto/rsa"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
+)
+
+// signatureScheme is the way outgoing requests to a server are signed
+type signatureScheme int
+
+const (
+	schemeMessageSignature signatureScheme = iota // RFC 9421 Signature-Input and Signature headers
+	schemeCavage                                  // draft-cavage Signature header
+)
+
+// Names of the schemes as stored per host
+var schemeNames = map[signatureScheme]string{
+	schemeMessageSignature: "rfc9421",
+	schemeCavage:           "cavage",
+}
+
+// How long a server that refused RFC 9421 signatures gets draft-cavage ones before RFC 9421 is tried again
+const cavageRecheckInterval = 24 * time.Hour
+
+// hostScheme is the scheme chosen for a host, and when it was chosen
+type hostScheme struct {
+	scheme signatureScheme
+	since  time.Time // Zero if nothing was chosen yet
+}
+
+// signatureSchemes remembers per host how to sign requests to it
+// Servers get RFC 9421 signatures until they refuse them, then draft-cavage ones for a while.
+// Choices are stored, hosts looked up before are cached
+type signatureSchemes struct {
+	mu    sync.Mutex
+	hosts map[string]hostScheme
+	now   func() time.Time
+}
+
+var hostSchemes = newSignatureSchemes()
+
+func newSignatureSchemes() *signatureSchemes {
+	return &signatureSchemes{
+		hosts: make(map[string]hostScheme),
+		now:   time.Now,
+	}
+}
+
+// forHost returns the scheme to sign requests to a host with, and whether that scheme was chosen before
+// Hosts that refused RFC 9421 get it again once cavageRecheckInterval has passed
+func (s *signatureSchemes) forHost(host string) (signatureScheme, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current := s.lookup(host)
+	if current.scheme == schemeCavage && s.now().Sub(current.since) >= cavageRecheckInterval {
+		return schemeMessageSignature, false
+	}
+	return current.scheme, !current.since.IsZero()
+}
+
+// useMessageSignatures remembers that a host accepts RFC 9421 signatures
+func (s *signatureSchemes) useMessageSignatures(host string) {
+	s.choose(host, schemeMessageSignature)
+}
+
+// useCavage remembers that a host only accepts draft-cavage signatures
+func (s *signatureSchemes) useCavage(host string) {
+	s.choose(host, schemeCavage)
+}
+
+// lookup returns the cached or stored scheme of a host, RFC 9421 for unknown hosts
+// The caller holds s.mu
+func (s *signatureSchemes) lookup(host string) hostScheme {
+	if cached, ok := s.hosts[host]; ok {
+		return cached
+	}
+
+	current := hostScheme{scheme: schemeMessageSignature}
+	err, stored := db.GetDB().ReadSignatureScheme(host)
+	if err == nil {
+		current.since = stored.UpdatedAt
+		if stored.Scheme == schemeNames[schemeCavage] {
+			current.scheme = schemeCavage
+		}
+	}
+	s.hosts[host] = current
+	return current
+}
+
+// choose caches and stores the scheme of a host
+func (s *signatureSchemes) choose(host string, scheme signatureScheme) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chosen := hostScheme{scheme: scheme, since: s.now()}
+	s.hosts[host] = chosen
+	err := db.GetDB().SetSignatureScheme(&domain.SignatureScheme{
+		Host:      host,
+		Scheme:    schemeNames[scheme],
+		UpdatedAt: chosen.since,
+	})
+	if err != nil {
+		log.Printf("Failed to store the signature scheme of %s: %v", host, err)
+	}
+}
+
+// signRequest signs an outgoing request with the given scheme
+func signRequest(req *http.Request, privateKey *rsa.PrivateKey, keyId string, scheme signatureScheme) error {
+	if scheme == schemeCavage {
+		return SignRequest(req, privateKey, keyId)
+	}
+	return signMessage(req, privateKey, keyId, time.Now())
+}
+
+// sendSigned sends a request signed with the scheme chosen for the host of uri
+// newRequest builds the request signed with a scheme. Requests are signed RFC 9421 first; a server
+// that refuses the signature gets the request once more signed draft-cavage, and if it takes that one
+// requests to it are signed draft-cavage from then on
+func sendSigned(client *http.Client, uri string, newRequest func(scheme signatureScheme) (*http.Request, error)) (*http.Response, error) {
+	host := deliveryHost(uri)
+	scheme, chosen := hostSchemes.forHost(host)
+
+	req, err := newRequest(scheme)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil || scheme == schemeCavage {
+		return resp, err
+	}
+	if !signatureRefused(resp) {
+		if !chosen {
+			hostSchemes.useMessageSignatures(host)
+		}
+		return resp, nil
+	}
+
+	// Drain the refused response so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+
+	req, err = newRequest(schemeCavage)
+	if err != nil {
+		return nil, err
+	}
+	resp, err = client.Do(req)
+	if err == nil && !signatureRefused(resp) {
+		log.Printf("%s refused an RFC 9421 signature, signing requests to it draft-cavage", host)
+		hostSchemes.useCavage(host)
+	}
+	return resp, err
+}
+
+// signatureRefused reports whether a response means the server didn't accept the request's signature
+// Servers answer 401 Unauthorized for signatures they can't verify; 403 Forbidden, e.g. for blocked
+// servers, isn't about the signature and isn't retried
+func signatureRefused(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusUnauthorized
+}
@@ -0,0 +1,39 @@
+package activitypub
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/util"
+)
+
+// HashtagTags builds the ActivityPub Hashtag tags for the hashtags in a note
+func HashtagTags(message string, conf *util.AppConfig) []map[string]interface{} {
+	hashtags := util.ExtractHashtags(message)
+	tags := make([]map[string]interface{}, 0, len(hashtags))
+	for _, name := range hashtags {
+		tags = append(tags, map[string]interface{}{
+			"type": "Hashtag",
+			"href": fmt.Sprintf("https://%s/tags/%s", conf.Conf.SslDomain, url.PathEscape(name)),
+			"name": "#" + name,
+		})
+	}
+	return tags
+}
+
+// NoteTags returns all ActivityPub tags of a note: its mentions followed by its hashtags
+func NoteTags(message string, mentions []domain.Mention, conf *util.AppConfig) []map[string]interface{} {
+	return append(MentionTags(mentions, conf), HashtagTags(message, conf)...)
+}
+
+// hashtagNames returns the names of the Hashtag entries in a list of ActivityPub tags
+func hashtagNames(tags []ActivityTag) []string {
+	var names []string
+	for _, tag := range tags {
+		if tag.Type == "Hashtag" && tag.Name != "" {
+			names = append(names, tag.Name)
+		}
+	}
+	return names
+}
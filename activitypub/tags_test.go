@@ -0,0 +1,51 @@
+package activitypub
+
+import (
+	"testing"
+
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/util"
+)
+
+func TestNoteTags(t *testing.T) {
+	conf := &util.AppConfig{}
+	conf.Conf.SslDomain = "example.com"
+
+	mentions := []domain.Mention{
+		{Handle: "@bob", ActorURI: "https://example.com/users/bob"},
+	}
+
+	tags := NoteTags("hi @bob, #Stegodon v2 is out #release", mentions, conf)
+	if len(tags) != 3 {
+		t.Fatalf("Expected 3 tags, got %d", len(tags))
+	}
+	if tags[0]["type"] != "Mention" {
+		t.Errorf("Expected mentions first, got %v", tags[0]["type"])
+	}
+	if tags[1]["type"] != "Hashtag" {
+		t.Errorf("Expected type Hashtag, got %v", tags[1]["type"])
+	}
+	if tags[1]["name"] != "#stegodon" {
+		t.Errorf("Expected name #stegodon, got %v", tags[1]["name"])
+	}
+	if tags[1]["href"] != "https://example.com/tags/stegodon" {
+		t.Errorf("Expected tag page href, got %v", tags[1]["href"])
+	}
+
+	if tags := NoteTags("no tags", nil, conf); len(tags) != 0 {
+		t.Errorf("Expected no tags, got %v", tags)
+	}
+}
+
+func TestHashtagNames(t *testing.T) {
+	tags := []ActivityTag{
+		{Type: "Mention", Href: "https://example.com/users/alice", Name: "@alice"},
+		{Type: "Hashtag", Href: "https://remote.example/tags/go", Name: "#Go"},
+		{Type: "Hashtag", Href: "https://remote.example/tags/empty"},
+	}
+
+	names := hashtagNames(tags)
+	if len(names) != 1 || names[0] != "#Go" {
+		t.Errorf("Expected [#Go], got %v", names)
+	}
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"sync"
@@ -43,9 +44,9 @@ const (
 	sqlInsertUser            = `INSERT INTO accounts(id, username, publickey, web_public_key, web_private_key, created_at) VALUES (?, ?, ?, ?, ?, ?)`
 	sqlUpdateLoginUser       = `UPDATE accounts SET first_time_login = 0, username = ?, display_name = ?, summary = ? WHERE publickey = ?`
 	sqlUpdateLoginUserById   = `UPDATE accounts SET first_time_login = 0, username = ?, display_name = ?, summary = ? WHERE id = ?`
-	sqlSelectUserByPublicKey = `SELECT id, username, publickey, created_at, first_time_login, web_public_key, web_private_key, display_name, summary, avatar_url, is_admin, muted FROM accounts WHERE publickey = ?`
-	sqlSelectUserById        = `SELECT id, username, publickey, created_at, first_time_login, web_public_key, web_private_key, display_name, summary, avatar_url, is_admin, muted FROM accounts WHERE id = ?`
-	sqlSelectUserByUsername  = `SELECT id, username, publickey, created_at, first_time_login, web_public_key, web_private_key, display_name, summary, avatar_url, is_admin, muted FROM accounts WHERE username = ?`
+	sqlSelectUserByPublicKey = `SELECT id, username, publickey, created_at, first_time_login, web_public_key, web_private_key, display_name, summary, avatar_url, is_admin, muted, hide_collections, locked, also_known_as, moved_to FROM accounts WHERE publickey = ?`
+	sqlSelectUserById        = `SELECT id, username, publickey, created_at, first_time_login, web_public_key, web_private_key, display_name, summary, avatar_url, is_admin, muted, hide_collections, locked, also_known_as, moved_to FROM accounts WHERE id = ?`
+	sqlSelectUserByUsername  = `SELECT id, username, publickey, created_at, first_time_login, web_public_key, web_private_key, display_name, summary, avatar_url, is_admin, muted, hide_collections, locked, also_known_as, moved_to FROM accounts WHERE username = ?`
 
 	//Notes
 	sqlCreateNotesTable = `CREATE TABLE IF NOT EXISTS notes(
@@ -54,13 +55,16 @@ const (
                         message varchar(1000),
                         created_at timestamp default current_timestamp
                         )`
-	sqlInsertNote     = `INSERT INTO notes(id, user_id, message, created_at) VALUES (?, ?, ?, ?)`
-	sqlUpdateNote     = `UPDATE notes SET message = ?, edited_at = ? WHERE id = ?`
-	sqlDeleteNote     = `DELETE FROM notes WHERE id = ?`
-	sqlSelectNoteById = `SELECT notes.id, accounts.username, notes.message, notes.created_at, notes.edited_at FROM notes
+	sqlInsertNote      = `INSERT INTO notes(id, user_id, message, created_at) VALUES (?, ?, ?, ?)`
+	sqlInsertReplyNote = `INSERT INTO notes(id, user_id, message, in_reply_to_uri, created_at) VALUES (?, ?, ?, ?, ?)`
+	sqlUpdateNote      = `UPDATE notes SET message = ?, edited_at = ? WHERE id = ?`
+	sqlDeleteNote      = `DELETE FROM notes WHERE id = ?`
+	sqlSelectNoteById  = `SELECT notes.id, accounts.username, notes.message, notes.created_at, notes.edited_at, notes.in_reply_to_uri,
+                                                            COALESCE(notes.federated, 1), notes.imported_from FROM notes
     														INNER JOIN accounts ON accounts.id = notes.user_id
                                                             WHERE notes.id = ?`
-	sqlSelectNotesByUserId = `SELECT notes.id, accounts.username, notes.message, notes.created_at, notes.edited_at FROM notes
+	sqlSelectNotesByUserId = `SELECT notes.id, accounts.username, notes.message, notes.created_at, notes.edited_at,
+                                                            COALESCE(notes.federated, 1), notes.imported_from FROM notes
     														INNER JOIN accounts ON accounts.id = notes.user_id
                                                             WHERE notes.user_id = ?
                                                             ORDER BY notes.created_at DESC`
@@ -68,13 +72,20 @@ const (
     														INNER JOIN accounts ON accounts.id = notes.user_id
                                                             WHERE accounts.username = ?
                                                             ORDER BY notes.created_at DESC`
+	sqlSelectNotesInReplyTo = `SELECT notes.id, accounts.username, notes.message, notes.created_at, notes.edited_at, notes.in_reply_to_uri FROM notes
+    														INNER JOIN accounts ON accounts.id = notes.user_id
+                                                            WHERE notes.in_reply_to_uri = ?
+                                                            ORDER BY notes.created_at ASC`
 	sqlSelectAllNotes = `SELECT notes.id, accounts.username, notes.message, notes.created_at, notes.edited_at FROM notes
     														INNER JOIN accounts ON accounts.id = notes.user_id
                                                             ORDER BY notes.created_at DESC`
 
+	// Imported notes are never federated, the unique index on (user_id, imported_from) skips notes imported before
+	sqlInsertImportedNote = `INSERT OR IGNORE INTO notes(id, user_id, message, created_at, federated, imported_from) VALUES (?, ?, ?, ?, 0, ?)`
+
 	// Local users and local timeline queries
-	sqlSelectAllAccounts        = `SELECT id, username, publickey, created_at, first_time_login, web_public_key, web_private_key, display_name, summary, avatar_url, is_admin, muted FROM accounts WHERE first_time_login = 0 ORDER BY username ASC`
-	sqlSelectAllAccountsAdmin   = `SELECT id, username, publickey, created_at, first_time_login, web_public_key, web_private_key, display_name, summary, avatar_url, is_admin, muted FROM accounts ORDER BY created_at ASC`
+	sqlSelectAllAccounts        = `SELECT id, username, publickey, created_at, first_time_login, web_public_key, web_private_key, display_name, summary, avatar_url, is_admin, muted, hide_collections, locked, also_known_as, moved_to FROM accounts WHERE first_time_login = 0 ORDER BY username ASC`
+	sqlSelectAllAccountsAdmin   = `SELECT id, username, publickey, created_at, first_time_login, web_public_key, web_private_key, display_name, summary, avatar_url, is_admin, muted, hide_collections, locked, also_known_as, moved_to FROM accounts ORDER BY created_at ASC`
 	sqlCountAccounts            = `SELECT COUNT(*) FROM accounts`
 	sqlSelectLocalTimelineNotes = `SELECT notes.id, accounts.username, notes.message, notes.created_at, notes.edited_at FROM notes
 														INNER JOIN accounts ON accounts.id = notes.user_id
@@ -88,10 +99,11 @@ const (
 														ORDER BY notes.created_at DESC LIMIT ?`
 
 	// Outbox collection query - returns public notes for ActivityPub outbox
+	// Notes that aren't federated, like imported ones, were never published from here and are left out
 	sqlSelectPublicNotesByUsername = `SELECT notes.id, notes.user_id, notes.message, notes.created_at, notes.edited_at, notes.visibility, notes.object_uri
 														FROM notes
 														INNER JOIN accounts ON accounts.id = notes.user_id
-														WHERE accounts.username = ? AND notes.visibility = 'public'
+														WHERE accounts.username = ? AND notes.visibility = 'public' AND COALESCE(notes.federated, 1) = 1
 														ORDER BY notes.created_at DESC
 														LIMIT ? OFFSET ?`
 )
@@ -133,18 +145,51 @@ func (db *DB) CreateNote(userId uuid.UUID, message string) (uuid.UUID, error) {
 			return err
 		}
 		noteId = id
-		return nil
+		return db.replaceNoteTags(tx, noteId, message)
+	})
+	return noteId, err
+}
+
+// CreateReplyNote creates a note that replies to the post with the given ActivityPub URI
+func (db *DB) CreateReplyNote(userId uuid.UUID, message string, inReplyToURI string) (uuid.UUID, error) {
+	noteId := uuid.New()
+	err := db.wrapTransaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec(sqlInsertReplyNote, noteId, userId, message, inReplyToURI, time.Now().Format("2006-01-02 15:04:05"))
+		if err != nil {
+			return err
+		}
+		return db.replaceNoteTags(tx, noteId, message)
 	})
 	return noteId, err
 }
 
+// CreateImportedNote creates a note imported from another server with its original creation time
+// importedFrom is the URI of the original post; it returns false if the post was already imported
+func (db *DB) CreateImportedNote(userId uuid.UUID, message string, createdAt time.Time, importedFrom string) (bool, error) {
+	created := false
+	err := db.wrapTransaction(func(tx *sql.Tx) error {
+		noteId := uuid.New()
+		result, err := tx.Exec(sqlInsertImportedNote, noteId, userId, message, createdAt.Local().Format("2006-01-02 15:04:05"), importedFrom)
+		if err != nil {
+			return err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil || rows == 0 {
+			return err
+		}
+		created = true
+		return db.replaceNoteTags(tx, noteId, message)
+	})
+	return created, err
+}
+
 func (db *DB) UpdateNote(noteId uuid.UUID, message string) error {
 	return db.wrapTransaction(func(tx *sql.Tx) error {
 		err := db.updateNote(tx, noteId, message)
 		if err != nil {
 			return err
 		}
-		return nil
+		return db.replaceNoteTags(tx, noteId, message)
 	})
 }
 
@@ -181,10 +226,10 @@ func (db *DB) UpdateLoginById(username string, displayName string, summary strin
 func (db *DB) ReadAccBySession(s ssh.Session) (error, *domain.Account) {
 	publicKeyToString := util.PublicKeyToString(s.PublicKey())
 	var tempAcc domain.Account
-	var displayName, summary, avatarURL sql.NullString
-	var isAdmin, muted sql.NullInt64
+	var displayName, summary, avatarURL, alsoKnownAs, movedTo sql.NullString
+	var isAdmin, muted, hideCollections, locked sql.NullInt64
 	row := db.db.QueryRow(sqlSelectUserByPublicKey, util.PkToHash(publicKeyToString))
-	err := row.Scan(&tempAcc.Id, &tempAcc.Username, &tempAcc.Publickey, &tempAcc.CreatedAt, &tempAcc.FirstTimeLogin, &tempAcc.WebPublicKey, &tempAcc.WebPrivateKey, &displayName, &summary, &avatarURL, &isAdmin, &muted)
+	err := row.Scan(&tempAcc.Id, &tempAcc.Username, &tempAcc.Publickey, &tempAcc.CreatedAt, &tempAcc.FirstTimeLogin, &tempAcc.WebPublicKey, &tempAcc.WebPrivateKey, &displayName, &summary, &avatarURL, &isAdmin, &muted, &hideCollections, &locked, &alsoKnownAs, &movedTo)
 	if err == sql.ErrNoRows {
 		return err, nil
 	}
@@ -193,15 +238,19 @@ func (db *DB) ReadAccBySession(s ssh.Session) (error, *domain.Account) {
 	tempAcc.AvatarURL = avatarURL.String
 	tempAcc.IsAdmin = isAdmin.Int64 == 1
 	tempAcc.Muted = muted.Int64 == 1
+	tempAcc.HideCollections = hideCollections.Int64 == 1
+	tempAcc.Locked = locked.Int64 == 1
+	tempAcc.AlsoKnownAs = strings.Fields(alsoKnownAs.String)
+	tempAcc.MovedTo = movedTo.String
 	return err, &tempAcc
 }
 
 func (db *DB) ReadAccByPkHash(pkHash string) (error, *domain.Account) {
 	row := db.db.QueryRow(sqlSelectUserByPublicKey, pkHash)
 	var tempAcc domain.Account
-	var displayName, summary, avatarURL sql.NullString
-	var isAdmin, muted sql.NullInt64
-	err := row.Scan(&tempAcc.Id, &tempAcc.Username, &tempAcc.Publickey, &tempAcc.CreatedAt, &tempAcc.FirstTimeLogin, &tempAcc.WebPublicKey, &tempAcc.WebPrivateKey, &displayName, &summary, &avatarURL, &isAdmin, &muted)
+	var displayName, summary, avatarURL, alsoKnownAs, movedTo sql.NullString
+	var isAdmin, muted, hideCollections, locked sql.NullInt64
+	err := row.Scan(&tempAcc.Id, &tempAcc.Username, &tempAcc.Publickey, &tempAcc.CreatedAt, &tempAcc.FirstTimeLogin, &tempAcc.WebPublicKey, &tempAcc.WebPrivateKey, &displayName, &summary, &avatarURL, &isAdmin, &muted, &hideCollections, &locked, &alsoKnownAs, &movedTo)
 	if err == sql.ErrNoRows {
 		return err, nil
 	}
@@ -210,15 +259,19 @@ func (db *DB) ReadAccByPkHash(pkHash string) (error, *domain.Account) {
 	tempAcc.AvatarURL = avatarURL.String
 	tempAcc.IsAdmin = isAdmin.Int64 == 1
 	tempAcc.Muted = muted.Int64 == 1
+	tempAcc.HideCollections = hideCollections.Int64 == 1
+	tempAcc.Locked = locked.Int64 == 1
+	tempAcc.AlsoKnownAs = strings.Fields(alsoKnownAs.String)
+	tempAcc.MovedTo = movedTo.String
 	return err, &tempAcc
 }
 
 func (db *DB) ReadAccById(id uuid.UUID) (error, *domain.Account) {
 	row := db.db.QueryRow(sqlSelectUserById, id)
 	var tempAcc domain.Account
-	var displayName, summary, avatarURL sql.NullString
-	var isAdmin, muted sql.NullInt64
-	err := row.Scan(&tempAcc.Id, &tempAcc.Username, &tempAcc.Publickey, &tempAcc.CreatedAt, &tempAcc.FirstTimeLogin, &tempAcc.WebPublicKey, &tempAcc.WebPrivateKey, &displayName, &summary, &avatarURL, &isAdmin, &muted)
+	var displayName, summary, avatarURL, alsoKnownAs, movedTo sql.NullString
+	var isAdmin, muted, hideCollections, locked sql.NullInt64
+	err := row.Scan(&tempAcc.Id, &tempAcc.Username, &tempAcc.Publickey, &tempAcc.CreatedAt, &tempAcc.FirstTimeLogin, &tempAcc.WebPublicKey, &tempAcc.WebPrivateKey, &displayName, &summary, &avatarURL, &isAdmin, &muted, &hideCollections, &locked, &alsoKnownAs, &movedTo)
 	if err == sql.ErrNoRows {
 		return err, nil
 	}
@@ -227,15 +280,19 @@ func (db *DB) ReadAccById(id uuid.UUID) (error, *domain.Account) {
 	tempAcc.AvatarURL = avatarURL.String
 	tempAcc.IsAdmin = isAdmin.Int64 == 1
 	tempAcc.Muted = muted.Int64 == 1
+	tempAcc.HideCollections = hideCollections.Int64 == 1
+	tempAcc.Locked = locked.Int64 == 1
+	tempAcc.AlsoKnownAs = strings.Fields(alsoKnownAs.String)
+	tempAcc.MovedTo = movedTo.String
 	return err, &tempAcc
 }
 
 func (db *DB) ReadAccByUsername(username string) (error, *domain.Account) {
 	row := db.db.QueryRow(sqlSelectUserByUsername, username)
 	var tempAcc domain.Account
-	var displayName, summary, avatarURL sql.NullString
-	var isAdmin, muted sql.NullInt64
-	err := row.Scan(&tempAcc.Id, &tempAcc.Username, &tempAcc.Publickey, &tempAcc.CreatedAt, &tempAcc.FirstTimeLogin, &tempAcc.WebPublicKey, &tempAcc.WebPrivateKey, &displayName, &summary, &avatarURL, &isAdmin, &muted)
+	var displayName, summary, avatarURL, alsoKnownAs, movedTo sql.NullString
+	var isAdmin, muted, hideCollections, locked sql.NullInt64
+	err := row.Scan(&tempAcc.Id, &tempAcc.Username, &tempAcc.Publickey, &tempAcc.CreatedAt, &tempAcc.FirstTimeLogin, &tempAcc.WebPublicKey, &tempAcc.WebPrivateKey, &displayName, &summary, &avatarURL, &isAdmin, &muted, &hideCollections, &locked, &alsoKnownAs, &movedTo)
 	if err == sql.ErrNoRows {
 		return err, nil
 	}
@@ -244,6 +301,10 @@ func (db *DB) ReadAccByUsername(username string) (error, *domain.Account) {
 	tempAcc.AvatarURL = avatarURL.String
 	tempAcc.IsAdmin = isAdmin.Int64 == 1
 	tempAcc.Muted = muted.Int64 == 1
+	tempAcc.HideCollections = hideCollections.Int64 == 1
+	tempAcc.Locked = locked.Int64 == 1
+	tempAcc.AlsoKnownAs = strings.Fields(alsoKnownAs.String)
+	tempAcc.MovedTo = movedTo.String
 	return err, &tempAcc
 }
 
@@ -275,10 +336,11 @@ func (db *DB) ReadNotesByUserId(userId uuid.UUID) (error, *[]domain.Note) {
 	for rows.Next() {
 		var note domain.Note
 		var createdAtStr string
-		var editedAtStr sql.NullString
-		if err := rows.Scan(&note.Id, &note.CreatedBy, &note.Message, &createdAtStr, &editedAtStr); err != nil {
+		var editedAtStr, importedFrom sql.NullString
+		if err := rows.Scan(&note.Id, &note.CreatedBy, &note.Message, &createdAtStr, &editedAtStr, &note.Federated, &importedFrom); err != nil {
 			return err, &notes
 		}
+		note.ImportedFrom = importedFrom.String
 
 		if parsedTime, err := parseTimestamp(createdAtStr); err == nil {
 			note.CreatedAt = parsedTime
@@ -339,7 +401,8 @@ func (db *DB) ReadNoteId(id uuid.UUID) (error, *domain.Note) {
 	row := db.db.QueryRow(sqlSelectNoteById, id)
 	var note domain.Note
 	var editedAtStr sql.NullString
-	err := row.Scan(&note.Id, &note.CreatedBy, &note.Message, &note.CreatedAt, &editedAtStr)
+	var inReplyToURI, importedFrom sql.NullString
+	err := row.Scan(&note.Id, &note.CreatedBy, &note.Message, &note.CreatedAt, &editedAtStr, &inReplyToURI, &note.Federated, &importedFrom)
 	if err == sql.ErrNoRows {
 		return err, nil
 	}
@@ -348,9 +411,50 @@ func (db *DB) ReadNoteId(id uuid.UUID) (error, *domain.Note) {
 			note.EditedAt = &parsedTime
 		}
 	}
+	note.InReplyToURI = inReplyToURI.String
+	note.ImportedFrom = importedFrom.String
 	return err, &note
 }
 
+// ReadNotesInReplyTo returns local notes replying to the post with the given ActivityPub URI, oldest first
+func (db *DB) ReadNotesInReplyTo(inReplyToURI string) (error, *[]domain.Note) {
+	rows, err := db.db.Query(sqlSelectNotesInReplyTo, inReplyToURI)
+	if err != nil {
+		return err, nil
+	}
+	defer rows.Close()
+
+	var notes []domain.Note
+
+	for rows.Next() {
+		var note domain.Note
+		var createdAtStr string
+		var editedAtStr sql.NullString
+		var replyToStr sql.NullString
+		if err := rows.Scan(&note.Id, &note.CreatedBy, &note.Message, &createdAtStr, &editedAtStr, &replyToStr); err != nil {
+			return err, &notes
+		}
+
+		if parsedTime, err := parseTimestamp(createdAtStr); err == nil {
+			note.CreatedAt = parsedTime
+		}
+
+		if editedAtStr.Valid {
+			if parsedTime, err := parseTimestamp(editedAtStr.String); err == nil {
+				note.EditedAt = &parsedTime
+			}
+		}
+		note.InReplyToURI = replyToStr.String
+
+		notes = append(notes, note)
+	}
+	if err = rows.Err(); err != nil {
+		return err, &notes
+	}
+
+	return nil, &notes
+}
+
 func (db *DB) ReadAllNotes() (error, *[]domain.Note) {
 	rows, err := db.db.Query(sqlSelectAllNotes)
 	if err != nil {
@@ -513,6 +617,23 @@ func (db *DB) updateNote(tx *sql.Tx, noteId uuid.UUID, message string) error {
 
 func (db *DB) deleteNote(tx *sql.Tx, noteId uuid.UUID) error {
 	_, err := tx.Exec(sqlDeleteNote, noteId)
+	if err != nil {
+		return err
+	}
+	// Remove likes and shares pointing at the deleted note
+	_, err = tx.Exec("DELETE FROM likes WHERE note_id = ?", noteId.String())
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM shares WHERE note_id = ?", noteId.String())
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM mentions WHERE note_id = ?", noteId.String())
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM note_tags WHERE note_id = ?", noteId.String())
 	return err
 }
 
@@ -557,10 +678,10 @@ func (db *DB) wrapTransaction(f func(tx *sql.Tx) error) error {
 
 // Remote Accounts queries
 const (
-	sqlInsertRemoteAccount      = `INSERT INTO remote_accounts(id, username, domain, actor_uri, display_name, summary, inbox_uri, outbox_uri, public_key_pem, avatar_url, last_fetched_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	sqlSelectRemoteAccountByURI = `SELECT id, username, domain, actor_uri, display_name, summary, inbox_uri, outbox_uri, public_key_pem, avatar_url, last_fetched_at FROM remote_accounts WHERE actor_uri = ?`
-	sqlSelectRemoteAccountById  = `SELECT id, username, domain, actor_uri, display_name, summary, inbox_uri, outbox_uri, public_key_pem, avatar_url, last_fetched_at FROM remote_accounts WHERE id = ?`
-	sqlUpdateRemoteAccount      = `UPDATE remote_accounts SET display_name = ?, summary = ?, inbox_uri = ?, outbox_uri = ?, public_key_pem = ?, avatar_url = ?, last_fetched_at = ? WHERE actor_uri = ?`
+	sqlInsertRemoteAccount      = `INSERT INTO remote_accounts(id, username, domain, actor_uri, display_name, summary, inbox_uri, outbox_uri, public_key_pem, avatar_url, last_fetched_at, shared_inbox_uri, ed25519_key_id, ed25519_key_pem) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	sqlSelectRemoteAccountByURI = `SELECT id, username, domain, actor_uri, display_name, summary, inbox_uri, outbox_uri, public_key_pem, avatar_url, last_fetched_at, shared_inbox_uri, ed25519_key_id, ed25519_key_pem FROM remote_accounts WHERE actor_uri = ?`
+	sqlSelectRemoteAccountById  = `SELECT id, username, domain, actor_uri, display_name, summary, inbox_uri, outbox_uri, public_key_pem, avatar_url, last_fetched_at, shared_inbox_uri, ed25519_key_id, ed25519_key_pem FROM remote_accounts WHERE id = ?`
+	sqlUpdateRemoteAccount      = `UPDATE remote_accounts SET display_name = ?, summary = ?, inbox_uri = ?, outbox_uri = ?, public_key_pem = ?, avatar_url = ?, last_fetched_at = ?, shared_inbox_uri = ?, ed25519_key_id = ?, ed25519_key_pem = ? WHERE actor_uri = ?`
 )
 
 func (db *DB) CreateRemoteAccount(acc *domain.RemoteAccount) error {
@@ -577,6 +698,9 @@ func (db *DB) CreateRemoteAccount(acc *domain.RemoteAccount) error {
 			acc.PublicKeyPem,
 			acc.AvatarURL,
 			acc.LastFetchedAt,
+			acc.SharedInboxURI,
+			acc.Ed25519KeyId,
+			acc.Ed25519KeyPem,
 		)
 		return err
 	})
@@ -586,6 +710,7 @@ func (db *DB) ReadRemoteAccountByURI(uri string) (error, *domain.RemoteAccount)
 	row := db.db.QueryRow(sqlSelectRemoteAccountByURI, uri)
 	var acc domain.RemoteAccount
 	var idStr string
+	var sharedInboxURI, ed25519KeyId, ed25519KeyPem sql.NullString
 	err := row.Scan(
 		&idStr,
 		&acc.Username,
@@ -598,6 +723,9 @@ func (db *DB) ReadRemoteAccountByURI(uri string) (error, *domain.RemoteAccount)
 		&acc.PublicKeyPem,
 		&acc.AvatarURL,
 		&acc.LastFetchedAt,
+		&sharedInboxURI,
+		&ed25519KeyId,
+		&ed25519KeyPem,
 	)
 	if err == sql.ErrNoRows {
 		return err, nil
@@ -606,6 +734,9 @@ func (db *DB) ReadRemoteAccountByURI(uri string) (error, *domain.RemoteAccount)
 		return err, nil
 	}
 	acc.Id, _ = uuid.Parse(idStr)
+	acc.SharedInboxURI = sharedInboxURI.String
+	acc.Ed25519KeyId = ed25519KeyId.String
+	acc.Ed25519KeyPem = ed25519KeyPem.String
 	return nil, &acc
 }
 
@@ -613,6 +744,7 @@ func (db *DB) ReadRemoteAccountById(id uuid.UUID) (error, *domain.RemoteAccount)
 	row := db.db.QueryRow(sqlSelectRemoteAccountById, id.String())
 	var acc domain.RemoteAccount
 	var idStr string
+	var sharedInboxURI, ed25519KeyId, ed25519KeyPem sql.NullString
 	err := row.Scan(
 		&idStr,
 		&acc.Username,
@@ -625,6 +757,9 @@ func (db *DB) ReadRemoteAccountById(id uuid.UUID) (error, *domain.RemoteAccount)
 		&acc.PublicKeyPem,
 		&acc.AvatarURL,
 		&acc.LastFetchedAt,
+		&sharedInboxURI,
+		&ed25519KeyId,
+		&ed25519KeyPem,
 	)
 	if err == sql.ErrNoRows {
 		return err, nil
@@ -633,6 +768,9 @@ func (db *DB) ReadRemoteAccountById(id uuid.UUID) (error, *domain.RemoteAccount)
 		return err, nil
 	}
 	acc.Id, _ = uuid.Parse(idStr)
+	acc.SharedInboxURI = sharedInboxURI.String
+	acc.Ed25519KeyId = ed25519KeyId.String
+	acc.Ed25519KeyPem = ed25519KeyPem.String
 	return nil, &acc
 }
 
@@ -646,12 +784,84 @@ func (db *DB) UpdateRemoteAccount(acc *domain.RemoteAccount) error {
 			acc.PublicKeyPem,
 			acc.AvatarURL,
 			acc.LastFetchedAt,
+			acc.SharedInboxURI,
+			acc.Ed25519KeyId,
+			acc.Ed25519KeyPem,
 			acc.ActorURI,
 		)
 		return err
 	})
 }
 
+// Signature scheme queries
+const (
+	sqlUpsertSignatureScheme = `INSERT INTO signature_schemes(host, scheme, updated_at) VALUES (?, ?, ?)
+														ON CONFLICT(host) DO UPDATE SET scheme = excluded.scheme, updated_at = excluded.updated_at`
+	sqlSelectSignatureScheme = `SELECT host, scheme, updated_at FROM signature_schemes WHERE host = ?`
+)
+
+// SetSignatureScheme stores how requests to a remote server are signed
+func (db *DB) SetSignatureScheme(scheme *domain.SignatureScheme) error {
+	return db.wrapTransaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec(sqlUpsertSignatureScheme, scheme.Host, scheme.Scheme, scheme.UpdatedAt)
+		return err
+	})
+}
+
+// ReadSignatureScheme returns how requests to a remote server are signed, sql.ErrNoRows if nothing is stored
+func (db *DB) ReadSignatureScheme(host string) (error, *domain.SignatureScheme) {
+	var scheme domain.SignatureScheme
+	err := db.db.QueryRow(sqlSelectSignatureScheme, host).Scan(&scheme.Host, &scheme.Scheme, &scheme.UpdatedAt)
+	if err != nil {
+		return err, nil
+	}
+	return nil, &scheme
+}
+
+// Key change queries
+const (
+	sqlInsertKeyChange  = `INSERT INTO key_changes(id, actor_uri, old_key_pem, new_key_pem, changed_at) VALUES (?, ?, ?, ?, ?)`
+	sqlSelectKeyChanges = `SELECT id, actor_uri, old_key_pem, new_key_pem, changed_at FROM key_changes ORDER BY changed_at DESC LIMIT ?`
+)
+
+// CreateKeyChange records that the public key of a remote actor changed
+func (db *DB) CreateKeyChange(change *domain.KeyChange) error {
+	return db.wrapTransaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec(sqlInsertKeyChange,
+			change.Id.String(),
+			change.ActorURI,
+			change.OldKeyPem,
+			change.NewKeyPem,
+			change.ChangedAt,
+		)
+		return err
+	})
+}
+
+// ReadKeyChanges returns the most recent key changes of remote actors, newest first
+func (db *DB) ReadKeyChanges(limit int) (error, *[]domain.KeyChange) {
+	rows, err := db.db.Query(sqlSelectKeyChanges, limit)
+	if err != nil {
+		return err, nil
+	}
+	defer rows.Close()
+
+	var changes []domain.KeyChange
+	for rows.Next() {
+		var change domain.KeyChange
+		var idStr string
+		if err := rows.Scan(&idStr, &change.ActorURI, &change.OldKeyPem, &change.NewKeyPem, &change.ChangedAt); err != nil {
+			return err, &changes
+		}
+		change.Id, _ = uuid.Parse(idStr)
+		changes = append(changes, change)
+	}
+	if err = rows.Err(); err != nil {
+		return err, &changes
+	}
+	return nil, &changes
+}
+
 // Follow queries
 const (
 	sqlInsertFollow                  = `INSERT INTO follows(id, account_id, target_account_id, uri, accepted, created_at, is_local) VALUES (?, ?, ?, ?, ?, ?, ?)`
@@ -660,6 +870,7 @@ const (
 	sqlSelectLocalFollowsByAccountId = `SELECT id, account_id, target_account_id, uri, accepted, created_at FROM follows WHERE account_id = ? AND is_local = 1 AND accepted = 1`
 	sqlDeleteLocalFollow             = `DELETE FROM follows WHERE account_id = ? AND target_account_id = ? AND is_local = 1`
 	sqlCheckLocalFollow              = `SELECT COUNT(*) FROM follows WHERE account_id = ? AND target_account_id = ? AND is_local = 1`
+	sqlCheckFollowOrRequest          = `SELECT COUNT(*) FROM follows WHERE account_id = ? AND target_account_id = ?`
 	sqlSelectFollowByAccountIds      = `SELECT id, account_id, target_account_id, uri, accepted, created_at FROM follows WHERE account_id = ? AND target_account_id = ? AND accepted = 1`
 )
 
@@ -753,9 +964,9 @@ func (db *DB) AcceptFollowByURI(uri string) error {
 
 // Activity queries
 const (
-	sqlInsertActivity      = `INSERT INTO activities(id, activity_uri, activity_type, actor_uri, object_uri, raw_json, processed, local, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	sqlUpdateActivity      = `UPDATE activities SET raw_json = ?, processed = ?, object_uri = ? WHERE id = ?`
-	sqlSelectActivityByURI = `SELECT id, activity_uri, activity_type, actor_uri, object_uri, raw_json, processed, local, created_at FROM activities WHERE activity_uri = ?`
+	sqlInsertActivity      = `INSERT INTO activities(id, activity_uri, activity_type, actor_uri, object_uri, raw_json, processed, local, created_at, inbox_username, next_attempt_at, in_reply_to) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	sqlUpdateActivity      = `UPDATE activities SET raw_json = ?, processed = ?, object_uri = ?, in_reply_to = ? WHERE id = ?`
+	sqlSelectActivityByURI = `SELECT id, activity_uri, activity_type, actor_uri, object_uri, raw_json, processed, local, created_at, inbox_username, attempts FROM activities WHERE activity_uri = ?`
 )
 
 func (db *DB) CreateActivity(activity *domain.Activity) error {
@@ -770,6 +981,9 @@ func (db *DB) CreateActivity(activity *domain.Activity) error {
 			activity.Processed,
 			activity.Local,
 			activity.CreatedAt.Format("2006-01-02 15:04:05"),
+			sql.NullString{String: activity.InboxUsername, Valid: activity.InboxUsername != ""},
+			activity.CreatedAt,
+			nullableInReplyTo(activity.RawJSON),
 		)
 		return err
 	})
@@ -781,16 +995,49 @@ func (db *DB) UpdateActivity(activity *domain.Activity) error {
 			activity.RawJSON,
 			activity.Processed,
 			activity.ObjectURI,
+			nullableInReplyTo(activity.RawJSON),
 			activity.Id.String(),
 		)
 		return err
 	})
 }
 
+// activityInReplyTo returns the URI of the post the object of an activity replies to, if any
+func activityInReplyTo(rawJSON string) string {
+	var activity struct {
+		Object json.RawMessage `json:"object"`
+	}
+	if err := json.Unmarshal([]byte(rawJSON), &activity); err != nil {
+		return ""
+	}
+	var object struct {
+		InReplyTo interface{} `json:"inReplyTo"`
+	}
+	if err := json.Unmarshal(activity.Object, &object); err != nil {
+		return ""
+	}
+
+	switch inReplyTo := object.InReplyTo.(type) {
+	case string:
+		return inReplyTo
+	case map[string]interface{}:
+		id, _ := inReplyTo["id"].(string)
+		return id
+	}
+	return ""
+}
+
+// nullableInReplyTo returns the in_reply_to column of an activity, NULL if it's no reply
+func nullableInReplyTo(rawJSON string) sql.NullString {
+	inReplyTo := activityInReplyTo(rawJSON)
+	return sql.NullString{String: inReplyTo, Valid: inReplyTo != ""}
+}
+
 func (db *DB) ReadActivityByURI(uri string) (error, *domain.Activity) {
 	row := db.db.QueryRow(sqlSelectActivityByURI, uri)
 	var activity domain.Activity
 	var idStr string
+	var inboxUsername sql.NullString
 	err := row.Scan(
 		&idStr,
 		&activity.ActivityURI,
@@ -801,6 +1048,8 @@ func (db *DB) ReadActivityByURI(uri string) (error, *domain.Activity) {
 		&activity.Processed,
 		&activity.Local,
 		&activity.CreatedAt,
+		&inboxUsername,
+		&activity.Attempts,
 	)
 	if err == sql.ErrNoRows {
 		return err, nil
@@ -809,9 +1058,148 @@ func (db *DB) ReadActivityByURI(uri string) (error, *domain.Activity) {
 		return err, nil
 	}
 	activity.Id, _ = uuid.Parse(idStr)
+	activity.InboxUsername = inboxUsername.String
 	return nil, &activity
 }
 
+// Inbox processing queries
+const (
+	sqlSelectUnprocessedActivities = `SELECT id, activity_uri, activity_type, actor_uri, object_uri, raw_json, processed, local, created_at, inbox_username, attempts FROM activities
+										WHERE processed = 0 AND local = 0 AND next_attempt_at IS NOT NULL AND next_attempt_at <= ?
+										ORDER BY created_at ASC LIMIT ?`
+	sqlCountUnprocessedActivities = `SELECT COUNT(*) FROM activities WHERE processed = 0 AND local = 0 AND next_attempt_at IS NOT NULL`
+	sqlMarkActivityProcessed      = `UPDATE activities SET processed = 1, last_error = ? WHERE id = ?`
+	sqlUpdateActivityAttempt      = `UPDATE activities SET attempts = ?, next_attempt_at = ?, last_error = ? WHERE id = ?`
+	sqlScheduleActivity           = `UPDATE activities SET processed = 0, next_attempt_at = ? WHERE id = ?`
+)
+
+// ReadUnprocessedActivities returns received activities that are due for processing, oldest first
+func (db *DB) ReadUnprocessedActivities(limit int) (error, *[]domain.Activity) {
+	rows, err := db.db.Query(sqlSelectUnprocessedActivities, time.Now(), limit)
+	if err != nil {
+		return err, nil
+	}
+	defer rows.Close()
+
+	var activities []domain.Activity
+	for rows.Next() {
+		var activity domain.Activity
+		var idStr string
+		var objectURI, inboxUsername sql.NullString
+		if err := rows.Scan(&idStr, &activity.ActivityURI, &activity.ActivityType, &activity.ActorURI, &objectURI,
+			&activity.RawJSON, &activity.Processed, &activity.Local, &activity.CreatedAt, &inboxUsername, &activity.Attempts); err != nil {
+			return err, &activities
+		}
+		activity.Id, _ = uuid.Parse(idStr)
+		activity.ObjectURI = objectURI.String
+		activity.InboxUsername = inboxUsername.String
+		activities = append(activities, activity)
+	}
+	if err = rows.Err(); err != nil {
+		return err, &activities
+	}
+	return nil, &activities
+}
+
+// CountUnprocessedActivities returns the number of received activities waiting for processing
+func (db *DB) CountUnprocessedActivities() (int, error) {
+	var count int
+	err := db.db.QueryRow(sqlCountUnprocessedActivities).Scan(&count)
+	return count, err
+}
+
+// Activity recipient queries
+const (
+	sqlUpsertActivityRecipient = `INSERT INTO activity_recipients(activity_id, username, processed) VALUES (?, ?, ?)
+										ON CONFLICT(activity_id, username) DO UPDATE SET processed = excluded.processed`
+	sqlInsertActivityRecipient  = `INSERT OR IGNORE INTO activity_recipients(activity_id, username, processed) VALUES (?, ?, 0)`
+	sqlSelectActivityRecipients = `SELECT username, processed FROM activity_recipients WHERE activity_id = ?`
+	sqlCountPendingRecipients   = `SELECT COUNT(*) FROM activity_recipients WHERE activity_id = ? AND processed = 0`
+	sqlFinishActivityRecipients = `UPDATE activity_recipients SET processed = 1 WHERE activity_id = ?`
+)
+
+// SetActivityRecipient records whether a received activity was handled for a local user
+func (db *DB) SetActivityRecipient(activityId uuid.UUID, username string, processed bool) error {
+	return db.wrapTransaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec(sqlUpsertActivityRecipient, activityId.String(), username, processed)
+		return err
+	})
+}
+
+// AddActivityRecipient records another local user a stored activity was delivered to
+// Only the new recipient is processed, those handled before are skipped; a known recipient changes nothing
+func (db *DB) AddActivityRecipient(activityId uuid.UUID, username string) error {
+	return db.wrapTransaction(func(tx *sql.Tx) error {
+		result, err := tx.Exec(sqlInsertActivityRecipient, activityId.String(), username)
+		if err != nil {
+			return err
+		}
+		if added, err := result.RowsAffected(); err != nil || added == 0 {
+			return err
+		}
+		_, err = tx.Exec(sqlScheduleActivity, time.Now(), activityId.String())
+		return err
+	})
+}
+
+// ReadActivityRecipients returns the local users a received activity is recorded for
+func (db *DB) ReadActivityRecipients(activityId uuid.UUID) (error, *[]domain.ActivityRecipient) {
+	rows, err := db.db.Query(sqlSelectActivityRecipients, activityId.String())
+	if err != nil {
+		return err, nil
+	}
+	defer rows.Close()
+
+	var recipients []domain.ActivityRecipient
+	for rows.Next() {
+		recipient := domain.ActivityRecipient{ActivityId: activityId}
+		if err := rows.Scan(&recipient.Username, &recipient.Processed); err != nil {
+			return err, &recipients
+		}
+		recipients = append(recipients, recipient)
+	}
+	if err = rows.Err(); err != nil {
+		return err, &recipients
+	}
+	return nil, &recipients
+}
+
+// MarkActivityProcessed marks a received activity as done, with the error it was given up on if any
+// A processed activity stays queued when a recipient was added meanwhile.
+// Recipients it was given up on are marked as handled, so they aren't processed again
+func (db *DB) MarkActivityProcessed(id uuid.UUID, lastError string) error {
+	return db.wrapTransaction(func(tx *sql.Tx) error {
+		if lastError == "" {
+			var pending int
+			if err := tx.QueryRow(sqlCountPendingRecipients, id.String()).Scan(&pending); err != nil || pending > 0 {
+				return err
+			}
+		}
+		_, err := tx.Exec(sqlMarkActivityProcessed,
+			sql.NullString{String: lastError, Valid: lastError != ""},
+			id.String(),
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(sqlFinishActivityRecipients, id.String())
+		return err
+	})
+}
+
+// UpdateActivityAttempt schedules another processing attempt of a received activity
+func (db *DB) UpdateActivityAttempt(id uuid.UUID, attempts int, nextAttempt time.Time, lastError string) error {
+	return db.wrapTransaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec(sqlUpdateActivityAttempt,
+			attempts,
+			nextAttempt,
+			lastError,
+			id.String(),
+		)
+		return err
+	})
+}
+
 // ReadActivityByObjectURI reads an activity by searching for the object URI in the raw JSON
 // This is needed because Create activities are stored with their activity ID, not the Note ID
 func (db *DB) ReadActivityByObjectURI(objectURI string) (error, *domain.Activity) {
@@ -843,14 +1231,59 @@ func (db *DB) ReadActivityByObjectURI(objectURI string) (error, *domain.Activity
 	return nil, &activity
 }
 
-// ReadFederatedActivities returns recent Create activities from remote actors
+// ReadReplyActivities returns stored remote Create activities whose object replies to the given URI, oldest first
+func (db *DB) ReadReplyActivities(inReplyToURI string) (error, *[]domain.Activity) {
+	rows, err := db.db.Query(
+		`SELECT id, activity_uri, activity_type, actor_uri, object_uri, raw_json, processed, local, created_at
+		 FROM activities
+		 WHERE activity_type = 'Create' AND local = 0 AND in_reply_to = ?
+		 ORDER BY created_at ASC`,
+		inReplyToURI,
+	)
+	if err != nil {
+		return err, nil
+	}
+	defer rows.Close()
+
+	var activities []domain.Activity
+	for rows.Next() {
+		var activity domain.Activity
+		var idStr string
+		var createdAtStr string
+		if err := rows.Scan(&idStr, &activity.ActivityURI, &activity.ActivityType, &activity.ActorURI, &activity.ObjectURI, &activity.RawJSON, &activity.Processed, &activity.Local, &createdAtStr); err != nil {
+			return err, &activities
+		}
+		activity.Id, _ = uuid.Parse(idStr)
+
+		if parsedTime, err := parseTimestamp(createdAtStr); err == nil {
+			activity.CreatedAt = parsedTime
+		}
+
+		activities = append(activities, activity)
+	}
+	if err = rows.Err(); err != nil {
+		return err, &activities
+	}
+	return nil, &activities
+}
+
+// ReadFederatedActivities returns recent Create and Announce activities from remote actors
+// Posts by blocked actors and domains, including boosts of them, and by silenced or
+// suspended instances are left out
 const (
-	sqlSelectFederatedActivities          = `SELECT id, activity_uri, activity_type, actor_uri, object_uri, raw_json, processed, local, created_at FROM activities WHERE activity_type = 'Create' AND local = 0 ORDER BY created_at DESC LIMIT ?`
+	sqlSelectFederatedActivities          = `SELECT id, activity_uri, activity_type, actor_uri, object_uri, raw_json, processed, local, created_at FROM activities WHERE activity_type IN ('Create', 'Announce') AND local = 0 ORDER BY created_at DESC LIMIT ?`
 	sqlSelectFederatedActivitiesByFollows = `SELECT a.id, a.activity_uri, a.activity_type, a.actor_uri, a.object_uri, a.raw_json, a.processed, a.local, a.created_at
 		FROM activities a
 		INNER JOIN remote_accounts ra ON ra.actor_uri = a.actor_uri
 		INNER JOIN follows f ON f.target_account_id = ra.id
-		WHERE a.activity_type = 'Create' AND a.local = 0 AND f.account_id = ? AND f.accepted = 1 AND f.is_local = 0
+		WHERE a.activity_type IN ('Create', 'Announce') AND a.local = 0 AND f.account_id = ? AND f.accepted = 1 AND f.is_local = 0
+		AND NOT EXISTS (
+			SELECT 1 FROM blocks b WHERE b.account_id = f.account_id AND (
+				(b.is_domain = 0 AND (a.actor_uri = b.target OR a.object_uri LIKE b.target || '/%'))
+				OR (b.is_domain = 1 AND (a.actor_uri LIKE 'https://' || b.target || '/%' OR a.object_uri LIKE 'https://' || b.target || '/%'))
+			)
+		)
+		AND NOT EXISTS (` + sqlSilencedDomainCondition + `)
 		ORDER BY a.created_at DESC LIMIT ?`
 )
 
@@ -885,16 +1318,24 @@ func (db *DB) ReadFederatedActivities(accountId uuid.UUID, limit int) (error, *[
 
 // Delivery Queue queries
 const (
-	sqlInsertDeliveryQueue     = `INSERT INTO delivery_queue(id, inbox_uri, activity_json, attempts, next_retry_at, created_at) VALUES (?, ?, ?, ?, ?, ?)`
-	sqlSelectPendingDeliveries = `SELECT id, inbox_uri, activity_json, attempts, next_retry_at, created_at FROM delivery_queue WHERE next_retry_at <= ? ORDER BY created_at ASC LIMIT ?`
+	sqlInsertDeliveryQueue     = `INSERT INTO delivery_queue(id, account_id, inbox_uri, activity_json, attempts, next_retry_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	sqlSelectPendingDeliveries = `SELECT id, account_id, inbox_uri, activity_json, attempts, next_retry_at, created_at FROM delivery_queue WHERE next_retry_at <= ? ORDER BY created_at ASC LIMIT ?`
 	sqlUpdateDeliveryAttempt   = `UPDATE delivery_queue SET attempts = ?, next_retry_at = ? WHERE id = ?`
 	sqlDeleteDelivery          = `DELETE FROM delivery_queue WHERE id = ?`
+	sqlCountDeliveries         = `SELECT COUNT(*) FROM delivery_queue`
+	sqlCountDeliveriesByInbox  = `SELECT inbox_uri, COUNT(*) FROM delivery_queue GROUP BY inbox_uri`
+	sqlRetryDeliveriesNow      = `UPDATE delivery_queue SET next_retry_at = ? WHERE inbox_uri = ?`
 )
 
 func (db *DB) EnqueueDelivery(item *domain.DeliveryQueueItem) error {
+	var accountId any
+	if item.AccountId != uuid.Nil {
+		accountId = item.AccountId.String()
+	}
 	return db.wrapTransaction(func(tx *sql.Tx) error {
 		_, err := tx.Exec(sqlInsertDeliveryQueue,
 			item.Id.String(),
+			accountId,
 			item.InboxURI,
 			item.ActivityJSON,
 			item.Attempts,
@@ -916,10 +1357,14 @@ func (db *DB) ReadPendingDeliveries(limit int) (error, *[]domain.DeliveryQueueIt
 	for rows.Next() {
 		var item domain.DeliveryQueueItem
 		var idStr string
-		if err := rows.Scan(&idStr, &item.InboxURI, &item.ActivityJSON, &item.Attempts, &item.NextRetryAt, &item.CreatedAt); err != nil {
+		var accountIdStr sql.NullString
+		if err := rows.Scan(&idStr, &accountIdStr, &item.InboxURI, &item.ActivityJSON, &item.Attempts, &item.NextRetryAt, &item.CreatedAt); err != nil {
 			return err, &items
 		}
 		item.Id, _ = uuid.Parse(idStr)
+		if accountIdStr.Valid {
+			item.AccountId, _ = uuid.Parse(accountIdStr.String)
+		}
 		items = append(items, item)
 	}
 	if err = rows.Err(); err != nil {
@@ -942,50 +1387,212 @@ func (db *DB) DeleteDelivery(id uuid.UUID) error {
 	})
 }
 
-// Follower queries
-const (
-	sqlSelectFollowersByAccountId = `SELECT id, account_id, target_account_id, uri, accepted, created_at, is_local FROM follows WHERE target_account_id = ? AND accepted = 1`
-	sqlSelectFollowingByAccountId = `SELECT id, account_id, target_account_id, uri, accepted, created_at, is_local FROM follows WHERE account_id = ? AND accepted = 1`
-)
+// CountDeliveries returns the number of queued deliveries, including those waiting for a retry
+func (db *DB) CountDeliveries() (int, error) {
+	var count int
+	err := db.db.QueryRow(sqlCountDeliveries).Scan(&count)
+	return count, err
+}
 
-func (db *DB) ReadFollowersByAccountId(accountId uuid.UUID) (error, *[]domain.Follow) {
-	rows, err := db.db.Query(sqlSelectFollowersByAccountId, accountId.String())
+// CountDeliveriesByInbox returns the number of queued deliveries per inbox
+func (db *DB) CountDeliveriesByInbox() (map[string]int, error) {
+	rows, err := db.db.Query(sqlCountDeliveriesByInbox)
 	if err != nil {
-		return err, nil
+		return nil, err
 	}
 	defer rows.Close()
 
-	var followers []domain.Follow
+	counts := make(map[string]int)
 	for rows.Next() {
-		var follow domain.Follow
-		var idStr, accountIdStr, targetIdStr string
-		var isLocal int
-		if err := rows.Scan(&idStr, &accountIdStr, &targetIdStr, &follow.URI, &follow.Accepted, &follow.CreatedAt, &isLocal); err != nil {
-			return err, &followers
+		var inboxURI string
+		var count int
+		if err := rows.Scan(&inboxURI, &count); err != nil {
+			return counts, err
 		}
-		follow.Id, _ = uuid.Parse(idStr)
-		follow.AccountId, _ = uuid.Parse(accountIdStr)
-		follow.TargetAccountId, _ = uuid.Parse(targetIdStr)
-		follow.IsLocal = isLocal == 1
-		followers = append(followers, follow)
-	}
-	if err = rows.Err(); err != nil {
-		return err, &followers
+		counts[inboxURI] = count
 	}
-	return nil, &followers
+	return counts, rows.Err()
 }
 
-// ReadFollowingByAccountId returns all accounts that the given account is following (remote accounts)
-func (db *DB) ReadFollowingByAccountId(accountId uuid.UUID) (error, *[]domain.Follow) {
-	rows, err := db.db.Query(sqlSelectFollowingByAccountId, accountId.String())
-	if err != nil {
-		return err, nil
-	}
-	defer rows.Close()
+// RetryDeliveriesNow makes the queued deliveries to an inbox due immediately
+func (db *DB) RetryDeliveriesNow(inboxURI string) error {
+	return db.wrapTransaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec(sqlRetryDeliveriesNow, time.Now(), inboxURI)
+		return err
+	})
+}
 
-	var following []domain.Follow
-	for rows.Next() {
-		var follow domain.Follow
+// Dead letter queries
+const (
+	sqlInsertDeadDelivery   = `INSERT INTO delivery_dead_letters(id, account_id, inbox_uri, activity_json, attempts, last_error, last_status, created_at, failed_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	sqlSelectDeadDeliveries = `SELECT id, account_id, inbox_uri, activity_json, attempts, last_error, last_status, created_at, failed_at FROM delivery_dead_letters ORDER BY failed_at DESC`
+	sqlRequeueDeadDelivery  = `INSERT INTO delivery_queue(id, account_id, inbox_uri, activity_json, attempts, next_retry_at, created_at)
+								SELECT id, account_id, inbox_uri, activity_json, 0, ?, created_at FROM delivery_dead_letters WHERE inbox_uri = ?`
+	sqlDeleteDeadDeliveriesByInbox = `DELETE FROM delivery_dead_letters WHERE inbox_uri = ?`
+)
+
+// MoveDeliveryToDeadLetters removes an exhausted delivery from the queue and keeps it as a dead letter
+func (db *DB) MoveDeliveryToDeadLetters(item *domain.DeliveryQueueItem, lastError string, lastStatus int) error {
+	var accountId any
+	if item.AccountId != uuid.Nil {
+		accountId = item.AccountId.String()
+	}
+	return db.wrapTransaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec(sqlInsertDeadDelivery,
+			item.Id.String(),
+			accountId,
+			item.InboxURI,
+			item.ActivityJSON,
+			item.Attempts,
+			lastError,
+			lastStatus,
+			item.CreatedAt,
+			time.Now(),
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(sqlDeleteDelivery, item.Id.String())
+		return err
+	})
+}
+
+// ReadDeadDeliveries returns all dead letters, most recently failed first
+func (db *DB) ReadDeadDeliveries() (error, *[]domain.DeadDelivery) {
+	rows, err := db.db.Query(sqlSelectDeadDeliveries)
+	if err != nil {
+		return err, nil
+	}
+	defer rows.Close()
+
+	var items []domain.DeadDelivery
+	for rows.Next() {
+		var item domain.DeadDelivery
+		var idStr string
+		var accountIdStr, lastError sql.NullString
+		if err := rows.Scan(&idStr, &accountIdStr, &item.InboxURI, &item.ActivityJSON, &item.Attempts, &lastError, &item.LastStatus, &item.CreatedAt, &item.FailedAt); err != nil {
+			return err, &items
+		}
+		item.Id, _ = uuid.Parse(idStr)
+		if accountIdStr.Valid {
+			item.AccountId, _ = uuid.Parse(accountIdStr.String)
+		}
+		item.LastError = lastError.String
+		items = append(items, item)
+	}
+	if err = rows.Err(); err != nil {
+		return err, &items
+	}
+	return nil, &items
+}
+
+// RequeueDeadDeliveries moves the dead letters of an inbox back into the delivery queue with fresh attempts
+func (db *DB) RequeueDeadDeliveries(inboxURI string) (int, error) {
+	var count int
+	err := db.wrapTransaction(func(tx *sql.Tx) error {
+		result, err := tx.Exec(sqlRequeueDeadDelivery, time.Now(), inboxURI)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		count = int(affected)
+		_, err = tx.Exec(sqlDeleteDeadDeliveriesByInbox, inboxURI)
+		return err
+	})
+	return count, err
+}
+
+// PurgeDeadDeliveries deletes the dead letters of an inbox
+func (db *DB) PurgeDeadDeliveries(inboxURI string) (int, error) {
+	var count int
+	err := db.wrapTransaction(func(tx *sql.Tx) error {
+		result, err := tx.Exec(sqlDeleteDeadDeliveriesByInbox, inboxURI)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		count = int(affected)
+		return err
+	})
+	return count, err
+}
+
+// Follower queries
+const (
+	sqlSelectFollowersByAccountId      = `SELECT id, account_id, target_account_id, uri, accepted, created_at, is_local FROM follows WHERE target_account_id = ? AND accepted = 1`
+	sqlSelectFollowingByAccountId      = `SELECT id, account_id, target_account_id, uri, accepted, created_at, is_local FROM follows WHERE account_id = ? AND accepted = 1`
+	sqlSelectPendingFollowsByAccountId = `SELECT id, account_id, target_account_id, uri, accepted, created_at, is_local FROM follows WHERE target_account_id = ? AND accepted = 0 AND is_local = 0 ORDER BY created_at ASC`
+)
+
+func (db *DB) ReadFollowersByAccountId(accountId uuid.UUID) (error, *[]domain.Follow) {
+	rows, err := db.db.Query(sqlSelectFollowersByAccountId, accountId.String())
+	if err != nil {
+		return err, nil
+	}
+	defer rows.Close()
+
+	var followers []domain.Follow
+	for rows.Next() {
+		var follow domain.Follow
+		var idStr, accountIdStr, targetIdStr string
+		var isLocal int
+		if err := rows.Scan(&idStr, &accountIdStr, &targetIdStr, &follow.URI, &follow.Accepted, &follow.CreatedAt, &isLocal); err != nil {
+			return err, &followers
+		}
+		follow.Id, _ = uuid.Parse(idStr)
+		follow.AccountId, _ = uuid.Parse(accountIdStr)
+		follow.TargetAccountId, _ = uuid.Parse(targetIdStr)
+		follow.IsLocal = isLocal == 1
+		followers = append(followers, follow)
+	}
+	if err = rows.Err(); err != nil {
+		return err, &followers
+	}
+	return nil, &followers
+}
+
+// ReadPendingFollowsByAccountId returns the follow requests of remote actors awaiting approval by the given account
+func (db *DB) ReadPendingFollowsByAccountId(accountId uuid.UUID) (error, *[]domain.Follow) {
+	rows, err := db.db.Query(sqlSelectPendingFollowsByAccountId, accountId.String())
+	if err != nil {
+		return err, nil
+	}
+	defer rows.Close()
+
+	var requests []domain.Follow
+	for rows.Next() {
+		var follow domain.Follow
+		var idStr, accountIdStr, targetIdStr string
+		var isLocal int
+		if err := rows.Scan(&idStr, &accountIdStr, &targetIdStr, &follow.URI, &follow.Accepted, &follow.CreatedAt, &isLocal); err != nil {
+			return err, &requests
+		}
+		follow.Id, _ = uuid.Parse(idStr)
+		follow.AccountId, _ = uuid.Parse(accountIdStr)
+		follow.TargetAccountId, _ = uuid.Parse(targetIdStr)
+		follow.IsLocal = isLocal == 1
+		requests = append(requests, follow)
+	}
+	if err = rows.Err(); err != nil {
+		return err, &requests
+	}
+	return nil, &requests
+}
+
+// ReadFollowingByAccountId returns all accounts that the given account is following (remote accounts)
+func (db *DB) ReadFollowingByAccountId(accountId uuid.UUID) (error, *[]domain.Follow) {
+	rows, err := db.db.Query(sqlSelectFollowingByAccountId, accountId.String())
+	if err != nil {
+		return err, nil
+	}
+	defer rows.Close()
+
+	var following []domain.Follow
+	for rows.Next() {
+		var follow domain.Follow
 		var idStr, accountIdStr, targetIdStr string
 		var isLocal int
 		if err := rows.Scan(&idStr, &accountIdStr, &targetIdStr, &follow.URI, &follow.Accepted, &follow.CreatedAt, &isLocal); err != nil {
@@ -1014,9 +1621,9 @@ func (db *DB) ReadAllAccounts() (error, *[]domain.Account) {
 	var accounts []domain.Account
 	for rows.Next() {
 		var acc domain.Account
-		var displayName, summary, avatarURL sql.NullString
-		var isAdmin, muted sql.NullInt64
-		if err := rows.Scan(&acc.Id, &acc.Username, &acc.Publickey, &acc.CreatedAt, &acc.FirstTimeLogin, &acc.WebPublicKey, &acc.WebPrivateKey, &displayName, &summary, &avatarURL, &isAdmin, &muted); err != nil {
+		var displayName, summary, avatarURL, alsoKnownAs, movedTo sql.NullString
+		var isAdmin, muted, hideCollections, locked sql.NullInt64
+		if err := rows.Scan(&acc.Id, &acc.Username, &acc.Publickey, &acc.CreatedAt, &acc.FirstTimeLogin, &acc.WebPublicKey, &acc.WebPrivateKey, &displayName, &summary, &avatarURL, &isAdmin, &muted, &hideCollections, &locked, &alsoKnownAs, &movedTo); err != nil {
 			return err, &accounts
 		}
 		acc.DisplayName = displayName.String
@@ -1024,6 +1631,10 @@ func (db *DB) ReadAllAccounts() (error, *[]domain.Account) {
 		acc.AvatarURL = avatarURL.String
 		acc.IsAdmin = isAdmin.Int64 == 1
 		acc.Muted = muted.Int64 == 1
+		acc.HideCollections = hideCollections.Int64 == 1
+		acc.Locked = locked.Int64 == 1
+		acc.AlsoKnownAs = strings.Fields(alsoKnownAs.String)
+		acc.MovedTo = movedTo.String
 		accounts = append(accounts, acc)
 	}
 	if err = rows.Err(); err != nil {
@@ -1043,9 +1654,9 @@ func (db *DB) ReadAllAccountsAdmin() (error, *[]domain.Account) {
 	var accounts []domain.Account
 	for rows.Next() {
 		var acc domain.Account
-		var displayName, summary, avatarURL sql.NullString
-		var isAdmin, muted sql.NullInt64
-		if err := rows.Scan(&acc.Id, &acc.Username, &acc.Publickey, &acc.CreatedAt, &acc.FirstTimeLogin, &acc.WebPublicKey, &acc.WebPrivateKey, &displayName, &summary, &avatarURL, &isAdmin, &muted); err != nil {
+		var displayName, summary, avatarURL, alsoKnownAs, movedTo sql.NullString
+		var isAdmin, muted, hideCollections, locked sql.NullInt64
+		if err := rows.Scan(&acc.Id, &acc.Username, &acc.Publickey, &acc.CreatedAt, &acc.FirstTimeLogin, &acc.WebPublicKey, &acc.WebPrivateKey, &displayName, &summary, &avatarURL, &isAdmin, &muted, &hideCollections, &locked, &alsoKnownAs, &movedTo); err != nil {
 			return err, &accounts
 		}
 		acc.DisplayName = displayName.String
@@ -1053,6 +1664,10 @@ func (db *DB) ReadAllAccountsAdmin() (error, *[]domain.Account) {
 		acc.AvatarURL = avatarURL.String
 		acc.IsAdmin = isAdmin.Int64 == 1
 		acc.Muted = muted.Int64 == 1
+		acc.HideCollections = hideCollections.Int64 == 1
+		acc.Locked = locked.Int64 == 1
+		acc.AlsoKnownAs = strings.Fields(alsoKnownAs.String)
+		acc.MovedTo = movedTo.String
 		accounts = append(accounts, acc)
 	}
 	if err = rows.Err(); err != nil {
@@ -1074,8 +1689,20 @@ func (db *DB) CountAccounts() (int, error) {
 // DeleteAccount deletes a local account and all associated data (notes, follows, activities)
 func (db *DB) DeleteAccount(accountId uuid.UUID) error {
 	return db.wrapTransaction(func(tx *sql.Tx) error {
+		// Delete mentions in this user's notes
+		_, err := tx.Exec("DELETE FROM mentions WHERE note_id IN (SELECT id FROM notes WHERE user_id = ?)", accountId.String())
+		if err != nil {
+			return fmt.Errorf("failed to delete mentions: %w", err)
+		}
+
+		// Delete hashtags of this user's notes
+		_, err = tx.Exec("DELETE FROM note_tags WHERE note_id IN (SELECT id FROM notes WHERE user_id = ?)", accountId.String())
+		if err != nil {
+			return fmt.Errorf("failed to delete note tags: %w", err)
+		}
+
 		// Delete all notes by this user
-		_, err := tx.Exec("DELETE FROM notes WHERE user_id = ?", accountId.String())
+		_, err = tx.Exec("DELETE FROM notes WHERE user_id = ?", accountId.String())
 		if err != nil {
 			return fmt.Errorf("failed to delete notes: %w", err)
 		}
@@ -1093,6 +1720,18 @@ func (db *DB) DeleteAccount(accountId uuid.UUID) error {
 			return fmt.Errorf("failed to delete likes: %w", err)
 		}
 
+		// Delete all shares by this user
+		_, err = tx.Exec("DELETE FROM shares WHERE account_id = ?", accountId.String())
+		if err != nil {
+			return fmt.Errorf("failed to delete shares: %w", err)
+		}
+
+		// Delete all blocks of this user
+		_, err = tx.Exec("DELETE FROM blocks WHERE account_id = ?", accountId.String())
+		if err != nil {
+			return fmt.Errorf("failed to delete blocks: %w", err)
+		}
+
 		// Delete all delivery queue items for this user (if table exists)
 		_, err = tx.Exec("DELETE FROM delivery_queue WHERE account_id = ?", accountId.String())
 		if err != nil {
@@ -1100,6 +1739,12 @@ func (db *DB) DeleteAccount(accountId uuid.UUID) error {
 			log.Printf("Warning: failed to delete delivery queue items (table may not exist): %v", err)
 		}
 
+		// Delete the dead letters of this user too, requeueing them would send for a deleted account
+		_, err = tx.Exec("DELETE FROM delivery_dead_letters WHERE account_id = ?", accountId.String())
+		if err != nil {
+			return fmt.Errorf("failed to delete dead letters: %w", err)
+		}
+
 		// Note: We don't delete activities because they're linked by actor_uri (string) not account_id
 		// Activities will remain as a historical record even after account deletion
 		// This matches ActivityPub behavior where activities persist after account deletion
@@ -1117,8 +1762,20 @@ func (db *DB) DeleteAccount(accountId uuid.UUID) error {
 // MuteUser mutes a user and deletes all their posts
 func (db *DB) MuteUser(accountId uuid.UUID) error {
 	return db.wrapTransaction(func(tx *sql.Tx) error {
+		// Delete mentions in this user's notes
+		_, err := tx.Exec("DELETE FROM mentions WHERE note_id IN (SELECT id FROM notes WHERE user_id = ?)", accountId.String())
+		if err != nil {
+			return fmt.Errorf("failed to delete mentions: %w", err)
+		}
+
+		// Delete hashtags of this user's notes
+		_, err = tx.Exec("DELETE FROM note_tags WHERE note_id IN (SELECT id FROM notes WHERE user_id = ?)", accountId.String())
+		if err != nil {
+			return fmt.Errorf("failed to delete note tags: %w", err)
+		}
+
 		// Delete all notes by this user
-		_, err := tx.Exec("DELETE FROM notes WHERE user_id = ?", accountId.String())
+		_, err = tx.Exec("DELETE FROM notes WHERE user_id = ?", accountId.String())
 		if err != nil {
 			return fmt.Errorf("failed to delete notes: %w", err)
 		}
@@ -1134,6 +1791,38 @@ func (db *DB) MuteUser(accountId uuid.UUID) error {
 	})
 }
 
+// UpdateHideCollections sets whether an account's followers and following lists are hidden from federation
+func (db *DB) UpdateHideCollections(accountId uuid.UUID, hide bool) error {
+	return db.wrapTransaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE accounts SET hide_collections = ? WHERE id = ?", hide, accountId.String())
+		return err
+	})
+}
+
+// UpdateLocked sets whether follows of an account have to be approved manually
+func (db *DB) UpdateLocked(accountId uuid.UUID, locked bool) error {
+	return db.wrapTransaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE accounts SET locked = ? WHERE id = ?", locked, accountId.String())
+		return err
+	})
+}
+
+// UpdateAlsoKnownAs sets the actor URIs of other accounts of the same person
+func (db *DB) UpdateAlsoKnownAs(accountId uuid.UUID, aliases []string) error {
+	return db.wrapTransaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE accounts SET also_known_as = ? WHERE id = ?", strings.Join(aliases, " "), accountId.String())
+		return err
+	})
+}
+
+// UpdateMovedTo sets the actor URI of the account an account moved to
+func (db *DB) UpdateMovedTo(accountId uuid.UUID, movedTo string) error {
+	return db.wrapTransaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE accounts SET moved_to = ? WHERE id = ?", movedTo, accountId.String())
+		return err
+	})
+}
+
 // UnmuteUser unmutes a user
 func (db *DB) UnmuteUser(accountId uuid.UUID) error {
 	return db.wrapTransaction(func(tx *sql.Tx) error {
@@ -1251,6 +1940,16 @@ func (db *DB) IsFollowingLocal(followerAccountId, targetAccountId uuid.UUID) (bo
 	return count > 0, nil
 }
 
+// IsFollowingOrRequested checks if an account follows another account or asked to, accepted or not
+func (db *DB) IsFollowingOrRequested(followerAccountId, targetAccountId uuid.UUID) (bool, error) {
+	var count int
+	err := db.db.QueryRow(sqlCheckFollowOrRequest, followerAccountId.String(), targetAccountId.String()).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
 // ReadLocalFollowsByAccountId returns all local users that an account is following
 func (db *DB) ReadLocalFollowsByAccountId(accountId uuid.UUID) (error, *[]domain.Follow) {
 	rows, err := db.db.Query(sqlSelectLocalFollowsByAccountId, accountId.String())
@@ -1284,6 +1983,14 @@ func (db *DB) DeleteActivity(id uuid.UUID) error {
 	if err != nil {
 		return fmt.Errorf("failed to delete activity: %w", err)
 	}
+	_, err = db.db.Exec("DELETE FROM activity_tags WHERE activity_id = ?", id.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete activity tags: %w", err)
+	}
+	_, err = db.db.Exec("DELETE FROM activity_recipients WHERE activity_id = ?", id.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete activity recipients: %w", err)
+	}
 	return nil
 }
 
@@ -1291,17 +1998,18 @@ func (db *DB) DeleteActivity(id uuid.UUID) error {
 func (db *DB) ReadRemoteAccountByActorURI(actorURI string) (error, *domain.RemoteAccount) {
 	var account domain.RemoteAccount
 	var idStr string
+	var sharedInboxURI, ed25519KeyId, ed25519KeyPem sql.NullString
 
 	err := db.db.QueryRow(
 		`SELECT id, actor_uri, username, domain, display_name, summary, avatar_url,
-		 public_key_pem, inbox_uri, outbox_uri, last_fetched_at
+		 public_key_pem, inbox_uri, outbox_uri, last_fetched_at, shared_inbox_uri, ed25519_key_id, ed25519_key_pem
 		 FROM remote_accounts WHERE actor_uri = ?`,
 		actorURI,
 	).Scan(
 		&idStr, &account.ActorURI, &account.Username, &account.Domain,
 		&account.DisplayName, &account.Summary, &account.AvatarURL,
 		&account.PublicKeyPem, &account.InboxURI, &account.OutboxURI,
-		&account.LastFetchedAt,
+		&account.LastFetchedAt, &sharedInboxURI, &ed25519KeyId, &ed25519KeyPem,
 	)
 
 	if err != nil {
@@ -1312,6 +2020,9 @@ func (db *DB) ReadRemoteAccountByActorURI(actorURI string) (error, *domain.Remot
 	}
 
 	account.Id, _ = uuid.Parse(idStr)
+	account.SharedInboxURI = sharedInboxURI.String
+	account.Ed25519KeyId = ed25519KeyId.String
+	account.Ed25519KeyPem = ed25519KeyPem.String
 	return nil, &account
 }
 
@@ -1334,3 +2045,672 @@ func (db *DB) DeleteFollowsByRemoteAccountId(remoteAccountId uuid.UUID) error {
 	}
 	return nil
 }
+
+// Like queries
+const (
+	sqlInsertLike                   = `INSERT OR IGNORE INTO likes(id, account_id, note_id, uri, object_uri, created_at) VALUES (?, ?, ?, ?, ?, ?)`
+	sqlSelectLikeByURI              = `SELECT id, account_id, note_id, uri, object_uri, created_at FROM likes WHERE uri = ?`
+	sqlSelectLikeByAccountAndObject = `SELECT id, account_id, note_id, uri, object_uri, created_at FROM likes WHERE account_id = ? AND object_uri = ?`
+	sqlDeleteLikeByURI              = `DELETE FROM likes WHERE uri = ?`
+	sqlCountLikesByNoteId           = `SELECT COUNT(*) FROM likes WHERE note_id = ?`
+	sqlCountLikesByNoteIds          = `SELECT note_id, COUNT(*) FROM likes WHERE note_id IN (%s) GROUP BY note_id`
+	sqlSelectLikesByAccountId       = `SELECT id, account_id, note_id, uri, object_uri, created_at FROM likes WHERE account_id = ? ORDER BY created_at DESC`
+)
+
+// CreateLike stores a like on a note, ignoring duplicates from the same account
+// Likes of remote posts have no note id and are unique per object URI
+func (db *DB) CreateLike(like *domain.Like) error {
+	var noteId, objectURI any
+	if like.NoteId != uuid.Nil {
+		noteId = like.NoteId.String()
+	}
+	if like.ObjectURI != "" {
+		objectURI = like.ObjectURI
+	}
+	return db.wrapTransaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec(sqlInsertLike,
+			like.Id.String(),
+			like.AccountId.String(),
+			noteId,
+			like.URI,
+			objectURI,
+			like.CreatedAt,
+		)
+		return err
+	})
+}
+
+func (db *DB) ReadLikeByURI(uri string) (error, *domain.Like) {
+	return scanLike(db.db.QueryRow(sqlSelectLikeByURI, uri))
+}
+
+// ReadLikeByAccountAndObjectURI returns the like an account gave to a remote post, if any
+func (db *DB) ReadLikeByAccountAndObjectURI(accountId uuid.UUID, objectURI string) (error, *domain.Like) {
+	return scanLike(db.db.QueryRow(sqlSelectLikeByAccountAndObject, accountId.String(), objectURI))
+}
+
+// ReadLikesByAccountId returns the likes an account gave, newest first
+func (db *DB) ReadLikesByAccountId(accountId uuid.UUID) (error, *[]domain.Like) {
+	rows, err := db.db.Query(sqlSelectLikesByAccountId, accountId.String())
+	if err != nil {
+		return err, nil
+	}
+	defer rows.Close()
+
+	var likes []domain.Like
+	for rows.Next() {
+		err, like := scanLike(rows)
+		if err != nil {
+			return err, &likes
+		}
+		likes = append(likes, *like)
+	}
+	if err = rows.Err(); err != nil {
+		return err, &likes
+	}
+	return nil, &likes
+}
+
+// scanLike reads a like from a single row or from rows of one of the like queries
+func scanLike(row interface{ Scan(...any) error }) (error, *domain.Like) {
+	var like domain.Like
+	var idStr, accountIdStr string
+	var noteIdStr, objectURI sql.NullString
+	err := row.Scan(&idStr, &accountIdStr, &noteIdStr, &like.URI, &objectURI, &like.CreatedAt)
+	if err == sql.ErrNoRows {
+		return err, nil
+	}
+	if err != nil {
+		return err, nil
+	}
+	like.Id, _ = uuid.Parse(idStr)
+	like.AccountId, _ = uuid.Parse(accountIdStr)
+	like.NoteId, _ = uuid.Parse(noteIdStr.String)
+	like.ObjectURI = objectURI.String
+	return nil, &like
+}
+
+// DeleteLikeByURI removes a like by its ActivityPub Like activity URI (used for Undo)
+func (db *DB) DeleteLikeByURI(uri string) error {
+	return db.wrapTransaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec(sqlDeleteLikeByURI, uri)
+		return err
+	})
+}
+
+// CountLikesByNoteId returns the number of likes on a note
+func (db *DB) CountLikesByNoteId(noteId uuid.UUID) (int, error) {
+	var count int
+	err := db.db.QueryRow(sqlCountLikesByNoteId, noteId.String()).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// CountLikesByNoteIds returns the number of likes on each of the given notes in one query
+// Notes without likes are missing from the map
+func (db *DB) CountLikesByNoteIds(noteIds []uuid.UUID) (map[uuid.UUID]int, error) {
+	return db.countByNoteIds(sqlCountLikesByNoteIds, noteIds)
+}
+
+// countByNoteIds runs a query counting rows per note_id for a list of notes
+// The query has a %s placeholder for the list and returns note_id and count rows
+func (db *DB) countByNoteIds(query string, noteIds []uuid.UUID) (map[uuid.UUID]int, error) {
+	counts := make(map[uuid.UUID]int, len(noteIds))
+	if len(noteIds) == 0 {
+		return counts, nil
+	}
+
+	args := make([]interface{}, len(noteIds))
+	for i, noteId := range noteIds {
+		args[i] = noteId.String()
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(noteIds)), ", ")
+	rows, err := db.db.Query(fmt.Sprintf(query, placeholders), args...)
+	if err != nil {
+		return counts, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var noteIdStr string
+		var count int
+		if err := rows.Scan(&noteIdStr, &count); err != nil {
+			return counts, err
+		}
+		if noteId, err := uuid.Parse(noteIdStr); err == nil {
+			counts[noteId] = count
+		}
+	}
+	return counts, rows.Err()
+}
+
+// Share queries
+const (
+	sqlInsertShare                   = `INSERT OR IGNORE INTO shares(id, account_id, note_id, uri, object_uri, created_at) VALUES (?, ?, ?, ?, ?, ?)`
+	sqlSelectShareByURI              = `SELECT id, account_id, note_id, uri, object_uri, created_at FROM shares WHERE uri = ?`
+	sqlSelectShareByAccountAndObject = `SELECT id, account_id, note_id, uri, object_uri, created_at FROM shares WHERE account_id = ? AND object_uri = ?`
+	sqlDeleteShareByURI              = `DELETE FROM shares WHERE uri = ?`
+	sqlCountSharesByNoteId           = `SELECT COUNT(*) FROM shares WHERE note_id = ?`
+	sqlCountSharesByNoteIds          = `SELECT note_id, COUNT(*) FROM shares WHERE note_id IN (%s) GROUP BY note_id`
+)
+
+// CreateShare stores a boost of a note, ignoring duplicates from the same account
+// Boosts of remote posts have no note id and are keyed by their object URI
+func (db *DB) CreateShare(share *domain.Share) error {
+	var noteId, objectURI any
+	if share.NoteId != uuid.Nil {
+		noteId = share.NoteId.String()
+	}
+	if share.ObjectURI != "" {
+		objectURI = share.ObjectURI
+	}
+	return db.wrapTransaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec(sqlInsertShare,
+			share.Id.String(),
+			share.AccountId.String(),
+			noteId,
+			share.URI,
+			objectURI,
+			share.CreatedAt,
+		)
+		return err
+	})
+}
+
+func (db *DB) ReadShareByURI(uri string) (error, *domain.Share) {
+	return scanShare(db.db.QueryRow(sqlSelectShareByURI, uri))
+}
+
+// ReadShareByAccountAndObjectURI returns the boost an account made of a post, if any
+func (db *DB) ReadShareByAccountAndObjectURI(accountId uuid.UUID, objectURI string) (error, *domain.Share) {
+	return scanShare(db.db.QueryRow(sqlSelectShareByAccountAndObject, accountId.String(), objectURI))
+}
+
+func scanShare(row *sql.Row) (error, *domain.Share) {
+	var share domain.Share
+	var idStr, accountIdStr string
+	var noteIdStr, objectURI sql.NullString
+	err := row.Scan(&idStr, &accountIdStr, &noteIdStr, &share.URI, &objectURI, &share.CreatedAt)
+	if err == sql.ErrNoRows {
+		return err, nil
+	}
+	if err != nil {
+		return err, nil
+	}
+	share.Id, _ = uuid.Parse(idStr)
+	share.AccountId, _ = uuid.Parse(accountIdStr)
+	share.NoteId, _ = uuid.Parse(noteIdStr.String)
+	share.ObjectURI = objectURI.String
+	return nil, &share
+}
+
+// DeleteShareByURI removes a share by its ActivityPub Announce activity URI (used for Undo)
+func (db *DB) DeleteShareByURI(uri string) error {
+	return db.wrapTransaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec(sqlDeleteShareByURI, uri)
+		return err
+	})
+}
+
+// CountSharesByNoteId returns the number of boosts of a note
+func (db *DB) CountSharesByNoteId(noteId uuid.UUID) (int, error) {
+	var count int
+	err := db.db.QueryRow(sqlCountSharesByNoteId, noteId.String()).Scan(&count)
+	return count, err
+}
+
+// CountSharesByNoteIds returns the number of boosts of each of the given notes in one query
+// Notes without boosts are missing from the map
+func (db *DB) CountSharesByNoteIds(noteIds []uuid.UUID) (map[uuid.UUID]int, error) {
+	return db.countByNoteIds(sqlCountSharesByNoteIds, noteIds)
+}
+
+// Mention queries
+const (
+	sqlInsertMention          = `INSERT OR IGNORE INTO mentions(id, note_id, handle, actor_uri, created_at) VALUES (?, ?, ?, ?, ?)`
+	sqlDeleteMentionsByNoteId = `DELETE FROM mentions WHERE note_id = ?`
+	sqlSelectMentionsByNoteId = `SELECT id, note_id, handle, actor_uri, created_at FROM mentions WHERE note_id = ? ORDER BY created_at ASC`
+)
+
+// ReplaceNoteMentions stores the mentions of a note, replacing any previously stored ones
+func (db *DB) ReplaceNoteMentions(noteId uuid.UUID, mentions []domain.Mention) error {
+	return db.wrapTransaction(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(sqlDeleteMentionsByNoteId, noteId.String()); err != nil {
+			return err
+		}
+		for _, mention := range mentions {
+			_, err := tx.Exec(sqlInsertMention,
+				mention.Id.String(),
+				noteId.String(),
+				mention.Handle,
+				mention.ActorURI,
+				mention.CreatedAt,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ReadMentionsByNoteId returns the users mentioned in a note
+func (db *DB) ReadMentionsByNoteId(noteId uuid.UUID) (error, *[]domain.Mention) {
+	rows, err := db.db.Query(sqlSelectMentionsByNoteId, noteId.String())
+	if err != nil {
+		return err, nil
+	}
+	defer rows.Close()
+
+	var mentions []domain.Mention
+	for rows.Next() {
+		var mention domain.Mention
+		var idStr, noteIdStr string
+		if err := rows.Scan(&idStr, &noteIdStr, &mention.Handle, &mention.ActorURI, &mention.CreatedAt); err != nil {
+			return err, &mentions
+		}
+		mention.Id, _ = uuid.Parse(idStr)
+		mention.NoteId, _ = uuid.Parse(noteIdStr)
+		mentions = append(mentions, mention)
+	}
+	if err = rows.Err(); err != nil {
+		return err, &mentions
+	}
+	return nil, &mentions
+}
+
+// Tag queries
+const (
+	sqlInsertTag              = `INSERT OR IGNORE INTO tags(id, name, created_at) VALUES (?, ?, ?)`
+	sqlSelectTagIdByName      = `SELECT id FROM tags WHERE name = ?`
+	sqlDeleteNoteTagsByNoteId = `DELETE FROM note_tags WHERE note_id = ?`
+	sqlInsertNoteTag          = `INSERT OR IGNORE INTO note_tags(note_id, tag_id) VALUES (?, ?)`
+	sqlInsertActivityTag      = `INSERT OR IGNORE INTO activity_tags(activity_id, tag_id) VALUES (?, ?)`
+	sqlSelectPublicNotesByTag = `SELECT notes.id, accounts.username, notes.message, notes.created_at, notes.edited_at, notes.object_uri FROM notes
+														INNER JOIN accounts ON accounts.id = notes.user_id
+														INNER JOIN note_tags ON note_tags.note_id = notes.id
+														INNER JOIN tags ON tags.id = note_tags.tag_id
+														WHERE tags.name = ? AND notes.visibility = 'public'
+														ORDER BY notes.created_at DESC
+														LIMIT ? OFFSET ?`
+	sqlCountPublicNotesByTag = `SELECT COUNT(*) FROM notes
+														INNER JOIN note_tags ON note_tags.note_id = notes.id
+														INNER JOIN tags ON tags.id = note_tags.tag_id
+														WHERE tags.name = ? AND notes.visibility = 'public'`
+	sqlSelectActivitiesByTag = `SELECT a.id, a.activity_uri, a.activity_type, a.actor_uri, a.object_uri, a.raw_json, a.processed, a.local, a.created_at
+		FROM activities a
+		INNER JOIN activity_tags ON activity_tags.activity_id = a.id
+		INNER JOIN tags ON tags.id = activity_tags.tag_id
+		WHERE tags.name = ? AND a.activity_type = 'Create'
+		AND NOT EXISTS (` + sqlSilencedDomainCondition + `)
+		ORDER BY a.created_at DESC LIMIT ?`
+)
+
+// upsertTag returns the id of the tag with the given normalized name, creating it if needed
+func (db *DB) upsertTag(tx *sql.Tx, name string) (string, error) {
+	if _, err := tx.Exec(sqlInsertTag, uuid.New().String(), name, time.Now()); err != nil {
+		return "", err
+	}
+	var tagId string
+	err := tx.QueryRow(sqlSelectTagIdByName, name).Scan(&tagId)
+	return tagId, err
+}
+
+// replaceNoteTags indexes the hashtags of a note, replacing any previously indexed ones
+func (db *DB) replaceNoteTags(tx *sql.Tx, noteId uuid.UUID, message string) error {
+	if _, err := tx.Exec(sqlDeleteNoteTagsByNoteId, noteId.String()); err != nil {
+		return err
+	}
+	for _, name := range util.ExtractHashtags(message) {
+		tagId, err := db.upsertTag(tx, name)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(sqlInsertNoteTag, noteId.String(), tagId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateActivityTags indexes the hashtags of a stored remote post
+func (db *DB) CreateActivityTags(activityId uuid.UUID, tags []string) error {
+	return db.wrapTransaction(func(tx *sql.Tx) error {
+		for _, tag := range tags {
+			name := util.NormalizeHashtag(tag)
+			if name == "" {
+				continue
+			}
+			tagId, err := db.upsertTag(tx, name)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec(sqlInsertActivityTag, activityId.String(), tagId); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ReadPublicNotesByTag returns public local notes with the given hashtag, newest first
+func (db *DB) ReadPublicNotesByTag(tag string, limit, offset int) (error, *[]domain.Note) {
+	rows, err := db.db.Query(sqlSelectPublicNotesByTag, util.NormalizeHashtag(tag), limit, offset)
+	if err != nil {
+		return err, nil
+	}
+	defer rows.Close()
+
+	var notes []domain.Note
+	for rows.Next() {
+		var note domain.Note
+		var createdAtStr string
+		var editedAtStr, objectURI sql.NullString
+		if err := rows.Scan(&note.Id, &note.CreatedBy, &note.Message, &createdAtStr, &editedAtStr, &objectURI); err != nil {
+			return err, &notes
+		}
+
+		if parsedTime, err := parseTimestamp(createdAtStr); err == nil {
+			note.CreatedAt = parsedTime
+		}
+
+		if editedAtStr.Valid {
+			if parsedTime, err := parseTimestamp(editedAtStr.String); err == nil {
+				note.EditedAt = &parsedTime
+			}
+		}
+		note.ObjectURI = objectURI.String
+
+		notes = append(notes, note)
+	}
+	if err = rows.Err(); err != nil {
+		return err, &notes
+	}
+	return nil, &notes
+}
+
+// CountPublicNotesByTag returns the number of public local notes with the given hashtag
+func (db *DB) CountPublicNotesByTag(tag string) (int, error) {
+	var count int
+	err := db.db.QueryRow(sqlCountPublicNotesByTag, util.NormalizeHashtag(tag)).Scan(&count)
+	return count, err
+}
+
+// ReadActivitiesByTag returns stored remote posts with the given hashtag, newest first
+func (db *DB) ReadActivitiesByTag(tag string, limit int) (error, *[]domain.Activity) {
+	rows, err := db.db.Query(sqlSelectActivitiesByTag, util.NormalizeHashtag(tag), limit)
+	if err != nil {
+		return err, nil
+	}
+	defer rows.Close()
+
+	var activities []domain.Activity
+	for rows.Next() {
+		var activity domain.Activity
+		var idStr string
+		var createdAtStr string
+		if err := rows.Scan(&idStr, &activity.ActivityURI, &activity.ActivityType, &activity.ActorURI, &activity.ObjectURI, &activity.RawJSON, &activity.Processed, &activity.Local, &createdAtStr); err != nil {
+			return err, &activities
+		}
+		activity.Id, _ = uuid.Parse(idStr)
+
+		if parsedTime, err := parseTimestamp(createdAtStr); err == nil {
+			activity.CreatedAt = parsedTime
+		}
+
+		activities = append(activities, activity)
+	}
+	if err = rows.Err(); err != nil {
+		return err, &activities
+	}
+	return nil, &activities
+}
+
+// Block queries
+const (
+	sqlInsertBlock             = `INSERT INTO blocks(id, account_id, target, is_domain, uri, created_at) VALUES (?, ?, ?, ?, ?, ?)`
+	sqlSelectBlocksByAccountId = `SELECT id, account_id, target, is_domain, uri, created_at FROM blocks WHERE account_id = ? ORDER BY created_at DESC`
+	sqlSelectBlockById         = `SELECT id, account_id, target, is_domain, uri, created_at FROM blocks WHERE id = ?`
+	sqlDeleteBlock             = `DELETE FROM blocks WHERE id = ? AND account_id = ?`
+	sqlCountBlocksOfActor      = `SELECT COUNT(*) FROM blocks WHERE account_id = ? AND ((is_domain = 0 AND target = ?) OR (is_domain = 1 AND target = ?))`
+	sqlDeleteFollowsOfActor    = `DELETE FROM follows
+		WHERE (account_id = ? AND target_account_id IN (SELECT id FROM remote_accounts WHERE actor_uri = ?))
+		OR (target_account_id = ? AND account_id IN (SELECT id FROM remote_accounts WHERE actor_uri = ?))`
+	sqlDeleteFollowsOfDomain = `DELETE FROM follows
+		WHERE (account_id = ? AND target_account_id IN (SELECT id FROM remote_accounts WHERE domain = ?))
+		OR (target_account_id = ? AND account_id IN (SELECT id FROM remote_accounts WHERE domain = ?))`
+)
+
+// CreateBlock stores a block and removes the follow relationships with the blocked actor or domain in both directions
+func (db *DB) CreateBlock(block *domain.Block) error {
+	return db.wrapTransaction(func(tx *sql.Tx) error {
+		target := block.Target
+		if block.IsDomain {
+			target = strings.ToLower(target)
+		}
+
+		var uri sql.NullString
+		if block.URI != "" {
+			uri = sql.NullString{String: block.URI, Valid: true}
+		}
+		_, err := tx.Exec(sqlInsertBlock,
+			block.Id.String(),
+			block.AccountId.String(),
+			target,
+			block.IsDomain,
+			uri,
+			block.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert block: %w", err)
+		}
+
+		deleteFollows := sqlDeleteFollowsOfActor
+		if block.IsDomain {
+			deleteFollows = sqlDeleteFollowsOfDomain
+		}
+		accountId := block.AccountId.String()
+		if _, err := tx.Exec(deleteFollows, accountId, target, accountId, target); err != nil {
+			return fmt.Errorf("failed to delete follows: %w", err)
+		}
+		return nil
+	})
+}
+
+// ReadBlocksByAccountId returns the blocks of a user, newest first
+func (db *DB) ReadBlocksByAccountId(accountId uuid.UUID) (error, *[]domain.Block) {
+	rows, err := db.db.Query(sqlSelectBlocksByAccountId, accountId.String())
+	if err != nil {
+		return err, nil
+	}
+	defer rows.Close()
+
+	var blocks []domain.Block
+	for rows.Next() {
+		err, block := scanBlock(rows)
+		if err != nil {
+			return err, &blocks
+		}
+		blocks = append(blocks, *block)
+	}
+	if err = rows.Err(); err != nil {
+		return err, &blocks
+	}
+	return nil, &blocks
+}
+
+// ReadBlockById returns a single block
+func (db *DB) ReadBlockById(id uuid.UUID) (error, *domain.Block) {
+	return scanBlock(db.db.QueryRow(sqlSelectBlockById, id.String()))
+}
+
+// DeleteBlock removes a block of the given user
+func (db *DB) DeleteBlock(accountId, blockId uuid.UUID) error {
+	return db.wrapTransaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec(sqlDeleteBlock, blockId.String(), accountId.String())
+		return err
+	})
+}
+
+// IsBlocked reports whether a user blocked the given actor, either directly or through its domain
+func (db *DB) IsBlocked(accountId uuid.UUID, actorURI, actorDomain string) (bool, error) {
+	var count int
+	err := db.db.QueryRow(sqlCountBlocksOfActor, accountId.String(), actorURI, strings.ToLower(actorDomain)).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// scanBlock reads a block from a single row or from rows of one of the block queries
+func scanBlock(row interface{ Scan(...any) error }) (error, *domain.Block) {
+	var block domain.Block
+	var idStr, accountIdStr string
+	var isDomain int
+	var uri sql.NullString
+	var createdAtStr string
+	if err := row.Scan(&idStr, &accountIdStr, &block.Target, &isDomain, &uri, &createdAtStr); err != nil {
+		return err, nil
+	}
+	block.Id, _ = uuid.Parse(idStr)
+	block.AccountId, _ = uuid.Parse(accountIdStr)
+	block.IsDomain = isDomain == 1
+	block.URI = uri.String
+	if parsedTime, err := parseTimestamp(createdAtStr); err == nil {
+		block.CreatedAt = parsedTime
+	}
+	return nil, &block
+}
+
+// Domain block queries
+const (
+	sqlUpsertDomainBlock = `INSERT INTO domain_blocks(id, domain, severity, reject_media, public_comment, created_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(domain) DO UPDATE SET severity = excluded.severity, reject_media = excluded.reject_media, public_comment = excluded.public_comment`
+	sqlSelectDomainBlocks        = `SELECT id, domain, severity, reject_media, public_comment, created_at FROM domain_blocks ORDER BY domain ASC`
+	sqlSelectDomainBlockByDomain = `SELECT id, domain, severity, reject_media, public_comment, created_at FROM domain_blocks WHERE domain = ?`
+	sqlDeleteDomainBlock         = `DELETE FROM domain_blocks WHERE id = ?`
+	sqlDeleteFollowsWithDomain   = `DELETE FROM follows
+		WHERE target_account_id IN (SELECT id FROM remote_accounts WHERE domain = ? OR domain LIKE '%.' || ?)
+		OR account_id IN (SELECT id FROM remote_accounts WHERE domain = ? OR domain LIKE '%.' || ?)`
+
+	// Matches activities of actors on silenced or suspended domains and their subdomains
+	sqlSilencedDomainCondition = `SELECT 1 FROM domain_blocks d WHERE d.severity IN ('silence', 'suspend')
+		AND (a.actor_uri LIKE 'https://' || d.domain || '/%' OR a.actor_uri LIKE 'https://%.' || d.domain || '/%')`
+)
+
+// UpsertDomainBlock creates or updates the block of a domain
+// Suspending a domain also removes all follow relationships with its accounts
+func (db *DB) UpsertDomainBlock(block *domain.DomainBlock) error {
+	return db.wrapTransaction(func(tx *sql.Tx) error {
+		domainName := strings.ToLower(block.Domain)
+		_, err := tx.Exec(sqlUpsertDomainBlock,
+			block.Id.String(),
+			domainName,
+			block.Severity,
+			block.RejectMedia,
+			block.PublicComment,
+			block.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to store domain block: %w", err)
+		}
+
+		if block.Severity == domain.DomainBlockSuspend {
+			if _, err := tx.Exec(sqlDeleteFollowsWithDomain, domainName, domainName, domainName, domainName); err != nil {
+				return fmt.Errorf("failed to delete follows: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// ReadDomainBlocks returns all domain blocks ordered by domain
+func (db *DB) ReadDomainBlocks() (error, *[]domain.DomainBlock) {
+	rows, err := db.db.Query(sqlSelectDomainBlocks)
+	if err != nil {
+		return err, nil
+	}
+	defer rows.Close()
+
+	var blocks []domain.DomainBlock
+	for rows.Next() {
+		err, block := scanDomainBlock(rows)
+		if err != nil {
+			return err, &blocks
+		}
+		blocks = append(blocks, *block)
+	}
+	if err = rows.Err(); err != nil {
+		return err, &blocks
+	}
+	return nil, &blocks
+}
+
+// ReadDomainBlockByDomain returns the block of exactly the given domain
+func (db *DB) ReadDomainBlockByDomain(domainName string) (error, *domain.DomainBlock) {
+	return scanDomainBlock(db.db.QueryRow(sqlSelectDomainBlockByDomain, strings.ToLower(domainName)))
+}
+
+// DeleteDomainBlock lifts a domain block
+func (db *DB) DeleteDomainBlock(id uuid.UUID) error {
+	return db.wrapTransaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec(sqlDeleteDomainBlock, id.String())
+		return err
+	})
+}
+
+// scanDomainBlock reads a domain block from a single row or from rows of one of the domain block queries
+func scanDomainBlock(row interface{ Scan(...any) error }) (error, *domain.DomainBlock) {
+	var block domain.DomainBlock
+	var idStr string
+	var rejectMedia int
+	var publicComment sql.NullString
+	var createdAtStr string
+	if err := row.Scan(&idStr, &block.Domain, &block.Severity, &rejectMedia, &publicComment, &createdAtStr); err != nil {
+		return err, nil
+	}
+	block.Id, _ = uuid.Parse(idStr)
+	block.RejectMedia = rejectMedia == 1
+	block.PublicComment = publicComment.String
+	if parsedTime, err := parseTimestamp(createdAtStr); err == nil {
+		block.CreatedAt = parsedTime
+	}
+	return nil, &block
+}
+
+// Instance actor queries
+const (
+	sqlSelectInstanceKeys = `SELECT public_key, private_key FROM instance_actor WHERE id = 1`
+	sqlInsertInstanceKeys = `INSERT OR IGNORE INTO instance_actor(id, public_key, private_key, created_at) VALUES (1, ?, ?, ?)`
+)
+
+// ReadOrCreateInstanceKeys returns the keypair of the server-wide instance actor
+// The keypair is generated and stored on first use
+func (db *DB) ReadOrCreateInstanceKeys() (error, *util.RsaKeyPair) {
+	var keypair util.RsaKeyPair
+	err := db.db.QueryRow(sqlSelectInstanceKeys).Scan(&keypair.Public, &keypair.Private)
+	if err == nil {
+		return nil, &keypair
+	}
+	if err != sql.ErrNoRows {
+		return err, nil
+	}
+
+	log.Println("Generating keypair for the instance actor")
+	generated := util.GeneratePemKeypair()
+	err = db.wrapTransaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec(sqlInsertInstanceKeys, generated.Public, generated.Private, time.Now())
+		return err
+	})
+	if err != nil {
+		return err, nil
+	}
+
+	// Read back the stored keypair in case another caller created it first
+	if err := db.db.QueryRow(sqlSelectInstanceKeys).Scan(&keypair.Public, &keypair.Private); err != nil {
+		return err, nil
+	}
+	return nil, &keypair
+}
@@ -38,6 +38,8 @@ func setupTestDB(t *testing.T) *DB {
 	db.db.Exec(`ALTER TABLE notes ADD COLUMN federated INTEGER DEFAULT 1`)
 	db.db.Exec(`ALTER TABLE notes ADD COLUMN sensitive INTEGER DEFAULT 0`)
 	db.db.Exec(`ALTER TABLE notes ADD COLUMN content_warning TEXT`)
+	db.db.Exec(`ALTER TABLE notes ADD COLUMN imported_from TEXT`)
+	db.db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_notes_imported_from ON notes(user_id, imported_from)`)
 
 	// Add ActivityPub profile fields to accounts table
 	db.db.Exec(`ALTER TABLE accounts ADD COLUMN display_name varchar(255)`)
@@ -47,6 +49,10 @@ func setupTestDB(t *testing.T) *DB {
 	// Add admin fields to accounts table
 	db.db.Exec(`ALTER TABLE accounts ADD COLUMN is_admin INTEGER DEFAULT 0`)
 	db.db.Exec(`ALTER TABLE accounts ADD COLUMN muted INTEGER DEFAULT 0`)
+	db.db.Exec(`ALTER TABLE accounts ADD COLUMN hide_collections INTEGER DEFAULT 0`)
+	db.db.Exec(`ALTER TABLE accounts ADD COLUMN locked INTEGER DEFAULT 0`)
+	db.db.Exec(`ALTER TABLE accounts ADD COLUMN also_known_as TEXT`)
+	db.db.Exec(`ALTER TABLE accounts ADD COLUMN moved_to TEXT`)
 
 	// Create ActivityPub tables
 	db.db.Exec(`CREATE TABLE IF NOT EXISTS remote_accounts(
@@ -61,6 +67,9 @@ func setupTestDB(t *testing.T) *DB {
 		public_key_pem text,
 		avatar_url varchar(500),
 		last_fetched_at timestamp default current_timestamp,
+		shared_inbox_uri varchar(500),
+		ed25519_key_id varchar(500),
+		ed25519_key_pem text,
 		UNIQUE(username, domain)
 	)`)
 
@@ -84,17 +93,93 @@ func setupTestDB(t *testing.T) *DB {
 		raw_json text,
 		processed int default 0,
 		created_at timestamp default current_timestamp,
-		local int default 0
+		local int default 0,
+		inbox_username varchar(100),
+		attempts int default 0,
+		next_attempt_at timestamp,
+		last_error text,
+		in_reply_to text
+	)`)
+
+	db.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations(
+		name TEXT NOT NULL PRIMARY KEY,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	)`)
 
 	db.db.Exec(`CREATE TABLE IF NOT EXISTS likes(
 		id uuid NOT NULL PRIMARY KEY,
 		account_id uuid NOT NULL,
-		note_id uuid NOT NULL,
+		note_id uuid,
+		uri varchar(500),
+		created_at timestamp default current_timestamp,
+		object_uri TEXT,
+		UNIQUE(account_id, note_id)
+	)`)
+	db.db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_likes_account_object_uri ON likes(account_id, object_uri)`)
+
+	db.db.Exec(`CREATE TABLE IF NOT EXISTS shares(
+		id uuid NOT NULL PRIMARY KEY,
+		account_id uuid NOT NULL,
+		note_id uuid,
 		uri varchar(500),
+		object_uri TEXT,
 		created_at timestamp default current_timestamp,
 		UNIQUE(account_id, note_id)
 	)`)
+	db.db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_shares_account_object_uri ON shares(account_id, object_uri)`)
+
+	db.db.Exec(`CREATE TABLE IF NOT EXISTS mentions(
+		id TEXT NOT NULL PRIMARY KEY,
+		note_id TEXT NOT NULL,
+		handle TEXT NOT NULL,
+		actor_uri TEXT NOT NULL,
+		created_at timestamp default current_timestamp,
+		UNIQUE(note_id, actor_uri)
+	)`)
+
+	db.db.Exec(`CREATE TABLE IF NOT EXISTS tags(
+		id TEXT NOT NULL PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE,
+		created_at timestamp default current_timestamp
+	)`)
+
+	db.db.Exec(`CREATE TABLE IF NOT EXISTS note_tags(
+		note_id TEXT NOT NULL,
+		tag_id TEXT NOT NULL,
+		PRIMARY KEY(note_id, tag_id)
+	)`)
+
+	db.db.Exec(`CREATE TABLE IF NOT EXISTS activity_tags(
+		activity_id TEXT NOT NULL,
+		tag_id TEXT NOT NULL,
+		PRIMARY KEY(activity_id, tag_id)
+	)`)
+
+	db.db.Exec(`CREATE TABLE IF NOT EXISTS blocks(
+		id TEXT NOT NULL PRIMARY KEY,
+		account_id TEXT NOT NULL,
+		target TEXT NOT NULL,
+		is_domain INTEGER DEFAULT 0,
+		uri TEXT,
+		created_at timestamp default current_timestamp,
+		UNIQUE(account_id, target)
+	)`)
+
+	db.db.Exec(`CREATE TABLE IF NOT EXISTS domain_blocks(
+		id TEXT NOT NULL PRIMARY KEY,
+		domain TEXT NOT NULL UNIQUE,
+		severity TEXT NOT NULL DEFAULT 'silence',
+		reject_media INTEGER DEFAULT 0,
+		public_comment TEXT,
+		created_at timestamp default current_timestamp
+	)`)
+
+	db.db.Exec(`CREATE TABLE IF NOT EXISTS instance_actor(
+		id INTEGER NOT NULL PRIMARY KEY CHECK (id = 1),
+		public_key TEXT NOT NULL,
+		private_key TEXT NOT NULL,
+		created_at timestamp default current_timestamp
+	)`)
 
 	db.db.Exec(`CREATE TABLE IF NOT EXISTS delivery_queue(
 		id uuid NOT NULL PRIMARY KEY,
@@ -106,6 +191,39 @@ func setupTestDB(t *testing.T) *DB {
 		account_id TEXT
 	)`)
 
+	db.db.Exec(`CREATE TABLE IF NOT EXISTS delivery_dead_letters(
+		id uuid NOT NULL PRIMARY KEY,
+		account_id TEXT,
+		inbox_uri varchar(500) NOT NULL,
+		activity_json text NOT NULL,
+		attempts int default 0,
+		last_error text,
+		last_status int default 0,
+		created_at timestamp default current_timestamp,
+		failed_at timestamp default current_timestamp
+	)`)
+
+	db.db.Exec(`CREATE TABLE IF NOT EXISTS signature_schemes(
+		host varchar(255) NOT NULL PRIMARY KEY,
+		scheme varchar(20) NOT NULL,
+		updated_at timestamp default current_timestamp
+	)`)
+
+	db.db.Exec(`CREATE TABLE IF NOT EXISTS activity_recipients(
+		activity_id uuid NOT NULL,
+		username varchar(100) NOT NULL,
+		processed int default 0,
+		PRIMARY KEY (activity_id, username)
+	)`)
+
+	db.db.Exec(`CREATE TABLE IF NOT EXISTS key_changes(
+		id uuid NOT NULL PRIMARY KEY,
+		actor_uri varchar(500) NOT NULL,
+		old_key_pem text NOT NULL,
+		new_key_pem text NOT NULL,
+		changed_at timestamp default current_timestamp
+	)`)
+
 	return db
 }
 
@@ -259,6 +377,81 @@ func TestCreateNote(t *testing.T) {
 	}
 }
 
+func TestCreateImportedNote(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	userId := uuid.New()
+	createTestAccount(t, db, userId, "testuser", "pubkey", "webpub", "webpriv")
+
+	published := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	original := "https://mastodon.example/users/testuser/statuses/1"
+	created, err := db.CreateImportedNote(userId, "Imported #note", published, original)
+	if err != nil {
+		t.Fatalf("CreateImportedNote failed: %v", err)
+	}
+	if !created {
+		t.Error("Expected the note to be created")
+	}
+
+	// Importing the same post again must not create a duplicate
+	created, err = db.CreateImportedNote(userId, "Imported #note", published, original)
+	if err != nil {
+		t.Fatalf("CreateImportedNote failed on re-import: %v", err)
+	}
+	if created {
+		t.Error("Expected the re-imported note to be skipped")
+	}
+
+	// Notes created locally don't collide with each other
+	if _, err := db.CreateNote(userId, "First"); err != nil {
+		t.Fatalf("CreateNote failed: %v", err)
+	}
+	if _, err := db.CreateNote(userId, "Second"); err != nil {
+		t.Fatalf("CreateNote failed: %v", err)
+	}
+
+	err, notes := db.ReadNotesByUserId(userId)
+	if err != nil {
+		t.Fatalf("ReadNotesByUserId failed: %v", err)
+	}
+	if len(*notes) != 3 {
+		t.Fatalf("Expected 3 notes, got %d", len(*notes))
+	}
+	imported := (*notes)[len(*notes)-1]
+	if !imported.CreatedAt.Equal(published) {
+		t.Errorf("Expected the original creation time %v, got %v", published, imported.CreatedAt)
+	}
+
+	if imported.ImportedFrom != original || imported.Federated {
+		t.Errorf("Expected an unfederated note imported from %s, got %q (federated %v)", original, imported.ImportedFrom, imported.Federated)
+	}
+	err, stored := db.ReadNoteId(imported.Id)
+	if err != nil {
+		t.Fatalf("ReadNoteId failed: %v", err)
+	}
+	if stored.ImportedFrom != original || stored.Federated {
+		t.Errorf("Expected ReadNoteId to return an unfederated imported note, got %q (federated %v)", stored.ImportedFrom, stored.Federated)
+	}
+	if !(*notes)[0].Federated {
+		t.Error("Notes written here should be federated")
+	}
+
+	// Imported notes are not served in the outbox
+	err, public := db.ReadPublicNotesByUsername("testuser", 10, 0)
+	if err != nil {
+		t.Fatalf("ReadPublicNotesByUsername failed: %v", err)
+	}
+	if len(*public) != 2 {
+		t.Fatalf("Expected the 2 local notes in the outbox, got %d", len(*public))
+	}
+	for _, note := range *public {
+		if note.Id == imported.Id {
+			t.Error("The imported note must not be in the outbox")
+		}
+	}
+}
+
 func TestReadNoteIdNotFound(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.db.Close()
@@ -546,6 +739,46 @@ func TestCreateRemoteAccount(t *testing.T) {
 	}
 }
 
+func TestRemoteAccountSharedInbox(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	remoteAcc := &domain.RemoteAccount{
+		Id:            uuid.New(),
+		Username:      "bob",
+		Domain:        "example.com",
+		ActorURI:      "https://example.com/users/bob",
+		InboxURI:      "https://example.com/users/bob/inbox",
+		PublicKeyPem:  "-----BEGIN PUBLIC KEY-----",
+		LastFetchedAt: time.Now(),
+	}
+	if err := db.CreateRemoteAccount(remoteAcc); err != nil {
+		t.Fatalf("CreateRemoteAccount failed: %v", err)
+	}
+
+	// Accounts fetched before shared inboxes were recorded have none
+	err, acc := db.ReadRemoteAccountById(remoteAcc.Id)
+	if err != nil {
+		t.Fatalf("ReadRemoteAccountById failed: %v", err)
+	}
+	if acc.SharedInboxURI != "" {
+		t.Errorf("Expected no shared inbox, got %s", acc.SharedInboxURI)
+	}
+
+	remoteAcc.SharedInboxURI = "https://example.com/inbox"
+	if err := db.UpdateRemoteAccount(remoteAcc); err != nil {
+		t.Fatalf("UpdateRemoteAccount failed: %v", err)
+	}
+
+	err, acc = db.ReadRemoteAccountByActorURI(remoteAcc.ActorURI)
+	if err != nil {
+		t.Fatalf("ReadRemoteAccountByActorURI failed: %v", err)
+	}
+	if acc.SharedInboxURI != "https://example.com/inbox" {
+		t.Errorf("Expected shared inbox, got %s", acc.SharedInboxURI)
+	}
+}
+
 func TestCreateLocalFollow(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.db.Close()
@@ -707,6 +940,31 @@ func TestDeleteAccount(t *testing.T) {
 		t.Fatalf("Failed to create activity: %v", err)
 	}
 
+	// Queue deliveries of alice and bob
+	for _, accountId := range []uuid.UUID{userId, user2Id} {
+		err = db.EnqueueDelivery(&domain.DeliveryQueueItem{
+			Id:           uuid.New(),
+			AccountId:    accountId,
+			InboxURI:     "https://remote.example/inbox",
+			ActivityJSON: `{"type":"Like"}`,
+			NextRetryAt:  time.Now(),
+			CreatedAt:    time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("Failed to queue delivery: %v", err)
+		}
+		err = db.MoveDeliveryToDeadLetters(&domain.DeliveryQueueItem{
+			Id:           uuid.New(),
+			AccountId:    accountId,
+			InboxURI:     "https://down.example/inbox",
+			ActivityJSON: `{"type":"Like"}`,
+			CreatedAt:    time.Now(),
+		}, "timeout", 0)
+		if err != nil {
+			t.Fatalf("Failed to keep dead letter: %v", err)
+		}
+	}
+
 	// Verify data exists before deletion
 	err, acc := db.ReadAccById(userId)
 	if err != nil || acc == nil {
@@ -747,6 +1005,15 @@ func TestDeleteAccount(t *testing.T) {
 		t.Errorf("Expected 0 follower relationships after deletion, got %d", len(*followers))
 	}
 
+	// Verify only alice's deliveries were dropped
+	if count, err := db.CountDeliveries(); err != nil || count != 1 {
+		t.Errorf("Expected bob's delivery to remain queued, got %d (%v)", count, err)
+	}
+	err, dead := db.ReadDeadDeliveries()
+	if err != nil || len(*dead) != 1 || (*dead)[0].AccountId != user2Id {
+		t.Errorf("Expected only bob's dead letter to remain, got %+v (%v)", dead, err)
+	}
+
 	// Note: Activities are NOT deleted (they remain as historical record)
 	// This matches ActivityPub behavior
 
@@ -1113,3 +1380,1449 @@ func TestCountAccounts(t *testing.T) {
 		t.Errorf("Expected 3 accounts, got %d", count)
 	}
 }
+
+func TestCreateAndDeleteLike(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	userId := uuid.New()
+	createTestAccount(t, db, userId, "alice", "pubkey", "webpub", "webpriv")
+	noteId, err := db.CreateNote(userId, "Likeable note")
+	if err != nil {
+		t.Fatalf("CreateNote failed: %v", err)
+	}
+
+	like := &domain.Like{
+		Id:        uuid.New(),
+		AccountId: uuid.New(),
+		NoteId:    noteId,
+		URI:       "https://remote.example/likes/1",
+		CreatedAt: time.Now(),
+	}
+	if err := db.CreateLike(like); err != nil {
+		t.Fatalf("CreateLike failed: %v", err)
+	}
+
+	// A repeated Like from the same account must not be counted twice
+	duplicate := *like
+	duplicate.Id = uuid.New()
+	duplicate.URI = "https://remote.example/likes/2"
+	if err := db.CreateLike(&duplicate); err != nil {
+		t.Fatalf("CreateLike duplicate failed: %v", err)
+	}
+
+	count, err := db.CountLikesByNoteId(noteId)
+	if err != nil {
+		t.Fatalf("CountLikesByNoteId failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 like, got %d", count)
+	}
+
+	err, stored := db.ReadLikeByURI(like.URI)
+	if err != nil {
+		t.Fatalf("ReadLikeByURI failed: %v", err)
+	}
+	if stored.NoteId != noteId {
+		t.Errorf("Expected NoteId %s, got %s", noteId, stored.NoteId)
+	}
+
+	if err := db.DeleteLikeByURI(like.URI); err != nil {
+		t.Fatalf("DeleteLikeByURI failed: %v", err)
+	}
+
+	count, _ = db.CountLikesByNoteId(noteId)
+	if count != 0 {
+		t.Errorf("Expected 0 likes after undo, got %d", count)
+	}
+}
+
+func TestDeleteNoteRemovesLikes(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	userId := uuid.New()
+	createTestAccount(t, db, userId, "alice", "pubkey", "webpub", "webpriv")
+	noteId, _ := db.CreateNote(userId, "Soon gone")
+
+	db.CreateLike(&domain.Like{
+		Id:        uuid.New(),
+		AccountId: uuid.New(),
+		NoteId:    noteId,
+		URI:       "https://remote.example/likes/3",
+		CreatedAt: time.Now(),
+	})
+
+	if err := db.DeleteNoteById(noteId); err != nil {
+		t.Fatalf("DeleteNoteById failed: %v", err)
+	}
+
+	count, _ := db.CountLikesByNoteId(noteId)
+	if count != 0 {
+		t.Errorf("Expected likes to be removed with the note, got %d", count)
+	}
+}
+
+func TestCountLikesByNoteIds(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	userId := uuid.New()
+	createTestAccount(t, db, userId, "alice", "pubkey", "webpub", "webpriv")
+	popular, _ := db.CreateNote(userId, "Popular")
+	liked, _ := db.CreateNote(userId, "Liked once")
+	ignored, _ := db.CreateNote(userId, "Not liked")
+	for _, noteId := range []uuid.UUID{popular, popular, liked} {
+		db.CreateLike(&domain.Like{
+			Id:        uuid.New(),
+			AccountId: uuid.New(),
+			NoteId:    noteId,
+			URI:       "https://remote.example/likes/" + uuid.New().String(),
+			CreatedAt: time.Now(),
+		})
+	}
+
+	counts, err := db.CountLikesByNoteIds([]uuid.UUID{popular, liked, ignored})
+	if err != nil {
+		t.Fatalf("CountLikesByNoteIds failed: %v", err)
+	}
+	if counts[popular] != 2 || counts[liked] != 1 || counts[ignored] != 0 {
+		t.Errorf("Unexpected like counts: %v", counts)
+	}
+	if counts, err := db.CountLikesByNoteIds(nil); err != nil || len(counts) != 0 {
+		t.Errorf("Expected no counts without notes, got %v (%v)", counts, err)
+	}
+}
+
+func TestReadLikesByAccountId(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	userId := uuid.New()
+	createTestAccount(t, db, userId, "alice", "pubkey", "webpub", "webpriv")
+
+	for i, objectURI := range []string{"https://remote.example/notes/1", "https://remote.example/notes/2"} {
+		db.CreateLike(&domain.Like{
+			Id:        uuid.New(),
+			AccountId: userId,
+			URI:       "https://example.com/activities/like-" + objectURI[len(objectURI)-1:],
+			ObjectURI: objectURI,
+			CreatedAt: time.Now().Add(time.Duration(i) * time.Minute),
+		})
+	}
+	db.CreateLike(&domain.Like{
+		Id:        uuid.New(),
+		AccountId: uuid.New(),
+		URI:       "https://other.example/likes/1",
+		ObjectURI: "https://remote.example/notes/1",
+		CreatedAt: time.Now(),
+	})
+
+	err, likes := db.ReadLikesByAccountId(userId)
+	if err != nil {
+		t.Fatalf("ReadLikesByAccountId failed: %v", err)
+	}
+	if len(*likes) != 2 {
+		t.Fatalf("Expected 2 likes of alice, got %d", len(*likes))
+	}
+	if (*likes)[0].ObjectURI != "https://remote.example/notes/2" {
+		t.Errorf("Expected the newest like first, got %s", (*likes)[0].ObjectURI)
+	}
+}
+
+func TestReadLikeByAccountAndObjectURI(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	userId := uuid.New()
+	createTestAccount(t, db, userId, "alice", "pubkey", "webpub", "webpriv")
+
+	objectURI := "https://remote.example/notes/42"
+	like := &domain.Like{
+		Id:        uuid.New(),
+		AccountId: userId,
+		URI:       "https://example.com/activities/like-1",
+		ObjectURI: objectURI,
+		CreatedAt: time.Now(),
+	}
+	if err := db.CreateLike(like); err != nil {
+		t.Fatalf("CreateLike failed: %v", err)
+	}
+
+	err, stored := db.ReadLikeByAccountAndObjectURI(userId, objectURI)
+	if err != nil {
+		t.Fatalf("ReadLikeByAccountAndObjectURI failed: %v", err)
+	}
+	if stored.URI != like.URI {
+		t.Errorf("Expected URI %s, got %s", like.URI, stored.URI)
+	}
+	if stored.ObjectURI != objectURI {
+		t.Errorf("Expected ObjectURI %s, got %s", objectURI, stored.ObjectURI)
+	}
+
+	// Another account has not liked the post
+	err, _ = db.ReadLikeByAccountAndObjectURI(uuid.New(), objectURI)
+	if err == nil {
+		t.Error("Expected error for account without a like")
+	}
+
+	db.DeleteLikeByURI(like.URI)
+	err, _ = db.ReadLikeByAccountAndObjectURI(userId, objectURI)
+	if err == nil {
+		t.Error("Expected error after like was deleted")
+	}
+}
+
+func TestCreateLikeOfRemotePosts(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	userId := uuid.New()
+	createTestAccount(t, db, userId, "alice", "pubkey", "webpub", "webpriv")
+	noteId, _ := db.CreateNote(userId, "Local note")
+
+	// A remote actor likes two local notes, alice likes two remote posts, one of them twice
+	remoteId := uuid.New()
+	otherNoteId, _ := db.CreateNote(userId, "Another local note")
+	likes := []domain.Like{
+		{AccountId: remoteId, NoteId: noteId, URI: "https://remote.example/likes/1"},
+		{AccountId: remoteId, NoteId: otherNoteId, URI: "https://remote.example/likes/2"},
+		{AccountId: userId, ObjectURI: "https://remote.example/notes/1", URI: "https://example.com/activities/like-1"},
+		{AccountId: userId, ObjectURI: "https://remote.example/notes/2", URI: "https://example.com/activities/like-2"},
+		{AccountId: userId, ObjectURI: "https://remote.example/notes/2", URI: "https://example.com/activities/like-3"},
+	}
+	for _, like := range likes {
+		like.Id = uuid.New()
+		like.CreatedAt = time.Now()
+		if err := db.CreateLike(&like); err != nil {
+			t.Fatalf("CreateLike %s failed: %v", like.URI, err)
+		}
+	}
+
+	var count int
+	db.db.QueryRow("SELECT COUNT(*) FROM likes").Scan(&count)
+	if count != 4 {
+		t.Errorf("Expected 4 likes, got %d", count)
+	}
+
+	err, stored := db.ReadLikeByAccountAndObjectURI(userId, "https://remote.example/notes/2")
+	if err != nil {
+		t.Fatalf("ReadLikeByAccountAndObjectURI failed: %v", err)
+	}
+	if stored.URI != "https://example.com/activities/like-2" || stored.NoteId != uuid.Nil {
+		t.Errorf("Expected the first like without note id, got %s with note %s", stored.URI, stored.NoteId)
+	}
+}
+
+func TestMigrateRemoteLikes(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	// Likes as stored before: remote posts had the id of their Create activity as note_id
+	db.db.Exec(`DROP TABLE likes`)
+	db.db.Exec(`CREATE TABLE likes (
+		id TEXT NOT NULL PRIMARY KEY,
+		account_id TEXT NOT NULL,
+		note_id TEXT NOT NULL,
+		uri TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		object_uri TEXT,
+		UNIQUE(account_id, note_id)
+	)`)
+	remoteId, userId, noteId := uuid.New(), uuid.New(), uuid.New()
+	db.db.Exec(`INSERT INTO likes(id, account_id, note_id, uri, object_uri) VALUES (?, ?, ?, ?, '')`,
+		uuid.New().String(), remoteId.String(), noteId.String(), "https://remote.example/likes/1")
+	db.db.Exec(`INSERT INTO likes(id, account_id, note_id, uri, object_uri) VALUES (?, ?, ?, ?, ?)`,
+		uuid.New().String(), userId.String(), uuid.New().String(), "https://example.com/activities/like-1", "https://remote.example/notes/1")
+
+	if err := db.wrapTransaction(db.migrateRemoteLikes); err != nil {
+		t.Fatalf("migrateRemoteLikes failed: %v", err)
+	}
+	// Migrating again changes nothing
+	if err := db.wrapTransaction(db.migrateRemoteLikes); err != nil {
+		t.Fatalf("migrateRemoteLikes failed on a migrated table: %v", err)
+	}
+
+	if count, _ := db.CountLikesByNoteId(noteId); count != 1 {
+		t.Errorf("Expected the like of the local note to keep its note id, got %d likes", count)
+	}
+	err, remote := db.ReadLikeByAccountAndObjectURI(userId, "https://remote.example/notes/1")
+	if err != nil {
+		t.Fatalf("ReadLikeByAccountAndObjectURI failed: %v", err)
+	}
+	if remote.NoteId != uuid.Nil {
+		t.Errorf("Expected the like of a remote post to lose its note id, got %s", remote.NoteId)
+	}
+
+	// Likes of remote posts are unique per object URI now
+	if err := db.CreateLike(&domain.Like{Id: uuid.New(), AccountId: userId, URI: "https://example.com/activities/like-2",
+		ObjectURI: "https://remote.example/notes/1", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("CreateLike failed: %v", err)
+	}
+	var count int
+	db.db.QueryRow("SELECT COUNT(*) FROM likes WHERE account_id = ?", userId.String()).Scan(&count)
+	if count != 1 {
+		t.Errorf("Expected 1 like of alice, got %d", count)
+	}
+}
+
+func TestCreateAndDeleteShare(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	userId := uuid.New()
+	createTestAccount(t, db, userId, "alice", "pubkey", "webpub", "webpriv")
+	noteId, _ := db.CreateNote(userId, "Boostable note")
+
+	share := &domain.Share{
+		Id:        uuid.New(),
+		AccountId: uuid.New(),
+		NoteId:    noteId,
+		URI:       "https://remote.example/announces/1",
+		ObjectURI: "https://example.com/notes/" + noteId.String(),
+		CreatedAt: time.Now(),
+	}
+	if err := db.CreateShare(share); err != nil {
+		t.Fatalf("CreateShare failed: %v", err)
+	}
+
+	// A repeated Announce from the same account must not be counted twice
+	duplicate := *share
+	duplicate.Id = uuid.New()
+	duplicate.URI = "https://remote.example/announces/2"
+	db.CreateShare(&duplicate)
+
+	count, err := db.CountSharesByNoteId(noteId)
+	if err != nil {
+		t.Fatalf("CountSharesByNoteId failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 share, got %d", count)
+	}
+
+	err, stored := db.ReadShareByAccountAndObjectURI(share.AccountId, share.ObjectURI)
+	if err != nil {
+		t.Fatalf("ReadShareByAccountAndObjectURI failed: %v", err)
+	}
+	if stored.URI != share.URI {
+		t.Errorf("Expected URI %s, got %s", share.URI, stored.URI)
+	}
+
+	if err := db.DeleteShareByURI(share.URI); err != nil {
+		t.Fatalf("DeleteShareByURI failed: %v", err)
+	}
+	count, _ = db.CountSharesByNoteId(noteId)
+	if count != 0 {
+		t.Errorf("Expected 0 shares after undo, got %d", count)
+	}
+}
+
+func TestCreateShareOfRemotePosts(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	userId := uuid.New()
+	createTestAccount(t, db, userId, "alice", "pubkey", "webpub", "webpriv")
+	noteId, _ := db.CreateNote(userId, "Local note")
+	otherNoteId, _ := db.CreateNote(userId, "Another local note")
+
+	// A remote actor boosts two local notes, alice boosts a remote post twice
+	remoteId := uuid.New()
+	shares := []domain.Share{
+		{AccountId: remoteId, NoteId: noteId, URI: "https://remote.example/announces/1", ObjectURI: "https://example.com/notes/" + noteId.String()},
+		{AccountId: remoteId, NoteId: otherNoteId, URI: "https://remote.example/announces/2", ObjectURI: "https://example.com/notes/" + otherNoteId.String()},
+		{AccountId: userId, URI: "https://example.com/activities/announce-1", ObjectURI: "https://remote.example/notes/1"},
+		{AccountId: userId, URI: "https://example.com/activities/announce-2", ObjectURI: "https://remote.example/notes/1"},
+	}
+	for _, share := range shares {
+		share.Id = uuid.New()
+		share.CreatedAt = time.Now()
+		if err := db.CreateShare(&share); err != nil {
+			t.Fatalf("CreateShare %s failed: %v", share.URI, err)
+		}
+	}
+
+	err, stored := db.ReadShareByAccountAndObjectURI(userId, "https://remote.example/notes/1")
+	if err != nil {
+		t.Fatalf("ReadShareByAccountAndObjectURI failed: %v", err)
+	}
+	if stored.URI != "https://example.com/activities/announce-1" || stored.NoteId != uuid.Nil {
+		t.Errorf("Expected the first boost without note id, got %s with note %s", stored.URI, stored.NoteId)
+	}
+
+	counts, err := db.CountSharesByNoteIds([]uuid.UUID{noteId, otherNoteId, uuid.New()})
+	if err != nil {
+		t.Fatalf("CountSharesByNoteIds failed: %v", err)
+	}
+	if len(counts) != 2 || counts[noteId] != 1 || counts[otherNoteId] != 1 {
+		t.Errorf("Expected one boost of each local note, got %v", counts)
+	}
+}
+
+func TestMigrateRemoteShares(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	userId := uuid.New()
+	createTestAccount(t, db, userId, "alice", "pubkey", "webpub", "webpriv")
+	noteId, _ := db.CreateNote(userId, "Boosted note")
+
+	// Shares as stored before: boosts of remote posts had the id of their Create activity as note_id
+	db.db.Exec(`DROP TABLE shares`)
+	db.db.Exec(`CREATE TABLE shares (
+		id TEXT NOT NULL PRIMARY KEY,
+		account_id TEXT NOT NULL,
+		note_id TEXT NOT NULL,
+		uri TEXT NOT NULL,
+		object_uri TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(account_id, note_id)
+	)`)
+	db.db.Exec(`INSERT INTO shares(id, account_id, note_id, uri, object_uri) VALUES (?, ?, ?, ?, ?)`,
+		uuid.New().String(), uuid.New().String(), noteId.String(), "https://remote.example/announces/1", "https://example.com/notes/"+noteId.String())
+	db.db.Exec(`INSERT INTO shares(id, account_id, note_id, uri, object_uri) VALUES (?, ?, ?, ?, ?)`,
+		uuid.New().String(), userId.String(), uuid.New().String(), "https://example.com/activities/announce-1", "https://remote.example/notes/1")
+
+	if err := db.wrapTransaction(db.migrateRemoteShares); err != nil {
+		t.Fatalf("migrateRemoteShares failed: %v", err)
+	}
+	// Migrating again changes nothing
+	if err := db.wrapTransaction(db.migrateRemoteShares); err != nil {
+		t.Fatalf("migrateRemoteShares failed on a migrated table: %v", err)
+	}
+
+	if count, _ := db.CountSharesByNoteId(noteId); count != 1 {
+		t.Errorf("Expected the boost of the local note to keep its note id, got %d boosts", count)
+	}
+	err, remote := db.ReadShareByAccountAndObjectURI(userId, "https://remote.example/notes/1")
+	if err != nil {
+		t.Fatalf("ReadShareByAccountAndObjectURI failed: %v", err)
+	}
+	if remote.NoteId != uuid.Nil {
+		t.Errorf("Expected the boost of a remote post to lose its note id, got %s", remote.NoteId)
+	}
+
+	// Boosts of remote posts are unique per object URI now
+	db.CreateShare(&domain.Share{Id: uuid.New(), AccountId: userId, URI: "https://example.com/activities/announce-2",
+		ObjectURI: "https://remote.example/notes/1", CreatedAt: time.Now()})
+	var count int
+	db.db.QueryRow("SELECT COUNT(*) FROM shares WHERE account_id = ?", userId.String()).Scan(&count)
+	if count != 1 {
+		t.Errorf("Expected 1 boost of alice, got %d", count)
+	}
+}
+
+func TestDeleteNoteRemovesShares(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	userId := uuid.New()
+	createTestAccount(t, db, userId, "alice", "pubkey", "webpub", "webpriv")
+	noteId, _ := db.CreateNote(userId, "Soon gone")
+
+	db.CreateShare(&domain.Share{
+		Id:        uuid.New(),
+		AccountId: uuid.New(),
+		NoteId:    noteId,
+		URI:       "https://remote.example/announces/3",
+		CreatedAt: time.Now(),
+	})
+
+	if err := db.DeleteNoteById(noteId); err != nil {
+		t.Fatalf("DeleteNoteById failed: %v", err)
+	}
+
+	count, _ := db.CountSharesByNoteId(noteId)
+	if count != 0 {
+		t.Errorf("Expected shares to be removed with the note, got %d", count)
+	}
+}
+
+func TestCreateReplyNote(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	userId := uuid.New()
+	createTestAccount(t, db, userId, "alice", "pubkey", "webpub", "webpriv")
+
+	parentURI := "https://remote.example/notes/1"
+	replyId, err := db.CreateReplyNote(userId, "Good point!", parentURI)
+	if err != nil {
+		t.Fatalf("CreateReplyNote failed: %v", err)
+	}
+	db.CreateNote(userId, "Unrelated note")
+
+	err, reply := db.ReadNoteId(replyId)
+	if err != nil {
+		t.Fatalf("ReadNoteId failed: %v", err)
+	}
+	if reply.InReplyToURI != parentURI {
+		t.Errorf("Expected InReplyToURI %s, got %s", parentURI, reply.InReplyToURI)
+	}
+
+	err, replies := db.ReadNotesInReplyTo(parentURI)
+	if err != nil {
+		t.Fatalf("ReadNotesInReplyTo failed: %v", err)
+	}
+	if len(*replies) != 1 {
+		t.Fatalf("Expected 1 reply, got %d", len(*replies))
+	}
+	if (*replies)[0].Id != replyId {
+		t.Errorf("Expected reply %s, got %s", replyId, (*replies)[0].Id)
+	}
+	if (*replies)[0].CreatedBy != "alice" {
+		t.Errorf("Expected reply by alice, got %s", (*replies)[0].CreatedBy)
+	}
+}
+
+func TestReadReplyActivities(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	parentURI := "https://example.com/notes/parent"
+	reply := &domain.Activity{
+		Id:           uuid.New(),
+		ActivityURI:  "https://remote.example/activities/1",
+		ActivityType: "Create",
+		ActorURI:     "https://remote.example/users/bob",
+		ObjectURI:    "https://remote.example/notes/1",
+		RawJSON:      `{"type":"Create","object":{"id":"https://remote.example/notes/1","inReplyTo":"` + parentURI + `"}}`,
+		CreatedAt:    time.Now(),
+	}
+	other := &domain.Activity{
+		Id:           uuid.New(),
+		ActivityURI:  "https://remote.example/activities/2",
+		ActivityType: "Create",
+		ActorURI:     "https://remote.example/users/bob",
+		ObjectURI:    "https://remote.example/notes/2",
+		RawJSON:      `{"type":"Create","object":{"id":"https://remote.example/notes/2","inReplyTo":null}}`,
+		CreatedAt:    time.Now(),
+	}
+	// Same parent, serialized with whitespace and escaped slashes
+	spaced := &domain.Activity{
+		Id:           uuid.New(),
+		ActivityURI:  "https://remote.example/activities/3",
+		ActivityType: "Create",
+		ActorURI:     "https://remote.example/users/bob",
+		ObjectURI:    "https://remote.example/notes/3",
+		RawJSON:      `{"type": "Create", "object": {"id": "https://remote.example/notes/3", "inReplyTo": "https:\/\/example.com\/notes\/parent"}}`,
+		CreatedAt:    time.Now().Add(time.Second),
+	}
+	// A parent URI with LIKE wildcards must only match itself
+	wildcard := &domain.Activity{
+		Id:           uuid.New(),
+		ActivityURI:  "https://remote.example/activities/4",
+		ActivityType: "Create",
+		ActorURI:     "https://remote.example/users/bob",
+		ObjectURI:    "https://remote.example/notes/4",
+		RawJSON:      `{"type":"Create","object":{"id":"https://remote.example/notes/4","inReplyTo":"https://example.com/notes/pa%"}}`,
+		CreatedAt:    time.Now(),
+	}
+	for _, activity := range []*domain.Activity{reply, other, spaced, wildcard} {
+		if err := db.CreateActivity(activity); err != nil {
+			t.Fatalf("CreateActivity failed: %v", err)
+		}
+	}
+
+	err, activities := db.ReadReplyActivities(parentURI)
+	if err != nil {
+		t.Fatalf("ReadReplyActivities failed: %v", err)
+	}
+	if len(*activities) != 2 {
+		t.Fatalf("Expected 2 reply activities, got %d", len(*activities))
+	}
+	if (*activities)[0].ObjectURI != reply.ObjectURI || (*activities)[1].ObjectURI != spaced.ObjectURI {
+		t.Errorf("Expected replies %s and %s, got %s and %s", reply.ObjectURI, spaced.ObjectURI, (*activities)[0].ObjectURI, (*activities)[1].ObjectURI)
+	}
+
+	err, activities = db.ReadReplyActivities("https://example.com/notes/pa%")
+	if err != nil {
+		t.Fatalf("ReadReplyActivities failed: %v", err)
+	}
+	if len(*activities) != 1 || (*activities)[0].ObjectURI != wildcard.ObjectURI {
+		t.Errorf("Expected only the reply to the wildcard URI, got %d activities", len(*activities))
+	}
+}
+
+func TestBackfillActivityInReplyToRunsOnce(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	parentURI := "https://example.com/notes/parent"
+	db.db.Exec(`INSERT INTO activities(id, activity_uri, activity_type, actor_uri, object_uri, raw_json, local)
+		VALUES (?, ?, 'Create', ?, ?, ?, 0)`,
+		uuid.New().String(), "https://remote.example/activities/1", "https://remote.example/users/bob",
+		"https://remote.example/notes/1", `{"type":"Create","object":{"inReplyTo":"`+parentURI+`"}}`)
+
+	backfill := func() {
+		if err := db.wrapTransaction(func(tx *sql.Tx) error {
+			return db.runOnce(tx, "backfill_activity_in_reply_to", db.backfillActivityInReplyTo)
+		}); err != nil {
+			t.Fatalf("backfill failed: %v", err)
+		}
+	}
+
+	backfill()
+	err, activities := db.ReadReplyActivities(parentURI)
+	if err != nil {
+		t.Fatalf("ReadReplyActivities failed: %v", err)
+	}
+	if len(*activities) != 1 {
+		t.Fatalf("Expected the backfilled reply, got %d activities", len(*activities))
+	}
+
+	// A second run is skipped, so rows written without the column stay untouched
+	db.db.Exec(`UPDATE activities SET in_reply_to = NULL`)
+	backfill()
+	err, activities = db.ReadReplyActivities(parentURI)
+	if err != nil {
+		t.Fatalf("ReadReplyActivities failed: %v", err)
+	}
+	if len(*activities) != 0 {
+		t.Errorf("Expected the backfill to run only once, got %d activities", len(*activities))
+	}
+}
+
+func TestReplaceNoteMentions(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	userId := uuid.New()
+	createTestAccount(t, db, userId, "alice", "pubkey", "webpub", "webpriv")
+	noteId, _ := db.CreateNote(userId, "Hello @bob and @carol@remote.example")
+
+	mentions := []domain.Mention{
+		{Id: uuid.New(), Handle: "@bob", ActorURI: "https://example.com/users/bob", CreatedAt: time.Now()},
+		{Id: uuid.New(), Handle: "@carol@remote.example", ActorURI: "https://remote.example/users/carol", CreatedAt: time.Now()},
+	}
+	if err := db.ReplaceNoteMentions(noteId, mentions); err != nil {
+		t.Fatalf("ReplaceNoteMentions failed: %v", err)
+	}
+
+	err, stored := db.ReadMentionsByNoteId(noteId)
+	if err != nil {
+		t.Fatalf("ReadMentionsByNoteId failed: %v", err)
+	}
+	if len(*stored) != 2 {
+		t.Fatalf("Expected 2 mentions, got %d", len(*stored))
+	}
+	if (*stored)[0].NoteId != noteId {
+		t.Errorf("Expected mention of note %s, got %s", noteId, (*stored)[0].NoteId)
+	}
+
+	// Editing a note replaces its mentions
+	if err := db.ReplaceNoteMentions(noteId, mentions[1:]); err != nil {
+		t.Fatalf("ReplaceNoteMentions failed: %v", err)
+	}
+	err, stored = db.ReadMentionsByNoteId(noteId)
+	if err != nil {
+		t.Fatalf("ReadMentionsByNoteId failed: %v", err)
+	}
+	if len(*stored) != 1 || (*stored)[0].ActorURI != "https://remote.example/users/carol" {
+		t.Errorf("Expected only carol to remain mentioned, got %+v", *stored)
+	}
+}
+
+func TestNoteTags(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	userId := uuid.New()
+	createTestAccount(t, db, userId, "alice", "pubkey", "webpub", "webpriv")
+
+	releaseId, err := db.CreateNote(userId, "v1.0 is out #Stegodon #release")
+	if err != nil {
+		t.Fatalf("CreateNote failed: %v", err)
+	}
+	otherId, _ := db.CreateNote(userId, "Another #stegodon note")
+	db.CreateNote(userId, "No tags here")
+
+	err, notes := db.ReadPublicNotesByTag("#STEGODON", 10, 0)
+	if err != nil {
+		t.Fatalf("ReadPublicNotesByTag failed: %v", err)
+	}
+	if len(*notes) != 2 {
+		t.Fatalf("Expected 2 notes tagged stegodon, got %d", len(*notes))
+	}
+	if (*notes)[0].CreatedBy != "alice" {
+		t.Errorf("Expected notes by alice, got %s", (*notes)[0].CreatedBy)
+	}
+
+	count, err := db.CountPublicNotesByTag("release")
+	if err != nil {
+		t.Fatalf("CountPublicNotesByTag failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 note tagged release, got %d", count)
+	}
+
+	// Editing a note re-indexes its tags
+	if err := db.UpdateNote(releaseId, "v1.0 is out"); err != nil {
+		t.Fatalf("UpdateNote failed: %v", err)
+	}
+	count, _ = db.CountPublicNotesByTag("release")
+	if count != 0 {
+		t.Errorf("Expected no notes tagged release after edit, got %d", count)
+	}
+
+	// Deleting a note removes it from the tag
+	if err := db.DeleteNoteById(otherId); err != nil {
+		t.Fatalf("DeleteNoteById failed: %v", err)
+	}
+	count, _ = db.CountPublicNotesByTag("stegodon")
+	if count != 0 {
+		t.Errorf("Expected no notes tagged stegodon after delete, got %d", count)
+	}
+}
+
+func TestBackfillNoteTagsRunsOnce(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	userId := uuid.New()
+	createTestAccount(t, db, userId, "alice", "pubkey", "webpub", "webpriv")
+	// Notes written before tags existed have no note_tags rows
+	db.db.Exec(`INSERT INTO notes(id, user_id, message, created_at) VALUES (?, ?, ?, ?)`,
+		uuid.New().String(), userId.String(), "Old #stegodon note", time.Now())
+
+	backfill := func() {
+		if err := db.wrapTransaction(func(tx *sql.Tx) error {
+			return db.runOnce(tx, "backfill_note_tags", db.backfillNoteTags)
+		}); err != nil {
+			t.Fatalf("backfill failed: %v", err)
+		}
+	}
+
+	backfill()
+	count, err := db.CountPublicNotesByTag("stegodon")
+	if err != nil {
+		t.Fatalf("CountPublicNotesByTag failed: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected the backfilled note, got %d", count)
+	}
+
+	// A second run is skipped instead of scanning the notes again
+	db.db.Exec(`DELETE FROM note_tags`)
+	backfill()
+	count, _ = db.CountPublicNotesByTag("stegodon")
+	if count != 0 {
+		t.Errorf("Expected the backfill to run only once, got %d notes", count)
+	}
+}
+
+func TestActivityTags(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	activity := &domain.Activity{
+		Id:           uuid.New(),
+		ActivityURI:  "https://remote.example/activities/1",
+		ActivityType: "Create",
+		ActorURI:     "https://remote.example/users/bob",
+		ObjectURI:    "https://remote.example/notes/1",
+		RawJSON:      `{"type":"Create"}`,
+		CreatedAt:    time.Now(),
+	}
+	if err := db.CreateActivity(activity); err != nil {
+		t.Fatalf("CreateActivity failed: %v", err)
+	}
+	if err := db.CreateActivityTags(activity.Id, []string{"#Stegodon", "#fediverse"}); err != nil {
+		t.Fatalf("CreateActivityTags failed: %v", err)
+	}
+
+	err, activities := db.ReadActivitiesByTag("stegodon", 10)
+	if err != nil {
+		t.Fatalf("ReadActivitiesByTag failed: %v", err)
+	}
+	if len(*activities) != 1 || (*activities)[0].Id != activity.Id {
+		t.Fatalf("Expected the tagged activity, got %+v", *activities)
+	}
+
+	if err := db.DeleteActivity(activity.Id); err != nil {
+		t.Fatalf("DeleteActivity failed: %v", err)
+	}
+	err, activities = db.ReadActivitiesByTag("fediverse", 10)
+	if err != nil {
+		t.Fatalf("ReadActivitiesByTag failed: %v", err)
+	}
+	if len(*activities) != 0 {
+		t.Errorf("Expected no tagged activities after delete, got %d", len(*activities))
+	}
+}
+
+func TestUpdateHideCollections(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	userId := uuid.New()
+	createTestAccount(t, db, userId, "alice", "pubkey", "webpub", "webpriv")
+
+	err, acc := db.ReadAccById(userId)
+	if err != nil {
+		t.Fatalf("ReadAccById failed: %v", err)
+	}
+	if acc.HideCollections {
+		t.Error("Collections should be visible by default")
+	}
+
+	if err := db.UpdateHideCollections(userId, true); err != nil {
+		t.Fatalf("UpdateHideCollections failed: %v", err)
+	}
+	_, acc = db.ReadAccById(userId)
+	if !acc.HideCollections {
+		t.Error("Expected collections to be hidden")
+	}
+
+	_, acc = db.ReadAccByUsername("alice")
+	if !acc.HideCollections {
+		t.Error("Expected hidden collections when reading by username")
+	}
+}
+
+func TestAccountMigration(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	userId := uuid.New()
+	createTestAccount(t, db, userId, "alice", "pubkey", "webpub", "webpriv")
+	_, acc := db.ReadAccById(userId)
+	if len(acc.AlsoKnownAs) != 0 || acc.MovedTo != "" {
+		t.Errorf("Expected no aliases and no move, got %v and %q", acc.AlsoKnownAs, acc.MovedTo)
+	}
+
+	aliases := []string{"https://old.example/users/alice", "https://other.example/@alice"}
+	if err := db.UpdateAlsoKnownAs(userId, aliases); err != nil {
+		t.Fatalf("UpdateAlsoKnownAs failed: %v", err)
+	}
+	if err := db.UpdateMovedTo(userId, "https://new.example/users/alice"); err != nil {
+		t.Fatalf("UpdateMovedTo failed: %v", err)
+	}
+
+	_, acc = db.ReadAccByUsername("alice")
+	if len(acc.AlsoKnownAs) != 2 || acc.AlsoKnownAs[0] != aliases[0] || acc.AlsoKnownAs[1] != aliases[1] {
+		t.Errorf("Expected aliases %v, got %v", aliases, acc.AlsoKnownAs)
+	}
+	if acc.MovedTo != "https://new.example/users/alice" {
+		t.Errorf("Expected moved to new account, got %q", acc.MovedTo)
+	}
+
+	if err := db.UpdateAlsoKnownAs(userId, nil); err != nil {
+		t.Fatalf("UpdateAlsoKnownAs failed: %v", err)
+	}
+	_, acc = db.ReadAccById(userId)
+	if len(acc.AlsoKnownAs) != 0 {
+		t.Errorf("Expected aliases to be removed, got %v", acc.AlsoKnownAs)
+	}
+}
+
+func TestPendingFollows(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	userId := uuid.New()
+	createTestAccount(t, db, userId, "alice", "pubkey", "webpub", "webpriv")
+	if err := db.UpdateLocked(userId, true); err != nil {
+		t.Fatalf("UpdateLocked failed: %v", err)
+	}
+	_, acc := db.ReadAccById(userId)
+	if !acc.Locked {
+		t.Error("Expected account to be locked")
+	}
+
+	pending := &domain.Follow{
+		Id:              uuid.New(),
+		AccountId:       uuid.New(),
+		TargetAccountId: userId,
+		URI:             "https://remote.example/follows/1",
+		Accepted:        false,
+		CreatedAt:       time.Now(),
+	}
+	accepted := &domain.Follow{
+		Id:              uuid.New(),
+		AccountId:       uuid.New(),
+		TargetAccountId: userId,
+		URI:             "https://remote.example/follows/2",
+		Accepted:        true,
+		CreatedAt:       time.Now(),
+	}
+	db.CreateFollow(pending)
+	db.CreateFollow(accepted)
+
+	err, requests := db.ReadPendingFollowsByAccountId(userId)
+	if err != nil {
+		t.Fatalf("ReadPendingFollowsByAccountId failed: %v", err)
+	}
+	if len(*requests) != 1 || (*requests)[0].URI != pending.URI {
+		t.Fatalf("Expected only the pending follow, got %v", *requests)
+	}
+
+	_, followers := db.ReadFollowersByAccountId(userId)
+	if len(*followers) != 1 {
+		t.Errorf("Pending follows should not count as followers, got %d", len(*followers))
+	}
+	if requested, err := db.IsFollowingOrRequested(pending.AccountId, userId); err != nil || !requested {
+		t.Errorf("Expected the follow request to be found, got %v (%v)", requested, err)
+	}
+	if requested, _ := db.IsFollowingOrRequested(userId, pending.AccountId); requested {
+		t.Error("Expected no follow in the other direction")
+	}
+
+	if err := db.AcceptFollowByURI(pending.URI); err != nil {
+		t.Fatalf("AcceptFollowByURI failed: %v", err)
+	}
+	_, requests = db.ReadPendingFollowsByAccountId(userId)
+	if len(*requests) != 0 {
+		t.Errorf("Expected no pending follows after accepting, got %d", len(*requests))
+	}
+	_, followers = db.ReadFollowersByAccountId(userId)
+	if len(*followers) != 2 {
+		t.Errorf("Expected 2 followers after accepting, got %d", len(*followers))
+	}
+}
+
+func TestBlocks(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	userId := uuid.New()
+	createTestAccount(t, db, userId, "alice", "pubkey", "webpub", "webpriv")
+
+	remotes := []*domain.RemoteAccount{
+		{Id: uuid.New(), Username: "bob", Domain: "example.com", ActorURI: "https://example.com/users/bob", LastFetchedAt: time.Now()},
+		{Id: uuid.New(), Username: "carol", Domain: "spam.example", ActorURI: "https://spam.example/users/carol", LastFetchedAt: time.Now()},
+		{Id: uuid.New(), Username: "dave", Domain: "other.example", ActorURI: "https://other.example/users/dave", LastFetchedAt: time.Now()},
+	}
+	for _, remote := range remotes {
+		if err := db.CreateRemoteAccount(remote); err != nil {
+			t.Fatalf("CreateRemoteAccount failed: %v", err)
+		}
+		// Alice follows every remote actor and is followed back
+		db.CreateFollow(&domain.Follow{Id: uuid.New(), AccountId: userId, TargetAccountId: remote.Id, URI: remote.ActorURI + "#follow-out", Accepted: true, CreatedAt: time.Now()})
+		db.CreateFollow(&domain.Follow{Id: uuid.New(), AccountId: remote.Id, TargetAccountId: userId, URI: remote.ActorURI + "#follow-in", Accepted: true, CreatedAt: time.Now()})
+		db.CreateActivity(&domain.Activity{
+			Id:           uuid.New(),
+			ActivityURI:  remote.ActorURI + "/activities/1",
+			ActivityType: "Create",
+			ActorURI:     remote.ActorURI,
+			ObjectURI:    remote.ActorURI + "/statuses/1",
+			RawJSON:      "{}",
+			CreatedAt:    time.Now(),
+		})
+	}
+
+	_, activities := db.ReadFederatedActivities(userId, 10)
+	if len(*activities) != 3 {
+		t.Fatalf("Expected 3 activities before blocking, got %d", len(*activities))
+	}
+
+	actorBlock := &domain.Block{Id: uuid.New(), AccountId: userId, Target: "https://example.com/users/bob", URI: "https://local.example/activities/block", CreatedAt: time.Now()}
+	domainBlock := &domain.Block{Id: uuid.New(), AccountId: userId, Target: "Spam.Example", IsDomain: true, CreatedAt: time.Now()}
+	if err := db.CreateBlock(actorBlock); err != nil {
+		t.Fatalf("CreateBlock failed: %v", err)
+	}
+	if err := db.CreateBlock(domainBlock); err != nil {
+		t.Fatalf("CreateBlock failed: %v", err)
+	}
+
+	// Follows with blocked actors are removed in both directions
+	_, following := db.ReadFollowingByAccountId(userId)
+	if len(*following) != 1 || (*following)[0].TargetAccountId != remotes[2].Id {
+		t.Errorf("Expected only the follow of dave to remain, got %v", *following)
+	}
+	_, followers := db.ReadFollowersByAccountId(userId)
+	if len(*followers) != 1 || (*followers)[0].AccountId != remotes[2].Id {
+		t.Errorf("Expected only dave as follower, got %v", *followers)
+	}
+
+	blocked, err := db.IsBlocked(userId, "https://example.com/users/bob", "example.com")
+	if err != nil || !blocked {
+		t.Errorf("Expected bob to be blocked, got %v (%v)", blocked, err)
+	}
+	blocked, _ = db.IsBlocked(userId, "https://spam.example/users/anyone", "spam.example")
+	if !blocked {
+		t.Error("Expected actors of a blocked domain to be blocked")
+	}
+	blocked, _ = db.IsBlocked(userId, "https://other.example/users/dave", "other.example")
+	if blocked {
+		t.Error("Expected dave not to be blocked")
+	}
+
+	err, blocks := db.ReadBlocksByAccountId(userId)
+	if err != nil {
+		t.Fatalf("ReadBlocksByAccountId failed: %v", err)
+	}
+	if len(*blocks) != 2 {
+		t.Fatalf("Expected 2 blocks, got %d", len(*blocks))
+	}
+
+	err, stored := db.ReadBlockById(domainBlock.Id)
+	if err != nil {
+		t.Fatalf("ReadBlockById failed: %v", err)
+	}
+	if stored.Target != "spam.example" || !stored.IsDomain {
+		t.Errorf("Expected lowercased domain block, got %+v", stored)
+	}
+
+	// Boosts of blocked actors are filtered from the timeline
+	db.CreateActivity(&domain.Activity{
+		Id:           uuid.New(),
+		ActivityURI:  "https://other.example/users/dave/activities/2",
+		ActivityType: "Announce",
+		ActorURI:     "https://other.example/users/dave",
+		ObjectURI:    "https://example.com/users/bob/statuses/1",
+		RawJSON:      "{}",
+		CreatedAt:    time.Now(),
+	})
+	_, activities = db.ReadFederatedActivities(userId, 10)
+	if len(*activities) != 1 || (*activities)[0].ActivityType != "Create" {
+		t.Errorf("Expected only dave's own post in the timeline, got %v", *activities)
+	}
+
+	if err := db.DeleteBlock(userId, actorBlock.Id); err != nil {
+		t.Fatalf("DeleteBlock failed: %v", err)
+	}
+	blocked, _ = db.IsBlocked(userId, "https://example.com/users/bob", "example.com")
+	if blocked {
+		t.Error("Expected bob to be unblocked")
+	}
+}
+
+func TestDomainBlocks(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	userId := uuid.New()
+	createTestAccount(t, db, userId, "alice", "pubkey", "webpub", "webpriv")
+
+	remotes := []*domain.RemoteAccount{
+		{Id: uuid.New(), Username: "bob", Domain: "loud.example", ActorURI: "https://loud.example/users/bob", LastFetchedAt: time.Now()},
+		{Id: uuid.New(), Username: "carol", Domain: "eu.spam.example", ActorURI: "https://eu.spam.example/users/carol", LastFetchedAt: time.Now()},
+		{Id: uuid.New(), Username: "dave", Domain: "other.example", ActorURI: "https://other.example/users/dave", LastFetchedAt: time.Now()},
+	}
+	for _, remote := range remotes {
+		db.CreateRemoteAccount(remote)
+		db.CreateFollow(&domain.Follow{Id: uuid.New(), AccountId: userId, TargetAccountId: remote.Id, URI: remote.ActorURI + "#follow", Accepted: true, CreatedAt: time.Now()})
+		db.CreateActivity(&domain.Activity{
+			Id:           uuid.New(),
+			ActivityURI:  remote.ActorURI + "/activities/1",
+			ActivityType: "Create",
+			ActorURI:     remote.ActorURI,
+			ObjectURI:    remote.ActorURI + "/statuses/1",
+			RawJSON:      "{}",
+			CreatedAt:    time.Now(),
+		})
+	}
+
+	silence := &domain.DomainBlock{Id: uuid.New(), Domain: "Loud.Example", Severity: domain.DomainBlockSilence, CreatedAt: time.Now()}
+	suspend := &domain.DomainBlock{Id: uuid.New(), Domain: "spam.example", Severity: domain.DomainBlockSuspend, PublicComment: "spam", CreatedAt: time.Now()}
+	if err := db.UpsertDomainBlock(silence); err != nil {
+		t.Fatalf("UpsertDomainBlock failed: %v", err)
+	}
+	if err := db.UpsertDomainBlock(suspend); err != nil {
+		t.Fatalf("UpsertDomainBlock failed: %v", err)
+	}
+
+	// Silenced follows stay, follows with suspended subdomains are removed
+	_, following := db.ReadFollowingByAccountId(userId)
+	if len(*following) != 2 {
+		t.Errorf("Expected 2 remaining follows, got %d", len(*following))
+	}
+
+	// Silenced and suspended domains are hidden from the timeline
+	_, activities := db.ReadFederatedActivities(userId, 10)
+	if len(*activities) != 1 || (*activities)[0].ActorURI != "https://other.example/users/dave" {
+		t.Errorf("Expected only dave's post in the timeline, got %v", *activities)
+	}
+
+	err, block := db.ReadDomainBlockByDomain("LOUD.example")
+	if err != nil {
+		t.Fatalf("ReadDomainBlockByDomain failed: %v", err)
+	}
+	if block.Severity != domain.DomainBlockSilence {
+		t.Errorf("Expected silence, got %s", block.Severity)
+	}
+
+	// Blocking a domain again updates the existing block
+	update := &domain.DomainBlock{Id: uuid.New(), Domain: "loud.example", Severity: domain.DomainBlockNoop, RejectMedia: true, CreatedAt: time.Now()}
+	if err := db.UpsertDomainBlock(update); err != nil {
+		t.Fatalf("UpsertDomainBlock failed: %v", err)
+	}
+	err, blocks := db.ReadDomainBlocks()
+	if err != nil {
+		t.Fatalf("ReadDomainBlocks failed: %v", err)
+	}
+	if len(*blocks) != 2 {
+		t.Fatalf("Expected 2 domain blocks, got %d", len(*blocks))
+	}
+	if (*blocks)[0].Domain != "loud.example" || (*blocks)[0].Severity != domain.DomainBlockNoop || !(*blocks)[0].RejectMedia {
+		t.Errorf("Expected updated block, got %+v", (*blocks)[0])
+	}
+
+	if err := db.DeleteDomainBlock((*blocks)[1].Id); err != nil {
+		t.Fatalf("DeleteDomainBlock failed: %v", err)
+	}
+	_, blocks = db.ReadDomainBlocks()
+	if len(*blocks) != 1 {
+		t.Errorf("Expected 1 domain block after deleting, got %d", len(*blocks))
+	}
+}
+
+func TestReadOrCreateInstanceKeys(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	err, first := db.ReadOrCreateInstanceKeys()
+	if err != nil {
+		t.Fatalf("ReadOrCreateInstanceKeys failed: %v", err)
+	}
+	if first.Public == "" || first.Private == "" {
+		t.Fatal("Expected a generated keypair")
+	}
+
+	// The keypair is only generated once
+	err, second := db.ReadOrCreateInstanceKeys()
+	if err != nil {
+		t.Fatalf("ReadOrCreateInstanceKeys failed: %v", err)
+	}
+	if second.Private != first.Private {
+		t.Error("Expected the stored keypair to be returned")
+	}
+}
+
+func TestCountDeliveries(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		item := &domain.DeliveryQueueItem{
+			Id:           uuid.New(),
+			InboxURI:     "https://remote.example/inbox",
+			ActivityJSON: `{"type":"Create"}`,
+			NextRetryAt:  now.Add(time.Duration(i) * time.Hour),
+			CreatedAt:    now,
+		}
+		if err := db.EnqueueDelivery(item); err != nil {
+			t.Fatalf("EnqueueDelivery failed: %v", err)
+		}
+	}
+
+	// Deliveries waiting for a retry are counted as well
+	count, err := db.CountDeliveries()
+	if err != nil {
+		t.Fatalf("CountDeliveries failed: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 queued deliveries, got %d", count)
+	}
+}
+
+func TestDeadDeliveries(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	accountId := uuid.New()
+	inbox := "https://down.example/inbox"
+	item := &domain.DeliveryQueueItem{
+		Id:           uuid.New(),
+		AccountId:    accountId,
+		InboxURI:     inbox,
+		ActivityJSON: `{"type":"Create"}`,
+		Attempts:     10,
+		NextRetryAt:  time.Now(),
+		CreatedAt:    time.Now(),
+	}
+	if err := db.EnqueueDelivery(item); err != nil {
+		t.Fatalf("EnqueueDelivery failed: %v", err)
+	}
+
+	if err := db.MoveDeliveryToDeadLetters(item, "remote server returned status: 502", 502); err != nil {
+		t.Fatalf("MoveDeliveryToDeadLetters failed: %v", err)
+	}
+	if count, _ := db.CountDeliveries(); count != 0 {
+		t.Errorf("Expected the delivery to leave the queue, got %d queued", count)
+	}
+
+	err, dead := db.ReadDeadDeliveries()
+	if err != nil {
+		t.Fatalf("ReadDeadDeliveries failed: %v", err)
+	}
+	if len(*dead) != 1 {
+		t.Fatalf("Expected 1 dead delivery, got %d", len(*dead))
+	}
+	if (*dead)[0].Id != item.Id || (*dead)[0].AccountId != accountId || (*dead)[0].LastStatus != 502 || (*dead)[0].Attempts != 10 || (*dead)[0].LastError == "" {
+		t.Errorf("Unexpected dead delivery: %+v", (*dead)[0])
+	}
+
+	// Requeued deliveries start over
+	requeued, err := db.RequeueDeadDeliveries(inbox)
+	if err != nil || requeued != 1 {
+		t.Fatalf("RequeueDeadDeliveries = %d, %v", requeued, err)
+	}
+	err, pending := db.ReadPendingDeliveries(10)
+	if err != nil || len(*pending) != 1 || (*pending)[0].Attempts != 0 || (*pending)[0].AccountId != accountId {
+		t.Fatalf("Expected the requeued delivery to be pending for its account with no attempts, got %+v (%v)", pending, err)
+	}
+	if _, dead = db.ReadDeadDeliveries(); len(*dead) != 0 {
+		t.Errorf("Expected no dead deliveries after requeue, got %d", len(*dead))
+	}
+
+	counts, err := db.CountDeliveriesByInbox()
+	if err != nil || counts[inbox] != 1 {
+		t.Errorf("Expected 1 queued delivery for %s, got %v (%v)", inbox, counts, err)
+	}
+
+	db.MoveDeliveryToDeadLetters(&(*pending)[0], "timeout", 0)
+	purged, err := db.PurgeDeadDeliveries(inbox)
+	if err != nil || purged != 1 {
+		t.Fatalf("PurgeDeadDeliveries = %d, %v", purged, err)
+	}
+	if _, dead = db.ReadDeadDeliveries(); len(*dead) != 0 {
+		t.Errorf("Expected no dead deliveries after purge, got %d", len(*dead))
+	}
+}
+
+func TestRetryDeliveriesNow(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	item := &domain.DeliveryQueueItem{
+		Id:           uuid.New(),
+		AccountId:    uuid.New(),
+		InboxURI:     "https://slow.example/inbox",
+		ActivityJSON: `{"type":"Create"}`,
+		Attempts:     3,
+		NextRetryAt:  time.Now().Add(time.Hour),
+		CreatedAt:    time.Now(),
+	}
+	db.EnqueueDelivery(item)
+
+	if _, pending := db.ReadPendingDeliveries(10); len(*pending) != 0 {
+		t.Fatalf("Expected no due deliveries, got %d", len(*pending))
+	}
+	if err := db.RetryDeliveriesNow(item.InboxURI); err != nil {
+		t.Fatalf("RetryDeliveriesNow failed: %v", err)
+	}
+	_, pending := db.ReadPendingDeliveries(10)
+	if len(*pending) != 1 {
+		t.Fatalf("Expected the delivery to be due, got %d", len(*pending))
+	}
+	if (*pending)[0].AccountId != item.AccountId {
+		t.Errorf("Expected the delivery of account %s, got %s", item.AccountId, (*pending)[0].AccountId)
+	}
+}
+
+func TestUnprocessedActivities(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	activity := &domain.Activity{
+		Id:            uuid.New(),
+		ActivityURI:   "https://remote.example/activities/1",
+		ActivityType:  "Follow",
+		ActorURI:      "https://remote.example/users/bob",
+		ObjectURI:     "https://example.com/users/alice",
+		RawJSON:       `{"type":"Follow"}`,
+		CreatedAt:     time.Now().Add(-time.Second),
+		InboxUsername: "alice",
+	}
+	if err := db.CreateActivity(activity); err != nil {
+		t.Fatalf("CreateActivity failed: %v", err)
+	}
+
+	err, due := db.ReadUnprocessedActivities(10)
+	if err != nil {
+		t.Fatalf("ReadUnprocessedActivities failed: %v", err)
+	}
+	if len(*due) != 1 || (*due)[0].InboxUsername != "alice" {
+		t.Fatalf("Expected the activity for alice to be due, got %+v", due)
+	}
+
+	// A failed attempt postpones the activity
+	if err := db.UpdateActivityAttempt(activity.Id, 1, time.Now().Add(time.Minute), "timeout"); err != nil {
+		t.Fatalf("UpdateActivityAttempt failed: %v", err)
+	}
+	if _, due = db.ReadUnprocessedActivities(10); len(*due) != 0 {
+		t.Errorf("Expected no due activities while waiting for a retry, got %d", len(*due))
+	}
+	if count, _ := db.CountUnprocessedActivities(); count != 1 {
+		t.Errorf("Expected 1 unprocessed activity, got %d", count)
+	}
+
+	// Handled for alice, the activity is done
+	if err := db.SetActivityRecipient(activity.Id, "alice", true); err != nil {
+		t.Fatalf("SetActivityRecipient failed: %v", err)
+	}
+	if err := db.MarkActivityProcessed(activity.Id, ""); err != nil {
+		t.Fatalf("MarkActivityProcessed failed: %v", err)
+	}
+	if count, _ := db.CountUnprocessedActivities(); count != 0 {
+		t.Errorf("Expected no unprocessed activities, got %d", count)
+	}
+
+	// Delivered to bob's inbox later, the activity is due again for bob only
+	if err := db.AddActivityRecipient(activity.Id, "bob"); err != nil {
+		t.Fatalf("AddActivityRecipient failed: %v", err)
+	}
+	_, due = db.ReadUnprocessedActivities(10)
+	if len(*due) != 1 || (*due)[0].InboxUsername != "alice" || (*due)[0].Attempts != 1 {
+		t.Fatalf("Expected the activity to be due without resetting it, got %+v", due)
+	}
+	_, recipients := db.ReadActivityRecipients(activity.Id)
+	for _, recipient := range *recipients {
+		if recipient.Processed != (recipient.Username == "alice") {
+			t.Errorf("Expected only alice to be handled, got %+v", recipient)
+		}
+	}
+
+	// Added again, a known recipient changes nothing
+	db.SetActivityRecipient(activity.Id, "bob", true)
+	db.MarkActivityProcessed(activity.Id, "")
+	if err := db.AddActivityRecipient(activity.Id, "bob"); err != nil {
+		t.Fatalf("AddActivityRecipient failed: %v", err)
+	}
+	if count, _ := db.CountUnprocessedActivities(); count != 0 {
+		t.Errorf("Expected no unprocessed activities, got %d", count)
+	}
+
+	err, stored := db.ReadActivityByURI(activity.ActivityURI)
+	if err != nil || !stored.Processed {
+		t.Errorf("Expected a processed activity, got %+v (%v)", stored, err)
+	}
+}
+
+func TestMarkActivityProcessedWithPendingRecipient(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	activity := &domain.Activity{
+		Id:            uuid.New(),
+		ActivityURI:   "https://remote.example/activities/3",
+		ActivityType:  "Like",
+		ActorURI:      "https://remote.example/users/bob",
+		RawJSON:       `{"type":"Like"}`,
+		CreatedAt:     time.Now().Add(-time.Second),
+		InboxUsername: "alice",
+	}
+	if err := db.CreateActivity(activity); err != nil {
+		t.Fatalf("CreateActivity failed: %v", err)
+	}
+
+	// carol's delivery arrived while alice's was processed
+	db.SetActivityRecipient(activity.Id, "alice", true)
+	db.AddActivityRecipient(activity.Id, "carol")
+	if err := db.MarkActivityProcessed(activity.Id, ""); err != nil {
+		t.Fatalf("MarkActivityProcessed failed: %v", err)
+	}
+	if count, _ := db.CountUnprocessedActivities(); count != 1 {
+		t.Errorf("Expected the activity to stay queued for carol, got %d", count)
+	}
+}
+
+func TestActivityRecipients(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	activity := &domain.Activity{
+		Id:           uuid.New(),
+		ActivityURI:  "https://remote.example/activities/2",
+		ActivityType: "Create",
+		ActorURI:     "https://remote.example/users/bob",
+		RawJSON:      `{"type":"Create"}`,
+		CreatedAt:    time.Now().Add(-time.Second),
+	}
+	if err := db.CreateActivity(activity); err != nil {
+		t.Fatalf("CreateActivity failed: %v", err)
+	}
+
+	// alice was handled, carol failed and is retried
+	if err := db.SetActivityRecipient(activity.Id, "alice", true); err != nil {
+		t.Fatalf("SetActivityRecipient failed: %v", err)
+	}
+	if err := db.SetActivityRecipient(activity.Id, "carol", false); err != nil {
+		t.Fatalf("SetActivityRecipient failed: %v", err)
+	}
+	err, recipients := db.ReadActivityRecipients(activity.Id)
+	if err != nil {
+		t.Fatalf("ReadActivityRecipients failed: %v", err)
+	}
+	processed := make(map[string]bool)
+	for _, recipient := range *recipients {
+		processed[recipient.Username] = recipient.Processed
+	}
+	if len(processed) != 2 || !processed["alice"] || processed["carol"] {
+		t.Errorf("Expected alice handled and carol pending, got %v", processed)
+	}
+
+	// Giving up on the activity gives up on its pending recipients
+	if err := db.MarkActivityProcessed(activity.Id, "timeout"); err != nil {
+		t.Fatalf("MarkActivityProcessed failed: %v", err)
+	}
+	_, recipients = db.ReadActivityRecipients(activity.Id)
+	for _, recipient := range *recipients {
+		if !recipient.Processed {
+			t.Errorf("Expected %s to be given up on", recipient.Username)
+		}
+	}
+
+	if err := db.DeleteActivity(activity.Id); err != nil {
+		t.Fatalf("DeleteActivity failed: %v", err)
+	}
+	if _, recipients = db.ReadActivityRecipients(activity.Id); len(*recipients) != 0 {
+		t.Errorf("Expected the recipients to be deleted with the activity, got %d", len(*recipients))
+	}
+}
+
+func TestSignatureSchemes(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	if err, _ := db.ReadSignatureScheme("remote.example"); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows for an unknown host, got %v", err)
+	}
+
+	upgraded := time.Now().Truncate(time.Second)
+	for _, scheme := range []string{"rfc9421", "cavage"} {
+		if err := db.SetSignatureScheme(&domain.SignatureScheme{Host: "remote.example", Scheme: scheme, UpdatedAt: upgraded}); err != nil {
+			t.Fatalf("SetSignatureScheme failed: %v", err)
+		}
+	}
+
+	err, stored := db.ReadSignatureScheme("remote.example")
+	if err != nil {
+		t.Fatalf("ReadSignatureScheme failed: %v", err)
+	}
+	if stored.Scheme != "cavage" || !stored.UpdatedAt.Equal(upgraded) {
+		t.Errorf("Expected the latest scheme cavage at %v, got %s at %v", upgraded, stored.Scheme, stored.UpdatedAt)
+	}
+}
+
+func TestKeyChanges(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.db.Close()
+
+	actor := "https://mastodon.example/users/alice"
+	first := &domain.KeyChange{Id: uuid.New(), ActorURI: actor, OldKeyPem: "key-1", NewKeyPem: "key-2", ChangedAt: time.Now().Add(-time.Hour)}
+	second := &domain.KeyChange{Id: uuid.New(), ActorURI: actor, OldKeyPem: "key-2", NewKeyPem: "key-3", ChangedAt: time.Now()}
+	for _, change := range []*domain.KeyChange{first, second} {
+		if err := db.CreateKeyChange(change); err != nil {
+			t.Fatalf("CreateKeyChange failed: %v", err)
+		}
+	}
+
+	err, changes := db.ReadKeyChanges(10)
+	if err != nil {
+		t.Fatalf("ReadKeyChanges failed: %v", err)
+	}
+	if len(*changes) != 2 {
+		t.Fatalf("Expected 2 key changes, got %d", len(*changes))
+	}
+	if (*changes)[0].Id != second.Id || (*changes)[0].NewKeyPem != "key-3" {
+		t.Errorf("Expected the newest change first, got %+v", (*changes)[0])
+	}
+
+	if _, changes = db.ReadKeyChanges(1); len(*changes) != 1 {
+		t.Errorf("Expected the limit to apply, got %d changes", len(*changes))
+	}
+}
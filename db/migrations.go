@@ -4,6 +4,9 @@ import (
 	"database/sql"
 	"encoding/json"
 	"log"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 // SQL for new ActivityPub tables
@@ -37,6 +40,9 @@ const (
 		public_key_pem TEXT NOT NULL,
 		avatar_url TEXT,
 		last_fetched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		shared_inbox_uri TEXT,
+		ed25519_key_id TEXT,
+		ed25519_key_pem TEXT,
 		UNIQUE(username, domain)
 	)`
 
@@ -55,7 +61,11 @@ const (
 		raw_json TEXT NOT NULL,
 		processed INTEGER DEFAULT 0,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		local INTEGER DEFAULT 0
+		local INTEGER DEFAULT 0,
+		inbox_username TEXT,
+		attempts INTEGER DEFAULT 0,
+		next_attempt_at TIMESTAMP,
+		last_error TEXT
 	)`
 
 	sqlCreateActivitiesIndices = `
@@ -66,20 +76,145 @@ const (
 	`
 
 	// Likes/favorites table
+	// Likes of local notes have a note_id, likes of remote posts an object_uri instead
 	sqlCreateLikesTable = `CREATE TABLE IF NOT EXISTS likes (
 		id TEXT NOT NULL PRIMARY KEY,
 		account_id TEXT NOT NULL,
-		note_id TEXT NOT NULL,
+		note_id TEXT,
 		uri TEXT NOT NULL,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		UNIQUE(account_id, note_id)
 	)`
 
+	// Copies likes into a table whose note_id may be NULL, dropping the note_id of likes of remote posts
+	sqlRebuildLikesTable = `
+		CREATE TABLE likes_rebuilt (
+			id TEXT NOT NULL PRIMARY KEY,
+			account_id TEXT NOT NULL,
+			note_id TEXT,
+			uri TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			object_uri TEXT,
+			UNIQUE(account_id, note_id)
+		);
+		INSERT INTO likes_rebuilt(id, account_id, note_id, uri, created_at, object_uri)
+			SELECT id, account_id, CASE WHEN object_uri IS NULL OR object_uri = '' THEN note_id END, uri, created_at, object_uri FROM likes;
+		DROP TABLE likes;
+		ALTER TABLE likes_rebuilt RENAME TO likes;
+	`
+
 	sqlCreateLikesIndices = `
 		CREATE INDEX IF NOT EXISTS idx_likes_note_id ON likes(note_id);
 		CREATE INDEX IF NOT EXISTS idx_likes_account_id ON likes(account_id);
 	`
 
+	// Shares/boosts table
+	// Boosts of local notes have a note_id, boosts of remote posts only an object_uri
+	sqlCreateSharesTable = `CREATE TABLE IF NOT EXISTS shares (
+		id TEXT NOT NULL PRIMARY KEY,
+		account_id TEXT NOT NULL,
+		note_id TEXT,
+		uri TEXT NOT NULL,
+		object_uri TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(account_id, note_id)
+	)`
+
+	// Copies shares into a table whose note_id may be NULL, dropping note_ids that aren't local notes
+	sqlRebuildSharesTable = `
+		CREATE TABLE shares_rebuilt (
+			id TEXT NOT NULL PRIMARY KEY,
+			account_id TEXT NOT NULL,
+			note_id TEXT,
+			uri TEXT NOT NULL,
+			object_uri TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(account_id, note_id)
+		);
+		INSERT INTO shares_rebuilt(id, account_id, note_id, uri, object_uri, created_at)
+			SELECT id, account_id, CASE WHEN note_id IN (SELECT id FROM notes) THEN note_id END, uri, object_uri, created_at FROM shares;
+		DROP TABLE shares;
+		ALTER TABLE shares_rebuilt RENAME TO shares;
+	`
+
+	sqlCreateSharesIndices = `
+		CREATE INDEX IF NOT EXISTS idx_shares_note_id ON shares(note_id);
+		CREATE INDEX IF NOT EXISTS idx_shares_account_id ON shares(account_id);
+		CREATE INDEX IF NOT EXISTS idx_shares_uri ON shares(uri);
+		CREATE INDEX IF NOT EXISTS idx_shares_object_uri ON shares(object_uri);
+	`
+
+	// Mentions in local notes
+	sqlCreateMentionsTable = `CREATE TABLE IF NOT EXISTS mentions (
+		id TEXT NOT NULL PRIMARY KEY,
+		note_id TEXT NOT NULL,
+		handle TEXT NOT NULL,
+		actor_uri TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(note_id, actor_uri)
+	)`
+
+	sqlCreateMentionsIndices = `
+		CREATE INDEX IF NOT EXISTS idx_mentions_note_id ON mentions(note_id);
+	`
+
+	// Hashtags, shared by local notes and stored remote posts
+	sqlCreateTagsTable = `CREATE TABLE IF NOT EXISTS tags (
+		id TEXT NOT NULL PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+
+	sqlCreateNoteTagsTable = `CREATE TABLE IF NOT EXISTS note_tags (
+		note_id TEXT NOT NULL,
+		tag_id TEXT NOT NULL,
+		PRIMARY KEY(note_id, tag_id)
+	)`
+
+	sqlCreateActivityTagsTable = `CREATE TABLE IF NOT EXISTS activity_tags (
+		activity_id TEXT NOT NULL,
+		tag_id TEXT NOT NULL,
+		PRIMARY KEY(activity_id, tag_id)
+	)`
+
+	sqlCreateTagsIndices = `
+		CREATE INDEX IF NOT EXISTS idx_note_tags_tag_id ON note_tags(tag_id);
+		CREATE INDEX IF NOT EXISTS idx_activity_tags_tag_id ON activity_tags(tag_id);
+	`
+
+	// User-level blocks of remote actors and domains
+	sqlCreateBlocksTable = `CREATE TABLE IF NOT EXISTS blocks (
+		id TEXT NOT NULL PRIMARY KEY,
+		account_id TEXT NOT NULL,
+		target TEXT NOT NULL,
+		is_domain INTEGER DEFAULT 0,
+		uri TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(account_id, target)
+	)`
+
+	sqlCreateBlocksIndices = `
+		CREATE INDEX IF NOT EXISTS idx_blocks_account_id ON blocks(account_id);
+	`
+
+	// Instance-wide domain blocks managed by admins
+	sqlCreateDomainBlocksTable = `CREATE TABLE IF NOT EXISTS domain_blocks (
+		id TEXT NOT NULL PRIMARY KEY,
+		domain TEXT NOT NULL UNIQUE,
+		severity TEXT NOT NULL DEFAULT 'silence',
+		reject_media INTEGER DEFAULT 0,
+		public_comment TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+
+	// Keypair of the server-wide instance actor, a single row
+	sqlCreateInstanceActorTable = `CREATE TABLE IF NOT EXISTS instance_actor (
+		id INTEGER NOT NULL PRIMARY KEY CHECK (id = 1),
+		public_key TEXT NOT NULL,
+		private_key TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+
 	// Delivery queue table
 	sqlCreateDeliveryQueueTable = `CREATE TABLE IF NOT EXISTS delivery_queue (
 		id TEXT NOT NULL PRIMARY KEY,
@@ -94,6 +229,48 @@ const (
 		CREATE INDEX IF NOT EXISTS idx_delivery_queue_next_retry ON delivery_queue(next_retry_at);
 	`
 
+	// Deliveries given up on after all attempts failed
+	sqlCreateDeliveryDeadLettersTable = `CREATE TABLE IF NOT EXISTS delivery_dead_letters (
+		id TEXT NOT NULL PRIMARY KEY,
+		account_id TEXT,
+		inbox_uri TEXT NOT NULL,
+		activity_json TEXT NOT NULL,
+		attempts INTEGER DEFAULT 0,
+		last_error TEXT,
+		last_status INTEGER DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		failed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+
+	sqlCreateDeliveryDeadLettersIndices = `
+		CREATE INDEX IF NOT EXISTS idx_delivery_dead_letters_inbox_uri ON delivery_dead_letters(inbox_uri);
+	`
+
+	sqlCreateKeyChangesTable = `CREATE TABLE IF NOT EXISTS key_changes (
+		id TEXT NOT NULL PRIMARY KEY,
+		actor_uri TEXT NOT NULL,
+		old_key_pem TEXT NOT NULL,
+		new_key_pem TEXT NOT NULL,
+		changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+
+	sqlCreateKeyChangesIndices = `
+		CREATE INDEX IF NOT EXISTS idx_key_changes_changed_at ON key_changes(changed_at DESC);
+	`
+
+	sqlCreateActivityRecipientsTable = `CREATE TABLE IF NOT EXISTS activity_recipients (
+		activity_id TEXT NOT NULL,
+		username TEXT NOT NULL,
+		processed INTEGER DEFAULT 0,
+		PRIMARY KEY (activity_id, username)
+	)`
+
+	sqlCreateSignatureSchemesTable = `CREATE TABLE IF NOT EXISTS signature_schemes (
+		host TEXT NOT NULL PRIMARY KEY,
+		scheme TEXT NOT NULL,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+
 	// Extend existing tables with new columns
 	sqlExtendAccountsTable = `
 		ALTER TABLE accounts ADD COLUMN display_name TEXT;
@@ -110,6 +287,12 @@ const (
 		ALTER TABLE notes ADD COLUMN content_warning TEXT;
 	`
 
+	// Data migrations that ran on this database, so they run only once
+	sqlCreateSchemaMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+		name TEXT NOT NULL PRIMARY KEY,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+
 	sqlCreateNotesIndices = `
 		CREATE INDEX IF NOT EXISTS idx_notes_user_id ON notes(user_id);
 		CREATE INDEX IF NOT EXISTS idx_notes_created_at ON notes(created_at DESC);
@@ -133,9 +316,48 @@ func (db *DB) RunMigrations() error {
 		if err := db.createTableIfNotExists(tx, sqlCreateLikesTable, "likes"); err != nil {
 			return err
 		}
+		if err := db.createTableIfNotExists(tx, sqlCreateSharesTable, "shares"); err != nil {
+			return err
+		}
+		if err := db.createTableIfNotExists(tx, sqlCreateMentionsTable, "mentions"); err != nil {
+			return err
+		}
+		if err := db.createTableIfNotExists(tx, sqlCreateTagsTable, "tags"); err != nil {
+			return err
+		}
+		if err := db.createTableIfNotExists(tx, sqlCreateNoteTagsTable, "note_tags"); err != nil {
+			return err
+		}
+		if err := db.createTableIfNotExists(tx, sqlCreateActivityTagsTable, "activity_tags"); err != nil {
+			return err
+		}
+		if err := db.createTableIfNotExists(tx, sqlCreateBlocksTable, "blocks"); err != nil {
+			return err
+		}
+		if err := db.createTableIfNotExists(tx, sqlCreateDomainBlocksTable, "domain_blocks"); err != nil {
+			return err
+		}
+		if err := db.createTableIfNotExists(tx, sqlCreateInstanceActorTable, "instance_actor"); err != nil {
+			return err
+		}
 		if err := db.createTableIfNotExists(tx, sqlCreateDeliveryQueueTable, "delivery_queue"); err != nil {
 			return err
 		}
+		if err := db.createTableIfNotExists(tx, sqlCreateSchemaMigrationsTable, "schema_migrations"); err != nil {
+			return err
+		}
+		if err := db.createTableIfNotExists(tx, sqlCreateDeliveryDeadLettersTable, "delivery_dead_letters"); err != nil {
+			return err
+		}
+		if err := db.createTableIfNotExists(tx, sqlCreateKeyChangesTable, "key_changes"); err != nil {
+			return err
+		}
+		if err := db.createTableIfNotExists(tx, sqlCreateActivityRecipientsTable, "activity_recipients"); err != nil {
+			return err
+		}
+		if err := db.createTableIfNotExists(tx, sqlCreateSignatureSchemesTable, "signature_schemes"); err != nil {
+			return err
+		}
 
 		// Create indices
 		if _, err := tx.Exec(sqlCreateFollowsIndices); err != nil {
@@ -150,9 +372,27 @@ func (db *DB) RunMigrations() error {
 		if _, err := tx.Exec(sqlCreateLikesIndices); err != nil {
 			log.Printf("Warning: Failed to create likes indices: %v", err)
 		}
+		if _, err := tx.Exec(sqlCreateSharesIndices); err != nil {
+			log.Printf("Warning: Failed to create shares indices: %v", err)
+		}
+		if _, err := tx.Exec(sqlCreateMentionsIndices); err != nil {
+			log.Printf("Warning: Failed to create mentions indices: %v", err)
+		}
+		if _, err := tx.Exec(sqlCreateTagsIndices); err != nil {
+			log.Printf("Warning: Failed to create tags indices: %v", err)
+		}
+		if _, err := tx.Exec(sqlCreateBlocksIndices); err != nil {
+			log.Printf("Warning: Failed to create blocks indices: %v", err)
+		}
 		if _, err := tx.Exec(sqlCreateDeliveryQueueIndices); err != nil {
 			log.Printf("Warning: Failed to create delivery_queue indices: %v", err)
 		}
+		if _, err := tx.Exec(sqlCreateDeliveryDeadLettersIndices); err != nil {
+			log.Printf("Warning: Failed to create delivery_dead_letters indices: %v", err)
+		}
+		if _, err := tx.Exec(sqlCreateKeyChangesIndices); err != nil {
+			log.Printf("Warning: Failed to create key_changes indices: %v", err)
+		}
 		if _, err := tx.Exec(sqlCreateNotesIndices); err != nil {
 			log.Printf("Warning: Failed to create notes indices: %v", err)
 		}
@@ -165,6 +405,26 @@ func (db *DB) RunMigrations() error {
 			log.Printf("Warning: Failed to backfill activity object_uri: %v", err)
 		}
 
+		// Backfill in_reply_to for activities received before it was stored
+		if err := db.runOnce(tx, "backfill_activity_in_reply_to", db.backfillActivityInReplyTo); err != nil {
+			log.Printf("Warning: Failed to backfill activity in_reply_to: %v", err)
+		}
+
+		// Index hashtags of notes written before tags existed
+		if err := db.runOnce(tx, "backfill_note_tags", db.backfillNoteTags); err != nil {
+			log.Printf("Warning: Failed to backfill note tags: %v", err)
+		}
+
+		// Key likes of remote posts by object_uri instead of a note_id
+		if err := db.migrateRemoteLikes(tx); err != nil {
+			log.Printf("Warning: Failed to migrate likes of remote posts: %v", err)
+		}
+
+		// Key boosts of remote posts by object_uri instead of the id of their activity
+		if err := db.migrateRemoteShares(tx); err != nil {
+			log.Printf("Warning: Failed to migrate shares of remote posts: %v", err)
+		}
+
 		return nil
 	})
 }
@@ -186,6 +446,10 @@ func (db *DB) extendExistingTables(tx *sql.Tx) {
 	tx.Exec("ALTER TABLE accounts ADD COLUMN avatar_url TEXT")
 	tx.Exec("ALTER TABLE accounts ADD COLUMN is_admin INTEGER DEFAULT 0")
 	tx.Exec("ALTER TABLE accounts ADD COLUMN muted INTEGER DEFAULT 0")
+	tx.Exec("ALTER TABLE accounts ADD COLUMN hide_collections INTEGER DEFAULT 0")
+	tx.Exec("ALTER TABLE accounts ADD COLUMN locked INTEGER DEFAULT 0")
+	tx.Exec("ALTER TABLE accounts ADD COLUMN also_known_as TEXT")
+	tx.Exec("ALTER TABLE accounts ADD COLUMN moved_to TEXT")
 
 	// Try to add columns to notes table (ignore errors if they exist)
 	tx.Exec("ALTER TABLE notes ADD COLUMN visibility TEXT DEFAULT 'public'")
@@ -196,15 +460,184 @@ func (db *DB) extendExistingTables(tx *sql.Tx) {
 	tx.Exec("ALTER TABLE notes ADD COLUMN content_warning TEXT")
 	tx.Exec("ALTER TABLE notes ADD COLUMN edited_at TIMESTAMP")
 
+	// Add imported_from column to notes table to import posts from other servers only once
+	tx.Exec("ALTER TABLE notes ADD COLUMN imported_from TEXT")
+	tx.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_notes_imported_from ON notes(user_id, imported_from)")
+
 	// Add is_local column to follows table to support local follows
 	tx.Exec("ALTER TABLE follows ADD COLUMN is_local INTEGER DEFAULT 0")
 
 	// Add account_id column to delivery_queue table to support account-based cleanup
 	tx.Exec("ALTER TABLE delivery_queue ADD COLUMN account_id TEXT")
 
+	// Add object_uri column to likes table to support liking remote posts
+	tx.Exec("ALTER TABLE likes ADD COLUMN object_uri TEXT")
+	tx.Exec("CREATE INDEX IF NOT EXISTS idx_likes_object_uri ON likes(object_uri)")
+
+	// Add in_reply_to column to activities table to find the replies to a post
+	tx.Exec("ALTER TABLE activities ADD COLUMN in_reply_to TEXT")
+	tx.Exec("CREATE INDEX IF NOT EXISTS idx_activities_in_reply_to ON activities(in_reply_to)")
+
+	// Add shared_inbox_uri column to remote_accounts table to collapse deliveries per server
+	tx.Exec("ALTER TABLE remote_accounts ADD COLUMN shared_inbox_uri TEXT")
+
+	// Add Ed25519 key columns to remote_accounts table for RFC 9421 signatures
+	tx.Exec("ALTER TABLE remote_accounts ADD COLUMN ed25519_key_id TEXT")
+	tx.Exec("ALTER TABLE remote_accounts ADD COLUMN ed25519_key_pem TEXT")
+
+	// Add processing state columns to activities table for the asynchronous inbox
+	// Unprocessed activities stored before are due right away
+	tx.Exec("ALTER TABLE activities ADD COLUMN inbox_username TEXT")
+	tx.Exec("ALTER TABLE activities ADD COLUMN attempts INTEGER DEFAULT 0")
+	tx.Exec("ALTER TABLE activities ADD COLUMN next_attempt_at TIMESTAMP")
+	tx.Exec("ALTER TABLE activities ADD COLUMN last_error TEXT")
+	tx.Exec("UPDATE activities SET next_attempt_at = created_at WHERE next_attempt_at IS NULL AND processed = 0 AND local = 0")
+	tx.Exec("CREATE INDEX IF NOT EXISTS idx_activities_next_attempt ON activities(processed, next_attempt_at)")
+
 	log.Println("Extended existing tables with new columns")
 }
 
+// migrateRemoteLikes makes likes.note_id optional, so likes of remote posts no longer need
+// a stand-in note_id that can collide, and makes object_uri unique per account instead
+func (db *DB) migrateRemoteLikes(tx *sql.Tx) error {
+	var noteIdRequired bool
+	if err := tx.QueryRow(`SELECT "notnull" FROM pragma_table_info('likes') WHERE name = 'note_id'`).Scan(&noteIdRequired); err != nil {
+		return err
+	}
+
+	if noteIdRequired {
+		if _, err := tx.Exec(sqlRebuildLikesTable); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(sqlCreateLikesIndices); err != nil {
+			return err
+		}
+		log.Println("Rebuilt likes table with an optional note_id")
+	}
+
+	// Likes of local notes have no object_uri, so they don't collide under the unique index.
+	// The rebuilt table lost the object_uri index added by extendExistingTables
+	_, err := tx.Exec(`
+		UPDATE likes SET object_uri = NULL WHERE object_uri = '';
+		CREATE INDEX IF NOT EXISTS idx_likes_object_uri ON likes(object_uri);
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_likes_account_object_uri ON likes(account_id, object_uri);
+	`)
+	return err
+}
+
+// migrateRemoteShares makes shares.note_id optional, so boosts of remote posts no longer need
+// the id of their activity as a stand-in note_id, and makes object_uri unique per account instead
+func (db *DB) migrateRemoteShares(tx *sql.Tx) error {
+	var noteIdRequired bool
+	if err := tx.QueryRow(`SELECT "notnull" FROM pragma_table_info('shares') WHERE name = 'note_id'`).Scan(&noteIdRequired); err != nil {
+		return err
+	}
+
+	if noteIdRequired {
+		if _, err := tx.Exec(sqlRebuildSharesTable); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(sqlCreateSharesIndices); err != nil {
+			return err
+		}
+		log.Println("Rebuilt shares table with an optional note_id")
+	}
+
+	_, err := tx.Exec(`
+		UPDATE shares SET object_uri = NULL WHERE object_uri = '';
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_shares_account_object_uri ON shares(account_id, object_uri);
+	`)
+	return err
+}
+
+// backfillNoteTags indexes the hashtags of notes that have no tags yet
+func (db *DB) backfillNoteTags(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT id, message FROM notes WHERE message LIKE '%#%' AND id NOT IN (SELECT note_id FROM note_tags)`)
+	if err != nil {
+		return err
+	}
+
+	messages := make(map[string]string)
+	for rows.Next() {
+		var id, message string
+		if err := rows.Scan(&id, &message); err != nil {
+			log.Printf("Warning: Failed to scan note: %v", err)
+			continue
+		}
+		messages[id] = message
+	}
+	rows.Close()
+
+	indexed := 0
+	for id, message := range messages {
+		noteId, err := uuid.Parse(id)
+		if err != nil {
+			continue
+		}
+		if err := db.replaceNoteTags(tx, noteId, message); err != nil {
+			log.Printf("Warning: Failed to index tags for note %s: %v", id, err)
+			continue
+		}
+		indexed++
+	}
+
+	if indexed > 0 {
+		log.Printf("Indexed hashtags of %d existing notes", indexed)
+	}
+	return nil
+}
+
+// runOnce runs a data migration unless it ran on this database before, and records that it ran
+func (db *DB) runOnce(tx *sql.Tx, name string, migrate func(tx *sql.Tx) error) error {
+	var applied int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM schema_migrations WHERE name = ?`, name).Scan(&applied); err != nil {
+		return err
+	}
+	if applied > 0 {
+		return nil
+	}
+
+	if err := migrate(tx); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`INSERT INTO schema_migrations(name, applied_at) VALUES (?, ?)`, name, time.Now())
+	return err
+}
+
+// backfillActivityInReplyTo extracts in_reply_to from raw_json for the Create activities stored before it was kept
+func (db *DB) backfillActivityInReplyTo(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT id, raw_json FROM activities WHERE activity_type = 'Create' AND in_reply_to IS NULL`)
+	if err != nil {
+		return err
+	}
+
+	inReplyTo := make(map[string]string)
+	for rows.Next() {
+		var id, rawJSON string
+		if err := rows.Scan(&id, &rawJSON); err != nil {
+			log.Printf("Warning: Failed to scan activity: %v", err)
+			continue
+		}
+		if uri := activityInReplyTo(rawJSON); uri != "" {
+			inReplyTo[id] = uri
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, uri := range inReplyTo {
+		if _, err := tx.Exec(`UPDATE activities SET in_reply_to = ? WHERE id = ?`, uri, id); err != nil {
+			return err
+		}
+	}
+	if len(inReplyTo) > 0 {
+		log.Printf("Backfilled in_reply_to for %d activities", len(inReplyTo))
+	}
+	return nil
+}
+
 // backfillActivityObjectURIs extracts object_uri from raw_json for activities that are missing it
 func (db *DB) backfillActivityObjectURIs(tx *sql.Tx) error {
 	// Find activities with empty object_uri
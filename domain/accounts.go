@@ -28,6 +28,11 @@ type Account struct {
 	// Admin fields
 	IsAdmin bool
 	Muted   bool
+	// Privacy settings
+	HideCollections bool     // Only expose follower and following counts to federation
+	Locked          bool     // Follows must be approved manually
+	AlsoKnownAs     []string // Actor URIs of other accounts of the same person
+	MovedTo         string   // Actor URI of the account this one moved to
 }
 
 func (acc *Account) ToString() string {
@@ -7,17 +7,20 @@ import (
 
 // RemoteAccount represents a cached federated user
 type RemoteAccount struct {
-	Id            uuid.UUID
-	Username      string
-	Domain        string
-	ActorURI      string
-	DisplayName   string
-	Summary       string
-	InboxURI      string
-	OutboxURI     string
-	PublicKeyPem  string
-	AvatarURL     string
-	LastFetchedAt time.Time
+	Id             uuid.UUID
+	Username       string
+	Domain         string
+	ActorURI       string
+	DisplayName    string
+	Summary        string
+	InboxURI       string
+	SharedInboxURI string // endpoints.sharedInbox of the actor, empty if the server has none
+	OutboxURI      string
+	PublicKeyPem   string
+	Ed25519KeyId   string // Id of an Ed25519 key published as assertionMethod, empty if the actor has none
+	Ed25519KeyPem  string
+	AvatarURL      string
+	LastFetchedAt  time.Time
 }
 
 // Follow represents a follow relationship
@@ -35,30 +38,116 @@ type Follow struct {
 type Like struct {
 	Id        uuid.UUID
 	AccountId uuid.UUID // Who liked (can be local or remote)
-	NoteId    uuid.UUID // Which note was liked
+	NoteId    uuid.UUID // Which local note was liked (uuid.Nil for remote posts)
 	URI       string    // ActivityPub Like activity URI
+	ObjectURI string    // ActivityPub object URI of a liked remote post, which keys it (empty for local notes)
+	CreatedAt time.Time
+}
+
+// Share represents a boost (Announce) of a note
+type Share struct {
+	Id        uuid.UUID
+	AccountId uuid.UUID // Who boosted (can be local or remote)
+	NoteId    uuid.UUID // Which local note was boosted (uuid.Nil for remote posts)
+	URI       string    // ActivityPub Announce activity URI
+	ObjectURI string    // ActivityPub object URI of the boosted post
 	CreatedAt time.Time
 }
 
+// Mention represents a user mentioned in a local note
+type Mention struct {
+	Id        uuid.UUID
+	NoteId    uuid.UUID
+	Handle    string // Mention as written in the note (@user or @user@domain)
+	ActorURI  string // ActivityPub actor URI of the mentioned user
+	CreatedAt time.Time
+}
+
+// Block represents a user's block of a remote actor or a whole domain
+type Block struct {
+	Id        uuid.UUID
+	AccountId uuid.UUID // Local account that blocks
+	Target    string    // Actor URI, or domain for domain blocks
+	IsDomain  bool
+	URI       string // ActivityPub Block activity URI (empty for domain blocks)
+	CreatedAt time.Time
+}
+
+// Domain block severities, named as in Mastodon's domain blocklist CSV
+const (
+	DomainBlockSilence = "silence" // Posts are hidden from timelines
+	DomainBlockSuspend = "suspend" // All federation with the domain stops
+	DomainBlockNoop    = "noop"    // Only the media flag applies
+)
+
+// DomainBlock represents an instance-wide block of a remote domain, managed by admins
+type DomainBlock struct {
+	Id            uuid.UUID
+	Domain        string
+	Severity      string // silence, suspend or noop
+	RejectMedia   bool   // Drop avatars and attachments from the domain
+	PublicComment string
+	CreatedAt     time.Time
+}
+
 // Activity represents an ActivityPub activity (for logging/deduplication)
 type Activity struct {
-	Id           uuid.UUID
-	ActivityURI  string
-	ActivityType string // Follow, Create, Like, Announce, Undo, etc.
-	ActorURI     string
-	ObjectURI    string
-	RawJSON      string
-	Processed    bool
-	CreatedAt    time.Time
-	Local        bool // true if originated from this server
+	Id            uuid.UUID
+	ActivityURI   string
+	ActivityType  string // Follow, Create, Like, Announce, Undo, etc.
+	ActorURI      string
+	ObjectURI     string
+	RawJSON       string
+	Processed     bool
+	CreatedAt     time.Time
+	Local         bool   // true if originated from this server
+	InboxUsername string // Recipient of a delivery to a personal inbox, empty for the shared inbox
+	Attempts      int    // Failed processing attempts
+}
+
+// ActivityRecipient is a local user a received activity is processed for
+type ActivityRecipient struct {
+	ActivityId uuid.UUID
+	Username   string
+	Processed  bool // Handled, or given up on; not processed again
 }
 
 // DeliveryQueueItem represents an item in the delivery queue
 type DeliveryQueueItem struct {
 	Id           uuid.UUID
+	AccountId    uuid.UUID // Local account the activity is sent for, its deliveries are dropped with it
 	InboxURI     string
 	ActivityJSON string // The complete activity to deliver
 	Attempts     int
 	NextRetryAt  time.Time
 	CreatedAt    time.Time
 }
+
+// DeadDelivery is a delivery that was given up on after all attempts failed
+type DeadDelivery struct {
+	Id           uuid.UUID
+	AccountId    uuid.UUID // Local account the activity was sent for
+	InboxURI     string
+	ActivityJSON string
+	Attempts     int
+	LastError    string
+	LastStatus   int // HTTP status of the last attempt, 0 if the server didn't answer
+	CreatedAt    time.Time
+	FailedAt     time.Time
+}
+
+// SignatureScheme records how requests to a remote server are signed
+type SignatureScheme struct {
+	Host      string
+	Scheme    string // "rfc9421" or "cavage"
+	UpdatedAt time.Time
+}
+
+// KeyChange records that a remote actor's public key was replaced, e.g. after a key rotation
+type KeyChange struct {
+	Id        uuid.UUID
+	ActorURI  string
+	OldKeyPem string
+	NewKeyPem string
+	ChangedAt time.Time
+}
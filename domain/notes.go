@@ -7,8 +7,9 @@ import (
 )
 
 type SaveNote struct {
-	UserId  uuid.UUID
-	Message string
+	UserId       uuid.UUID
+	Message      string
+	InReplyToURI string // URI of the post this note replies to (empty for top-level notes)
 }
 
 type Note struct {
@@ -22,6 +23,7 @@ type Note struct {
 	InReplyToURI   string // URI of the note this is replying to
 	ObjectURI      string // ActivityPub object URI
 	Federated      bool   // Whether to federate this note
+	ImportedFrom   string // ActivityPub id of the post this note was imported from (empty for notes written here)
 	Sensitive      bool   // Contains sensitive content
 	ContentWarning string // Content warning text
 }
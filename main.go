@@ -44,7 +44,11 @@ func main() {
 
 	// Start ActivityPub delivery worker if enabled
 	if conf.Conf.WithAp {
+		if err := activitypub.InitInstanceActor(conf); err != nil {
+			log.Printf("Warning: Remote fetches will be unsigned: %v", err)
+		}
 		activitypub.StartDeliveryWorker(conf)
+		activitypub.StartInboxWorker(conf)
 	}
 
 	s, err := wish.NewServer(
@@ -54,6 +58,8 @@ func main() {
 		//wish.WithAuthorizedKeys(".ssh"),
 		wish.WithMiddleware(
 			middleware.MainTui(),
+			middleware.Commands(conf),
+			middleware.Scp(conf),
 			middleware.AuthMiddleware(conf),
 			logging.Middleware(), // last middleware executed first
 		),
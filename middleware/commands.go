@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/deemkeen/stegodon/activitypub"
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/util"
+	"github.com/deemkeen/stegodon/web"
+)
+
+// Largest files accepted by an import
+const (
+	maxImportSize = 10 << 20  // Account lists
+	maxOutboxSize = 100 << 20 // Outbox of an archive
+)
+
+// Commands runs the commands given on the ssh command line instead of starting the TUI, e.g.
+// ssh host export > archive.zip
+// ssh host export following_accounts.csv > following_accounts.csv
+// ssh host import following_accounts.csv < following_accounts.csv
+// ssh host import outbox.json < outbox.json
+func Commands(conf *util.AppConfig) wish.Middleware {
+	return func(h ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			args := s.Command()
+			if len(args) == 0 {
+				h(s)
+				return
+			}
+
+			acc, err := commandAccount(s)
+			if err != nil {
+				wish.Fatalln(s, err)
+				return
+			}
+
+			switch {
+			case args[0] == "export" && (len(args) == 1 || args[1] == archiveFile):
+				_, err = web.WriteArchive(s, acc, conf)
+			case args[0] == "export" && len(args) == 2:
+				err = exportList(s, acc, args[1], conf)
+			case args[0] == "import" && len(args) == 2 && args[1] == activitypub.OutboxFile:
+				err = importOutbox(s, acc)
+			case args[0] == "import" && len(args) == 2:
+				err = importList(s, acc, args[1], conf)
+			default:
+				err = fmt.Errorf("usage: export | export <list> | import <list> | import %s\nlists: %s", activitypub.OutboxFile, strings.Join(activitypub.AccountLists, ", "))
+			}
+			if err != nil {
+				wish.Fatalln(s, err)
+			}
+		}
+	}
+}
+
+// commandAccount returns the account of a session that runs a command or copies files
+// Accounts have to be set up in the TUI first
+func commandAccount(s ssh.Session) (*domain.Account, error) {
+	err, acc := db.GetDB().ReadAccBySession(s)
+	if err != nil || acc == nil {
+		return nil, fmt.Errorf("account not found")
+	}
+	if acc.FirstTimeLogin == domain.TRUE {
+		return nil, fmt.Errorf("log in without a command first to set up your account")
+	}
+	return acc, nil
+}
+
+// exportList writes an account list of the session's account as CSV
+func exportList(s ssh.Session, acc *domain.Account, name string, conf *util.AppConfig) error {
+	list, err := activitypub.AccountListByName(name)
+	if err != nil {
+		return err
+	}
+	entries, err := activitypub.ExportAccountList(acc, list, conf)
+	if err != nil {
+		return err
+	}
+	return activitypub.WriteAccountListCSV(s, list, entries)
+}
+
+// importList imports an account list read as CSV from the session and waits for the import to finish
+// The import keeps running in the background if the connection is closed
+func importList(s ssh.Session, acc *domain.Account, name string, conf *util.AppConfig) error {
+	list, err := activitypub.AccountListByName(name)
+	if err != nil {
+		return err
+	}
+	entries, err := activitypub.ParseAccountListCSV(io.LimitReader(s, maxImportSize))
+	if err != nil {
+		return err
+	}
+
+	done, err := activitypub.StartImport(acc, list, entries, conf)
+	if err != nil {
+		return err
+	}
+	wish.Printf(s, "Importing %d entries of %s...\n", len(entries), list)
+
+	select {
+	case <-done:
+	case <-s.Context().Done():
+		return nil
+	}
+
+	job := activitypub.ImportStatus(acc.Id)
+	for _, importErr := range job.Errors {
+		wish.Errorf(s, "%s: %s\n", importErr.Entry, importErr.Err)
+	}
+	wish.Printf(s, "Imported %d of %d entries (%d skipped, %d failed)\n", job.Imported, job.Total, job.Skipped, len(job.Errors))
+	return nil
+}
+
+// importOutbox imports the public posts of an outbox read from the session as notes
+func importOutbox(s ssh.Session, acc *domain.Account) error {
+	result, err := activitypub.ImportOutbox(acc, io.LimitReader(s, maxOutboxSize))
+	if err != nil {
+		return err
+	}
+	wish.Printf(s, "Imported %d of %d posts (%d imported before, %d not public)\n", result.Imported, result.Total, result.Skipped, result.Ignored)
+	return nil
+}
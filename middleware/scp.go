@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"time"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/charmbracelet/wish/scp"
+	"github.com/deemkeen/stegodon/activitypub"
+	"github.com/deemkeen/stegodon/util"
+	"github.com/deemkeen/stegodon/web"
+)
+
+// Name of the account archive copied with scp
+const archiveFile = "archive.zip"
+
+// listFiles serves the archive and the account lists of a user as files over scp:
+// copying one from the server exports it, copying a list to the server imports it in the background
+// and copying an outbox.json to the server imports its posts
+type listFiles struct {
+	conf *util.AppConfig
+}
+
+// Scp lets users copy their archive and account lists with scp, e.g.
+// scp host:archive.zip ., scp following_accounts.csv host: or scp outbox.json host:
+func Scp(conf *util.AppConfig) wish.Middleware {
+	files := &listFiles{conf: conf}
+	return scp.Middleware(files, files)
+}
+
+func (f *listFiles) Glob(_ ssh.Session, pattern string) ([]string, error) {
+	return []string{pattern}, nil
+}
+
+func (f *listFiles) WalkDir(ssh.Session, string, fs.WalkDirFunc) error {
+	return fmt.Errorf("recursive copies are not supported")
+}
+
+func (f *listFiles) NewDirEntry(_ ssh.Session, name string) (*scp.DirEntry, error) {
+	return nil, fmt.Errorf("%s is not a directory", name)
+}
+
+func (f *listFiles) NewFileEntry(s ssh.Session, name string) (*scp.FileEntry, func() error, error) {
+	acc, err := commandAccount(s)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var buf bytes.Buffer
+	fileName := path.Base(name)
+	if fileName == archiveFile {
+		if _, err := web.WriteArchive(&buf, acc, f.conf); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		list, err := activitypub.AccountListByName(fileName)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries, err := activitypub.ExportAccountList(acc, list, f.conf)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := activitypub.WriteAccountListCSV(&buf, list, entries); err != nil {
+			return nil, nil, err
+		}
+		fileName = list
+	}
+
+	now := time.Now().Unix()
+	return &scp.FileEntry{
+		Name:     fileName,
+		Filepath: name,
+		Mode:     0o644,
+		Size:     int64(buf.Len()),
+		Reader:   &buf,
+		Atime:    now,
+		Mtime:    now,
+	}, nil, nil
+}
+
+func (f *listFiles) Mkdir(_ ssh.Session, entry *scp.DirEntry) error {
+	return fmt.Errorf("can't create directory %s", entry.Name)
+}
+
+func (f *listFiles) Write(s ssh.Session, entry *scp.FileEntry) (int64, error) {
+	acc, err := commandAccount(s)
+	if err != nil {
+		return 0, err
+	}
+	if entry.Name == activitypub.OutboxFile {
+		if entry.Size > maxOutboxSize {
+			return 0, fmt.Errorf("%s is larger than %d bytes", entry.Name, maxOutboxSize)
+		}
+		data, err := io.ReadAll(entry.Reader)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := activitypub.ImportOutbox(acc, bytes.NewReader(data)); err != nil {
+			return 0, err
+		}
+		return int64(len(data)), nil
+	}
+
+	list, err := activitypub.AccountListByName(entry.Name)
+	if err != nil {
+		return 0, err
+	}
+	if entry.Size > maxImportSize {
+		return 0, fmt.Errorf("%s is larger than %d bytes", entry.Name, maxImportSize)
+	}
+
+	data, err := io.ReadAll(entry.Reader)
+	if err != nil {
+		return 0, err
+	}
+	entries, err := activitypub.ParseAccountListCSV(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	if _, err := activitypub.StartImport(acc, list, entries, f.conf); err != nil {
+		return 0, err
+	}
+	return int64(len(data)), nil
+}
@@ -0,0 +1,318 @@
+package blocklist
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/deemkeen/stegodon/activitypub"
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/ui/common"
+	"github.com/deemkeen/stegodon/util"
+	"github.com/google/uuid"
+)
+
+var (
+	itemStyle = lipgloss.NewStyle().
+			PaddingLeft(2).
+			MarginBottom(0)
+
+	selectedStyle = lipgloss.NewStyle().
+			PaddingLeft(2).
+			MarginBottom(0).
+			Foreground(lipgloss.Color(common.COLOR_GREEN)).
+			Bold(true)
+
+	emptyStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_DARK_GREY)).
+			Italic(true)
+
+	statusStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_BLUE))
+
+	errorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_RED))
+)
+
+type Model struct {
+	AccountId uuid.UUID
+	TextInput textinput.Model
+	Editing   bool // Whether the block input has focus
+	Blocks    []domain.Block
+	Selected  int
+	Width     int
+	Height    int
+	Status    string
+	Error     string
+}
+
+func InitialModel(accountId uuid.UUID, width, height int) Model {
+	ti := textinput.New()
+	ti.Placeholder = "@user@domain or domain.com"
+	ti.CharLimit = 100
+	ti.Width = 50
+
+	return Model{
+		AccountId: accountId,
+		TextInput: ti,
+		Editing:   false,
+		Blocks:    []domain.Block{},
+		Selected:  0,
+		Width:     width,
+		Height:    height,
+		Status:    "",
+		Error:     "",
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return loadBlocks(m.AccountId)
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case blocksLoadedMsg:
+		m.Blocks = msg.blocks
+		if m.Selected >= len(m.Blocks) {
+			m.Selected = max(len(m.Blocks)-1, 0)
+		}
+		return m, nil
+
+	case clearStatusMsg:
+		m.Status = ""
+		m.Error = ""
+		return m, nil
+
+	case blockResultMsg:
+		if msg.err != nil {
+			m.Error = fmt.Sprintf("Failed: %v", msg.err)
+			m.Status = ""
+		} else {
+			if msg.blocked {
+				m.Status = fmt.Sprintf("Blocked %s", msg.target)
+			} else {
+				m.Status = fmt.Sprintf("Unblocked %s", msg.target)
+			}
+			m.Error = ""
+		}
+		return m, tea.Batch(loadBlocks(m.AccountId), clearStatusAfter(2*time.Second))
+
+	case tea.KeyMsg:
+		if m.Editing {
+			switch msg.String() {
+			case "enter":
+				input := strings.TrimSpace(m.TextInput.Value())
+				if input == "" {
+					return m, nil
+				}
+				m.TextInput.SetValue("")
+				m.TextInput.Blur()
+				m.Editing = false
+				m.Status = fmt.Sprintf("Blocking %s...", input)
+				m.Error = ""
+				return m, blockCmd(m.AccountId, input)
+			case "esc":
+				m.TextInput.SetValue("")
+				m.TextInput.Blur()
+				m.Editing = false
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.TextInput, cmd = m.TextInput.Update(msg)
+			return m, cmd
+		}
+
+		switch msg.String() {
+		case "up", "k":
+			if m.Selected > 0 {
+				m.Selected--
+			}
+		case "down", "j":
+			if m.Selected < len(m.Blocks)-1 {
+				m.Selected++
+			}
+		case "n", "/":
+			// Enter a new actor or domain to block
+			m.Editing = true
+			return m, m.TextInput.Focus()
+		case "u", "d":
+			if len(m.Blocks) > 0 && m.Selected < len(m.Blocks) {
+				block := m.Blocks[m.Selected]
+				return m, unblockCmd(m.AccountId, block.Id, blockLabel(block))
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m Model) View() string {
+	var s strings.Builder
+
+	s.WriteString(common.CaptionStyle.Render(fmt.Sprintf("blocked (%d)", len(m.Blocks))))
+	s.WriteString("\n\n")
+
+	if m.Editing {
+		s.WriteString("Block a user or a whole domain:\n")
+		s.WriteString("(e.g., @user@mastodon.social or spam.example)\n\n")
+		s.WriteString(m.TextInput.View())
+		s.WriteString("\n\n")
+	}
+
+	if len(m.Blocks) == 0 {
+		s.WriteString(emptyStyle.Render("You haven't blocked anyone.\nPress n to block a user or a domain."))
+	} else {
+		displayCount := min(len(m.Blocks), 10)
+		for i := 0; i < displayCount; i++ {
+			text := "• " + blockLabel(m.Blocks[i])
+			if m.Blocks[i].IsDomain {
+				text += " (domain)"
+			}
+
+			if i == m.Selected && !m.Editing {
+				s.WriteString("→ " + selectedStyle.Render(text))
+			} else {
+				s.WriteString("  " + itemStyle.Render(text))
+			}
+			s.WriteString("\n")
+		}
+
+		if len(m.Blocks) > 10 {
+			s.WriteString(itemStyle.Render(fmt.Sprintf("... and %d more", len(m.Blocks)-10)))
+			s.WriteString("\n")
+		}
+	}
+
+	s.WriteString("\n")
+
+	if m.Status != "" {
+		s.WriteString(statusStyle.Render(m.Status))
+		s.WriteString("\n\n")
+	}
+
+	if m.Error != "" {
+		s.WriteString(errorStyle.Render(m.Error))
+		s.WriteString("\n\n")
+	}
+
+	return s.String()
+}
+
+// blockLabel returns a readable name for a blocked actor or domain
+func blockLabel(block domain.Block) string {
+	if block.IsDomain {
+		return block.Target
+	}
+	err, remoteAcc := db.GetDB().ReadRemoteAccountByURI(block.Target)
+	if err != nil || remoteAcc == nil {
+		return block.Target
+	}
+	return fmt.Sprintf("@%s@%s", remoteAcc.Username, remoteAcc.Domain)
+}
+
+// blocksLoadedMsg is sent when the block list is loaded
+type blocksLoadedMsg struct {
+	blocks []domain.Block
+}
+
+// blockResultMsg is sent when a block or unblock completes
+type blockResultMsg struct {
+	target  string
+	blocked bool
+	err     error
+}
+
+// clearStatusMsg is sent after a delay to clear status/error messages
+type clearStatusMsg struct{}
+
+// clearStatusAfter returns a command that sends clearStatusMsg after a duration
+func clearStatusAfter(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(t time.Time) tea.Msg {
+		return clearStatusMsg{}
+	})
+}
+
+// loadBlocks loads the blocks of the given account
+func loadBlocks(accountId uuid.UUID) tea.Cmd {
+	return func() tea.Msg {
+		database := db.GetDB()
+		err, blocks := database.ReadBlocksByAccountId(accountId)
+		if err != nil {
+			log.Printf("Failed to load blocks: %v", err)
+			return blocksLoadedMsg{blocks: []domain.Block{}}
+		}
+
+		if blocks == nil {
+			return blocksLoadedMsg{blocks: []domain.Block{}}
+		}
+
+		return blocksLoadedMsg{blocks: *blocks}
+	}
+}
+
+// blockCmd blocks a remote actor (@user@domain) or a whole domain
+func blockCmd(accountId uuid.UUID, input string) tea.Cmd {
+	return func() tea.Msg {
+		return blockResultMsg{target: input, blocked: true, err: blockTarget(accountId, input)}
+	}
+}
+
+// blockTarget resolves the input to an actor or domain and stores the block
+func blockTarget(accountId uuid.UUID, input string) error {
+	database := db.GetDB()
+	err, localAccount := database.ReadAccById(accountId)
+	if err != nil {
+		return fmt.Errorf("failed to get local account: %w", err)
+	}
+
+	handle := strings.TrimPrefix(input, "@")
+	if !strings.Contains(handle, "@") {
+		err := activitypub.BlockDomain(localAccount, handle)
+		if errors.Is(err, activitypub.ErrInvalidDomain) {
+			return fmt.Errorf("invalid format. Use: @user@domain.com or domain.com")
+		}
+		return err
+	}
+
+	parts := strings.Split(handle, "@")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("invalid format. Use: @user@domain.com or domain.com")
+	}
+
+	actorURI, err := activitypub.ResolveWebFinger(parts[0], parts[1])
+	if err != nil {
+		return fmt.Errorf("webfinger resolution failed: %w", err)
+	}
+
+	// Get config (TODO: pass from main)
+	conf, err := util.ReadConf()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	return activitypub.SendBlock(localAccount, actorURI, conf)
+}
+
+// unblockCmd lifts a block
+func unblockCmd(accountId, blockId uuid.UUID, label string) tea.Cmd {
+	return func() tea.Msg {
+		database := db.GetDB()
+		err, localAccount := database.ReadAccById(accountId)
+		if err != nil {
+			return blockResultMsg{target: label, err: fmt.Errorf("failed to get local account: %w", err)}
+		}
+
+		conf, err := util.ReadConf()
+		if err != nil {
+			return blockResultMsg{target: label, err: fmt.Errorf("failed to read config: %w", err)}
+		}
+
+		err = activitypub.SendUndoBlock(localAccount, blockId, conf)
+		return blockResultMsg{target: label, err: err}
+	}
+}
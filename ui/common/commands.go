@@ -21,6 +21,15 @@ const (
 	LocalUsersView        // Browse and follow local users
 	AdminPanelView        // Admin panel for user management (admin only)
 	DeleteAccountView     // Delete account with confirmation
+	ThreadView            // View a conversation thread
+	TagView               // View posts with a hashtag
+	FollowRequestsView    // Approve or reject pending follows
+	BlocksView            // Manage blocked actors and domains
+	DomainBlocksView      // Instance-wide domain blocklist (admin only)
+	DeliveriesView        // Delivery queue and failing servers (admin only)
+	KeyChangesView        // Key rotations of remote actors (admin only)
+	MoveAccountView       // Aliases and moving the account to another server
+	ImportExportView      // Account archive and progress of follow and block list imports
 )
 
 // EditNoteMsg is sent when user wants to edit an existing note
@@ -34,3 +43,19 @@ type EditNoteMsg struct {
 type DeleteNoteMsg struct {
 	NoteId uuid.UUID
 }
+
+// ReplyNoteMsg is sent when user wants to reply to a post
+type ReplyNoteMsg struct {
+	InReplyToURI string // ActivityPub URI of the post being replied to
+	Handle       string // Handle of the post author to address (empty for own posts)
+}
+
+// ShowThreadMsg is sent when user wants to view the conversation around a post
+type ShowThreadMsg struct {
+	ObjectURI string
+}
+
+// ShowTagMsg is sent when user wants to view posts with a hashtag
+type ShowTagMsg struct {
+	Tag string // Tag to show, empty to ask for one
+}
@@ -0,0 +1,304 @@
+package deliveries
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/deemkeen/stegodon/activitypub"
+	"github.com/deemkeen/stegodon/ui/common"
+)
+
+var (
+	itemStyle = lipgloss.NewStyle().
+			PaddingLeft(2).
+			MarginBottom(0)
+
+	selectedStyle = lipgloss.NewStyle().
+			PaddingLeft(2).
+			MarginBottom(0).
+			Foreground(lipgloss.Color(common.COLOR_GREEN)).
+			Bold(true)
+
+	openStyle = lipgloss.NewStyle().
+			PaddingLeft(2).
+			MarginBottom(0).
+			Foreground(lipgloss.Color(common.COLOR_RED))
+
+	detailStyle = lipgloss.NewStyle().
+			PaddingLeft(6).
+			Foreground(lipgloss.Color(common.COLOR_DARK_GREY))
+
+	emptyStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_DARK_GREY)).
+			Italic(true)
+
+	statusStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_BLUE))
+
+	errorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_RED))
+)
+
+type Model struct {
+	Hosts      []activitypub.DeliveryHost // Hosts with queued, dead or failing deliveries
+	Selected   int
+	Confirming bool // Whether purging the dead letters of the selected host awaits confirmation
+	Width      int
+	Height     int
+	Status     string
+	Error      string
+}
+
+func InitialModel(width, height int) Model {
+	return Model{
+		Hosts:      []activitypub.DeliveryHost{},
+		Selected:   0,
+		Confirming: false,
+		Width:      width,
+		Height:     height,
+		Status:     "",
+		Error:      "",
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return loadDeliveries()
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case deliveriesLoadedMsg:
+		m.Hosts = msg.hosts
+		if m.Selected >= len(m.Hosts) {
+			m.Selected = max(len(m.Hosts)-1, 0)
+		}
+		return m, nil
+
+	case clearStatusMsg:
+		m.Status = ""
+		m.Error = ""
+		return m, nil
+
+	case deliveriesChangedMsg:
+		if msg.err != nil {
+			m.Error = fmt.Sprintf("Failed: %v", msg.err)
+			m.Status = ""
+		} else {
+			m.Status = msg.status
+			m.Error = ""
+		}
+		return m, tea.Batch(loadDeliveries(), clearStatusAfter(3*time.Second))
+
+	case tea.KeyMsg:
+		if m.Confirming {
+			m.Confirming = false
+			if msg.String() == "y" && m.Selected < len(m.Hosts) {
+				return m, purgeHostCmd(m.Hosts[m.Selected].Host)
+			}
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "up", "k":
+			if m.Selected > 0 {
+				m.Selected--
+			}
+		case "down", "j":
+			if m.Selected < len(m.Hosts)-1 {
+				m.Selected++
+			}
+		case "r":
+			// Retry the queued deliveries to the selected host right away
+			if len(m.Hosts) > 0 && m.Selected < len(m.Hosts) {
+				return m, retryHostCmd(m.Hosts[m.Selected].Host)
+			}
+		case "a":
+			// Requeue all dead letters of the selected host
+			if len(m.Hosts) > 0 && m.Selected < len(m.Hosts) {
+				return m, requeueHostCmd(m.Hosts[m.Selected].Host)
+			}
+		case "p":
+			if len(m.Hosts) > 0 && m.Selected < len(m.Hosts) && m.Hosts[m.Selected].Dead > 0 {
+				m.Confirming = true
+			}
+		case "f":
+			return m, loadDeliveries()
+		}
+	}
+	return m, nil
+}
+
+func (m Model) View() string {
+	var s strings.Builder
+
+	queued, dead := 0, 0
+	for _, host := range m.Hosts {
+		queued += host.Pending
+		dead += host.Dead
+	}
+	s.WriteString(common.CaptionStyle.Render(fmt.Sprintf("deliveries (%d queued, %d dead)", queued, dead)))
+	s.WriteString("\n\n")
+
+	if m.Confirming && m.Selected < len(m.Hosts) {
+		host := m.Hosts[m.Selected]
+		s.WriteString(errorStyle.Render(fmt.Sprintf("Purge %d dead deliveries to %s? (y/n)", host.Dead, host.Host)))
+		s.WriteString("\n\n")
+	}
+
+	if len(m.Hosts) == 0 {
+		s.WriteString(emptyStyle.Render("Nothing queued, all servers are reachable."))
+	} else {
+		start := 0
+		if m.Selected >= 10 {
+			start = m.Selected - 9
+		}
+		end := min(start+10, len(m.Hosts))
+		for i := start; i < end; i++ {
+			host := m.Hosts[i]
+			text := fmt.Sprintf("• %s: %d queued, %d dead", host.Host, host.Pending, host.Dead)
+			if breaker := breakerLabel(host.Breaker); breaker != "" {
+				text += " [" + breaker + "]"
+			}
+
+			switch {
+			case i == m.Selected:
+				s.WriteString("→ " + selectedStyle.Render(text))
+			case host.Breaker.State == activitypub.BreakerOpen:
+				s.WriteString("  " + openStyle.Render(text))
+			default:
+				s.WriteString("  " + itemStyle.Render(text))
+			}
+			s.WriteString("\n")
+			if lastError := lastErrorLabel(host); lastError != "" {
+				s.WriteString(detailStyle.Render(truncate(lastError, 70)))
+				s.WriteString("\n")
+			}
+		}
+
+		if len(m.Hosts) > end {
+			s.WriteString(itemStyle.Render(fmt.Sprintf("... and %d more", len(m.Hosts)-end)))
+			s.WriteString("\n")
+		}
+	}
+
+	s.WriteString("\n")
+
+	if m.Status != "" {
+		s.WriteString(statusStyle.Render(m.Status))
+		s.WriteString("\n\n")
+	}
+
+	if m.Error != "" {
+		s.WriteString(errorStyle.Render(m.Error))
+		s.WriteString("\n\n")
+	}
+
+	return s.String()
+}
+
+// breakerLabel describes the circuit breaker of a host, e.g. "open, retry in 4m0s"
+func breakerLabel(status activitypub.HostStatus) string {
+	switch status.State {
+	case "":
+		return ""
+	case activitypub.BreakerOpen:
+		wait := time.Until(status.RetryAt)
+		if wait <= 0 {
+			return "open, retry now"
+		}
+		return "open, retry in " + wait.Round(time.Second).String()
+	default:
+		return fmt.Sprintf("%s, %d failures", status.State, status.Failures)
+	}
+}
+
+// lastErrorLabel returns the most recent delivery error of a host
+func lastErrorLabel(host activitypub.DeliveryHost) string {
+	if host.Breaker.LastError != "" {
+		return host.Breaker.LastError
+	}
+	if host.Dead == 0 {
+		return ""
+	}
+	if host.LastStatus != 0 {
+		return fmt.Sprintf("HTTP %d: %s", host.LastStatus, host.LastError)
+	}
+	return host.LastError
+}
+
+func truncate(text string, length int) string {
+	if len(text) <= length {
+		return text
+	}
+	return text[:length-3] + "..."
+}
+
+// deliveriesLoadedMsg is sent when the delivery state is loaded
+type deliveriesLoadedMsg struct {
+	hosts []activitypub.DeliveryHost
+}
+
+// deliveriesChangedMsg is sent after deliveries of a host were retried, requeued or purged
+type deliveriesChangedMsg struct {
+	status string
+	err    error
+}
+
+// clearStatusMsg is sent after a delay to clear status/error messages
+type clearStatusMsg struct{}
+
+// clearStatusAfter returns a command that sends clearStatusMsg after a duration
+func clearStatusAfter(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(t time.Time) tea.Msg {
+		return clearStatusMsg{}
+	})
+}
+
+// loadDeliveries loads the queued and dead deliveries grouped by host
+func loadDeliveries() tea.Cmd {
+	return func() tea.Msg {
+		hosts, err := activitypub.DeliveryHosts()
+		if err != nil {
+			log.Printf("Failed to load deliveries: %v", err)
+			return deliveriesLoadedMsg{hosts: []activitypub.DeliveryHost{}}
+		}
+		return deliveriesLoadedMsg{hosts: hosts}
+	}
+}
+
+// retryHostCmd retries the queued deliveries to a host now
+func retryHostCmd(host string) tea.Cmd {
+	return func() tea.Msg {
+		err := activitypub.RetryDeliveryHost(host)
+		if err != nil {
+			log.Printf("Failed to retry deliveries to %s: %v", host, err)
+		}
+		return deliveriesChangedMsg{status: fmt.Sprintf("Retrying deliveries to %s", host), err: err}
+	}
+}
+
+// requeueHostCmd moves the dead deliveries to a host back into the queue
+func requeueHostCmd(host string) tea.Cmd {
+	return func() tea.Msg {
+		count, err := activitypub.RequeueDeliveryHost(host)
+		if err != nil {
+			log.Printf("Failed to requeue deliveries to %s: %v", host, err)
+		}
+		return deliveriesChangedMsg{status: fmt.Sprintf("Requeued %d deliveries to %s", count, host), err: err}
+	}
+}
+
+// purgeHostCmd deletes the dead deliveries to a host
+func purgeHostCmd(host string) tea.Cmd {
+	return func() tea.Msg {
+		count, err := activitypub.PurgeDeliveryHost(host)
+		if err != nil {
+			log.Printf("Failed to purge deliveries to %s: %v", host, err)
+		}
+		return deliveriesChangedMsg{status: fmt.Sprintf("Purged %d dead deliveries to %s", count, host), err: err}
+	}
+}
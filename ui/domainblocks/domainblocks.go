@@ -0,0 +1,361 @@
+package domainblocks
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/deemkeen/stegodon/activitypub"
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/ui/common"
+	"github.com/deemkeen/stegodon/util"
+	"github.com/google/uuid"
+)
+
+// File in the config directory used for CSV import and export
+const blocklistFile = "domain_blocks.csv"
+
+var (
+	itemStyle = lipgloss.NewStyle().
+			PaddingLeft(2).
+			MarginBottom(0)
+
+	selectedStyle = lipgloss.NewStyle().
+			PaddingLeft(2).
+			MarginBottom(0).
+			Foreground(lipgloss.Color(common.COLOR_GREEN)).
+			Bold(true)
+
+	suspendedStyle = lipgloss.NewStyle().
+			PaddingLeft(2).
+			MarginBottom(0).
+			Foreground(lipgloss.Color(common.COLOR_RED))
+
+	emptyStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_DARK_GREY)).
+			Italic(true)
+
+	statusStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_BLUE))
+
+	errorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_RED))
+)
+
+// Order in which the severity of a block is cycled
+var severities = []string{domain.DomainBlockSilence, domain.DomainBlockSuspend, domain.DomainBlockNoop}
+
+type Model struct {
+	TextInput textinput.Model
+	Editing   bool // Whether the domain input has focus
+	Blocks    []domain.DomainBlock
+	Selected  int
+	Width     int
+	Height    int
+	Status    string
+	Error     string
+}
+
+func InitialModel(width, height int) Model {
+	ti := textinput.New()
+	ti.Placeholder = "domain.com [silence|suspend|noop]"
+	ti.CharLimit = 100
+	ti.Width = 50
+
+	return Model{
+		TextInput: ti,
+		Editing:   false,
+		Blocks:    []domain.DomainBlock{},
+		Selected:  0,
+		Width:     width,
+		Height:    height,
+		Status:    "",
+		Error:     "",
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return loadDomainBlocks()
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case domainBlocksLoadedMsg:
+		m.Blocks = msg.blocks
+		if m.Selected >= len(m.Blocks) {
+			m.Selected = max(len(m.Blocks)-1, 0)
+		}
+		return m, nil
+
+	case clearStatusMsg:
+		m.Status = ""
+		m.Error = ""
+		return m, nil
+
+	case domainBlocksChangedMsg:
+		if msg.err != nil {
+			m.Error = fmt.Sprintf("Failed: %v", msg.err)
+			m.Status = ""
+		} else {
+			m.Status = msg.status
+			m.Error = ""
+		}
+		return m, tea.Batch(loadDomainBlocks(), clearStatusAfter(3*time.Second))
+
+	case tea.KeyMsg:
+		if m.Editing {
+			switch msg.String() {
+			case "enter":
+				fields := strings.Fields(m.TextInput.Value())
+				if len(fields) == 0 {
+					return m, nil
+				}
+				severity := domain.DomainBlockSuspend
+				if len(fields) > 1 {
+					severity = strings.ToLower(fields[1])
+				}
+				if !validSeverity(severity) {
+					m.Error = "Severity must be silence, suspend or noop"
+					return m, clearStatusAfter(2 * time.Second)
+				}
+				m.TextInput.SetValue("")
+				m.TextInput.Blur()
+				m.Editing = false
+				return m, saveDomainBlockCmd(domain.DomainBlock{
+					Id:        uuid.New(),
+					Domain:    strings.ToLower(fields[0]),
+					Severity:  severity,
+					CreatedAt: time.Now(),
+				})
+			case "esc":
+				m.TextInput.SetValue("")
+				m.TextInput.Blur()
+				m.Editing = false
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.TextInput, cmd = m.TextInput.Update(msg)
+			return m, cmd
+		}
+
+		switch msg.String() {
+		case "up", "k":
+			if m.Selected > 0 {
+				m.Selected--
+			}
+		case "down", "j":
+			if m.Selected < len(m.Blocks)-1 {
+				m.Selected++
+			}
+		case "n":
+			// Block a new domain
+			m.Editing = true
+			return m, m.TextInput.Focus()
+		case "s":
+			// Cycle the severity of the selected block
+			if len(m.Blocks) > 0 && m.Selected < len(m.Blocks) {
+				block := m.Blocks[m.Selected]
+				block.Severity = nextSeverity(block.Severity)
+				return m, saveDomainBlockCmd(block)
+			}
+		case "m":
+			// Toggle media rejection of the selected block
+			if len(m.Blocks) > 0 && m.Selected < len(m.Blocks) {
+				block := m.Blocks[m.Selected]
+				block.RejectMedia = !block.RejectMedia
+				return m, saveDomainBlockCmd(block)
+			}
+		case "d":
+			if len(m.Blocks) > 0 && m.Selected < len(m.Blocks) {
+				return m, deleteDomainBlockCmd(m.Blocks[m.Selected])
+			}
+		case "i":
+			return m, importDomainBlocksCmd()
+		case "e":
+			return m, exportDomainBlocksCmd()
+		}
+	}
+	return m, nil
+}
+
+func (m Model) View() string {
+	var s strings.Builder
+
+	s.WriteString(common.CaptionStyle.Render(fmt.Sprintf("domain blocks (%d)", len(m.Blocks))))
+	s.WriteString("\n\n")
+
+	if m.Editing {
+		s.WriteString("Block a domain for the whole instance:\n")
+		s.WriteString("(e.g., spam.example suspend)\n\n")
+		s.WriteString(m.TextInput.View())
+		s.WriteString("\n\n")
+	}
+
+	if len(m.Blocks) == 0 {
+		s.WriteString(emptyStyle.Render("No blocked domains.\nPress n to block a domain or i to import " + blocklistFile + "."))
+	} else {
+		start := 0
+		if m.Selected >= 10 {
+			start = m.Selected - 9
+		}
+		end := min(start+10, len(m.Blocks))
+		for i := start; i < end; i++ {
+			block := m.Blocks[i]
+			text := fmt.Sprintf("• %s [%s]", block.Domain, severityLabel(block))
+			if block.PublicComment != "" {
+				text += " " + block.PublicComment
+			}
+
+			switch {
+			case i == m.Selected && !m.Editing:
+				s.WriteString("→ " + selectedStyle.Render(text))
+			case block.Severity == domain.DomainBlockSuspend:
+				s.WriteString("  " + suspendedStyle.Render(text))
+			default:
+				s.WriteString("  " + itemStyle.Render(text))
+			}
+			s.WriteString("\n")
+		}
+
+		if len(m.Blocks) > end {
+			s.WriteString(itemStyle.Render(fmt.Sprintf("... and %d more", len(m.Blocks)-end)))
+			s.WriteString("\n")
+		}
+	}
+
+	s.WriteString("\n")
+
+	if m.Status != "" {
+		s.WriteString(statusStyle.Render(m.Status))
+		s.WriteString("\n\n")
+	}
+
+	if m.Error != "" {
+		s.WriteString(errorStyle.Render(m.Error))
+		s.WriteString("\n\n")
+	}
+
+	return s.String()
+}
+
+// severityLabel describes what a block does, e.g. "silence, reject-media"
+func severityLabel(block domain.DomainBlock) string {
+	switch {
+	case block.Severity == domain.DomainBlockNoop && block.RejectMedia:
+		return "reject-media"
+	case block.RejectMedia:
+		return block.Severity + ", reject-media"
+	default:
+		return block.Severity
+	}
+}
+
+func validSeverity(severity string) bool {
+	for _, s := range severities {
+		if s == severity {
+			return true
+		}
+	}
+	return false
+}
+
+func nextSeverity(severity string) string {
+	for i, s := range severities {
+		if s == severity {
+			return severities[(i+1)%len(severities)]
+		}
+	}
+	return severities[0]
+}
+
+// domainBlocksLoadedMsg is sent when the domain blocks are loaded
+type domainBlocksLoadedMsg struct {
+	blocks []domain.DomainBlock
+}
+
+// domainBlocksChangedMsg is sent after the blocklist was changed
+type domainBlocksChangedMsg struct {
+	status string
+	err    error
+}
+
+// clearStatusMsg is sent after a delay to clear status/error messages
+type clearStatusMsg struct{}
+
+// clearStatusAfter returns a command that sends clearStatusMsg after a duration
+func clearStatusAfter(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(t time.Time) tea.Msg {
+		return clearStatusMsg{}
+	})
+}
+
+// loadDomainBlocks loads the instance's domain blocks
+func loadDomainBlocks() tea.Cmd {
+	return func() tea.Msg {
+		err, blocks := db.GetDB().ReadDomainBlocks()
+		if err != nil {
+			log.Printf("Failed to load domain blocks: %v", err)
+			return domainBlocksLoadedMsg{blocks: []domain.DomainBlock{}}
+		}
+
+		if blocks == nil {
+			return domainBlocksLoadedMsg{blocks: []domain.DomainBlock{}}
+		}
+
+		return domainBlocksLoadedMsg{blocks: *blocks}
+	}
+}
+
+// saveDomainBlockCmd creates or updates a domain block
+func saveDomainBlockCmd(block domain.DomainBlock) tea.Cmd {
+	return func() tea.Msg {
+		if !strings.Contains(block.Domain, ".") || strings.ContainsAny(block.Domain, "/@") {
+			return domainBlocksChangedMsg{err: fmt.Errorf("invalid domain %q", block.Domain)}
+		}
+		err := db.GetDB().UpsertDomainBlock(&block)
+		if err != nil {
+			log.Printf("Failed to save domain block for %s: %v", block.Domain, err)
+		}
+		return domainBlocksChangedMsg{status: fmt.Sprintf("%s: %s", block.Domain, severityLabel(block)), err: err}
+	}
+}
+
+// deleteDomainBlockCmd lifts a domain block
+func deleteDomainBlockCmd(block domain.DomainBlock) tea.Cmd {
+	return func() tea.Msg {
+		err := db.GetDB().DeleteDomainBlock(block.Id)
+		if err != nil {
+			log.Printf("Failed to delete domain block for %s: %v", block.Domain, err)
+		}
+		return domainBlocksChangedMsg{status: fmt.Sprintf("Unblocked %s", block.Domain), err: err}
+	}
+}
+
+// importDomainBlocksCmd imports the blocklist CSV from the config directory
+func importDomainBlocksCmd() tea.Cmd {
+	return func() tea.Msg {
+		path := util.ResolveFilePath(blocklistFile)
+		count, err := activitypub.ImportDomainBlocks(path)
+		if err != nil {
+			log.Printf("Failed to import domain blocks: %v", err)
+		}
+		return domainBlocksChangedMsg{status: fmt.Sprintf("Imported %d domain blocks from %s", count, path), err: err}
+	}
+}
+
+// exportDomainBlocksCmd exports the blocklist as CSV to the config directory
+func exportDomainBlocksCmd() tea.Cmd {
+	return func() tea.Msg {
+		path := util.ResolveFilePath(blocklistFile)
+		count, err := activitypub.ExportDomainBlocks(path)
+		if err != nil {
+			log.Printf("Failed to export domain blocks: %v", err)
+		}
+		return domainBlocksChangedMsg{status: fmt.Sprintf("Exported %d domain blocks to %s", count, path), err: err}
+	}
+}
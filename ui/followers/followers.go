@@ -3,6 +3,7 @@ package followers
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -21,14 +22,23 @@ var (
 	emptyStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color(common.COLOR_DARK_GREY)).
 			Italic(true)
+
+	statusStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_BLUE))
+
+	errorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_RED))
 )
 
 type Model struct {
-	AccountId uuid.UUID
-	Followers []domain.Follow
-	Offset    int // Pagination offset
-	Width     int
-	Height    int
+	AccountId       uuid.UUID
+	Followers       []domain.Follow
+	HideCollections bool // Whether follower and following lists are hidden from other servers
+	Offset          int  // Pagination offset
+	Width           int
+	Height          int
+	Status          string
+	Error           string
 }
 
 func InitialModel(accountId uuid.UUID, width, height int) Model {
@@ -49,9 +59,30 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case followersLoadedMsg:
 		m.Followers = msg.followers
+		m.HideCollections = msg.hideCollections
 		m.Offset = 0 // Reset offset on reload
 		return m, nil
 
+	case clearStatusMsg:
+		m.Status = ""
+		m.Error = ""
+		return m, nil
+
+	case hideCollectionsToggledMsg:
+		if msg.err != nil {
+			m.Error = fmt.Sprintf("Failed to update privacy: %v", msg.err)
+			m.Status = ""
+		} else {
+			m.HideCollections = msg.hide
+			if msg.hide {
+				m.Status = "Follower and following lists are now hidden"
+			} else {
+				m.Status = "Follower and following lists are now public"
+			}
+			m.Error = ""
+		}
+		return m, clearStatusAfter(2 * time.Second)
+
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "up", "k", "left":
@@ -63,6 +94,9 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			if len(m.Followers) > 0 && m.Offset < len(m.Followers)-1 {
 				m.Offset++
 			}
+		case "h":
+			// Toggle whether other servers may see who follows you and who you follow
+			return m, toggleHideCollectionsCmd(m.AccountId, !m.HideCollections)
 		}
 	}
 	return m, nil
@@ -71,9 +105,22 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 func (m Model) View() string {
 	var s strings.Builder
 
-	s.WriteString(common.CaptionStyle.Render(fmt.Sprintf("followers (%d)", len(m.Followers))))
+	caption := fmt.Sprintf("followers (%d)", len(m.Followers))
+	if m.HideCollections {
+		caption += " • lists hidden from other servers"
+	}
+	s.WriteString(common.CaptionStyle.Render(caption))
 	s.WriteString("\n\n")
 
+	if m.Status != "" {
+		s.WriteString(statusStyle.Render(m.Status))
+		s.WriteString("\n\n")
+	}
+	if m.Error != "" {
+		s.WriteString(errorStyle.Render(m.Error))
+		s.WriteString("\n\n")
+	}
+
 	if len(m.Followers) == 0 {
 		s.WriteString(emptyStyle.Render("No followers yet. Share your account to get followers!"))
 	} else {
@@ -129,23 +176,57 @@ func (m Model) View() string {
 
 // followersLoadedMsg is sent when followers are loaded
 type followersLoadedMsg struct {
-	followers []domain.Follow
+	followers       []domain.Follow
+	hideCollections bool
 }
 
 // loadFollowers loads the followers for the given account
 func loadFollowers(accountId uuid.UUID) tea.Cmd {
 	return func() tea.Msg {
 		database := db.GetDB()
+
+		hideCollections := false
+		if err, acc := database.ReadAccById(accountId); err == nil && acc != nil {
+			hideCollections = acc.HideCollections
+		}
+
 		err, followers := database.ReadFollowersByAccountId(accountId)
 		if err != nil {
 			log.Printf("Failed to load followers: %v", err)
-			return followersLoadedMsg{followers: []domain.Follow{}}
+			return followersLoadedMsg{followers: []domain.Follow{}, hideCollections: hideCollections}
 		}
 
 		if followers == nil {
-			return followersLoadedMsg{followers: []domain.Follow{}}
+			return followersLoadedMsg{followers: []domain.Follow{}, hideCollections: hideCollections}
 		}
 
-		return followersLoadedMsg{followers: *followers}
+		return followersLoadedMsg{followers: *followers, hideCollections: hideCollections}
 	}
 }
+
+// hideCollectionsToggledMsg is sent after the follower list privacy was changed
+type hideCollectionsToggledMsg struct {
+	hide bool
+	err  error
+}
+
+// toggleHideCollectionsCmd hides or shows the account's follower and following lists to other servers
+func toggleHideCollectionsCmd(accountId uuid.UUID, hide bool) tea.Cmd {
+	return func() tea.Msg {
+		err := db.GetDB().UpdateHideCollections(accountId, hide)
+		if err != nil {
+			log.Printf("Failed to update follower list privacy: %v", err)
+		}
+		return hideCollectionsToggledMsg{hide: hide, err: err}
+	}
+}
+
+// clearStatusMsg is sent after a delay to clear status/error messages
+type clearStatusMsg struct{}
+
+// clearStatusAfter returns a command that sends clearStatusMsg after a duration
+func clearStatusAfter(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(t time.Time) tea.Msg {
+		return clearStatusMsg{}
+	})
+}
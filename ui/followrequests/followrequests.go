@@ -0,0 +1,313 @@
+package followrequests
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/deemkeen/stegodon/activitypub"
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/ui/common"
+	"github.com/deemkeen/stegodon/util"
+	"github.com/google/uuid"
+)
+
+var (
+	itemStyle = lipgloss.NewStyle().
+			PaddingLeft(2).
+			MarginBottom(0)
+
+	selectedStyle = lipgloss.NewStyle().
+			PaddingLeft(2).
+			MarginBottom(0).
+			Foreground(lipgloss.Color(common.COLOR_GREEN)).
+			Bold(true)
+
+	emptyStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_DARK_GREY)).
+			Italic(true)
+
+	statusStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_BLUE))
+
+	errorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_RED))
+)
+
+type Model struct {
+	AccountId uuid.UUID
+	Requests  []domain.Follow
+	Locked    bool // Whether follows of the account need approval
+	Selected  int
+	Width     int
+	Height    int
+	Status    string
+	Error     string
+}
+
+func InitialModel(accountId uuid.UUID, width, height int) Model {
+	return Model{
+		AccountId: accountId,
+		Requests:  []domain.Follow{},
+		Selected:  0,
+		Width:     width,
+		Height:    height,
+		Status:    "",
+		Error:     "",
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return loadRequests(m.AccountId)
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case requestsLoadedMsg:
+		m.Requests = msg.requests
+		m.Locked = msg.locked
+		if m.Selected >= len(m.Requests) {
+			m.Selected = max(len(m.Requests)-1, 0)
+		}
+		return m, nil
+
+	case clearStatusMsg:
+		m.Status = ""
+		m.Error = ""
+		return m, nil
+
+	case requestAnsweredMsg:
+		if msg.err != nil {
+			m.Error = fmt.Sprintf("Failed to answer request: %v", msg.err)
+			m.Status = ""
+		} else {
+			if msg.accepted {
+				m.Status = fmt.Sprintf("Accepted follow from %s", msg.handle)
+			} else {
+				m.Status = fmt.Sprintf("Rejected follow from %s", msg.handle)
+			}
+			m.Error = ""
+		}
+		return m, tea.Batch(loadRequests(m.AccountId), clearStatusAfter(2*time.Second))
+
+	case lockToggledMsg:
+		if msg.err != nil {
+			m.Error = fmt.Sprintf("Failed to update account: %v", msg.err)
+			m.Status = ""
+		} else {
+			m.Locked = msg.locked
+			if msg.locked {
+				m.Status = "Account locked: new follows need your approval"
+			} else {
+				m.Status = "Account unlocked: new follows are accepted automatically"
+			}
+			m.Error = ""
+		}
+		return m, clearStatusAfter(2 * time.Second)
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if m.Selected > 0 {
+				m.Selected--
+			}
+		case "down", "j":
+			if m.Selected < len(m.Requests)-1 {
+				m.Selected++
+			}
+		case "a", "enter":
+			if len(m.Requests) > 0 && m.Selected < len(m.Requests) {
+				return m, answerRequestCmd(m.AccountId, m.Requests[m.Selected], true)
+			}
+		case "x":
+			if len(m.Requests) > 0 && m.Selected < len(m.Requests) {
+				return m, answerRequestCmd(m.AccountId, m.Requests[m.Selected], false)
+			}
+		case "l":
+			return m, toggleLockedCmd(m.AccountId, !m.Locked)
+		}
+	}
+	return m, nil
+}
+
+func (m Model) View() string {
+	var s strings.Builder
+
+	caption := fmt.Sprintf("follow requests (%d)", len(m.Requests))
+	if m.Locked {
+		caption += " • account locked"
+	}
+	s.WriteString(common.CaptionStyle.Render(caption))
+	s.WriteString("\n\n")
+
+	if len(m.Requests) == 0 {
+		if m.Locked {
+			s.WriteString(emptyStyle.Render("No pending follow requests."))
+		} else {
+			s.WriteString(emptyStyle.Render("No pending follow requests.\nLock your account to approve new followers yourself."))
+		}
+	} else {
+		displayCount := min(len(m.Requests), 10)
+		for i := 0; i < displayCount; i++ {
+			request := m.Requests[i]
+			database := db.GetDB()
+
+			err, remoteAcc := database.ReadRemoteAccountById(request.AccountId)
+			if err != nil {
+				log.Printf("Failed to read remote account: %v", err)
+				continue
+			}
+
+			displayName := remoteAcc.DisplayName
+			if displayName == "" {
+				displayName = remoteAcc.Username
+			}
+
+			userText := fmt.Sprintf("• %s (@%s@%s)",
+				displayName,
+				remoteAcc.Username,
+				remoteAcc.Domain,
+			)
+
+			if i == m.Selected {
+				s.WriteString("→ " + selectedStyle.Render(userText))
+			} else {
+				s.WriteString("  " + itemStyle.Render(userText))
+			}
+			s.WriteString("\n")
+		}
+
+		if len(m.Requests) > 10 {
+			s.WriteString(itemStyle.Render(fmt.Sprintf("... and %d more", len(m.Requests)-10)))
+			s.WriteString("\n")
+		}
+	}
+
+	s.WriteString("\n")
+
+	if m.Status != "" {
+		s.WriteString(statusStyle.Render(m.Status))
+		s.WriteString("\n\n")
+	}
+
+	if m.Error != "" {
+		s.WriteString(errorStyle.Render(m.Error))
+		s.WriteString("\n\n")
+	}
+
+	return s.String()
+}
+
+// requestsLoadedMsg is sent when the pending follow requests are loaded
+type requestsLoadedMsg struct {
+	requests []domain.Follow
+	locked   bool
+}
+
+// requestAnsweredMsg is sent after a follow request was accepted or rejected
+type requestAnsweredMsg struct {
+	handle   string
+	accepted bool
+	err      error
+}
+
+// lockToggledMsg is sent after the account was locked or unlocked
+type lockToggledMsg struct {
+	locked bool
+	err    error
+}
+
+// clearStatusMsg is sent after a delay to clear status/error messages
+type clearStatusMsg struct{}
+
+// clearStatusAfter returns a command that sends clearStatusMsg after a duration
+func clearStatusAfter(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(t time.Time) tea.Msg {
+		return clearStatusMsg{}
+	})
+}
+
+// loadRequests loads the pending follow requests for the given account
+func loadRequests(accountId uuid.UUID) tea.Cmd {
+	return func() tea.Msg {
+		database := db.GetDB()
+
+		locked := false
+		if err, acc := database.ReadAccById(accountId); err == nil && acc != nil {
+			locked = acc.Locked
+		}
+
+		err, requests := database.ReadPendingFollowsByAccountId(accountId)
+		if err != nil {
+			log.Printf("Failed to load follow requests: %v", err)
+			return requestsLoadedMsg{requests: []domain.Follow{}, locked: locked}
+		}
+
+		if requests == nil {
+			return requestsLoadedMsg{requests: []domain.Follow{}, locked: locked}
+		}
+
+		return requestsLoadedMsg{requests: *requests, locked: locked}
+	}
+}
+
+// answerRequestCmd accepts or rejects a follow request and notifies the requesting actor
+func answerRequestCmd(accountId uuid.UUID, request domain.Follow, accept bool) tea.Cmd {
+	return func() tea.Msg {
+		database := db.GetDB()
+
+		err, localAccount := database.ReadAccById(accountId)
+		if err != nil {
+			return requestAnsweredMsg{accepted: accept, err: fmt.Errorf("account not found: %w", err)}
+		}
+
+		err, remoteActor := database.ReadRemoteAccountById(request.AccountId)
+		if err != nil {
+			return requestAnsweredMsg{accepted: accept, err: fmt.Errorf("remote account not found: %w", err)}
+		}
+		handle := fmt.Sprintf("@%s@%s", remoteActor.Username, remoteActor.Domain)
+
+		// Update the follow state first, a rejected follow is removed entirely
+		if accept {
+			err = database.AcceptFollowByURI(request.URI)
+		} else {
+			err = database.DeleteFollowByURI(request.URI)
+		}
+		if err != nil {
+			return requestAnsweredMsg{handle: handle, accepted: accept, err: err}
+		}
+
+		// Get config (TODO: pass from main)
+		conf, err := util.ReadConf()
+		if err != nil {
+			log.Printf("Failed to read config: %v", err)
+			return requestAnsweredMsg{handle: handle, accepted: accept}
+		}
+
+		if accept {
+			err = activitypub.SendAccept(localAccount, remoteActor, request.URI, conf)
+		} else {
+			err = activitypub.SendReject(localAccount, remoteActor, request.URI, conf)
+		}
+		if err != nil {
+			log.Printf("Failed to answer follow request %s: %v", request.URI, err)
+		}
+
+		return requestAnsweredMsg{handle: handle, accepted: accept}
+	}
+}
+
+// toggleLockedCmd locks or unlocks the account for manual follower approval
+func toggleLockedCmd(accountId uuid.UUID, locked bool) tea.Cmd {
+	return func() tea.Msg {
+		err := db.GetDB().UpdateLocked(accountId, locked)
+		if err != nil {
+			log.Printf("Failed to update locked state: %v", err)
+		}
+		return lockToggledMsg{locked: locked, err: err}
+	}
+}
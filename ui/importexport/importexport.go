@@ -0,0 +1,242 @@
+package importexport
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/deemkeen/stegodon/activitypub"
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/ui/common"
+	"github.com/deemkeen/stegodon/util"
+	"github.com/deemkeen/stegodon/web"
+	"github.com/google/uuid"
+)
+
+// How often the progress of a running import is refreshed
+const refreshInterval = time.Second
+
+var (
+	itemStyle = lipgloss.NewStyle().
+			PaddingLeft(2).
+			MarginBottom(0)
+
+	selectedStyle = lipgloss.NewStyle().
+			PaddingLeft(2).
+			MarginBottom(0).
+			Foreground(lipgloss.Color(common.COLOR_GREEN)).
+			Bold(true)
+
+	commandStyle = lipgloss.NewStyle().
+			PaddingLeft(2).
+			Foreground(lipgloss.Color(common.COLOR_BLUE))
+
+	emptyStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_DARK_GREY)).
+			Italic(true)
+
+	statusStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_GREEN))
+
+	errorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_RED))
+)
+
+type Model struct {
+	AccountId uuid.UUID
+	Job       *activitypub.ImportJob // Latest import of the account, nil if there was none
+	Selected  int                    // Selected failed entry
+	Width     int
+	Height    int
+	Status    string
+	Error     string
+}
+
+func InitialModel(accountId uuid.UUID, width, height int) Model {
+	return Model{
+		AccountId: accountId,
+		Width:     width,
+		Height:    height,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return loadImportStatus(m.AccountId)
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case importStatusMsg:
+		m.Job = msg.job
+		if m.Job == nil {
+			return m, nil
+		}
+		if m.Selected >= len(m.Job.Errors) {
+			m.Selected = max(len(m.Job.Errors)-1, 0)
+		}
+		// Keep following a running import
+		if !m.Job.Finished {
+			return m, tea.Tick(refreshInterval, func(time.Time) tea.Msg {
+				return refreshMsg{}
+			})
+		}
+		return m, nil
+
+	case refreshMsg:
+		return m, loadImportStatus(m.AccountId)
+
+	case archiveResultMsg:
+		if msg.err != nil {
+			m.Error = fmt.Sprintf("Failed: %v", msg.err)
+			m.Status = ""
+		} else {
+			m.Status = fmt.Sprintf("Archive ready: %d notes, %d likes and %d follows (%d KB)",
+				msg.summary.Notes, msg.summary.Likes, msg.summary.Follows, (msg.size+1023)/1024)
+			m.Error = ""
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if m.Selected > 0 {
+				m.Selected--
+			}
+		case "down", "j":
+			if m.Job != nil && m.Selected < len(m.Job.Errors)-1 {
+				m.Selected++
+			}
+		case "f":
+			return m, loadImportStatus(m.AccountId)
+		case "e":
+			m.Status = "Preparing archive..."
+			m.Error = ""
+			return m, archiveCmd(m.AccountId)
+		}
+	}
+	return m, nil
+}
+
+func (m Model) View() string {
+	var s strings.Builder
+
+	s.WriteString(common.CaptionStyle.Render("import & export"))
+	s.WriteString("\n\n")
+
+	s.WriteString("Download an archive of your profile, notes, likes and follows:\n")
+	s.WriteString(commandStyle.Render("ssh <server> export > archive.zip"))
+	s.WriteString("\n")
+	s.WriteString(commandStyle.Render("scp <server>:archive.zip ."))
+	s.WriteString("\n\n")
+	if m.Status != "" {
+		s.WriteString(statusStyle.Render(m.Status))
+		s.WriteString("\n\n")
+	}
+	if m.Error != "" {
+		s.WriteString(errorStyle.Render(m.Error))
+		s.WriteString("\n\n")
+	}
+
+	s.WriteString("Export a list in Mastodon's CSV format:\n")
+	s.WriteString(commandStyle.Render("ssh <server> export following_accounts.csv > following_accounts.csv"))
+	s.WriteString("\n")
+	s.WriteString(commandStyle.Render("scp <server>:following_accounts.csv ."))
+	s.WriteString("\n\n")
+	s.WriteString("Import a list, e.g. from a Mastodon export:\n")
+	s.WriteString(commandStyle.Render("ssh <server> import following_accounts.csv < following_accounts.csv"))
+	s.WriteString("\n")
+	s.WriteString(commandStyle.Render("scp following_accounts.csv <server>:"))
+	s.WriteString("\n\n")
+	s.WriteString(fmt.Sprintf("Lists: %s\n\n", strings.Join(activitypub.AccountLists, ", ")))
+	s.WriteString("Import the public posts of an archive's outbox as notes:\n")
+	s.WriteString(commandStyle.Render("ssh <server> import outbox.json < outbox.json"))
+	s.WriteString("\n\n")
+
+	if m.Job == nil {
+		s.WriteString(emptyStyle.Render("No import yet."))
+		return s.String()
+	}
+
+	job := m.Job
+	state := "running"
+	if job.Finished {
+		state = "finished " + job.FinishedAt.Format("2006-01-02 15:04")
+	}
+	s.WriteString(fmt.Sprintf("Import of %s (%s)\n", job.List, state))
+	s.WriteString(fmt.Sprintf("%d of %d processed: %d imported, %d skipped, %d failed\n\n",
+		job.Processed, job.Total, job.Imported, job.Skipped, len(job.Errors)))
+
+	if len(job.Errors) == 0 {
+		return s.String()
+	}
+
+	start := 0
+	if m.Selected >= 10 {
+		start = m.Selected - 9
+	}
+	end := min(start+10, len(job.Errors))
+	for i := start; i < end; i++ {
+		importErr := job.Errors[i]
+		text := "• " + importErr.Entry
+		if i == m.Selected {
+			s.WriteString("→ " + selectedStyle.Render(text))
+		} else {
+			s.WriteString("  " + itemStyle.Render(text))
+		}
+		s.WriteString("\n")
+		s.WriteString("      " + errorStyle.Render(importErr.Err))
+		s.WriteString("\n")
+	}
+
+	if len(job.Errors) > end {
+		s.WriteString(itemStyle.Render(fmt.Sprintf("... and %d more", len(job.Errors)-end)))
+		s.WriteString("\n")
+	}
+
+	return s.String()
+}
+
+// importStatusMsg is sent when the progress of the latest import is loaded
+type importStatusMsg struct {
+	job *activitypub.ImportJob
+}
+
+// refreshMsg is sent to reload the progress of a running import
+type refreshMsg struct{}
+
+// loadImportStatus loads the progress of the latest import of the account
+func loadImportStatus(accountId uuid.UUID) tea.Cmd {
+	return func() tea.Msg {
+		return importStatusMsg{job: activitypub.ImportStatus(accountId)}
+	}
+}
+
+// archiveResultMsg is sent when the archive was prepared
+type archiveResultMsg struct {
+	summary web.ArchiveSummary
+	size    int
+	err     error
+}
+
+// archiveCmd builds the archive of the account to check what a download will contain
+func archiveCmd(accountId uuid.UUID) tea.Cmd {
+	return func() tea.Msg {
+		err, acc := db.GetDB().ReadAccById(accountId)
+		if err != nil {
+			return archiveResultMsg{err: fmt.Errorf("failed to get local account: %w", err)}
+		}
+
+		// Get config (TODO: pass from main)
+		conf, err := util.ReadConf()
+		if err != nil {
+			return archiveResultMsg{err: fmt.Errorf("failed to read config: %w", err)}
+		}
+
+		var buf bytes.Buffer
+		summary, err := web.WriteArchive(&buf, acc, conf)
+		return archiveResultMsg{summary: summary, size: buf.Len(), err: err}
+	}
+}
@@ -0,0 +1,142 @@
+package keychanges
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/deemkeen/stegodon/activitypub"
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/ui/common"
+)
+
+// Number of key changes loaded into the view
+const keyChangesLimit = 100
+
+var (
+	itemStyle = lipgloss.NewStyle().
+			PaddingLeft(2).
+			MarginBottom(0)
+
+	selectedStyle = lipgloss.NewStyle().
+			PaddingLeft(2).
+			MarginBottom(0).
+			Foreground(lipgloss.Color(common.COLOR_GREEN)).
+			Bold(true)
+
+	detailStyle = lipgloss.NewStyle().
+			PaddingLeft(6).
+			Foreground(lipgloss.Color(common.COLOR_DARK_GREY))
+
+	emptyStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_DARK_GREY)).
+			Italic(true)
+)
+
+type Model struct {
+	Changes  []domain.KeyChange // Most recent key changes of remote actors, newest first
+	Selected int
+	Width    int
+	Height   int
+}
+
+func InitialModel(width, height int) Model {
+	return Model{
+		Changes:  []domain.KeyChange{},
+		Selected: 0,
+		Width:    width,
+		Height:   height,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return loadKeyChanges()
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case keyChangesLoadedMsg:
+		m.Changes = msg.changes
+		if m.Selected >= len(m.Changes) {
+			m.Selected = max(len(m.Changes)-1, 0)
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if m.Selected > 0 {
+				m.Selected--
+			}
+		case "down", "j":
+			if m.Selected < len(m.Changes)-1 {
+				m.Selected++
+			}
+		case "f":
+			return m, loadKeyChanges()
+		}
+	}
+	return m, nil
+}
+
+func (m Model) View() string {
+	var s strings.Builder
+
+	s.WriteString(common.CaptionStyle.Render(fmt.Sprintf("key changes (%d)", len(m.Changes))))
+	s.WriteString("\n\n")
+
+	if len(m.Changes) == 0 {
+		s.WriteString(emptyStyle.Render("No remote actor changed its key yet."))
+		return s.String()
+	}
+
+	start := 0
+	if m.Selected >= 10 {
+		start = m.Selected - 9
+	}
+	end := min(start+10, len(m.Changes))
+	for i := start; i < end; i++ {
+		change := m.Changes[i]
+		text := fmt.Sprintf("• %s %s", change.ChangedAt.Format("2006-01-02 15:04"), change.ActorURI)
+
+		if i == m.Selected {
+			s.WriteString("→ " + selectedStyle.Render(text))
+		} else {
+			s.WriteString("  " + itemStyle.Render(text))
+		}
+		s.WriteString("\n")
+		s.WriteString(detailStyle.Render(fmt.Sprintf("%s → %s",
+			activitypub.KeyFingerprint(change.OldKeyPem), activitypub.KeyFingerprint(change.NewKeyPem))))
+		s.WriteString("\n")
+	}
+
+	if len(m.Changes) > end {
+		s.WriteString(itemStyle.Render(fmt.Sprintf("... and %d more", len(m.Changes)-end)))
+		s.WriteString("\n")
+	}
+
+	return s.String()
+}
+
+// keyChangesLoadedMsg is sent when the key history is loaded
+type keyChangesLoadedMsg struct {
+	changes []domain.KeyChange
+}
+
+// loadKeyChanges loads the most recent key changes of remote actors
+func loadKeyChanges() tea.Cmd {
+	return func() tea.Msg {
+		err, changes := db.GetDB().ReadKeyChanges(keyChangesLimit)
+		if err != nil {
+			log.Printf("Failed to load key changes: %v", err)
+			return keyChangesLoadedMsg{changes: []domain.KeyChange{}}
+		}
+		if changes == nil {
+			return keyChangesLoadedMsg{changes: []domain.KeyChange{}}
+		}
+		return keyChangesLoadedMsg{changes: *changes}
+	}
+}
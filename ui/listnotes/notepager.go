@@ -61,6 +61,8 @@ type Model struct {
 	userId           uuid.UUID
 	confirmingDelete bool      // True when showing delete confirmation
 	deleteTargetId   uuid.UUID // ID of note pending deletion
+	likeCounts       map[uuid.UUID]int
+	shareCounts      map[uuid.UUID]int
 }
 
 func (m Model) Init() tea.Cmd {
@@ -71,6 +73,8 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case notesLoadedMsg:
 		m.Notes = msg.notes
+		m.likeCounts = msg.likeCounts
+		m.shareCounts = msg.shareCounts
 		// Restore selection after reload, but make sure it's within bounds
 		if m.Selected >= len(m.Notes) {
 			m.Selected = max(0, len(m.Notes)-1)
@@ -127,6 +131,16 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				m.confirmingDelete = true
 				m.deleteTargetId = m.Notes[m.Selected].Id
 			}
+		case "r":
+			// Reply to selected note
+			if len(m.Notes) > 0 && m.Selected < len(m.Notes) {
+				return m, replyNoteCmd(m.Notes[m.Selected].Id)
+			}
+		case "t":
+			// Show the conversation around the selected note
+			if len(m.Notes) > 0 && m.Selected < len(m.Notes) {
+				return m, showThreadCmd(m.Notes[m.Selected].Id)
+			}
 		}
 	}
 	return m, nil
@@ -167,6 +181,12 @@ func (m Model) View() string {
 			if note.EditedAt != nil {
 				timeStr += " (edited)"
 			}
+			if likes := m.likeCounts[note.Id]; likes > 0 {
+				timeStr += fmt.Sprintf(" • ♥ %d", likes)
+			}
+			if shares := m.shareCounts[note.Id]; shares > 0 {
+				timeStr += fmt.Sprintf(" • ⟳ %d", shares)
+			}
 
 			// Convert Markdown links to OSC 8 hyperlinks
 			messageWithLinks := util.MarkdownLinksToTerminal(note.Message)
@@ -215,7 +235,9 @@ func (m Model) View() string {
 
 // notesLoadedMsg is sent when notes are loaded
 type notesLoadedMsg struct {
-	notes []domain.Note
+	notes       []domain.Note
+	likeCounts  map[uuid.UUID]int
+	shareCounts map[uuid.UUID]int
 }
 
 // loadNotes loads notes for the given user
@@ -232,7 +254,54 @@ func loadNotes(userId uuid.UUID) tea.Cmd {
 			return notesLoadedMsg{notes: []domain.Note{}}
 		}
 
-		return notesLoadedMsg{notes: *notes}
+		// Count likes and boosts received from the fediverse
+		noteIds := make([]uuid.UUID, 0, len(*notes))
+		for _, note := range *notes {
+			noteIds = append(noteIds, note.Id)
+		}
+		likeCounts, err := database.CountLikesByNoteIds(noteIds)
+		if err != nil {
+			log.Printf("Failed to count likes: %v", err)
+		}
+		shareCounts, err := database.CountSharesByNoteIds(noteIds)
+		if err != nil {
+			log.Printf("Failed to count shares: %v", err)
+		}
+
+		return notesLoadedMsg{notes: *notes, likeCounts: likeCounts, shareCounts: shareCounts}
+	}
+}
+
+// noteURI returns the ActivityPub URI of a local note
+func noteURI(noteId uuid.UUID) (string, error) {
+	conf, err := util.ReadConf()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://%s/notes/%s", conf.Conf.SslDomain, noteId.String()), nil
+}
+
+// replyNoteCmd starts a reply to one of the user's own notes
+func replyNoteCmd(noteId uuid.UUID) tea.Cmd {
+	return func() tea.Msg {
+		uri, err := noteURI(noteId)
+		if err != nil {
+			log.Printf("Failed to read config for reply: %v", err)
+			return nil
+		}
+		return common.ReplyNoteMsg{InReplyToURI: uri}
+	}
+}
+
+// showThreadCmd opens the thread view for one of the user's own notes
+func showThreadCmd(noteId uuid.UUID) tea.Cmd {
+	return func() tea.Msg {
+		uri, err := noteURI(noteId)
+		if err != nil {
+			log.Printf("Failed to read config for thread view: %v", err)
+			return nil
+		}
+		return common.ShowThreadMsg{ObjectURI: uri}
 	}
 }
 
@@ -241,10 +310,10 @@ func deleteNoteCmd(noteId uuid.UUID) tea.Cmd {
 	return func() tea.Msg {
 		database := db.GetDB()
 
-		// Get note details before deletion for federation
+		// Get note details before deletion for federation, imported notes were never federated
 		err, note := database.ReadNoteId(noteId)
 		var accountUsername string
-		if err == nil && note != nil {
+		if err == nil && note != nil && note.Federated {
 			accountUsername = note.CreatedBy
 		}
 
@@ -324,5 +393,7 @@ func NewPager(userId uuid.UUID, width int, height int) Model {
 		userId:           userId,
 		confirmingDelete: false,
 		deleteTargetId:   uuid.Nil,
+		likeCounts:       map[uuid.UUID]int{},
+		shareCounts:      map[uuid.UUID]int{},
 	}
 }
@@ -7,6 +7,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/deemkeen/stegodon/activitypub"
 	"github.com/deemkeen/stegodon/db"
 	"github.com/deemkeen/stegodon/domain"
 	"github.com/deemkeen/stegodon/ui/common"
@@ -31,14 +32,37 @@ var (
 	emptyStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color(common.COLOR_DARK_GREY)).
 			Italic(true)
+
+	statusStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_BLUE))
+
+	errorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_RED))
+
+	// Inverted styles for selected posts
+	selectedTimeStyle = lipgloss.NewStyle().
+				Align(lipgloss.Left).
+				Foreground(lipgloss.Color(common.COLOR_WHITE)) // White
+
+	selectedAuthorStyle = lipgloss.NewStyle().
+				Align(lipgloss.Left).
+				Foreground(lipgloss.Color(common.COLOR_WHITE)). // White
+				Bold(true)
+
+	selectedContentStyle = lipgloss.NewStyle().
+				Align(lipgloss.Left).
+				Foreground(lipgloss.Color(common.COLOR_WHITE)) // White
 )
 
 type Model struct {
 	AccountId uuid.UUID
 	Posts     []domain.Note
 	Offset    int // Pagination offset
+	Selected  int // Currently selected post index
 	Width     int
 	Height    int
+	Status    string
+	Error     string
 }
 
 func InitialModel(accountId uuid.UUID, width, height int) Model {
@@ -46,8 +70,11 @@ func InitialModel(accountId uuid.UUID, width, height int) Model {
 		AccountId: accountId,
 		Posts:     []domain.Note{},
 		Offset:    0,
+		Selected:  0,
 		Width:     width,
 		Height:    height,
+		Status:    "",
+		Error:     "",
 	}
 }
 
@@ -76,19 +103,59 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 
 	case postsLoadedMsg:
 		m.Posts = msg.posts
-		// Don't reset offset on auto-refresh, only on manual Init
+		// Keep selection within bounds after reload
+		if m.Selected >= len(m.Posts) {
+			m.Selected = max(0, len(m.Posts)-1)
+		}
+		// Keep Offset in sync
+		m.Offset = m.Selected
 		return m, nil
 
+	case clearStatusMsg:
+		m.Status = ""
+		m.Error = ""
+		return m, nil
+
+	case boostToggledMsg:
+		if msg.err != nil {
+			m.Error = msg.err.Error()
+			m.Status = ""
+		} else if msg.boosted {
+			m.Status = "Boosted post"
+			m.Error = ""
+		} else {
+			m.Status = "Unboosted post"
+			m.Error = ""
+		}
+		return m, clearStatusAfter(2 * time.Second)
+
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "up", "k", "left":
-			if m.Offset > 0 {
-				m.Offset--
+			if m.Selected > 0 {
+				m.Selected--
+				m.Offset = m.Selected // Keep selected at top
 			}
 		case "down", "j", "right":
-			// Allow scrolling if we have any posts at all
-			if len(m.Posts) > 0 && m.Offset < len(m.Posts)-1 {
-				m.Offset++
+			if len(m.Posts) > 0 && m.Selected < len(m.Posts)-1 {
+				m.Selected++
+				m.Offset = m.Selected // Keep selected at top
+			}
+		case "#":
+			// Show posts with the first hashtag of the selected post
+			tag := ""
+			if len(m.Posts) > 0 && m.Selected < len(m.Posts) {
+				if tags := util.ExtractHashtags(m.Posts[m.Selected].Message); len(tags) > 0 {
+					tag = tags[0]
+				}
+			}
+			return m, func() tea.Msg {
+				return common.ShowTagMsg{Tag: tag}
+			}
+		case "b":
+			// Boost or unboost the selected post
+			if len(m.Posts) > 0 && m.Selected < len(m.Posts) {
+				return m, toggleBoostCmd(m.AccountId, m.Posts[m.Selected])
 			}
 		}
 	}
@@ -104,6 +171,10 @@ func (m Model) View() string {
 	if len(m.Posts) == 0 {
 		s.WriteString(emptyStyle.Render("No local posts yet.\nCreate some notes or invite others to join!"))
 	} else {
+		// Calculate right panel width for selection background
+		leftPanelWidth := m.Width / 3
+		rightPanelWidth := m.Width - leftPanelWidth - 6
+
 		itemsPerPage := 10
 		start := m.Offset
 		end := start + itemsPerPage
@@ -117,10 +188,20 @@ func (m Model) View() string {
 			// Convert Markdown links to OSC 8 hyperlinks
 			messageWithLinks := util.MarkdownLinksToTerminal(post.Message)
 
-			// Render in vertical layout like notes list
-			timeStr := timeStyle.Render(formatTime(post.CreatedAt))
-			authorStr := authorStyle.Render("@" + post.CreatedBy)
-			contentStr := contentStyle.Render(truncate(messageWithLinks, 150))
+			// Render in vertical layout like notes list, the selected post inverted
+			var timeStr, authorStr, contentStr string
+			if i == m.Selected {
+				selectedBg := lipgloss.NewStyle().
+					Background(lipgloss.Color(common.COLOR_LIGHTBLUE)).
+					Width(rightPanelWidth - 4)
+				timeStr = selectedBg.Render(selectedTimeStyle.Render(formatTime(post.CreatedAt)))
+				authorStr = selectedBg.Render(selectedAuthorStyle.Render("@" + post.CreatedBy))
+				contentStr = selectedBg.Render(selectedContentStyle.Render(truncate(messageWithLinks, 150)))
+			} else {
+				timeStr = timeStyle.Render(formatTime(post.CreatedAt))
+				authorStr = authorStyle.Render("@" + post.CreatedBy)
+				contentStr = contentStyle.Render(truncate(messageWithLinks, 150))
+			}
 
 			postContent := lipgloss.JoinVertical(lipgloss.Left, timeStr, authorStr, contentStr)
 			s.WriteString(postContent)
@@ -128,6 +209,16 @@ func (m Model) View() string {
 		}
 	}
 
+	if m.Status != "" {
+		s.WriteString(statusStyle.Render(m.Status))
+		s.WriteString("\n\n")
+	}
+
+	if m.Error != "" {
+		s.WriteString(errorStyle.Render(m.Error))
+		s.WriteString("\n\n")
+	}
+
 	return s.String()
 }
 
@@ -154,6 +245,60 @@ func loadLocalPosts(accountId uuid.UUID) tea.Cmd {
 	}
 }
 
+// boostToggledMsg is sent after a note was boosted or unboosted
+type boostToggledMsg struct {
+	boosted bool
+	err     error
+}
+
+// clearStatusMsg is sent after a delay to clear status/error messages
+type clearStatusMsg struct{}
+
+// clearStatusAfter returns a command that sends clearStatusMsg after a duration
+func clearStatusAfter(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(t time.Time) tea.Msg {
+		return clearStatusMsg{}
+	})
+}
+
+// toggleBoostCmd sends an Announce for a local note, or an Undo if it was already boosted
+func toggleBoostCmd(accountId uuid.UUID, note domain.Note) tea.Cmd {
+	return func() tea.Msg {
+		database := db.GetDB()
+		err, account := database.ReadAccById(accountId)
+		if err != nil {
+			return boostToggledMsg{err: fmt.Errorf("failed to load account: %w", err)}
+		}
+
+		conf, err := util.ReadConf()
+		if err != nil {
+			return boostToggledMsg{err: fmt.Errorf("failed to read config: %w", err)}
+		}
+
+		if !conf.Conf.WithAp {
+			return boostToggledMsg{err: fmt.Errorf("ActivityPub is disabled")}
+		}
+
+		objectURI := fmt.Sprintf("https://%s/notes/%s", conf.Conf.SslDomain, note.Id.String())
+		authorURI := fmt.Sprintf("https://%s/users/%s", conf.Conf.SslDomain, note.CreatedBy)
+
+		err, share := database.ReadShareByAccountAndObjectURI(accountId, objectURI)
+		if err == nil && share != nil {
+			if err := activitypub.SendUndoAnnounce(account, objectURI, authorURI, conf); err != nil {
+				log.Printf("Failed to unboost note: %v", err)
+				return boostToggledMsg{err: err}
+			}
+			return boostToggledMsg{boosted: false}
+		}
+
+		if err := activitypub.SendAnnounce(account, objectURI, authorURI, conf); err != nil {
+			log.Printf("Failed to boost note: %v", err)
+			return boostToggledMsg{err: err}
+		}
+		return boostToggledMsg{boosted: true}
+	}
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -0,0 +1,379 @@
+package moveaccount
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/deemkeen/stegodon/activitypub"
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/ui/common"
+	"github.com/deemkeen/stegodon/util"
+	"github.com/google/uuid"
+)
+
+var (
+	itemStyle = lipgloss.NewStyle().
+			PaddingLeft(2).
+			MarginBottom(0)
+
+	selectedStyle = lipgloss.NewStyle().
+			PaddingLeft(2).
+			MarginBottom(0).
+			Foreground(lipgloss.Color(common.COLOR_GREEN)).
+			Bold(true)
+
+	emptyStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_DARK_GREY)).
+			Italic(true)
+
+	warningStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_RED)).
+			Bold(true)
+
+	statusStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_BLUE))
+
+	errorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_RED))
+)
+
+type Model struct {
+	AccountId  uuid.UUID
+	Aliases    []string // Actor URIs of other accounts of the user (alsoKnownAs)
+	MovedTo    string   // Actor URI of the account the user moved to
+	TextInput  textinput.Model
+	Editing    bool   // Whether the input has focus
+	Moving     bool   // Whether the input is the account to move to instead of a new alias
+	Confirming bool   // Whether the move waits for confirmation
+	Target     string // Actor URI of the account to move to, once it was checked
+	Selected   int
+	Width      int
+	Height     int
+	Status     string
+	Error      string
+}
+
+func InitialModel(accountId uuid.UUID, width, height int) Model {
+	ti := textinput.New()
+	ti.Placeholder = "user@domain or @user@domain"
+	ti.CharLimit = 100
+	ti.Width = 50
+
+	return Model{
+		AccountId: accountId,
+		Aliases:   []string{},
+		TextInput: ti,
+		Width:     width,
+		Height:    height,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return loadAccount(m.AccountId)
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case accountLoadedMsg:
+		m.Aliases = msg.aliases
+		m.MovedTo = msg.movedTo
+		if m.Selected >= len(m.Aliases) {
+			m.Selected = max(len(m.Aliases)-1, 0)
+		}
+		return m, nil
+
+	case clearStatusMsg:
+		m.Status = ""
+		m.Error = ""
+		return m, nil
+
+	case aliasResultMsg:
+		if msg.err != nil {
+			m.Error = fmt.Sprintf("Failed: %v", msg.err)
+			m.Status = ""
+		} else {
+			m.Status = msg.status
+			m.Error = ""
+		}
+		return m, tea.Batch(loadAccount(m.AccountId), clearStatusAfter(2*time.Second))
+
+	case targetResolvedMsg:
+		if msg.err != nil {
+			m.Error = fmt.Sprintf("Failed: %v", msg.err)
+			m.Status = ""
+			return m, clearStatusAfter(5 * time.Second)
+		}
+		m.Target = msg.target
+		m.Confirming = true
+		m.Status = ""
+		m.Error = ""
+		return m, nil
+
+	case moveResultMsg:
+		if msg.err != nil {
+			m.Error = fmt.Sprintf("Failed: %v", msg.err)
+			m.Status = ""
+		} else {
+			m.Status = fmt.Sprintf("Moved to %s, your followers are asked to follow it", msg.target)
+			m.Error = ""
+		}
+		return m, tea.Batch(loadAccount(m.AccountId), clearStatusAfter(5*time.Second))
+
+	case tea.KeyMsg:
+		if m.Confirming {
+			switch msg.String() {
+			case "y", "Y":
+				target := m.Target
+				m.Confirming = false
+				m.Target = ""
+				m.Status = fmt.Sprintf("Moving to %s...", target)
+				return m, moveCmd(m.AccountId, target)
+			case "n", "N", "esc":
+				m.Confirming = false
+				m.Target = ""
+			}
+			return m, nil
+		}
+
+		if m.Editing {
+			switch msg.String() {
+			case "enter":
+				input := strings.TrimSpace(m.TextInput.Value())
+				if input == "" {
+					return m, nil
+				}
+				m.TextInput.SetValue("")
+				m.TextInput.Blur()
+				m.Editing = false
+				m.Error = ""
+				if m.Moving {
+					m.Status = fmt.Sprintf("Checking %s...", input)
+					return m, resolveTargetCmd(m.AccountId, input)
+				}
+				m.Status = fmt.Sprintf("Adding %s...", input)
+				return m, addAliasCmd(m.AccountId, input)
+			case "esc":
+				m.TextInput.SetValue("")
+				m.TextInput.Blur()
+				m.Editing = false
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.TextInput, cmd = m.TextInput.Update(msg)
+			return m, cmd
+		}
+
+		switch msg.String() {
+		case "up", "k":
+			if m.Selected > 0 {
+				m.Selected--
+			}
+		case "down", "j":
+			if m.Selected < len(m.Aliases)-1 {
+				m.Selected++
+			}
+		case "a", "n":
+			// Enter the old account that will move here
+			m.Editing = true
+			m.Moving = false
+			return m, m.TextInput.Focus()
+		case "m":
+			// Enter the new account to move to
+			m.Editing = true
+			m.Moving = true
+			return m, m.TextInput.Focus()
+		case "d":
+			if len(m.Aliases) > 0 && m.Selected < len(m.Aliases) {
+				return m, removeAliasCmd(m.AccountId, m.Aliases[m.Selected])
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m Model) View() string {
+	var s strings.Builder
+
+	s.WriteString(common.CaptionStyle.Render("move account"))
+	s.WriteString("\n\n")
+
+	if m.MovedTo != "" {
+		s.WriteString(warningStyle.Render("This account moved to " + m.MovedTo))
+		s.WriteString("\n\n")
+	}
+
+	if m.Confirming {
+		s.WriteString(warningStyle.Render(fmt.Sprintf("Move this account to %s?", m.Target)))
+		s.WriteString("\n\n")
+		s.WriteString("Your followers will be asked to follow the new account\n")
+		s.WriteString("and this account will point to it.\n\n")
+		s.WriteString("Press y to move or n to cancel.")
+		return s.String()
+	}
+
+	if m.Editing {
+		if m.Moving {
+			s.WriteString("Move to another account:\n")
+			s.WriteString("(it has to list this account as an alias first)\n\n")
+		} else {
+			s.WriteString("Add an account that will move here:\n")
+			s.WriteString("(e.g., @user@mastodon.social)\n\n")
+		}
+		s.WriteString(m.TextInput.View())
+		s.WriteString("\n\n")
+	}
+
+	s.WriteString(fmt.Sprintf("Aliases (%d):\n\n", len(m.Aliases)))
+	if len(m.Aliases) == 0 {
+		s.WriteString(emptyStyle.Render("No aliases yet.\nPress a to add the account you are moving from."))
+	} else {
+		displayCount := min(len(m.Aliases), 10)
+		for i := 0; i < displayCount; i++ {
+			text := "• " + m.Aliases[i]
+			if i == m.Selected && !m.Editing {
+				s.WriteString("→ " + selectedStyle.Render(text))
+			} else {
+				s.WriteString("  " + itemStyle.Render(text))
+			}
+			s.WriteString("\n")
+		}
+
+		if len(m.Aliases) > 10 {
+			s.WriteString(itemStyle.Render(fmt.Sprintf("... and %d more", len(m.Aliases)-10)))
+			s.WriteString("\n")
+		}
+	}
+
+	s.WriteString("\n\n")
+
+	if m.Status != "" {
+		s.WriteString(statusStyle.Render(m.Status))
+		s.WriteString("\n\n")
+	}
+
+	if m.Error != "" {
+		s.WriteString(errorStyle.Render(m.Error))
+		s.WriteString("\n\n")
+	}
+
+	return s.String()
+}
+
+// accountLoadedMsg is sent when the aliases and the move of the account are loaded
+type accountLoadedMsg struct {
+	aliases []string
+	movedTo string
+}
+
+// aliasResultMsg is sent when an alias was added or removed
+type aliasResultMsg struct {
+	status string
+	err    error
+}
+
+// targetResolvedMsg is sent when the account to move to was checked
+type targetResolvedMsg struct {
+	target string
+	err    error
+}
+
+// moveResultMsg is sent when the Move was sent
+type moveResultMsg struct {
+	target string
+	err    error
+}
+
+// clearStatusMsg is sent after a delay to clear status/error messages
+type clearStatusMsg struct{}
+
+// clearStatusAfter returns a command that sends clearStatusMsg after a duration
+func clearStatusAfter(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(t time.Time) tea.Msg {
+		return clearStatusMsg{}
+	})
+}
+
+// loadAccount loads the aliases and the move of the given account
+func loadAccount(accountId uuid.UUID) tea.Cmd {
+	return func() tea.Msg {
+		err, acc := db.GetDB().ReadAccById(accountId)
+		if err != nil || acc == nil {
+			log.Printf("Failed to load account: %v", err)
+			return accountLoadedMsg{aliases: []string{}}
+		}
+		return accountLoadedMsg{aliases: acc.AlsoKnownAs, movedTo: acc.MovedTo}
+	}
+}
+
+// addAliasCmd adds an account of the user on another server to the aliases
+func addAliasCmd(accountId uuid.UUID, handle string) tea.Cmd {
+	return func() tea.Msg {
+		err, localAccount := db.GetDB().ReadAccById(accountId)
+		if err != nil {
+			return aliasResultMsg{err: fmt.Errorf("failed to get local account: %w", err)}
+		}
+
+		aliasURI, err := activitypub.AddAlias(localAccount, handle)
+		if err != nil {
+			return aliasResultMsg{err: err}
+		}
+		return aliasResultMsg{status: fmt.Sprintf("Added alias %s", aliasURI)}
+	}
+}
+
+// removeAliasCmd removes an alias
+func removeAliasCmd(accountId uuid.UUID, aliasURI string) tea.Cmd {
+	return func() tea.Msg {
+		err, localAccount := db.GetDB().ReadAccById(accountId)
+		if err != nil {
+			return aliasResultMsg{err: fmt.Errorf("failed to get local account: %w", err)}
+		}
+
+		if err := activitypub.RemoveAlias(localAccount, aliasURI); err != nil {
+			return aliasResultMsg{err: err}
+		}
+		return aliasResultMsg{status: fmt.Sprintf("Removed alias %s", aliasURI)}
+	}
+}
+
+// resolveTargetCmd checks that the account to move to lists this account as an alias
+func resolveTargetCmd(accountId uuid.UUID, handle string) tea.Cmd {
+	return func() tea.Msg {
+		err, localAccount := db.GetDB().ReadAccById(accountId)
+		if err != nil {
+			return targetResolvedMsg{err: fmt.Errorf("failed to get local account: %w", err)}
+		}
+
+		// Get config (TODO: pass from main)
+		conf, err := util.ReadConf()
+		if err != nil {
+			return targetResolvedMsg{err: fmt.Errorf("failed to read config: %w", err)}
+		}
+
+		target, err := activitypub.ResolveMoveTarget(localAccount, handle, conf)
+		return targetResolvedMsg{target: target, err: err}
+	}
+}
+
+// moveCmd moves the account and its followers to the target account
+func moveCmd(accountId uuid.UUID, target string) tea.Cmd {
+	return func() tea.Msg {
+		err, localAccount := db.GetDB().ReadAccById(accountId)
+		if err != nil {
+			return moveResultMsg{target: target, err: fmt.Errorf("failed to get local account: %w", err)}
+		}
+
+		conf, err := util.ReadConf()
+		if err != nil {
+			return moveResultMsg{target: target, err: fmt.Errorf("failed to read config: %w", err)}
+		}
+
+		err = activitypub.SendMove(localAccount, target, conf)
+		return moveResultMsg{target: target, err: err}
+	}
+}
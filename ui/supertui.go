@@ -10,16 +10,25 @@ import (
 	"github.com/deemkeen/stegodon/db"
 	"github.com/deemkeen/stegodon/domain"
 	"github.com/deemkeen/stegodon/ui/admin"
+	"github.com/deemkeen/stegodon/ui/blocklist"
 	"github.com/deemkeen/stegodon/ui/common"
 	"github.com/deemkeen/stegodon/ui/createuser"
 	"github.com/deemkeen/stegodon/ui/deleteaccount"
+	"github.com/deemkeen/stegodon/ui/deliveries"
+	"github.com/deemkeen/stegodon/ui/domainblocks"
 	"github.com/deemkeen/stegodon/ui/followers"
 	"github.com/deemkeen/stegodon/ui/following"
+	"github.com/deemkeen/stegodon/ui/followrequests"
 	"github.com/deemkeen/stegodon/ui/followuser"
 	"github.com/deemkeen/stegodon/ui/header"
+	"github.com/deemkeen/stegodon/ui/importexport"
+	"github.com/deemkeen/stegodon/ui/keychanges"
 	"github.com/deemkeen/stegodon/ui/listnotes"
 	"github.com/deemkeen/stegodon/ui/localtimeline"
 	"github.com/deemkeen/stegodon/ui/localusers"
+	"github.com/deemkeen/stegodon/ui/moveaccount"
+	"github.com/deemkeen/stegodon/ui/tagview"
+	"github.com/deemkeen/stegodon/ui/threadview"
 	"github.com/deemkeen/stegodon/ui/timeline"
 	"github.com/deemkeen/stegodon/ui/writenote"
 )
@@ -45,12 +54,23 @@ type MainModel struct {
 	listModel          listnotes.Model
 	followModel        followuser.Model
 	followersModel     followers.Model
+	requestsModel      followrequests.Model
 	followingModel     following.Model
+	blocksModel        blocklist.Model
 	timelineModel      timeline.Model
 	localTimelineModel localtimeline.Model
 	localUsersModel    localusers.Model
 	adminModel         admin.Model
+	domainBlocksModel  domainblocks.Model
+	deliveriesModel    deliveries.Model
+	keyChangesModel    keychanges.Model
+	moveAccountModel   moveaccount.Model
+	importExportModel  importexport.Model
 	deleteAccountModel deleteaccount.Model
+	threadModel        threadview.Model
+	threadReturnState  common.SessionState // View to return to when leaving the thread view
+	tagModel           tagview.Model
+	tagReturnState     common.SessionState // View to return to when leaving the tag view
 }
 
 func updateUserModelCmd(acc *domain.Account) tea.Cmd {
@@ -74,11 +94,18 @@ func NewModel(acc domain.Account, width int, height int) MainModel {
 	listModel := listnotes.NewPager(acc.Id, width, height)
 	followModel := followuser.InitialModel(acc.Id)
 	followersModel := followers.InitialModel(acc.Id, width, height)
+	requestsModel := followrequests.InitialModel(acc.Id, width, height)
 	followingModel := following.InitialModel(acc.Id, width, height)
+	blocksModel := blocklist.InitialModel(acc.Id, width, height)
 	timelineModel := timeline.InitialModel(acc.Id, width, height)
 	localTimelineModel := localtimeline.InitialModel(acc.Id, width, height)
 	localUsersModel := localusers.InitialModel(acc.Id, width, height)
 	adminModel := admin.InitialModel(acc.Id, width, height)
+	domainBlocksModel := domainblocks.InitialModel(width, height)
+	deliveriesModel := deliveries.InitialModel(width, height)
+	keyChangesModel := keychanges.InitialModel(width, height)
+	moveAccountModel := moveaccount.InitialModel(acc.Id, width, height)
+	importExportModel := importexport.InitialModel(acc.Id, width, height)
 	deleteAccountModel := deleteaccount.InitialModel(&acc)
 
 	m := MainModel{state: common.CreateUserView}
@@ -87,11 +114,18 @@ func NewModel(acc domain.Account, width int, height int) MainModel {
 	m.listModel = listModel
 	m.followModel = followModel
 	m.followersModel = followersModel
+	m.requestsModel = requestsModel
 	m.followingModel = followingModel
+	m.blocksModel = blocksModel
 	m.timelineModel = timelineModel
 	m.localTimelineModel = localTimelineModel
 	m.localUsersModel = localUsersModel
 	m.adminModel = adminModel
+	m.domainBlocksModel = domainBlocksModel
+	m.deliveriesModel = deliveriesModel
+	m.keyChangesModel = keyChangesModel
+	m.moveAccountModel = moveAccountModel
+	m.importExportModel = importExportModel
 	m.deleteAccountModel = deleteAccountModel
 	m.headerModel = headerModel
 	m.account = acc
@@ -166,14 +200,22 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.state = common.FollowUserView
 		case common.FollowersView:
 			m.state = common.FollowersView
+		case common.FollowRequestsView:
+			m.state = common.FollowRequestsView
 		case common.FollowingView:
 			m.state = common.FollowingView
+		case common.BlocksView:
+			m.state = common.BlocksView
 		case common.FederatedTimelineView:
 			m.state = common.FederatedTimelineView
 		case common.LocalTimelineView:
 			m.state = common.LocalTimelineView
 		case common.LocalUsersView:
 			m.state = common.LocalUsersView
+		case common.MoveAccountView:
+			m.state = common.MoveAccountView
+		case common.ImportExportView:
+			m.state = common.ImportExportView
 		case common.DeleteAccountView:
 			m.state = common.DeleteAccountView
 		case common.UpdateNoteList:
@@ -189,6 +231,31 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 		return m, tea.Batch(cmds...)
 
+	case common.ReplyNoteMsg:
+		// Route ReplyNote message to writenote model and switch to CreateNoteView
+		m.createModel, cmd = m.createModel.Update(msg)
+		m.state = common.CreateNoteView
+		cmds = append(cmds, cmd)
+		return m, tea.Batch(cmds...)
+
+	case common.ShowThreadMsg:
+		// Open the thread view, remembering where we came from
+		if m.state != common.ThreadView {
+			m.threadReturnState = m.state
+		}
+		m.threadModel = threadview.InitialModel(m.account.Id, msg.ObjectURI, m.width, m.height)
+		m.state = common.ThreadView
+		return m, m.threadModel.Init()
+
+	case common.ShowTagMsg:
+		// Open the tag view, remembering where we came from
+		if m.state != common.TagView {
+			m.tagReturnState = m.state
+		}
+		m.tagModel = tagview.InitialModel(m.account.Id, msg.Tag, m.width, m.height)
+		m.state = common.TagView
+		return m, m.tagModel.Init()
+
 	case common.DeleteNoteMsg:
 		// Note was deleted, reload the list
 		m.listModel = listnotes.NewPager(m.account.Id, m.width, m.height)
@@ -198,6 +265,17 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch msg.String() {
 		case "ctrl+c":
 			return m, tea.Quit
+		case "esc":
+			// Leave the thread view
+			if m.state == common.ThreadView {
+				m.state = m.threadReturnState
+				return m, getViewInitCmd(m.state, &m)
+			}
+			// Leave the tag view
+			if m.state == common.TagView {
+				m.state = m.tagReturnState
+				return m, getViewInitCmd(m.state, &m)
+			}
 		case "tab":
 			// Cycle through main views (excluding create user)
 			if m.state == common.CreateUserView {
@@ -216,19 +294,37 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case common.FollowUserView:
 				m.state = common.FollowersView
 			case common.FollowersView:
+				m.state = common.FollowRequestsView
+			case common.FollowRequestsView:
 				m.state = common.FollowingView
 			case common.FollowingView:
+				m.state = common.BlocksView
+			case common.BlocksView:
 				m.state = common.LocalUsersView
 			case common.LocalUsersView:
 				if m.account.IsAdmin {
 					m.state = common.AdminPanelView
 				} else {
-					m.state = common.DeleteAccountView
+					m.state = common.MoveAccountView
 				}
 			case common.AdminPanelView:
+				m.state = common.DomainBlocksView
+			case common.DomainBlocksView:
+				m.state = common.DeliveriesView
+			case common.DeliveriesView:
+				m.state = common.KeyChangesView
+			case common.KeyChangesView:
+				m.state = common.MoveAccountView
+			case common.MoveAccountView:
+				m.state = common.ImportExportView
+			case common.ImportExportView:
 				m.state = common.DeleteAccountView
 			case common.DeleteAccountView:
 				m.state = common.CreateNoteView
+			case common.ThreadView:
+				m.state = m.threadReturnState
+			case common.TagView:
+				m.state = m.tagReturnState
 			}
 			// Reload data when switching to certain views
 			if oldState != m.state {
@@ -254,18 +350,36 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.state = common.LocalTimelineView
 			case common.FollowersView:
 				m.state = common.FollowUserView
-			case common.FollowingView:
+			case common.FollowRequestsView:
 				m.state = common.FollowersView
-			case common.LocalUsersView:
+			case common.FollowingView:
+				m.state = common.FollowRequestsView
+			case common.BlocksView:
 				m.state = common.FollowingView
+			case common.LocalUsersView:
+				m.state = common.BlocksView
 			case common.AdminPanelView:
 				m.state = common.LocalUsersView
-			case common.DeleteAccountView:
+			case common.DomainBlocksView:
+				m.state = common.AdminPanelView
+			case common.DeliveriesView:
+				m.state = common.DomainBlocksView
+			case common.KeyChangesView:
+				m.state = common.DeliveriesView
+			case common.MoveAccountView:
 				if m.account.IsAdmin {
-					m.state = common.AdminPanelView
+					m.state = common.KeyChangesView
 				} else {
 					m.state = common.LocalUsersView
 				}
+			case common.ImportExportView:
+				m.state = common.MoveAccountView
+			case common.DeleteAccountView:
+				m.state = common.ImportExportView
+			case common.ThreadView:
+				m.state = m.threadReturnState
+			case common.TagView:
+				m.state = m.tagReturnState
 			}
 			// Reload data when switching to certain views
 			if oldState != m.state {
@@ -306,8 +420,12 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 		m.followersModel, cmd = m.followersModel.Update(msg)
 		cmds = append(cmds, cmd)
+		m.requestsModel, cmd = m.requestsModel.Update(msg)
+		cmds = append(cmds, cmd)
 		m.followingModel, cmd = m.followingModel.Update(msg)
 		cmds = append(cmds, cmd)
+		m.blocksModel, cmd = m.blocksModel.Update(msg)
+		cmds = append(cmds, cmd)
 		m.timelineModel, cmd = m.timelineModel.Update(msg)
 		cmds = append(cmds, cmd)
 		m.localTimelineModel, cmd = m.localTimelineModel.Update(msg)
@@ -316,6 +434,10 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 		m.listModel, cmd = m.listModel.Update(msg)
 		cmds = append(cmds, cmd)
+		m.threadModel, cmd = m.threadModel.Update(msg)
+		cmds = append(cmds, cmd)
+		m.tagModel, cmd = m.tagModel.Update(msg)
+		cmds = append(cmds, cmd)
 	}
 
 	// Route keyboard input ONLY to active model
@@ -331,8 +453,12 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.followModel, cmd = m.followModel.Update(msg)
 		case common.FollowersView:
 			m.followersModel, cmd = m.followersModel.Update(msg)
+		case common.FollowRequestsView:
+			m.requestsModel, cmd = m.requestsModel.Update(msg)
 		case common.FollowingView:
 			m.followingModel, cmd = m.followingModel.Update(msg)
+		case common.BlocksView:
+			m.blocksModel, cmd = m.blocksModel.Update(msg)
 		case common.FederatedTimelineView:
 			m.timelineModel, cmd = m.timelineModel.Update(msg)
 		case common.LocalTimelineView:
@@ -341,8 +467,22 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.localUsersModel, cmd = m.localUsersModel.Update(msg)
 		case common.AdminPanelView:
 			m.adminModel, cmd = m.adminModel.Update(msg)
+		case common.DomainBlocksView:
+			m.domainBlocksModel, cmd = m.domainBlocksModel.Update(msg)
+		case common.DeliveriesView:
+			m.deliveriesModel, cmd = m.deliveriesModel.Update(msg)
+		case common.KeyChangesView:
+			m.keyChangesModel, cmd = m.keyChangesModel.Update(msg)
+		case common.MoveAccountView:
+			m.moveAccountModel, cmd = m.moveAccountModel.Update(msg)
+		case common.ImportExportView:
+			m.importExportModel, cmd = m.importExportModel.Update(msg)
 		case common.DeleteAccountView:
 			m.deleteAccountModel, cmd = m.deleteAccountModel.Update(msg)
+		case common.ThreadView:
+			m.threadModel, cmd = m.threadModel.Update(msg)
+		case common.TagView:
+			m.tagModel, cmd = m.tagModel.Update(msg)
 		}
 		cmds = append(cmds, cmd)
 	} else {
@@ -354,6 +494,21 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case common.AdminPanelView:
 			m.adminModel, cmd = m.adminModel.Update(msg)
 			cmds = append(cmds, cmd)
+		case common.DomainBlocksView:
+			m.domainBlocksModel, cmd = m.domainBlocksModel.Update(msg)
+			cmds = append(cmds, cmd)
+		case common.DeliveriesView:
+			m.deliveriesModel, cmd = m.deliveriesModel.Update(msg)
+			cmds = append(cmds, cmd)
+		case common.KeyChangesView:
+			m.keyChangesModel, cmd = m.keyChangesModel.Update(msg)
+			cmds = append(cmds, cmd)
+		case common.MoveAccountView:
+			m.moveAccountModel, cmd = m.moveAccountModel.Update(msg)
+			cmds = append(cmds, cmd)
+		case common.ImportExportView:
+			m.importExportModel, cmd = m.importExportModel.Update(msg)
+			cmds = append(cmds, cmd)
 		case common.FollowersView:
 			m.followersModel, cmd = m.followersModel.Update(msg)
 			cmds = append(cmds, cmd)
@@ -439,6 +594,14 @@ func (m MainModel) View() string {
 		Margin(1).
 		Render(m.followersModel.View())
 
+	requestsStyleStr := lipgloss.NewStyle().
+		MaxHeight(availableHeight).
+		Height(availableHeight).
+		Width(rightPanelWidth).
+		MaxWidth(rightPanelWidth).
+		Margin(1).
+		Render(m.requestsModel.View())
+
 	followingStyleStr := lipgloss.NewStyle().
 		MaxHeight(availableHeight).
 		Height(availableHeight).
@@ -447,6 +610,14 @@ func (m MainModel) View() string {
 		Margin(1).
 		Render(m.followingModel.View())
 
+	blocksStyleStr := lipgloss.NewStyle().
+		MaxHeight(availableHeight).
+		Height(availableHeight).
+		Width(rightPanelWidth).
+		MaxWidth(rightPanelWidth).
+		Margin(1).
+		Render(m.blocksModel.View())
+
 	timelineStyleStr := lipgloss.NewStyle().
 		MaxHeight(availableHeight).
 		Height(availableHeight).
@@ -479,6 +650,46 @@ func (m MainModel) View() string {
 		Margin(1).
 		Render(m.adminModel.View())
 
+	domainBlocksStyleStr := lipgloss.NewStyle().
+		MaxHeight(availableHeight).
+		Height(availableHeight).
+		Width(rightPanelWidth).
+		MaxWidth(rightPanelWidth).
+		Margin(1).
+		Render(m.domainBlocksModel.View())
+
+	deliveriesStyleStr := lipgloss.NewStyle().
+		MaxHeight(availableHeight).
+		Height(availableHeight).
+		Width(rightPanelWidth).
+		MaxWidth(rightPanelWidth).
+		Margin(1).
+		Render(m.deliveriesModel.View())
+
+	keyChangesStyleStr := lipgloss.NewStyle().
+		MaxHeight(availableHeight).
+		Height(availableHeight).
+		Width(rightPanelWidth).
+		MaxWidth(rightPanelWidth).
+		Margin(1).
+		Render(m.keyChangesModel.View())
+
+	moveAccountStyleStr := lipgloss.NewStyle().
+		MaxHeight(availableHeight).
+		Height(availableHeight).
+		Width(rightPanelWidth).
+		MaxWidth(rightPanelWidth).
+		Margin(1).
+		Render(m.moveAccountModel.View())
+
+	importExportStyleStr := lipgloss.NewStyle().
+		MaxHeight(availableHeight).
+		Height(availableHeight).
+		Width(rightPanelWidth).
+		MaxWidth(rightPanelWidth).
+		Margin(1).
+		Render(m.importExportModel.View())
+
 	deleteAccountStyleStr := lipgloss.NewStyle().
 		MaxHeight(availableHeight).
 		Height(availableHeight).
@@ -487,6 +698,22 @@ func (m MainModel) View() string {
 		Margin(1).
 		Render(m.deleteAccountModel.View())
 
+	threadStyleStr := lipgloss.NewStyle().
+		MaxHeight(availableHeight).
+		Height(availableHeight).
+		Width(rightPanelWidth).
+		MaxWidth(rightPanelWidth).
+		Margin(1).
+		Render(m.threadModel.View())
+
+	tagStyleStr := lipgloss.NewStyle().
+		MaxHeight(availableHeight).
+		Height(availableHeight).
+		Width(rightPanelWidth).
+		MaxWidth(rightPanelWidth).
+		Margin(1).
+		Render(m.tagModel.View())
+
 	if m.state == common.CreateUserView {
 		s = m.newUserModel.ViewWithWidth(m.width, m.height)
 		return s
@@ -512,10 +739,18 @@ func (m MainModel) View() string {
 			s += lipgloss.JoinHorizontal(lipgloss.Top,
 				modelStyle.Render(createStyleStr),
 				focusedModelStyle.Render(followersStyleStr))
+		case common.FollowRequestsView:
+			s += lipgloss.JoinHorizontal(lipgloss.Top,
+				modelStyle.Render(createStyleStr),
+				focusedModelStyle.Render(requestsStyleStr))
 		case common.FollowingView:
 			s += lipgloss.JoinHorizontal(lipgloss.Top,
 				modelStyle.Render(createStyleStr),
 				focusedModelStyle.Render(followingStyleStr))
+		case common.BlocksView:
+			s += lipgloss.JoinHorizontal(lipgloss.Top,
+				modelStyle.Render(createStyleStr),
+				focusedModelStyle.Render(blocksStyleStr))
 		case common.FederatedTimelineView:
 			s += lipgloss.JoinHorizontal(lipgloss.Top,
 				modelStyle.Render(createStyleStr),
@@ -532,33 +767,103 @@ func (m MainModel) View() string {
 			s += lipgloss.JoinHorizontal(lipgloss.Top,
 				modelStyle.Render(createStyleStr),
 				focusedModelStyle.Render(adminStyleStr))
+		case common.DomainBlocksView:
+			s += lipgloss.JoinHorizontal(lipgloss.Top,
+				modelStyle.Render(createStyleStr),
+				focusedModelStyle.Render(domainBlocksStyleStr))
+		case common.DeliveriesView:
+			s += lipgloss.JoinHorizontal(lipgloss.Top,
+				modelStyle.Render(createStyleStr),
+				focusedModelStyle.Render(deliveriesStyleStr))
+		case common.KeyChangesView:
+			s += lipgloss.JoinHorizontal(lipgloss.Top,
+				modelStyle.Render(createStyleStr),
+				focusedModelStyle.Render(keyChangesStyleStr))
+		case common.MoveAccountView:
+			s += lipgloss.JoinHorizontal(lipgloss.Top,
+				modelStyle.Render(createStyleStr),
+				focusedModelStyle.Render(moveAccountStyleStr))
+		case common.ImportExportView:
+			s += lipgloss.JoinHorizontal(lipgloss.Top,
+				modelStyle.Render(createStyleStr),
+				focusedModelStyle.Render(importExportStyleStr))
 		case common.DeleteAccountView:
 			s += lipgloss.JoinHorizontal(lipgloss.Top,
 				modelStyle.Render(createStyleStr),
 				focusedModelStyle.Render(deleteAccountStyleStr))
+		case common.ThreadView:
+			s += lipgloss.JoinHorizontal(lipgloss.Top,
+				modelStyle.Render(createStyleStr),
+				focusedModelStyle.Render(threadStyleStr))
+		case common.TagView:
+			s += lipgloss.JoinHorizontal(lipgloss.Top,
+				modelStyle.Render(createStyleStr),
+				focusedModelStyle.Render(tagStyleStr))
 		}
 
 		// Help text
 		var viewCommands string
 		switch m.state {
 		case common.ListNotesView:
-			viewCommands = "↑/↓: select • u: edit • d: delete"
+			viewCommands = "↑/↓: select • u: edit • d: delete • r: reply • t: thread"
 		case common.FollowUserView:
 			viewCommands = "enter: follow"
 		case common.FollowersView:
-			viewCommands = "↑/↓: scroll"
+			viewCommands = "↑/↓: scroll • h: hide/show follow lists"
+		case common.FollowRequestsView:
+			viewCommands = "↑/↓: select • a/enter: accept • x: reject • l: lock/unlock account"
 		case common.FollowingView:
 			viewCommands = "↑/↓: select • u/enter: unfollow"
+		case common.BlocksView:
+			if m.blocksModel.Editing {
+				viewCommands = "enter: block • esc: cancel"
+			} else {
+				viewCommands = "↑/↓: select • n: block user or domain • u: unblock"
+			}
 		case common.FederatedTimelineView:
-			viewCommands = "↑/↓: select • o: open URL"
+			viewCommands = "↑/↓: select • o: open URL • r: reply • t: thread • #: tag • l: like/unlike • b: boost/unboost"
 		case common.LocalTimelineView:
-			viewCommands = "↑/↓: scroll"
+			viewCommands = "↑/↓: scroll • #: tag • b: boost/unboost"
 		case common.LocalUsersView:
 			viewCommands = "↑/↓: select • enter: toggle follow"
 		case common.AdminPanelView:
 			viewCommands = "↑/↓: select • m: mute • k: kick"
+		case common.DomainBlocksView:
+			if m.domainBlocksModel.Editing {
+				viewCommands = "enter: block domain • esc: cancel"
+			} else {
+				viewCommands = "↑/↓: select • n: new • s: severity • m: reject media • d: unblock • i/e: import/export CSV"
+			}
+		case common.DeliveriesView:
+			if m.deliveriesModel.Confirming {
+				viewCommands = "y: purge • n: cancel"
+			} else {
+				viewCommands = "↑/↓: select • r: retry now • a: requeue dead • p: purge dead • f: refresh"
+			}
+		case common.KeyChangesView:
+			viewCommands = "↑/↓: select • f: refresh"
+		case common.MoveAccountView:
+			if m.moveAccountModel.Confirming {
+				viewCommands = "y: move • n: cancel"
+			} else if m.moveAccountModel.Editing && m.moveAccountModel.Moving {
+				viewCommands = "enter: check account • esc: cancel"
+			} else if m.moveAccountModel.Editing {
+				viewCommands = "enter: add alias • esc: cancel"
+			} else {
+				viewCommands = "↑/↓: select • a: add alias • d: remove alias • m: move account"
+			}
+		case common.ImportExportView:
+			viewCommands = "↑/↓: select • e: prepare archive • f: refresh"
 		case common.DeleteAccountView:
 			viewCommands = "y: confirm • n/esc: cancel"
+		case common.ThreadView:
+			viewCommands = "↑/↓: select • r: reply • esc: back"
+		case common.TagView:
+			if m.tagModel.Editing {
+				viewCommands = "enter: show tag • esc: back"
+			} else {
+				viewCommands = "↑/↓: select • r: reply • t: thread • #: other tag • esc: back"
+			}
 		default:
 			viewCommands = " "
 		}
@@ -595,8 +900,12 @@ func (m MainModel) currentFocusedModel() string {
 		return "follow user"
 	case common.FollowersView:
 		return "followers"
+	case common.FollowRequestsView:
+		return "follow requests"
 	case common.FollowingView:
 		return "following"
+	case common.BlocksView:
+		return "blocked"
 	case common.FederatedTimelineView:
 		return "federated timeline"
 	case common.LocalTimelineView:
@@ -605,8 +914,22 @@ func (m MainModel) currentFocusedModel() string {
 		return "local users"
 	case common.AdminPanelView:
 		return "admin panel"
+	case common.DomainBlocksView:
+		return "domain blocks"
+	case common.DeliveriesView:
+		return "deliveries"
+	case common.KeyChangesView:
+		return "key changes"
+	case common.MoveAccountView:
+		return "move account"
+	case common.ImportExportView:
+		return "import & export"
 	case common.DeleteAccountView:
 		return "delete account"
+	case common.ThreadView:
+		return "thread"
+	case common.TagView:
+		return "tag"
 	default:
 		return "create user"
 	}
@@ -617,8 +940,12 @@ func getViewInitCmd(state common.SessionState, m *MainModel) tea.Cmd {
 	switch state {
 	case common.FollowersView:
 		return m.followersModel.Init()
+	case common.FollowRequestsView:
+		return m.requestsModel.Init()
 	case common.FollowingView:
 		return m.followingModel.Init()
+	case common.BlocksView:
+		return m.blocksModel.Init()
 	case common.FederatedTimelineView:
 		return m.timelineModel.Init()
 	case common.LocalTimelineView:
@@ -627,6 +954,16 @@ func getViewInitCmd(state common.SessionState, m *MainModel) tea.Cmd {
 		return m.localUsersModel.Init()
 	case common.AdminPanelView:
 		return m.adminModel.Init()
+	case common.DomainBlocksView:
+		return m.domainBlocksModel.Init()
+	case common.DeliveriesView:
+		return m.deliveriesModel.Init()
+	case common.KeyChangesView:
+		return m.keyChangesModel.Init()
+	case common.MoveAccountView:
+		return m.moveAccountModel.Init()
+	case common.ImportExportView:
+		return m.importExportModel.Init()
 	case common.ListNotesView:
 		return m.listModel.Init()
 	default:
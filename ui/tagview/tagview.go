@@ -0,0 +1,367 @@
+package tagview
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/ui/common"
+	"github.com/deemkeen/stegodon/util"
+	"github.com/google/uuid"
+)
+
+// Number of local and remote posts loaded per tag
+const postLimit = 50
+
+var (
+	timeStyle = lipgloss.NewStyle().
+			Align(lipgloss.Left).
+			Foreground(lipgloss.Color(common.COLOR_DARK_GREY))
+
+	authorStyle = lipgloss.NewStyle().
+			Align(lipgloss.Left).
+			Foreground(lipgloss.Color(common.COLOR_GREEN)).
+			Bold(true)
+
+	contentStyle = lipgloss.NewStyle().
+			Align(lipgloss.Left)
+
+	emptyStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_DARK_GREY)).
+			Italic(true)
+
+	// Inverted styles for the selected post
+	selectedTimeStyle = lipgloss.NewStyle().
+				Align(lipgloss.Left).
+				Foreground(lipgloss.Color(common.COLOR_WHITE))
+
+	selectedAuthorStyle = lipgloss.NewStyle().
+				Align(lipgloss.Left).
+				Foreground(lipgloss.Color(common.COLOR_WHITE)).
+				Bold(true)
+
+	selectedContentStyle = lipgloss.NewStyle().
+				Align(lipgloss.Left).
+				Foreground(lipgloss.Color(common.COLOR_WHITE))
+)
+
+type Model struct {
+	AccountId uuid.UUID
+	Tag       string // Normalized tag without #
+	TextInput textinput.Model
+	Editing   bool // Whether the tag input has focus
+	Posts     []TagPost
+	Offset    int // Pagination offset
+	Selected  int // Currently selected post index
+	Loaded    bool
+	Width     int
+	Height    int
+}
+
+type TagPost struct {
+	Author    string
+	Content   string
+	Time      time.Time
+	ObjectURI string
+}
+
+func InitialModel(accountId uuid.UUID, tag string, width, height int) Model {
+	tag = util.NormalizeHashtag(tag)
+
+	ti := textinput.New()
+	ti.Placeholder = "tag"
+	ti.Prompt = "#"
+	ti.CharLimit = 100
+	ti.Width = 30
+	ti.SetValue(tag)
+
+	m := Model{
+		AccountId: accountId,
+		Tag:       tag,
+		TextInput: ti,
+		Editing:   tag == "",
+		Posts:     []TagPost{},
+		Offset:    0,
+		Selected:  0,
+		Loaded:    false,
+		Width:     width,
+		Height:    height,
+	}
+	if m.Editing {
+		m.TextInput.Focus()
+	}
+	return m
+}
+
+func (m Model) Init() tea.Cmd {
+	if m.Tag == "" {
+		return textinput.Blink
+	}
+	return loadTagPosts(m.Tag)
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tagLoadedMsg:
+		if msg.tag != m.Tag {
+			return m, nil
+		}
+		m.Posts = msg.posts
+		m.Loaded = true
+		m.Selected = 0
+		m.Offset = 0
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.Editing {
+			if msg.String() == "enter" {
+				tag := util.NormalizeHashtag(m.TextInput.Value())
+				if tag == "" {
+					return m, nil
+				}
+				m.Tag = tag
+				m.TextInput.SetValue(tag)
+				m.TextInput.Blur()
+				m.Editing = false
+				m.Loaded = false
+				return m, loadTagPosts(tag)
+			}
+			var cmd tea.Cmd
+			m.TextInput, cmd = m.TextInput.Update(msg)
+			return m, cmd
+		}
+
+		switch msg.String() {
+		case "up", "k":
+			if m.Selected > 0 {
+				m.Selected--
+				m.Offset = m.Selected
+			}
+		case "down", "j":
+			if len(m.Posts) > 0 && m.Selected < len(m.Posts)-1 {
+				m.Selected++
+				m.Offset = m.Selected
+			}
+		case "#", "/":
+			// Look up another tag
+			m.Editing = true
+			m.TextInput.SetValue("")
+			return m, m.TextInput.Focus()
+		case "r":
+			// Reply to the selected post
+			if len(m.Posts) > 0 && m.Selected < len(m.Posts) {
+				selectedPost := m.Posts[m.Selected]
+				return m, func() tea.Msg {
+					return common.ReplyNoteMsg{
+						InReplyToURI: selectedPost.ObjectURI,
+						Handle:       selectedPost.Author,
+					}
+				}
+			}
+		case "t":
+			// Show the conversation around the selected post
+			if len(m.Posts) > 0 && m.Selected < len(m.Posts) {
+				selectedPost := m.Posts[m.Selected]
+				return m, func() tea.Msg {
+					return common.ShowThreadMsg{ObjectURI: selectedPost.ObjectURI}
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m Model) View() string {
+	var s strings.Builder
+
+	if m.Tag == "" {
+		s.WriteString(common.CaptionStyle.Render("posts by tag"))
+	} else {
+		s.WriteString(common.CaptionStyle.Render(fmt.Sprintf("#%s (%d posts)", m.Tag, len(m.Posts))))
+	}
+	s.WriteString("\n\n")
+
+	if m.Editing {
+		s.WriteString(m.TextInput.View())
+		s.WriteString("\n\n")
+		s.WriteString(emptyStyle.Render("Enter a tag and press enter."))
+		return s.String()
+	}
+
+	if !m.Loaded {
+		s.WriteString(emptyStyle.Render("Loading posts..."))
+		return s.String()
+	}
+
+	if len(m.Posts) == 0 {
+		s.WriteString(emptyStyle.Render("No posts with this tag yet."))
+		return s.String()
+	}
+
+	leftPanelWidth := m.Width / 3
+	rightPanelWidth := m.Width - leftPanelWidth - 6
+
+	itemsPerPage := 5
+	start := m.Offset
+	end := start + itemsPerPage
+	if end > len(m.Posts) {
+		end = len(m.Posts)
+	}
+
+	for i := start; i < end; i++ {
+		post := m.Posts[i]
+		timeStr := formatTime(post.Time)
+
+		if i == m.Selected {
+			selectedBg := lipgloss.NewStyle().
+				Background(lipgloss.Color(common.COLOR_LIGHTBLUE)).
+				Width(rightPanelWidth - 4)
+
+			s.WriteString(selectedBg.Render(selectedTimeStyle.Render(timeStr)) + "\n")
+			s.WriteString(selectedBg.Render(selectedAuthorStyle.Render(post.Author)) + "\n")
+			s.WriteString(selectedBg.Render(selectedContentStyle.Render(truncate(post.Content, 150))))
+		} else {
+			unselectedStyle := lipgloss.NewStyle().Width(rightPanelWidth - 4)
+
+			s.WriteString(unselectedStyle.Render(timeStyle.Render(timeStr)) + "\n")
+			s.WriteString(unselectedStyle.Render(authorStyle.Render(post.Author)) + "\n")
+			s.WriteString(unselectedStyle.Render(contentStyle.Render(truncate(post.Content, 150))))
+		}
+
+		s.WriteString("\n\n")
+	}
+
+	return s.String()
+}
+
+// tagLoadedMsg is sent when the posts of a tag are loaded
+type tagLoadedMsg struct {
+	tag   string
+	posts []TagPost
+}
+
+// loadTagPosts collects local notes and stored remote posts with a tag, newest first
+func loadTagPosts(tag string) tea.Cmd {
+	return func() tea.Msg {
+		database := db.GetDB()
+		posts := []TagPost{}
+
+		conf, err := util.ReadConf()
+		if err != nil {
+			log.Printf("Failed to read config for tag view: %v", err)
+			return tagLoadedMsg{tag: tag, posts: posts}
+		}
+
+		err, notes := database.ReadPublicNotesByTag(tag, postLimit, 0)
+		if err != nil {
+			log.Printf("Failed to load local posts for tag %s: %v", tag, err)
+		} else if notes != nil {
+			for _, note := range *notes {
+				objectURI := note.ObjectURI
+				if objectURI == "" {
+					objectURI = fmt.Sprintf("https://%s/notes/%s", conf.Conf.SslDomain, note.Id.String())
+				}
+				posts = append(posts, TagPost{
+					Author:    "@" + note.CreatedBy,
+					Content:   note.Message,
+					Time:      note.CreatedAt,
+					ObjectURI: objectURI,
+				})
+			}
+		}
+
+		err, activities := database.ReadActivitiesByTag(tag, postLimit)
+		if err != nil {
+			log.Printf("Failed to load remote posts for tag %s: %v", tag, err)
+		} else if activities != nil {
+			for _, activity := range *activities {
+				if post := parseRemotePost(activity.RawJSON, activity.ActorURI, activity.CreatedAt); post != nil {
+					posts = append(posts, *post)
+				}
+			}
+		}
+
+		sort.SliceStable(posts, func(i, j int) bool {
+			return posts[i].Time.After(posts[j].Time)
+		})
+		return tagLoadedMsg{tag: tag, posts: posts}
+	}
+}
+
+// parseRemotePost extracts a post from a stored Create activity
+func parseRemotePost(rawJSON string, actorURI string, createdAt time.Time) *TagPost {
+	var create struct {
+		Object struct {
+			ID      string `json:"id"`
+			Content string `json:"content"`
+		} `json:"object"`
+	}
+	if err := json.Unmarshal([]byte(rawJSON), &create); err != nil {
+		log.Printf("Failed to parse tagged post JSON: %v", err)
+		return nil
+	}
+	if create.Object.ID == "" {
+		return nil
+	}
+
+	handle := actorURI
+	err, remoteAcc := db.GetDB().ReadRemoteAccountByActorURI(actorURI)
+	if err == nil && remoteAcc != nil {
+		handle = "@" + remoteAcc.Username + "@" + remoteAcc.Domain
+	}
+
+	return &TagPost{
+		Author:    handle,
+		Content:   stripHTMLTags(create.Object.Content),
+		Time:      createdAt,
+		ObjectURI: create.Object.ID,
+	}
+}
+
+var htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
+
+// stripHTMLTags removes HTML tags from a string and converts common HTML entities
+func stripHTMLTags(html string) string {
+	text := htmlTagRegex.ReplaceAllString(html, "")
+
+	text = strings.ReplaceAll(text, "&lt;", "<")
+	text = strings.ReplaceAll(text, "&gt;", ">")
+	text = strings.ReplaceAll(text, "&amp;", "&")
+	text = strings.ReplaceAll(text, "&quot;", "\"")
+	text = strings.ReplaceAll(text, "&#39;", "'")
+	text = strings.ReplaceAll(text, "&nbsp;", " ")
+
+	return strings.TrimSpace(text)
+}
+
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen-3] + "..."
+}
+
+func formatTime(t time.Time) string {
+	duration := time.Since(t)
+
+	if duration < time.Minute {
+		return "just now"
+	} else if duration < time.Hour {
+		mins := int(duration.Minutes())
+		return fmt.Sprintf("%dm ago", mins)
+	} else if duration < 24*time.Hour {
+		hours := int(duration.Hours())
+		return fmt.Sprintf("%dh ago", hours)
+	} else {
+		days := int(duration.Hours() / 24)
+		return fmt.Sprintf("%dd ago", days)
+	}
+}
@@ -0,0 +1,403 @@
+package threadview
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/ui/common"
+	"github.com/deemkeen/stegodon/util"
+	"github.com/google/uuid"
+)
+
+// Limits to keep thread loading bounded on long or cyclic conversations
+const (
+	maxAncestors = 20
+	maxDepth     = 10
+)
+
+var (
+	timeStyle = lipgloss.NewStyle().
+			Align(lipgloss.Left).
+			Foreground(lipgloss.Color(common.COLOR_DARK_GREY))
+
+	authorStyle = lipgloss.NewStyle().
+			Align(lipgloss.Left).
+			Foreground(lipgloss.Color(common.COLOR_GREEN)).
+			Bold(true)
+
+	contentStyle = lipgloss.NewStyle().
+			Align(lipgloss.Left)
+
+	emptyStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_DARK_GREY)).
+			Italic(true)
+
+	// Inverted styles for the selected post
+	selectedTimeStyle = lipgloss.NewStyle().
+				Align(lipgloss.Left).
+				Foreground(lipgloss.Color(common.COLOR_WHITE))
+
+	selectedAuthorStyle = lipgloss.NewStyle().
+				Align(lipgloss.Left).
+				Foreground(lipgloss.Color(common.COLOR_WHITE)).
+				Bold(true)
+
+	selectedContentStyle = lipgloss.NewStyle().
+				Align(lipgloss.Left).
+				Foreground(lipgloss.Color(common.COLOR_WHITE))
+)
+
+type Model struct {
+	AccountId uuid.UUID
+	ObjectURI string // Post the thread was opened from
+	Posts     []ThreadPost
+	Offset    int // Pagination offset
+	Selected  int // Currently selected post index
+	Loaded    bool
+	Width     int
+	Height    int
+}
+
+type ThreadPost struct {
+	Author    string
+	Content   string
+	Time      time.Time
+	ObjectURI string
+	InReplyTo string
+	Depth     int // Nesting level below the thread root
+}
+
+func InitialModel(accountId uuid.UUID, objectURI string, width, height int) Model {
+	return Model{
+		AccountId: accountId,
+		ObjectURI: objectURI,
+		Posts:     []ThreadPost{},
+		Offset:    0,
+		Selected:  0,
+		Loaded:    false,
+		Width:     width,
+		Height:    height,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return loadThread(m.ObjectURI)
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case threadLoadedMsg:
+		if msg.objectURI != m.ObjectURI {
+			return m, nil
+		}
+		m.Posts = msg.posts
+		m.Loaded = true
+		// Start with the post the thread was opened from
+		m.Selected = 0
+		for i, post := range m.Posts {
+			if post.ObjectURI == m.ObjectURI {
+				m.Selected = i
+				break
+			}
+		}
+		m.Offset = m.Selected
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if m.Selected > 0 {
+				m.Selected--
+				m.Offset = m.Selected
+			}
+		case "down", "j":
+			if len(m.Posts) > 0 && m.Selected < len(m.Posts)-1 {
+				m.Selected++
+				m.Offset = m.Selected
+			}
+		case "r":
+			// Reply to the selected post
+			if len(m.Posts) > 0 && m.Selected < len(m.Posts) {
+				selectedPost := m.Posts[m.Selected]
+				return m, func() tea.Msg {
+					return common.ReplyNoteMsg{
+						InReplyToURI: selectedPost.ObjectURI,
+						Handle:       selectedPost.Author,
+					}
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m Model) View() string {
+	var s strings.Builder
+
+	s.WriteString(common.CaptionStyle.Render(fmt.Sprintf("thread (%d posts)", len(m.Posts))))
+	s.WriteString("\n\n")
+
+	if !m.Loaded {
+		s.WriteString(emptyStyle.Render("Loading thread..."))
+		return s.String()
+	}
+
+	if len(m.Posts) == 0 {
+		s.WriteString(emptyStyle.Render("This post is not stored locally."))
+		return s.String()
+	}
+
+	leftPanelWidth := m.Width / 3
+	rightPanelWidth := m.Width - leftPanelWidth - 6
+
+	itemsPerPage := 5
+	start := m.Offset
+	end := start + itemsPerPage
+	if end > len(m.Posts) {
+		end = len(m.Posts)
+	}
+
+	for i := start; i < end; i++ {
+		post := m.Posts[i]
+		indent := strings.Repeat("  ", min(post.Depth, 4))
+		width := rightPanelWidth - 4 - len(indent)
+
+		timeStr := formatTime(post.Time)
+		if post.Depth > 0 {
+			timeStr = "↳ " + timeStr
+		}
+
+		if i == m.Selected {
+			selectedBg := lipgloss.NewStyle().
+				Background(lipgloss.Color(common.COLOR_LIGHTBLUE)).
+				Width(width)
+
+			s.WriteString(indent + selectedBg.Render(selectedTimeStyle.Render(timeStr)) + "\n")
+			s.WriteString(indent + selectedBg.Render(selectedAuthorStyle.Render(post.Author)) + "\n")
+			s.WriteString(indent + selectedBg.Render(selectedContentStyle.Render(truncate(post.Content, 150))))
+		} else {
+			unselectedStyle := lipgloss.NewStyle().Width(width)
+
+			s.WriteString(indent + unselectedStyle.Render(timeStyle.Render(timeStr)) + "\n")
+			s.WriteString(indent + unselectedStyle.Render(authorStyle.Render(post.Author)) + "\n")
+			s.WriteString(indent + unselectedStyle.Render(contentStyle.Render(truncate(post.Content, 150))))
+		}
+
+		s.WriteString("\n\n")
+	}
+
+	return s.String()
+}
+
+// threadLoadedMsg is sent when a thread is loaded
+type threadLoadedMsg struct {
+	objectURI string
+	posts     []ThreadPost
+}
+
+// loadThread walks up to the root of the conversation and collects all known replies below it
+func loadThread(objectURI string) tea.Cmd {
+	return func() tea.Msg {
+		conf, err := util.ReadConf()
+		if err != nil {
+			log.Printf("Failed to read config for thread view: %v", err)
+			return threadLoadedMsg{objectURI: objectURI}
+		}
+		localPrefix := fmt.Sprintf("https://%s/notes/", conf.Conf.SslDomain)
+
+		root := lookupPost(objectURI, localPrefix)
+		if root == nil {
+			return threadLoadedMsg{objectURI: objectURI}
+		}
+
+		// Find the start of the conversation
+		seen := map[string]bool{root.ObjectURI: true}
+		for i := 0; i < maxAncestors && root.InReplyTo != ""; i++ {
+			if seen[root.InReplyTo] {
+				break
+			}
+			parent := lookupPost(root.InReplyTo, localPrefix)
+			if parent == nil {
+				break
+			}
+			seen[parent.ObjectURI] = true
+			root = parent
+		}
+
+		posts := []ThreadPost{}
+		collectReplies(*root, 0, localPrefix, map[string]bool{}, &posts)
+		return threadLoadedMsg{objectURI: objectURI, posts: posts}
+	}
+}
+
+// collectReplies appends a post and all stored replies below it, depth-first in chronological order
+func collectReplies(post ThreadPost, depth int, localPrefix string, visited map[string]bool, posts *[]ThreadPost) {
+	if visited[post.ObjectURI] {
+		return
+	}
+	visited[post.ObjectURI] = true
+	post.Depth = depth
+	*posts = append(*posts, post)
+
+	if depth >= maxDepth {
+		return
+	}
+
+	replies := readReplies(post.ObjectURI, localPrefix)
+	sort.SliceStable(replies, func(i, j int) bool {
+		return replies[i].Time.Before(replies[j].Time)
+	})
+	for _, reply := range replies {
+		collectReplies(reply, depth+1, localPrefix, visited, posts)
+	}
+}
+
+// lookupPost resolves a post URI to a local note or a stored remote post
+func lookupPost(uri string, localPrefix string) *ThreadPost {
+	database := db.GetDB()
+
+	if strings.HasPrefix(uri, localPrefix) {
+		noteId, err := uuid.Parse(strings.TrimPrefix(uri, localPrefix))
+		if err != nil {
+			return nil
+		}
+		err, note := database.ReadNoteId(noteId)
+		if err != nil || note == nil {
+			return nil
+		}
+		return &ThreadPost{
+			Author:    "@" + note.CreatedBy,
+			Content:   note.Message,
+			Time:      note.CreatedAt,
+			ObjectURI: uri,
+			InReplyTo: note.InReplyToURI,
+		}
+	}
+
+	err, activity := database.ReadActivityByObjectURI(uri)
+	if err != nil || activity == nil {
+		return nil
+	}
+	return parseRemotePost(activity.RawJSON, activity.ActorURI, activity.CreatedAt)
+}
+
+// readReplies returns local and remote replies to a post
+func readReplies(uri string, localPrefix string) []ThreadPost {
+	database := db.GetDB()
+	var replies []ThreadPost
+
+	err, notes := database.ReadNotesInReplyTo(uri)
+	if err != nil {
+		log.Printf("Failed to load local replies to %s: %v", uri, err)
+	} else if notes != nil {
+		for _, note := range *notes {
+			replies = append(replies, ThreadPost{
+				Author:    "@" + note.CreatedBy,
+				Content:   note.Message,
+				Time:      note.CreatedAt,
+				ObjectURI: localPrefix + note.Id.String(),
+				InReplyTo: note.InReplyToURI,
+			})
+		}
+	}
+
+	err, activities := database.ReadReplyActivities(uri)
+	if err != nil {
+		log.Printf("Failed to load remote replies to %s: %v", uri, err)
+	} else if activities != nil {
+		for _, activity := range *activities {
+			if post := parseRemotePost(activity.RawJSON, activity.ActorURI, activity.CreatedAt); post != nil {
+				replies = append(replies, *post)
+			}
+		}
+	}
+
+	return replies
+}
+
+// parseRemotePost extracts a thread post from a stored Create activity
+func parseRemotePost(rawJSON string, actorURI string, createdAt time.Time) *ThreadPost {
+	var create struct {
+		Object struct {
+			ID        string `json:"id"`
+			Content   string `json:"content"`
+			InReplyTo string `json:"inReplyTo"`
+		} `json:"object"`
+	}
+	if err := json.Unmarshal([]byte(rawJSON), &create); err != nil {
+		log.Printf("Failed to parse thread post JSON: %v", err)
+		return nil
+	}
+	if create.Object.ID == "" {
+		return nil
+	}
+
+	handle := actorURI
+	err, remoteAcc := db.GetDB().ReadRemoteAccountByActorURI(actorURI)
+	if err == nil && remoteAcc != nil {
+		handle = "@" + remoteAcc.Username + "@" + remoteAcc.Domain
+	}
+
+	return &ThreadPost{
+		Author:    handle,
+		Content:   stripHTMLTags(create.Object.Content),
+		Time:      createdAt,
+		ObjectURI: create.Object.ID,
+		InReplyTo: create.Object.InReplyTo,
+	}
+}
+
+var htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
+
+// stripHTMLTags removes HTML tags from a string and converts common HTML entities
+func stripHTMLTags(html string) string {
+	text := htmlTagRegex.ReplaceAllString(html, "")
+
+	text = strings.ReplaceAll(text, "&lt;", "<")
+	text = strings.ReplaceAll(text, "&gt;", ">")
+	text = strings.ReplaceAll(text, "&amp;", "&")
+	text = strings.ReplaceAll(text, "&quot;", "\"")
+	text = strings.ReplaceAll(text, "&#39;", "'")
+	text = strings.ReplaceAll(text, "&nbsp;", " ")
+
+	return strings.TrimSpace(text)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen-3] + "..."
+}
+
+func formatTime(t time.Time) string {
+	duration := time.Since(t)
+
+	if duration < time.Minute {
+		return "just now"
+	} else if duration < time.Hour {
+		mins := int(duration.Minutes())
+		return fmt.Sprintf("%dm ago", mins)
+	} else if duration < 24*time.Hour {
+		hours := int(duration.Hours())
+		return fmt.Sprintf("%dh ago", hours)
+	} else {
+		days := int(duration.Hours() / 24)
+		return fmt.Sprintf("%dd ago", days)
+	}
+}
@@ -12,8 +12,10 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/deemkeen/stegodon/activitypub"
 	"github.com/deemkeen/stegodon/db"
 	"github.com/deemkeen/stegodon/ui/common"
+	"github.com/deemkeen/stegodon/util"
 	"github.com/google/uuid"
 )
 
@@ -34,6 +36,18 @@ var (
 			Foreground(lipgloss.Color(common.COLOR_DARK_GREY)).
 			Italic(true)
 
+	likedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_RED))
+
+	boostedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_GREEN))
+
+	statusStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_BLUE))
+
+	errorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(common.COLOR_RED))
+
 	// Inverted styles for selected posts
 	selectedTimeStyle = lipgloss.NewStyle().
 				Align(lipgloss.Left).
@@ -56,13 +70,20 @@ type Model struct {
 	Selected  int // Currently selected post index
 	Width     int
 	Height    int
+	Status    string
+	Error     string
 }
 
 type FederatedPost struct {
-	Actor     string
-	Content   string
-	Time      time.Time
-	ObjectURI string // URL to the original post
+	Actor      string
+	ActorURI   string // ActivityPub URI of the post author
+	ActivityId uuid.UUID
+	Content    string
+	Time       time.Time
+	ObjectURI  string // URL to the original post
+	Liked      bool   // true if the current user liked this post
+	BoostedBy  string // handle of the followed account that boosted this post (empty for own posts)
+	Boosted    bool   // true if the current user boosted this post
 }
 
 func InitialModel(accountId uuid.UUID, width, height int) Model {
@@ -73,6 +94,8 @@ func InitialModel(accountId uuid.UUID, width, height int) Model {
 		Selected:  0,
 		Width:     width,
 		Height:    height,
+		Status:    "",
+		Error:     "",
 	}
 }
 
@@ -109,6 +132,49 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		m.Offset = m.Selected
 		return m, nil
 
+	case clearStatusMsg:
+		m.Status = ""
+		m.Error = ""
+		return m, nil
+
+	case likeToggledMsg:
+		if msg.err != nil {
+			m.Error = msg.err.Error()
+			m.Status = ""
+			return m, clearStatusAfter(2 * time.Second)
+		}
+		for i := range m.Posts {
+			if m.Posts[i].ObjectURI == msg.objectURI {
+				m.Posts[i].Liked = msg.liked
+			}
+		}
+		if msg.liked {
+			m.Status = "Liked post"
+		} else {
+			m.Status = "Unliked post"
+		}
+		m.Error = ""
+		return m, clearStatusAfter(2 * time.Second)
+
+	case boostToggledMsg:
+		if msg.err != nil {
+			m.Error = msg.err.Error()
+			m.Status = ""
+			return m, clearStatusAfter(2 * time.Second)
+		}
+		for i := range m.Posts {
+			if m.Posts[i].ObjectURI == msg.objectURI {
+				m.Posts[i].Boosted = msg.boosted
+			}
+		}
+		if msg.boosted {
+			m.Status = "Boosted post"
+		} else {
+			m.Status = "Unboosted post"
+		}
+		m.Error = ""
+		return m, clearStatusAfter(2 * time.Second)
+
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "up", "k":
@@ -129,6 +195,56 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 					return m, openURLCmd(selectedPost.ObjectURI)
 				}
 			}
+		case "l":
+			// Like or unlike the selected post
+			if len(m.Posts) > 0 && m.Selected < len(m.Posts) {
+				selectedPost := m.Posts[m.Selected]
+				if selectedPost.ObjectURI != "" && selectedPost.ActorURI != "" {
+					return m, toggleLikeCmd(m.AccountId, selectedPost)
+				}
+			}
+		case "r":
+			// Reply to the selected post
+			if len(m.Posts) > 0 && m.Selected < len(m.Posts) {
+				selectedPost := m.Posts[m.Selected]
+				if selectedPost.ObjectURI != "" {
+					return m, func() tea.Msg {
+						return common.ReplyNoteMsg{
+							InReplyToURI: selectedPost.ObjectURI,
+							Handle:       selectedPost.Actor,
+						}
+					}
+				}
+			}
+		case "t":
+			// Show the conversation around the selected post
+			if len(m.Posts) > 0 && m.Selected < len(m.Posts) {
+				selectedPost := m.Posts[m.Selected]
+				if selectedPost.ObjectURI != "" {
+					return m, func() tea.Msg {
+						return common.ShowThreadMsg{ObjectURI: selectedPost.ObjectURI}
+					}
+				}
+			}
+		case "#":
+			// Show posts with the first hashtag of the selected post
+			tag := ""
+			if len(m.Posts) > 0 && m.Selected < len(m.Posts) {
+				if tags := util.ExtractHashtags(m.Posts[m.Selected].Content); len(tags) > 0 {
+					tag = tags[0]
+				}
+			}
+			return m, func() tea.Msg {
+				return common.ShowTagMsg{Tag: tag}
+			}
+		case "b":
+			// Boost or unboost the selected post
+			if len(m.Posts) > 0 && m.Selected < len(m.Posts) {
+				selectedPost := m.Posts[m.Selected]
+				if selectedPost.ObjectURI != "" && selectedPost.ActorURI != "" {
+					return m, toggleBoostCmd(m.AccountId, selectedPost)
+				}
+			}
 		}
 	}
 	return m, nil
@@ -166,8 +282,18 @@ func (m Model) View() string {
 					Background(lipgloss.Color(common.COLOR_LIGHTBLUE)).
 					Width(rightPanelWidth - 4)
 
+				if post.BoostedBy != "" {
+					s.WriteString(selectedBg.Render(selectedTimeStyle.Render("⟳ boosted by "+post.BoostedBy)) + "\n")
+				}
 				timeFormatted := selectedBg.Render(selectedTimeStyle.Render(timeStr))
-				authorFormatted := selectedBg.Render(selectedAuthorStyle.Render(post.Actor))
+				authorText := selectedAuthorStyle.Render(post.Actor)
+				if post.Liked {
+					authorText += selectedAuthorStyle.Render(" ♥")
+				}
+				if post.Boosted {
+					authorText += selectedAuthorStyle.Render(" ⟳")
+				}
+				authorFormatted := selectedBg.Render(authorText)
 				contentFormatted := selectedBg.Render(selectedContentStyle.Render(truncate(post.Content, 150)))
 
 				s.WriteString(timeFormatted + "\n")
@@ -178,8 +304,18 @@ func (m Model) View() string {
 				unselectedStyle := lipgloss.NewStyle().
 					Width(rightPanelWidth - 4)
 
+				if post.BoostedBy != "" {
+					s.WriteString(unselectedStyle.Render(timeStyle.Render("⟳ boosted by "+post.BoostedBy)) + "\n")
+				}
 				timeFormatted := unselectedStyle.Render(timeStyle.Render(timeStr))
-				authorFormatted := unselectedStyle.Render(authorStyle.Render(post.Actor))
+				authorText := authorStyle.Render(post.Actor)
+				if post.Liked {
+					authorText += likedStyle.Render(" ♥")
+				}
+				if post.Boosted {
+					authorText += boostedStyle.Render(" ⟳")
+				}
+				authorFormatted := unselectedStyle.Render(authorText)
 				contentFormatted := unselectedStyle.Render(contentStyle.Render(truncate(post.Content, 150)))
 
 				s.WriteString(timeFormatted + "\n")
@@ -191,6 +327,16 @@ func (m Model) View() string {
 		}
 	}
 
+	if m.Status != "" {
+		s.WriteString(statusStyle.Render(m.Status))
+		s.WriteString("\n\n")
+	}
+
+	if m.Error != "" {
+		s.WriteString(errorStyle.Render(m.Error))
+		s.WriteString("\n\n")
+	}
+
 	return s.String()
 }
 
@@ -218,12 +364,15 @@ func loadFederatedPosts(accountId uuid.UUID) tea.Cmd {
 		for _, activity := range *activities {
 			// Parse the raw JSON to extract content
 			// Handle both Create and Update activities (Update is stored in Create activities)
+			// Announce activities carry the boosted post as embedded object
 			var activityWrapper struct {
-				Type   string `json:"type"`
-				Object struct {
-					ID      string `json:"id"`
-					Content string `json:"content"`
-				} `json:"object"`
+				Type   string          `json:"type"`
+				Object json.RawMessage `json:"object"`
+			}
+			var object struct {
+				ID           string `json:"id"`
+				Content      string `json:"content"`
+				AttributedTo string `json:"attributedTo"`
 			}
 
 			if err := json.Unmarshal([]byte(activity.RawJSON), &activityWrapper); err != nil {
@@ -231,32 +380,58 @@ func loadFederatedPosts(accountId uuid.UUID) tea.Cmd {
 				continue
 			}
 
+			// Skip boosts whose original post could not be fetched (object is only a URI)
+			if err := json.Unmarshal(activityWrapper.Object, &object); err != nil {
+				continue
+			}
+
 			// Skip if content is empty
-			if activityWrapper.Object.Content == "" {
+			if object.Content == "" {
 				continue
 			}
 
 			// Strip HTML tags from content
-			cleanContent := stripHTMLTags(activityWrapper.Object.Content)
+			cleanContent := stripHTMLTags(object.Content)
+
+			// For boosts the post author is the original author, not the booster
+			authorURI := activity.ActorURI
+			boostedBy := ""
+			if activityWrapper.Type == "Announce" {
+				boostedBy = formatHandle(activity.ActorURI)
+				if object.AttributedTo != "" {
+					authorURI = object.AttributedTo
+				}
+			}
 
 			// Get remote account to format handle as username@domain
-			handle := activity.ActorURI // fallback to URI
-			err, remoteAcc := database.ReadRemoteAccountByActorURI(activity.ActorURI)
-			if err == nil && remoteAcc != nil {
-				handle = "@" + remoteAcc.Username + "@" + remoteAcc.Domain
-			}
+			handle := formatHandle(authorURI)
 
 			// Use ObjectURI from activity, or extract from raw JSON if empty
 			objectURI := activity.ObjectURI
-			if objectURI == "" && activityWrapper.Object.ID != "" {
-				objectURI = activityWrapper.Object.ID
+			if objectURI == "" && object.ID != "" {
+				objectURI = object.ID
+			}
+
+			// Check whether the current user already liked this post
+			liked := false
+			boosted := false
+			if objectURI != "" {
+				err, like := database.ReadLikeByAccountAndObjectURI(accountId, objectURI)
+				liked = err == nil && like != nil
+				err, share := database.ReadShareByAccountAndObjectURI(accountId, objectURI)
+				boosted = err == nil && share != nil
 			}
 
 			posts = append(posts, FederatedPost{
-				Actor:     handle,
-				Content:   cleanContent,
-				Time:      activity.CreatedAt,
-				ObjectURI: objectURI,
+				Actor:      handle,
+				ActorURI:   authorURI,
+				BoostedBy:  boostedBy,
+				ActivityId: activity.Id,
+				Content:    cleanContent,
+				Time:       activity.CreatedAt,
+				ObjectURI:  objectURI,
+				Liked:      liked,
+				Boosted:    boosted,
 			})
 		}
 
@@ -264,6 +439,107 @@ func loadFederatedPosts(accountId uuid.UUID) tea.Cmd {
 	}
 }
 
+// formatHandle returns @username@domain for a cached remote actor, or the actor URI itself
+func formatHandle(actorURI string) string {
+	err, remoteAcc := db.GetDB().ReadRemoteAccountByActorURI(actorURI)
+	if err == nil && remoteAcc != nil {
+		return "@" + remoteAcc.Username + "@" + remoteAcc.Domain
+	}
+	return actorURI
+}
+
+// likeToggledMsg is sent after a post was liked or unliked
+type likeToggledMsg struct {
+	objectURI string
+	liked     bool
+	err       error
+}
+
+// clearStatusMsg is sent after a delay to clear status/error messages
+type clearStatusMsg struct{}
+
+// clearStatusAfter returns a command that sends clearStatusMsg after a duration
+func clearStatusAfter(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(t time.Time) tea.Msg {
+		return clearStatusMsg{}
+	})
+}
+
+// toggleLikeCmd sends a Like or Undo Like for the given post
+func toggleLikeCmd(accountId uuid.UUID, post FederatedPost) tea.Cmd {
+	return func() tea.Msg {
+		database := db.GetDB()
+		err, account := database.ReadAccById(accountId)
+		if err != nil {
+			return likeToggledMsg{objectURI: post.ObjectURI, err: fmt.Errorf("failed to load account: %w", err)}
+		}
+
+		conf, err := util.ReadConf()
+		if err != nil {
+			return likeToggledMsg{objectURI: post.ObjectURI, err: fmt.Errorf("failed to read config: %w", err)}
+		}
+
+		if !conf.Conf.WithAp {
+			return likeToggledMsg{objectURI: post.ObjectURI, err: fmt.Errorf("ActivityPub is disabled")}
+		}
+
+		if post.Liked {
+			if err := activitypub.SendUndoLike(account, post.ObjectURI, post.ActorURI, conf); err != nil {
+				log.Printf("Failed to unlike post: %v", err)
+				return likeToggledMsg{objectURI: post.ObjectURI, liked: true, err: err}
+			}
+			return likeToggledMsg{objectURI: post.ObjectURI, liked: false}
+		}
+
+		if err := activitypub.SendLike(account, post.ObjectURI, post.ActorURI, conf); err != nil {
+			log.Printf("Failed to like post: %v", err)
+			return likeToggledMsg{objectURI: post.ObjectURI, liked: false, err: err}
+		}
+		return likeToggledMsg{objectURI: post.ObjectURI, liked: true}
+	}
+}
+
+// boostToggledMsg is sent after a post was boosted or unboosted
+type boostToggledMsg struct {
+	objectURI string
+	boosted   bool
+	err       error
+}
+
+// toggleBoostCmd sends an Announce or Undo Announce for the given post
+func toggleBoostCmd(accountId uuid.UUID, post FederatedPost) tea.Cmd {
+	return func() tea.Msg {
+		database := db.GetDB()
+		err, account := database.ReadAccById(accountId)
+		if err != nil {
+			return boostToggledMsg{objectURI: post.ObjectURI, err: fmt.Errorf("failed to load account: %w", err)}
+		}
+
+		conf, err := util.ReadConf()
+		if err != nil {
+			return boostToggledMsg{objectURI: post.ObjectURI, err: fmt.Errorf("failed to read config: %w", err)}
+		}
+
+		if !conf.Conf.WithAp {
+			return boostToggledMsg{objectURI: post.ObjectURI, err: fmt.Errorf("ActivityPub is disabled")}
+		}
+
+		if post.Boosted {
+			if err := activitypub.SendUndoAnnounce(account, post.ObjectURI, post.ActorURI, conf); err != nil {
+				log.Printf("Failed to unboost post: %v", err)
+				return boostToggledMsg{objectURI: post.ObjectURI, boosted: true, err: err}
+			}
+			return boostToggledMsg{objectURI: post.ObjectURI, boosted: false}
+		}
+
+		if err := activitypub.SendAnnounce(account, post.ObjectURI, post.ActorURI, conf); err != nil {
+			log.Printf("Failed to boost post: %v", err)
+			return boostToggledMsg{objectURI: post.ObjectURI, boosted: false, err: err}
+		}
+		return boostToggledMsg{objectURI: post.ObjectURI, boosted: true}
+	}
+}
+
 var htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
 
 // stripHTMLTags removes HTML tags from a string and converts common HTML entities
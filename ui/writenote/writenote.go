@@ -27,6 +27,8 @@ type Model struct {
 	isEditing         bool      // True when editing an existing note
 	editingNoteId     uuid.UUID // ID of note being edited
 	originalCreatedAt time.Time // Original creation time (preserved during edit)
+	inReplyToURI      string    // URI of the post being replied to (empty for new notes)
+	replyToHandle     string    // Handle of the author being replied to
 }
 
 func InitialNote(contentWidth int, userId uuid.UUID) Model {
@@ -54,7 +56,13 @@ func createNoteModelCmd(note *domain.SaveNote) tea.Cmd {
 		database := db.GetDB()
 
 		// Create note in database and get the created note ID
-		noteId, err := database.CreateNote(note.UserId, note.Message)
+		var noteId uuid.UUID
+		var err error
+		if note.InReplyToURI != "" {
+			noteId, err = database.CreateReplyNote(note.UserId, note.Message, note.InReplyToURI)
+		} else {
+			noteId, err = database.CreateNote(note.UserId, note.Message)
+		}
 		if err != nil {
 			log.Println("Note could not be saved!")
 			return common.UpdateNoteList
@@ -122,6 +130,11 @@ func updateNoteModelCmd(noteId uuid.UUID, message string) tea.Cmd {
 				return
 			}
 
+			// Imported notes were never federated, so there is nothing to update
+			if !note.Federated {
+				return
+			}
+
 			// Get the account
 			err, account := database.ReadAccByUsername(note.CreatedBy)
 			if err != nil {
@@ -169,6 +182,24 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		m.originalCreatedAt = msg.CreatedAt
 		m.Textarea.SetValue(msg.Message)
 		m.Textarea.Focus()
+		m.inReplyToURI = ""
+		m.replyToHandle = ""
+		return m, nil
+
+	case common.ReplyNoteMsg:
+		// Enter reply mode: address the author of the parent post
+		m.isEditing = false
+		m.editingNoteId = uuid.Nil
+		m.originalCreatedAt = time.Time{}
+		m.inReplyToURI = msg.InReplyToURI
+		m.replyToHandle = msg.Handle
+		if msg.Handle != "" {
+			m.Textarea.SetValue(msg.Handle + " ")
+		} else {
+			m.Textarea.SetValue("")
+		}
+		m.Textarea.Focus()
+		m.lettersLeft = m.CharCount()
 		return m, nil
 
 	case tea.KeyMsg:
@@ -190,12 +221,15 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				m.originalCreatedAt = time.Time{}
 				return m, updateNoteModelCmd(noteId, value)
 			} else {
-				// Create new note
+				// Create new note (or reply)
 				note := domain.SaveNote{
-					UserId:  m.userId,
-					Message: value,
+					UserId:       m.userId,
+					Message:      value,
+					InReplyToURI: m.inReplyToURI,
 				}
 				m.Textarea.SetValue("")
+				m.inReplyToURI = ""
+				m.replyToHandle = ""
 				return m, createNoteModelCmd(&note)
 			}
 		case tea.KeyCtrlC:
@@ -209,6 +243,13 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				m.Textarea.SetValue("")
 				return m, nil
 			}
+			// Cancel reply mode
+			if m.inReplyToURI != "" {
+				m.inReplyToURI = ""
+				m.replyToHandle = ""
+				m.Textarea.SetValue("")
+				return m, nil
+			}
 		default:
 			if !m.Textarea.Focused() {
 				cmd = m.Textarea.Focus()
@@ -238,6 +279,8 @@ func (m Model) View() string {
 	helpText := "post message: ctrl+s"
 	if m.isEditing {
 		helpText = "save changes: ctrl+s\ncancel: esc"
+	} else if m.inReplyToURI != "" {
+		helpText = "post reply: ctrl+s\ncancel: esc"
 	}
 
 	// Build the help section with proper formatting
@@ -247,6 +290,11 @@ func (m Model) View() string {
 	captionText := "new note"
 	if m.isEditing {
 		captionText = "edit note"
+	} else if m.inReplyToURI != "" {
+		captionText = "reply"
+		if m.replyToHandle != "" {
+			captionText = "reply to " + m.replyToHandle
+		}
 	}
 	caption := common.CaptionStyle.PaddingLeft(5).Render(captionText)
 
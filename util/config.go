@@ -24,6 +24,13 @@ type AppConfig struct {
 		WithAp    bool   `yaml:"withAp"`
 		Single    bool   `yaml:"single"`
 		Closed    bool   `yaml:"closed"`
+		// Require signed requests for actors, outboxes and notes
+		AuthorizedFetch bool `yaml:"authorizedFetch"`
+		// Delivery workers in total and concurrent deliveries per destination host
+		DeliveryWorkers int `yaml:"deliveryWorkers"`
+		DeliveryPerHost int `yaml:"deliveryPerHost"`
+		// Workers processing received activities
+		InboxWorkers int `yaml:"inboxWorkers"`
 	}
 }
 
@@ -69,6 +76,10 @@ func ReadConf() (*AppConfig, error) {
 	envWithAp := os.Getenv("STEGODON_WITH_AP")
 	envSingle := os.Getenv("STEGODON_SINGLE")
 	envClosed := os.Getenv("STEGODON_CLOSED")
+	envAuthorizedFetch := os.Getenv("STEGODON_AUTHORIZED_FETCH")
+	envDeliveryWorkers := os.Getenv("STEGODON_DELIVERY_WORKERS")
+	envDeliveryPerHost := os.Getenv("STEGODON_DELIVERY_PER_HOST")
+	envInboxWorkers := os.Getenv("STEGODON_INBOX_WORKERS")
 
 	if envHost != "" {
 		c.Conf.Host = envHost
@@ -106,5 +117,33 @@ func ReadConf() (*AppConfig, error) {
 		c.Conf.Closed = true
 	}
 
+	if envAuthorizedFetch == "true" {
+		c.Conf.AuthorizedFetch = true
+	}
+
+	if envDeliveryWorkers != "" {
+		v, err := strconv.Atoi(envDeliveryWorkers)
+		if err != nil {
+			fmt.Println(err)
+		}
+		c.Conf.DeliveryWorkers = v
+	}
+
+	if envDeliveryPerHost != "" {
+		v, err := strconv.Atoi(envDeliveryPerHost)
+		if err != nil {
+			fmt.Println(err)
+		}
+		c.Conf.DeliveryPerHost = v
+	}
+
+	if envInboxWorkers != "" {
+		v, err := strconv.Atoi(envInboxWorkers)
+		if err != nil {
+			fmt.Println(err)
+		}
+		c.Conf.InboxWorkers = v
+	}
+
 	return c, nil
 }
@@ -15,6 +15,7 @@ import (
 	"html"
 	"log"
 	rnd "math/rand"
+	"net/url"
 	"regexp"
 	"strings"
 	"time"
@@ -119,6 +120,45 @@ func MarkdownLinksToHTML(text string) string {
 	return result
 }
 
+var (
+	htmlLineBreakRegex = regexp.MustCompile(`(?i)<br\s*/?>`)
+	htmlParagraphRegex = regexp.MustCompile(`(?i)</p>\s*<p[^>]*>`)
+	htmlAnchorRegex    = regexp.MustCompile(`(?is)<a\s[^>]*href="([^"]*)"[^>]*>(.*?)</a>`)
+	htmlTagRegex       = regexp.MustCompile(`<[^>]*>`)
+)
+
+// HTMLToMarkdown converts the HTML content of a post, e.g. from another server, back to a note message
+// Links become Markdown links [text](url), hashtags and mentions become plain #tag and @user@domain
+func HTMLToMarkdown(content string) string {
+	text := htmlParagraphRegex.ReplaceAllString(content, "\n\n")
+	text = htmlLineBreakRegex.ReplaceAllString(text, "\n")
+
+	text = htmlAnchorRegex.ReplaceAllStringFunc(text, func(match string) string {
+		matches := htmlAnchorRegex.FindStringSubmatch(match)
+		href := matches[1]
+		linkText := strings.TrimSpace(htmlTagRegex.ReplaceAllString(matches[2], ""))
+
+		switch {
+		case strings.HasPrefix(linkText, "#"):
+			return linkText
+		case strings.HasPrefix(linkText, "@"):
+			// Mentions only show the username, the server is taken from the profile link
+			if strings.Count(linkText, "@") == 1 {
+				if u, err := url.Parse(html.UnescapeString(href)); err == nil && u.Host != "" {
+					return linkText + "@" + u.Host
+				}
+			}
+			return linkText
+		case linkText == "":
+			return href
+		}
+		return fmt.Sprintf("[%s](%s)", linkText, href)
+	})
+
+	text = htmlTagRegex.ReplaceAllString(text, "")
+	return strings.TrimSpace(html.UnescapeString(text))
+}
+
 // ExtractMarkdownLinks returns a list of URLs from Markdown links in text
 func ExtractMarkdownLinks(text string) []string {
 	re := regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
@@ -157,3 +197,88 @@ func MarkdownLinksToTerminal(text string) string {
 
 	return result
 }
+
+// mentionRegex matches @user and @user@domain, but not e-mail addresses or URL paths
+var mentionRegex = regexp.MustCompile(`(^|[^\w@/>])@(\w+)(?:@([A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)+))?`)
+
+// Mention is an @user or @user@domain reference found in a note
+type Mention struct {
+	Username string
+	Domain   string // Empty for local users
+}
+
+// Handle returns the mention as written in the note (@user or @user@domain)
+func (m Mention) Handle() string {
+	if m.Domain == "" {
+		return "@" + m.Username
+	}
+	return "@" + m.Username + "@" + m.Domain
+}
+
+// ExtractMentions returns the unique mentions in text, in order of appearance
+func ExtractMentions(text string) []Mention {
+	matches := mentionRegex.FindAllStringSubmatch(text, -1)
+
+	seen := make(map[string]bool)
+	mentions := make([]Mention, 0, len(matches))
+	for _, match := range matches {
+		mention := Mention{Username: match[2], Domain: strings.ToLower(match[3])}
+		if seen[mention.Handle()] {
+			continue
+		}
+		seen[mention.Handle()] = true
+		mentions = append(mentions, mention)
+	}
+
+	return mentions
+}
+
+// MentionsToHTML converts mentions to HTML links, using the actor URL for each handle
+// Mentions without a known URL are left as plain text
+func MentionsToHTML(text string, hrefs map[string]string) string {
+	return mentionRegex.ReplaceAllStringFunc(text, func(match string) string {
+		matches := mentionRegex.FindStringSubmatch(match)
+		mention := Mention{Username: matches[2], Domain: strings.ToLower(matches[3])}
+		href, ok := hrefs[mention.Handle()]
+		if !ok {
+			return match
+		}
+		return fmt.Sprintf(`%s<span class="h-card"><a href="%s" class="u-url mention">@<span>%s</span></a></span>`,
+			matches[1], html.EscapeString(href), mention.Username)
+	})
+}
+
+// hashtagRegex matches #tag, but not URL fragments or HTML entities; tags must start with a letter
+var hashtagRegex = regexp.MustCompile(`(^|[^\w&/#>"=])#([\p{L}_][\p{L}\p{N}_]*)`)
+
+// NormalizeHashtag lowercases a tag and strips a leading #
+func NormalizeHashtag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// ExtractHashtags returns the unique normalized hashtags in text, in order of appearance
+func ExtractHashtags(text string) []string {
+	matches := hashtagRegex.FindAllStringSubmatch(text, -1)
+
+	seen := make(map[string]bool)
+	tags := make([]string, 0, len(matches))
+	for _, match := range matches {
+		tag := NormalizeHashtag(match[2])
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// HashtagsToHTML converts hashtags to links to the tag pages below baseURL
+func HashtagsToHTML(text string, baseURL string) string {
+	return hashtagRegex.ReplaceAllStringFunc(text, func(match string) string {
+		matches := hashtagRegex.FindStringSubmatch(match)
+		return fmt.Sprintf(`%s<a href="%s/tags/%s" class="mention hashtag" rel="tag">#<span>%s</span></a>`,
+			matches[1], baseURL, url.PathEscape(NormalizeHashtag(matches[2])), matches[2])
+	})
+}
@@ -255,3 +255,121 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"local mention", "hello @alice", []string{"@alice"}},
+		{"remote mention", "@bob@mastodon.social hi", []string{"@bob@mastodon.social"}},
+		{"multiple mentions", "@alice and @bob@example.com", []string{"@alice", "@bob@example.com"}},
+		{"duplicates", "@alice @alice", []string{"@alice"}},
+		{"trailing period", "thanks @bob@example.com.", []string{"@bob@example.com"}},
+		{"email address", "mail me at alice@example.com", []string{}},
+		{"url path", "see https://example.com/@alice", []string{}},
+		{"no mentions", "just text", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mentions := ExtractMentions(tt.input)
+			if len(mentions) != len(tt.expected) {
+				t.Fatalf("ExtractMentions(%q) returned %d mentions, want %d", tt.input, len(mentions), len(tt.expected))
+			}
+			for i, mention := range mentions {
+				if mention.Handle() != tt.expected[i] {
+					t.Errorf("Mention %d = %q, want %q", i, mention.Handle(), tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMentionsToHTML(t *testing.T) {
+	hrefs := map[string]string{
+		"@bob@example.com": "https://example.com/users/bob",
+	}
+
+	result := MentionsToHTML("hi @bob@example.com and @unknown", hrefs)
+
+	expected := `hi <span class="h-card"><a href="https://example.com/users/bob" class="u-url mention">@<span>bob</span></a></span> and @unknown`
+	if result != expected {
+		t.Errorf("MentionsToHTML() = %q, want %q", result, expected)
+	}
+}
+
+func TestHTMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"plain paragraph", "<p>Hello world</p>", "Hello world"},
+		{"paragraphs and line breaks", "<p>first<br>line</p><p>second &amp; last</p>", "first\nline\n\nsecond & last"},
+		{
+			"link",
+			`<p>see <a href="https://example.com/page?a=1&amp;b=2" rel="nofollow noopener" target="_blank"><span class="invisible">https://</span><span class="">example.com/page</span></a></p>`,
+			"see [https://example.com/page](https://example.com/page?a=1&b=2)",
+		},
+		{
+			"hashtag",
+			`<p><a href="https://mastodon.example/tags/go" class="mention hashtag" rel="tag">#<span>Go</span></a> rocks</p>`,
+			"#Go rocks",
+		},
+		{
+			"mention",
+			`<p><span class="h-card"><a href="https://mastodon.example/@bob" class="u-url mention">@<span>bob</span></a></span> hi</p>`,
+			"@bob@mastodon.example hi",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := HTMLToMarkdown(tt.content)
+			if result != tt.expected {
+				t.Errorf("HTMLToMarkdown(%q) = %q, want %q", tt.content, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExtractHashtags(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{"single tag", "Released v1.2 #stegodon", []string{"stegodon"}},
+		{"normalized and deduplicated", "#Go and #go and #GoLang", []string{"go", "golang"}},
+		{"url fragment ignored", "see https://example.com/page#section", []string{}},
+		{"numeric tag ignored", "issue #42 fixed", []string{}},
+		{"html entity ignored", "it&#39;s done", []string{}},
+		{"unicode tag", "#über release", []string{"über"}},
+		{"no tags", "plain note", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ExtractHashtags(tt.text)
+			if len(result) != len(tt.expected) {
+				t.Fatalf("ExtractHashtags(%q) = %v, want %v", tt.text, result, tt.expected)
+			}
+			for i := range result {
+				if result[i] != tt.expected[i] {
+					t.Errorf("ExtractHashtags(%q)[%d] = %q, want %q", tt.text, i, result[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHashtagsToHTML(t *testing.T) {
+	result := HashtagsToHTML("new release #Stegodon", "https://example.com")
+
+	expected := `new release <a href="https://example.com/tags/stegodon" class="mention hashtag" rel="tag">#<span>Stegodon</span></a>`
+	if result != expected {
+		t.Errorf("HashtagsToHTML() = %q, want %q", result, expected)
+	}
+}
@@ -3,9 +3,12 @@ package web
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"time"
 
+	"github.com/deemkeen/stegodon/activitypub"
 	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
 	"github.com/deemkeen/stegodon/util"
 	"github.com/google/uuid"
 	"strings"
@@ -45,7 +48,11 @@ func GetActor(actor string, conf *util.AppConfig) (error, string) {
 		`{
 					"@context": [
 						"https://www.w3.org/ns/activitystreams",
-						"https://w3id.org/security/v1"
+						"https://w3id.org/security/v1",
+						{
+							"alsoKnownAs": {"@id": "as:alsoKnownAs", "@type": "@id"},
+							"movedTo": {"@id": "as:movedTo", "@type": "@id"}
+						}
 					],
 
 					"id": "%s",
@@ -58,8 +65,9 @@ func GetActor(actor string, conf *util.AppConfig) (error, string) {
 					"followers": "%s",
 					"following": "%s",
 					"url": "%s",
-  					"manuallyApprovesFollowers": false,
+  					"manuallyApprovesFollowers": %t,
 					"discoverable": true,
+					%s
   					"endpoints": {
     					"sharedInbox": "%s"
   					},
@@ -76,11 +84,103 @@ func GetActor(actor string, conf *util.AppConfig) (error, string) {
 		getIRI(conf.Conf.SslDomain, username, followers),
 		getIRI(conf.Conf.SslDomain, username, following),
 		getIRI(conf.Conf.SslDomain, username, id),
+		acc.Locked,
+		migrationFields(acc),
 		getIRI(conf.Conf.SslDomain, username, sharedInbox),
 		getIRI(conf.Conf.SslDomain, username, id),
 		getIRI(conf.Conf.SslDomain, username, id), pubKey)
 }
 
+// migrationFields returns the alsoKnownAs and movedTo properties of an actor as JSON members,
+// each followed by a comma, or an empty string when the account has no aliases and didn't move
+func migrationFields(acc *domain.Account) string {
+	var fields strings.Builder
+	if len(acc.AlsoKnownAs) > 0 {
+		aliases, _ := json.Marshal(acc.AlsoKnownAs)
+		fmt.Fprintf(&fields, `"alsoKnownAs": %s,`, aliases)
+	}
+	if acc.MovedTo != "" {
+		movedTo, _ := json.Marshal(acc.MovedTo)
+		fmt.Fprintf(&fields, `"movedTo": %s,`, movedTo)
+	}
+	return fields.String()
+}
+
+// GetInstanceActor returns the server-wide instance actor as ActivityPub JSON
+// Remote servers fetch it to verify the signatures of our GET requests
+func GetInstanceActor(conf *util.AppConfig) (error, string) {
+	err, keypair := db.GetDB().ReadOrCreateInstanceKeys()
+	if err != nil {
+		return err, "{}"
+	}
+
+	jsonBytes, err := json.Marshal(makeInstanceActor(keypair.Public, conf))
+	if err != nil {
+		return err, "{}"
+	}
+	return nil, string(jsonBytes)
+}
+
+// makeInstanceActor builds the Application actor representing the whole server
+func makeInstanceActor(publicKeyPem string, conf *util.AppConfig) map[string]interface{} {
+	actorURI := activitypub.InstanceActorURI(conf)
+	return map[string]interface{}{
+		"@context": []string{
+			"https://www.w3.org/ns/activitystreams",
+			"https://w3id.org/security/v1",
+		},
+		"id":                        actorURI,
+		"type":                      "Application",
+		"preferredUsername":         conf.Conf.SslDomain,
+		"inbox":                     getIRI(conf.Conf.SslDomain, "", sharedInbox),
+		"url":                       fmt.Sprintf("https://%s/", conf.Conf.SslDomain),
+		"manuallyApprovesFollowers": true,
+		"endpoints": map[string]interface{}{
+			"sharedInbox": getIRI(conf.Conf.SslDomain, "", sharedInbox),
+		},
+		"publicKey": map[string]interface{}{
+			"id":           actorURI + "#main-key",
+			"owner":        actorURI,
+			"publicKeyPem": publicKeyPem,
+		},
+	}
+}
+
+// GetActorKey returns the minimal actor document with the user's public key
+// It is served to unverified requests in secure mode so that remote servers can check our signatures
+func GetActorKey(actor string, conf *util.AppConfig) (error, string) {
+	err, acc := db.GetDB().ReadAccByUsername(actor)
+	if err != nil {
+		return err, "{}"
+	}
+
+	jsonBytes, err := json.Marshal(makeActorKeyDocument(acc.Username, acc.WebPublicKey, conf))
+	if err != nil {
+		return err, "{}"
+	}
+	return nil, string(jsonBytes)
+}
+
+// makeActorKeyDocument builds an actor document without profile data
+func makeActorKeyDocument(username string, publicKeyPem string, conf *util.AppConfig) map[string]interface{} {
+	actorURI := getIRI(conf.Conf.SslDomain, username, id)
+	return map[string]interface{}{
+		"@context": []string{
+			"https://www.w3.org/ns/activitystreams",
+			"https://w3id.org/security/v1",
+		},
+		"id":                actorURI,
+		"type":              "Person",
+		"preferredUsername": username,
+		"inbox":             getIRI(conf.Conf.SslDomain, username, inbox),
+		"publicKey": map[string]interface{}{
+			"id":           actorURI + "#main-key",
+			"owner":        actorURI,
+			"publicKeyPem": publicKeyPem,
+		},
+	}
+}
+
 func getIRI(domain string, username string, action action) string {
 
 	prefix := fmt.Sprintf("https://%s/users/%s", domain, username)
@@ -110,6 +210,11 @@ func GetNoteObject(noteId uuid.UUID, conf *util.AppConfig) (error, string) {
 		return err, "{}"
 	}
 
+	// Notes that aren't federated, like imported ones, don't exist as objects on this server
+	if !note.Federated {
+		return fmt.Errorf("note %s is not federated", noteId), "{}"
+	}
+
 	// Get the account to build actor URI
 	err, account := database.ReadAccByUsername(note.CreatedBy)
 	if err != nil {
@@ -119,13 +224,15 @@ func GetNoteObject(noteId uuid.UUID, conf *util.AppConfig) (error, string) {
 	actorURI := fmt.Sprintf("https://%s/users/%s", conf.Conf.SslDomain, account.Username)
 	noteURI := fmt.Sprintf("https://%s/notes/%s", conf.Conf.SslDomain, note.Id.String())
 
+	mentions := readNoteMentions(note.Id)
+
 	// Build the Note object
 	noteObj := map[string]interface{}{
 		"@context":     "https://www.w3.org/ns/activitystreams",
 		"id":           noteURI,
 		"type":         "Note",
 		"attributedTo": actorURI,
-		"content":      note.Message,
+		"content":      activitypub.NoteContentHTML(note.Message, mentions, conf),
 		"published":    note.CreatedAt.Format(time.RFC3339),
 		"to": []string{
 			"https://www.w3.org/ns/activitystreams#Public",
@@ -140,6 +247,28 @@ func GetNoteObject(noteId uuid.UUID, conf *util.AppConfig) (error, string) {
 		noteObj["updated"] = note.EditedAt.Format(time.RFC3339)
 	}
 
+	// Link replies to the post they answer
+	if note.InReplyToURI != "" {
+		noteObj["inReplyTo"] = note.InReplyToURI
+	}
+
+	// Tag mentions and hashtags, and address mentioned actors
+	if tags := activitypub.NoteTags(note.Message, mentions, conf); len(tags) > 0 {
+		noteObj["tag"] = tags
+	}
+	for _, mention := range mentions {
+		noteObj["cc"] = append(noteObj["cc"].([]string), mention.ActorURI)
+	}
+
+	// Expose the like and share counts as inline collections
+	for _, collectionType := range []string{noteLikes, noteShares} {
+		totalItems, err := countNoteCollection(note.Id, collectionType)
+		if err != nil {
+			log.Printf("GetNoteObject: Failed to count %s for %s: %v", collectionType, note.Id, err)
+		}
+		noteObj[collectionType] = makeNoteCollection(noteURI, collectionType, totalItems)
+	}
+
 	jsonBytes, err := json.Marshal(noteObj)
 	if err != nil {
 		return err, "{}"
@@ -147,3 +276,65 @@ func GetNoteObject(noteId uuid.UUID, conf *util.AppConfig) (error, string) {
 
 	return nil, string(jsonBytes)
 }
+
+// readNoteMentions returns the stored mentions of a note, or nil if there are none
+func readNoteMentions(noteId uuid.UUID) []domain.Mention {
+	err, mentions := db.GetDB().ReadMentionsByNoteId(noteId)
+	if err != nil {
+		log.Printf("Failed to read mentions for note %s: %v", noteId, err)
+		return nil
+	}
+	if mentions == nil {
+		return nil
+	}
+	return *mentions
+}
+
+// Collections of a note that only expose their total count, named like their endpoints
+const (
+	noteLikes  = "likes"
+	noteShares = "shares"
+)
+
+// GetNoteCollection returns the likes or shares collection of a note as ActivityPub JSON
+func GetNoteCollection(noteId uuid.UUID, collectionType string, conf *util.AppConfig) (error, string) {
+	err, note := db.GetDB().ReadNoteId(noteId)
+	if err != nil {
+		return err, "{}"
+	}
+
+	totalItems, err := countNoteCollection(note.Id, collectionType)
+	if err != nil {
+		return err, "{}"
+	}
+
+	noteURI := fmt.Sprintf("https://%s/notes/%s", conf.Conf.SslDomain, note.Id.String())
+	collection := makeNoteCollection(noteURI, collectionType, totalItems)
+	collection["@context"] = "https://www.w3.org/ns/activitystreams"
+
+	jsonBytes, err := json.Marshal(collection)
+	if err != nil {
+		return err, "{}"
+	}
+	return nil, string(jsonBytes)
+}
+
+// countNoteCollection returns the number of likes or shares of a note
+func countNoteCollection(noteId uuid.UUID, collectionType string) (int, error) {
+	switch collectionType {
+	case noteLikes:
+		return db.GetDB().CountLikesByNoteId(noteId)
+	case noteShares:
+		return db.GetDB().CountSharesByNoteId(noteId)
+	}
+	return 0, fmt.Errorf("unknown note collection %q", collectionType)
+}
+
+// makeNoteCollection builds the likes or shares collection for a note, exposing only the total count
+func makeNoteCollection(noteURI string, collectionType string, totalItems int) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         fmt.Sprintf("%s/%s", noteURI, collectionType),
+		"type":       "Collection",
+		"totalItems": totalItems,
+	}
+}
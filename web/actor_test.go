@@ -0,0 +1,89 @@
+package web
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/util"
+)
+
+func TestMakeActorKeyDocument(t *testing.T) {
+	conf := &util.AppConfig{}
+	conf.Conf.SslDomain = "example.com"
+
+	doc := makeActorKeyDocument("alice", "PEM", conf)
+
+	if doc["id"] != "https://example.com/users/alice" {
+		t.Errorf("Expected actor id, got %v", doc["id"])
+	}
+	if doc["inbox"] != "https://example.com/users/alice/inbox" {
+		t.Errorf("Expected inbox, got %v", doc["inbox"])
+	}
+
+	key, ok := doc["publicKey"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected publicKey object")
+	}
+	if key["id"] != "https://example.com/users/alice#main-key" {
+		t.Errorf("Expected key id, got %v", key["id"])
+	}
+	if key["publicKeyPem"] != "PEM" {
+		t.Errorf("Expected public key, got %v", key["publicKeyPem"])
+	}
+
+	// Profile data and collections are left out
+	for _, field := range []string{"name", "summary", "outbox", "followers", "following", "icon"} {
+		if _, ok := doc[field]; ok {
+			t.Errorf("Key document should not contain %s", field)
+		}
+	}
+}
+
+func TestMakeInstanceActor(t *testing.T) {
+	conf := &util.AppConfig{}
+	conf.Conf.SslDomain = "example.com"
+
+	actor := makeInstanceActor("PEM", conf)
+
+	if actor["id"] != "https://example.com/actor" {
+		t.Errorf("Expected instance actor id, got %v", actor["id"])
+	}
+	if actor["type"] != "Application" {
+		t.Errorf("Expected type Application, got %v", actor["type"])
+	}
+	if actor["inbox"] != "https://example.com/inbox" {
+		t.Errorf("Expected shared inbox, got %v", actor["inbox"])
+	}
+
+	key := actor["publicKey"].(map[string]interface{})
+	if key["id"] != "https://example.com/actor#main-key" {
+		t.Errorf("Expected key id, got %v", key["id"])
+	}
+	if key["owner"] != "https://example.com/actor" {
+		t.Errorf("Expected key owner, got %v", key["owner"])
+	}
+}
+
+func TestMigrationFields(t *testing.T) {
+	if fields := migrationFields(&domain.Account{}); fields != "" {
+		t.Errorf("Expected no fields without aliases, got %q", fields)
+	}
+
+	acc := &domain.Account{
+		AlsoKnownAs: []string{"https://old.example/users/alice", "https://other.example/@alice"},
+		MovedTo:     "https://new.example/users/alice",
+	}
+	var actor map[string]interface{}
+	if err := json.Unmarshal([]byte("{"+migrationFields(acc)+`"id": "x"}`), &actor); err != nil {
+		t.Fatalf("Expected valid JSON members: %v", err)
+	}
+
+	aliases, ok := actor["alsoKnownAs"].([]interface{})
+	if !ok || len(aliases) != 2 || aliases[0] != "https://old.example/users/alice" {
+		t.Errorf("Expected both aliases, got %v", actor["alsoKnownAs"])
+	}
+	if actor["movedTo"] != "https://new.example/users/alice" {
+		t.Errorf("Expected movedTo, got %v", actor["movedTo"])
+	}
+}
@@ -0,0 +1,124 @@
+package web
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/deemkeen/stegodon/activitypub"
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/util"
+)
+
+// ArchiveSummary counts what an account archive contains
+type ArchiveSummary struct {
+	Notes   int
+	Likes   int
+	Follows int
+}
+
+// WriteArchive writes a zip archive of an account's data, laid out like Mastodon's archive:
+// actor.json with the profile, outbox.json with the notes as served by the outbox,
+// likes.json with the liked posts and following_accounts.csv with the followed accounts
+func WriteArchive(w io.Writer, acc *domain.Account, conf *util.AppConfig) (ArchiveSummary, error) {
+	var summary ArchiveSummary
+
+	actor, err := makeArchiveActor(acc, conf)
+	if err != nil {
+		return summary, err
+	}
+
+	err, notes := db.GetDB().ReadPublicNotesByUsername(acc.Username, 999999, 0)
+	if err != nil {
+		return summary, fmt.Errorf("failed to read notes: %w", err)
+	}
+	activities := makeNoteActivities(*notes, acc.Username, conf)
+	summary.Notes = len(activities)
+
+	err, likes := db.GetDB().ReadLikesByAccountId(acc.Id)
+	if err != nil {
+		return summary, fmt.Errorf("failed to read likes: %w", err)
+	}
+	likedURIs := make([]string, 0, len(*likes))
+	for _, like := range *likes {
+		if like.ObjectURI != "" {
+			likedURIs = append(likedURIs, like.ObjectURI)
+		}
+	}
+	summary.Likes = len(likedURIs)
+
+	follows, err := activitypub.ExportAccountList(acc, activitypub.ListFollowing, conf)
+	if err != nil {
+		return summary, err
+	}
+	summary.Follows = len(follows)
+	var followsCSV bytes.Buffer
+	if err := activitypub.WriteAccountListCSV(&followsCSV, activitypub.ListFollowing, follows); err != nil {
+		return summary, err
+	}
+
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"actor.json", actor},
+		{"outbox.json", makeArchiveCollection("outbox.json", activities, len(activities))},
+		{"likes.json", makeArchiveCollection("likes.json", likedURIs, len(likedURIs))},
+		{activitypub.ListFollowing, followsCSV.Bytes()},
+	}
+
+	archive := zip.NewWriter(w)
+	modified := time.Now()
+	for _, file := range files {
+		data, ok := file.content.([]byte)
+		if !ok {
+			if data, err = json.MarshalIndent(file.content, "", "  "); err != nil {
+				return summary, fmt.Errorf("failed to encode %s: %w", file.name, err)
+			}
+		}
+
+		fw, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return summary, err
+		}
+		if _, err := fw.Write(data); err != nil {
+			return summary, fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+	return summary, archive.Close()
+}
+
+// makeArchiveActor returns the actor document of an account with its avatar
+func makeArchiveActor(acc *domain.Account, conf *util.AppConfig) (map[string]interface{}, error) {
+	err, actorJSON := GetActor(acc.Username, conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read actor: %w", err)
+	}
+
+	var actor map[string]interface{}
+	if err := json.Unmarshal([]byte(actorJSON), &actor); err != nil {
+		return nil, fmt.Errorf("failed to parse actor: %w", err)
+	}
+	if acc.AvatarURL != "" {
+		actor["icon"] = map[string]interface{}{
+			"type": "Image",
+			"url":  acc.AvatarURL,
+		}
+	}
+	return actor, nil
+}
+
+// makeArchiveCollection returns a complete OrderedCollection as stored in an archive
+func makeArchiveCollection(id string, items interface{}, totalItems int) map[string]interface{} {
+	return map[string]interface{}{
+		"@context":     "https://www.w3.org/ns/activitystreams",
+		"id":           id,
+		"type":         "OrderedCollection",
+		"totalItems":   totalItems,
+		"orderedItems": items,
+	}
+}
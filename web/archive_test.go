@@ -0,0 +1,24 @@
+package web
+
+import "testing"
+
+func TestMakeArchiveCollection(t *testing.T) {
+	items := []string{"https://example.com/notes/1", "https://example.com/notes/2"}
+	collection := makeArchiveCollection("likes.json", items, len(items))
+
+	if collection["type"] != "OrderedCollection" {
+		t.Errorf("Expected type OrderedCollection, got %v", collection["type"])
+	}
+	if collection["id"] != "likes.json" {
+		t.Errorf("Expected id likes.json, got %v", collection["id"])
+	}
+	if collection["totalItems"] != 2 {
+		t.Errorf("Expected totalItems 2, got %v", collection["totalItems"])
+	}
+	if _, ok := collection["first"]; ok {
+		t.Error("Archive collections should contain all items instead of pages")
+	}
+	if got, ok := collection["orderedItems"].([]string); !ok || len(got) != 2 {
+		t.Errorf("Expected the 2 items, got %v", collection["orderedItems"])
+	}
+}
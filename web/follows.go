@@ -0,0 +1,136 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/util"
+	"github.com/google/uuid"
+)
+
+// Number of actors per followers/following collection page
+const followsPerPage = 20
+
+// GetFollowersCollection returns an ActivityPub OrderedCollection of the actors following a user
+// Without a page only the collection metadata is returned
+func GetFollowersCollection(actor string, page int, conf *util.AppConfig) (error, string) {
+	return getFollowCollection(actor, page, followers, conf)
+}
+
+// GetFollowingCollection returns an ActivityPub OrderedCollection of the actors a user follows
+// Without a page only the collection metadata is returned
+func GetFollowingCollection(actor string, page int, conf *util.AppConfig) (error, string) {
+	return getFollowCollection(actor, page, following, conf)
+}
+
+func getFollowCollection(actor string, page int, kind action, conf *util.AppConfig) (error, string) {
+	database := db.GetDB()
+	err, acc := database.ReadAccByUsername(actor)
+	if err != nil {
+		log.Printf("GetFollowCollection: User %s not found: %v", actor, err)
+		return err, "{}"
+	}
+
+	var follows *[]domain.Follow
+	if kind == followers {
+		err, follows = database.ReadFollowersByAccountId(acc.Id)
+	} else {
+		err, follows = database.ReadFollowingByAccountId(acc.Id)
+	}
+	if err != nil {
+		log.Printf("GetFollowCollection: Failed to read follows of %s: %v", actor, err)
+		return err, "{}"
+	}
+	if follows == nil {
+		follows = &[]domain.Follow{}
+	}
+
+	collectionURL := getIRI(conf.Conf.SslDomain, acc.Username, kind)
+
+	var collection map[string]interface{}
+	if page == 0 || acc.HideCollections {
+		// Accounts hiding their social graph only expose the count
+		collection = makeFollowCollection(collectionURL, len(*follows), !acc.HideCollections)
+	} else {
+		start := (page - 1) * followsPerPage
+		end := start + followsPerPage
+		if start > len(*follows) {
+			start = len(*follows)
+		}
+		if end > len(*follows) {
+			end = len(*follows)
+		}
+
+		items := make([]string, 0, end-start)
+		for _, follow := range (*follows)[start:end] {
+			// The other side of the relationship is the actor to list
+			otherId := follow.AccountId
+			if kind == following {
+				otherId = follow.TargetAccountId
+			}
+			if actorURI := followActorURI(otherId, follow.IsLocal, conf); actorURI != "" {
+				items = append(items, actorURI)
+			}
+		}
+		collection = makeFollowCollectionPage(collectionURL, page, items, end < len(*follows))
+	}
+
+	jsonData, err := json.Marshal(collection)
+	if err != nil {
+		log.Printf("GetFollowCollection: Failed to marshal collection: %v", err)
+		return err, "{}"
+	}
+	return nil, string(jsonData)
+}
+
+// followActorURI resolves the account on one side of a follow to its ActivityPub actor URI
+func followActorURI(accountId uuid.UUID, isLocal bool, conf *util.AppConfig) string {
+	database := db.GetDB()
+
+	if !isLocal {
+		if err, remoteAcc := database.ReadRemoteAccountById(accountId); err == nil && remoteAcc != nil {
+			return remoteAcc.ActorURI
+		}
+	}
+
+	// Local follows, and remote follows of local accounts
+	if err, localAcc := database.ReadAccById(accountId); err == nil && localAcc != nil {
+		return getIRI(conf.Conf.SslDomain, localAcc.Username, id)
+	}
+	return ""
+}
+
+// makeFollowCollection builds the metadata of a followers or following collection
+func makeFollowCollection(collectionURL string, totalItems int, withPages bool) map[string]interface{} {
+	collection := map[string]interface{}{
+		"@context":   "https://www.w3.org/ns/activitystreams",
+		"id":         collectionURL,
+		"type":       "OrderedCollection",
+		"totalItems": totalItems,
+	}
+	if withPages {
+		collection["first"] = fmt.Sprintf("%s?page=1", collectionURL)
+	}
+	return collection
+}
+
+// makeFollowCollectionPage builds one page of a followers or following collection
+func makeFollowCollectionPage(collectionURL string, page int, items []string, hasMore bool) map[string]interface{} {
+	collectionPage := map[string]interface{}{
+		"@context":     "https://www.w3.org/ns/activitystreams",
+		"id":           fmt.Sprintf("%s?page=%d", collectionURL, page),
+		"type":         "OrderedCollectionPage",
+		"partOf":       collectionURL,
+		"orderedItems": items,
+	}
+	if hasMore {
+		collectionPage["next"] = fmt.Sprintf("%s?page=%d", collectionURL, page+1)
+	}
+	if page > 1 {
+		collectionPage["prev"] = fmt.Sprintf("%s?page=%d", collectionURL, page-1)
+	}
+	return collectionPage
+}
@@ -0,0 +1,62 @@
+package web
+
+import "testing"
+
+func TestMakeFollowCollection(t *testing.T) {
+	collection := makeFollowCollection("https://example.com/users/alice/followers", 5, true)
+
+	if collection["type"] != "OrderedCollection" {
+		t.Errorf("Expected type OrderedCollection, got %v", collection["type"])
+	}
+	if collection["totalItems"] != 5 {
+		t.Errorf("Expected totalItems 5, got %v", collection["totalItems"])
+	}
+	if collection["first"] != "https://example.com/users/alice/followers?page=1" {
+		t.Errorf("Expected first page link, got %v", collection["first"])
+	}
+}
+
+func TestMakeFollowCollectionHidden(t *testing.T) {
+	collection := makeFollowCollection("https://example.com/users/alice/following", 5, false)
+
+	if collection["totalItems"] != 5 {
+		t.Errorf("Expected totalItems 5, got %v", collection["totalItems"])
+	}
+	if _, ok := collection["first"]; ok {
+		t.Error("Hidden collection should not link to its pages")
+	}
+}
+
+func TestMakeFollowCollectionPage(t *testing.T) {
+	collectionURL := "https://example.com/users/alice/followers"
+	items := []string{"https://remote.example/users/bob", "https://example.com/users/carol"}
+
+	page := makeFollowCollectionPage(collectionURL, 2, items, true)
+
+	if page["type"] != "OrderedCollectionPage" {
+		t.Errorf("Expected type OrderedCollectionPage, got %v", page["type"])
+	}
+	if page["id"] != collectionURL+"?page=2" {
+		t.Errorf("Expected page id, got %v", page["id"])
+	}
+	if page["partOf"] != collectionURL {
+		t.Errorf("Expected partOf %s, got %v", collectionURL, page["partOf"])
+	}
+	if got := page["orderedItems"].([]string); len(got) != 2 || got[0] != items[0] {
+		t.Errorf("Expected items %v, got %v", items, got)
+	}
+	if page["next"] != collectionURL+"?page=3" {
+		t.Errorf("Expected next page link, got %v", page["next"])
+	}
+	if page["prev"] != collectionURL+"?page=1" {
+		t.Errorf("Expected prev page link, got %v", page["prev"])
+	}
+
+	last := makeFollowCollectionPage(collectionURL, 1, items, false)
+	if _, ok := last["next"]; ok {
+		t.Error("Last page should not have a next link")
+	}
+	if _, ok := last["prev"]; ok {
+		t.Error("First page should not have a prev link")
+	}
+}
@@ -1,11 +1,17 @@
 package web
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"sync"
 	"time"
 
+	"github.com/deemkeen/stegodon/activitypub"
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/util"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"golang.org/x/time/rate"
 )
 
@@ -93,3 +99,42 @@ func MaxBytesMiddleware(maxBytes int64) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// requireSignedFetch enforces secure mode for GETs of a user's actor, outbox, collections and notes
+// When authorized fetch is enabled, requests that aren't signed by a verified actor the user
+// hasn't blocked are aborted with 401 or 403 and false is returned.
+// Without a username only the signature is checked, e.g. for collections spanning all users
+func requireSignedFetch(c *gin.Context, username string, conf *util.AppConfig) bool {
+	if !conf.Conf.AuthorizedFetch {
+		return true
+	}
+
+	actorURI, err := activitypub.VerifyFetch(c.Request, username)
+	if err == nil {
+		return true
+	}
+
+	log.Printf("Refusing GET %s from %q: %v", c.Request.URL.Path, actorURI, err)
+	c.Header("Content-Type", "application/activity+json; charset=utf-8")
+	if errors.Is(err, activitypub.ErrFetchForbidden) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+	} else {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Request must be signed"})
+	}
+	return false
+}
+
+// requireSignedNoteFetch enforces secure mode for GETs of a note and its collections
+// The note author's blocks decide who may fetch it, unknown notes are answered with 404
+func requireSignedNoteFetch(c *gin.Context, noteId uuid.UUID, conf *util.AppConfig) bool {
+	if !conf.Conf.AuthorizedFetch {
+		return true
+	}
+
+	err, note := db.GetDB().ReadNoteId(noteId)
+	if err != nil || note == nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Note not found"})
+		return false
+	}
+	return requireSignedFetch(c, note.CreatedBy, conf)
+}
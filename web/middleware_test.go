@@ -7,7 +7,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/deemkeen/stegodon/util"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"golang.org/x/time/rate"
 )
 
@@ -361,3 +363,84 @@ func TestRateLimitMiddlewareRecovery(t *testing.T) {
 		t.Errorf("Request after waiting should succeed, got status %d", w3.Code)
 	}
 }
+
+func TestRequireSignedFetch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name            string
+		authorizedFetch bool
+		expectedStatus  int
+	}{
+		{"secure mode off allows unsigned requests", false, http.StatusOK},
+		{"secure mode refuses unsigned requests", true, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := &util.AppConfig{}
+			conf.Conf.AuthorizedFetch = tt.authorizedFetch
+
+			router := gin.New()
+			router.GET("/users/:actor/outbox", func(c *gin.Context) {
+				if !requireSignedFetch(c, c.Param("actor"), conf) {
+					return
+				}
+				c.String(http.StatusOK, "{}")
+			})
+
+			req := httptest.NewRequest("GET", "/users/alice/outbox", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestRequireSignedFetchWithoutUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	conf := &util.AppConfig{}
+	conf.Conf.AuthorizedFetch = true
+
+	// Collections of all users, like a hashtag, still need a signature
+	router := gin.New()
+	router.GET("/tags/:tag", func(c *gin.Context) {
+		if !requireSignedFetch(c, "", conf) {
+			return
+		}
+		c.String(http.StatusOK, "{}")
+	})
+
+	req := httptest.NewRequest("GET", "/tags/golang", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestRequireSignedNoteFetchSecureModeOff(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	conf := &util.AppConfig{}
+	router := gin.New()
+	router.GET("/notes/:id/likes", func(c *gin.Context) {
+		if !requireSignedNoteFetch(c, uuid.New(), conf) {
+			return
+		}
+		c.String(http.StatusOK, "{}")
+	})
+
+	req := httptest.NewRequest("GET", "/notes/x/likes", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
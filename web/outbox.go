@@ -6,6 +6,7 @@ import (
 	"log"
 	"strconv"
 
+	"github.com/deemkeen/stegodon/activitypub"
 	"github.com/deemkeen/stegodon/db"
 	"github.com/deemkeen/stegodon/domain"
 	"github.com/deemkeen/stegodon/util"
@@ -125,8 +126,9 @@ func makeNoteActivities(notes []domain.Note, actor string, conf *util.AppConfig)
 			objectURI = fmt.Sprintf("%s/notes/%s", baseURL, note.Id.String())
 		}
 
-		// Convert Markdown links to HTML for ActivityPub content
-		contentHTML := util.MarkdownLinksToHTML(note.Message)
+		// Convert Markdown links and mentions to HTML for ActivityPub content
+		mentions := readNoteMentions(note.Id)
+		contentHTML := activitypub.NoteContentHTML(note.Message, mentions, conf)
 
 		// Build the Note object
 		noteObj := map[string]interface{}{
@@ -148,6 +150,10 @@ func makeNoteActivities(notes []domain.Note, actor string, conf *util.AppConfig)
 			noteObj["updated"] = note.EditedAt.Format("2006-01-02T15:04:05Z")
 		}
 
+		if tags := activitypub.NoteTags(note.Message, mentions, conf); len(tags) > 0 {
+			noteObj["tag"] = tags
+		}
+
 		// Build the Create activity wrapping the Note
 		activityURI := fmt.Sprintf("%s/activities/%s", baseURL, note.Id.String())
 		activity := map[string]interface{}{
@@ -112,3 +112,29 @@ func TestOutboxCollectionFields(t *testing.T) {
 		t.Error("Outbox should return a JSON object")
 	}
 }
+
+func TestMakeNoteCollection(t *testing.T) {
+	tests := []struct {
+		collectionType string
+		totalItems     int
+	}{
+		{noteLikes, 3},
+		{noteShares, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.collectionType, func(t *testing.T) {
+			collection := makeNoteCollection("https://example.com/notes/123", tt.collectionType, tt.totalItems)
+
+			if collection["id"] != "https://example.com/notes/123/"+tt.collectionType {
+				t.Errorf("Unexpected collection id: %v", collection["id"])
+			}
+			if collection["type"] != "Collection" {
+				t.Errorf("Expected type Collection, got %v", collection["type"])
+			}
+			if collection["totalItems"] != tt.totalItems {
+				t.Errorf("Expected totalItems %d, got %v", tt.totalItems, collection["totalItems"])
+			}
+		})
+	}
+}
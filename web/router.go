@@ -1,17 +1,14 @@
 package web
 
 import (
-	"bytes"
 	"embed"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
-	"io"
 	"log"
 	"strings"
 
 	"github.com/deemkeen/stegodon/activitypub"
-	"github.com/deemkeen/stegodon/db"
 	"github.com/deemkeen/stegodon/util"
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
@@ -48,13 +45,37 @@ func Router(conf *util.AppConfig) error {
 		HandleProfile(c, conf)
 	})
 
+	// Hashtag page, served as an ActivityPub collection to federated servers
+	g.GET("/tags/:tag", func(c *gin.Context) {
+		if conf.Conf.WithAp && wantsActivityJSON(c) {
+			// Tagged notes come from many users, so in secure mode only a verified signature is required
+			if !requireSignedFetch(c, "", conf) {
+				return
+			}
+			c.Header("Content-Type", "application/activity+json; charset=utf-8")
+			err, collection := GetTagCollection(c.Param("tag"), ParsePageParam(c.Query("page")), conf)
+			if err != nil {
+				c.Render(404, render.String{Format: "{}"})
+			} else {
+				c.Render(200, render.String{Format: collection})
+			}
+			return
+		}
+		HandleTag(c, conf)
+	})
+
 	// RSS Feed
 	g.GET("/feed", func(c *gin.Context) {
 
 		c.Header("Content-Type", "application/xml; charset=utf-8")
 
-		username := c.Query("username")
-		rss, err := GetRSS(conf, username)
+		var rss string
+		var err error
+		if tag := c.Query("tag"); tag != "" {
+			rss, err = GetTagRSS(conf, tag)
+		} else {
+			rss, err = GetRSS(conf, c.Query("username"))
+		}
 		if err != nil {
 			c.Render(404, render.String{Format: ""})
 		} else {
@@ -98,6 +119,10 @@ func Router(conf *util.AppConfig) error {
 				return
 			}
 
+			if !requireSignedNoteFetch(c, noteId, conf) {
+				return
+			}
+
 			err, note := GetNoteObject(noteId, conf)
 			if err != nil {
 				c.JSON(404, gin.H{"error": "Note not found"})
@@ -106,131 +131,73 @@ func Router(conf *util.AppConfig) error {
 			}
 		})
 
-		g.GET("/users/:actor", func(c *gin.Context) {
+		// Serve the likes and shares collections of notes
+		for _, collectionType := range []string{noteLikes, noteShares} {
+			g.GET("/notes/:id/"+collectionType, func(c *gin.Context) {
+				c.Header("Content-Type", "application/activity+json; charset=utf-8")
+
+				noteId, err := uuid.Parse(c.Param("id"))
+				if err != nil {
+					c.JSON(404, gin.H{"error": "Invalid note ID"})
+					return
+				}
 
+				if !requireSignedNoteFetch(c, noteId, conf) {
+					return
+				}
+
+				err, collection := GetNoteCollection(noteId, collectionType, conf)
+				if err != nil {
+					c.JSON(404, gin.H{"error": "Note not found"})
+				} else {
+					c.Render(200, render.String{Format: collection})
+				}
+			})
+		}
+
+		g.GET(activitypub.InstanceActorPath, func(c *gin.Context) {
 			c.Header("Content-Type", "application/activity+json; charset=utf-8")
-			err, actor := GetActor(c.Param("actor"), conf)
+			err, actor := GetInstanceActor(conf)
 			if err != nil {
-				c.Render(404, render.String{Format: actor})
+				log.Printf("Failed to serve instance actor: %v", err)
+				c.Render(500, render.String{Format: actor})
 			} else {
 				c.Render(200, render.String{Format: actor})
 			}
 		})
 
-		g.POST("/inbox", RateLimitMiddleware(apLimiter), maxBodySize, func(c *gin.Context) {
-			log.Println("POST /inbox (shared inbox)")
-			// Shared inbox - extract target username from activity object
-			body, err := c.GetRawData()
-			if err != nil {
-				log.Printf("Shared inbox: Failed to read body: %v", err)
-				c.Status(400)
-				return
-			}
-
-			// Parse to get target actor
-			var activity map[string]interface{}
-			if err := json.Unmarshal(body, &activity); err != nil {
-				log.Printf("Shared inbox: Failed to parse activity: %v", err)
-				c.Status(400)
-				return
-			}
-
-			// Extract username from activity addressing
-			var targetUsername string
-
-			// Helper function to extract username from URI
-			extractUsername := func(uri string) string {
-				// Check if it's one of our users: https://domain/users/username
-				if strings.Contains(uri, conf.Conf.SslDomain) && strings.Contains(uri, "/users/") {
-					parts := strings.Split(uri, "/")
-					for i, part := range parts {
-						if part == "users" && i+1 < len(parts) {
-							// Extract just the username, handle /followers suffix
-							username := parts[i+1]
-							// Remove /followers or /following if present
-							if slashIdx := strings.Index(username, "/"); slashIdx > 0 {
-								username = username[:slashIdx]
-							}
-							return username
-						}
-					}
-				}
-				return ""
-			}
+		g.GET("/users/:actor", func(c *gin.Context) {
 
-			// Try to find target in "to" field first
-			if toArray, ok := activity["to"].([]interface{}); ok {
-				for _, to := range toArray {
-					if toStr, ok := to.(string); ok {
-						if username := extractUsername(toStr); username != "" {
-							targetUsername = username
-							break
-						}
-					}
-				}
-			}
+			c.Header("Content-Type", "application/activity+json; charset=utf-8")
 
-			// If not found, try "cc" field (followers collections)
-			if targetUsername == "" {
-				if ccArray, ok := activity["cc"].([]interface{}); ok {
-					for _, cc := range ccArray {
-						if ccStr, ok := cc.(string); ok {
-							// Check for followers URI: https://domain/users/username/followers
-							if username := extractUsername(ccStr); username != "" {
-								targetUsername = username
-								break
-							}
-						}
+			// In secure mode unverified requests only get the public key, blocked ones nothing
+			if conf.Conf.AuthorizedFetch {
+				if _, err := activitypub.VerifyFetch(c.Request, c.Param("actor")); err != nil {
+					if errors.Is(err, activitypub.ErrFetchForbidden) {
+						c.Render(403, render.String{Format: "{}"})
+						return
 					}
-				}
-			}
-
-			// For Follow activities, check the object field
-			if targetUsername == "" {
-				if objStr, ok := activity["object"].(string); ok {
-					targetUsername = extractUsername(objStr)
-				}
-			}
-
-			if targetUsername == "" {
-				// For Create/Update/Delete activities, find which local user(s) follow this actor
-				actorURI, _ := activity["actor"].(string)
-				if actorURI != "" {
-					database := db.GetDB()
-
-					// Get the remote actor
-					err, remoteActor := database.ReadRemoteAccountByActorURI(actorURI)
-					if err == nil && remoteActor != nil {
-						// Find followers of this remote actor (local users who follow them)
-						err, followers := database.ReadFollowersByAccountId(remoteActor.Id)
-						if err == nil && followers != nil && len(*followers) > 0 {
-							// Get the first local user who follows this actor
-							firstFollower := (*followers)[0]
-							err, localAccount := database.ReadAccById(firstFollower.AccountId)
-							if err == nil && localAccount != nil {
-								targetUsername = localAccount.Username
-								log.Printf("Shared inbox: Routing to follower %s of %s", targetUsername, actorURI)
-							}
-						} else {
-							log.Printf("Shared inbox: No local followers found for %s", actorURI)
-						}
+					err, key := GetActorKey(c.Param("actor"), conf)
+					if err != nil {
+						c.Render(404, render.String{Format: key})
 					} else {
-						log.Printf("Shared inbox: Remote actor %s not found in cache", actorURI)
+						c.Render(200, render.String{Format: key})
 					}
+					return
 				}
 			}
 
-			if targetUsername == "" {
-				log.Printf("Shared inbox: Could not determine target username from activity type %v", activity["type"])
-				c.Status(202) // Accept anyway to be nice
-				return
+			err, actor := GetActor(c.Param("actor"), conf)
+			if err != nil {
+				c.Render(404, render.String{Format: actor})
+			} else {
+				c.Render(200, render.String{Format: actor})
 			}
+		})
 
-			log.Printf("Shared inbox: Routing to user %s", targetUsername)
-			// Create a new request with the body
-			req := c.Request.Clone(c.Request.Context())
-			req.Body = io.NopCloser(bytes.NewReader(body))
-			activitypub.HandleInbox(c.Writer, req, targetUsername, conf)
+		g.POST("/inbox", RateLimitMiddleware(apLimiter), maxBodySize, func(c *gin.Context) {
+			log.Println("POST /inbox (shared inbox)")
+			activitypub.HandleSharedInbox(c.Writer, c.Request, conf)
 		})
 
 		g.POST("/users/:actor/inbox", RateLimitMiddleware(apLimiter), maxBodySize, func(c *gin.Context) {
@@ -246,6 +213,10 @@ func Router(conf *util.AppConfig) error {
 
 			log.Printf("GET /users/%s/outbox (page=%d)", actor, page)
 
+			if !requireSignedFetch(c, actor, conf) {
+				return
+			}
+
 			err, outbox := GetOutbox(actor, page, conf)
 			if err != nil {
 				c.Header("Content-Type", "application/activity+json; charset=utf-8")
@@ -258,15 +229,31 @@ func Router(conf *util.AppConfig) error {
 		})
 
 		g.GET("/users/:actor/followers", func(c *gin.Context) {
-			log.Println("Get followers..")
+			if !requireSignedFetch(c, c.Param("actor"), conf) {
+				return
+			}
+
 			c.Header("Content-Type", "application/activity+json; charset=utf-8")
-			c.Render(200, render.String{Format: "{}"})
+			err, collection := GetFollowersCollection(c.Param("actor"), ParsePageParam(c.Query("page")), conf)
+			if err != nil {
+				c.Render(404, render.String{Format: "{}"})
+			} else {
+				c.Render(200, render.String{Format: collection})
+			}
 		})
 
 		g.GET("/users/:actor/following", func(c *gin.Context) {
-			log.Println("Get followers..")
+			if !requireSignedFetch(c, c.Param("actor"), conf) {
+				return
+			}
+
 			c.Header("Content-Type", "application/activity+json; charset=utf-8")
-			c.Render(200, render.String{Format: "{}"})
+			err, collection := GetFollowingCollection(c.Param("actor"), ParsePageParam(c.Query("page")), conf)
+			if err != nil {
+				c.Render(404, render.String{Format: "{}"})
+			} else {
+				c.Render(200, render.String{Format: collection})
+			}
 		})
 
 		g.GET("/.well-known/webfinger", func(c *gin.Context) {
@@ -294,3 +281,9 @@ func Router(conf *util.AppConfig) error {
 	}
 	return nil
 }
+
+// wantsActivityJSON reports whether a request asks for ActivityPub JSON instead of HTML
+func wantsActivityJSON(c *gin.Context) bool {
+	accept := c.GetHeader("Accept")
+	return strings.Contains(accept, "application/activity+json") || strings.Contains(accept, "application/ld+json")
+}
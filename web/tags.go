@@ -0,0 +1,223 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/deemkeen/stegodon/activitypub"
+	"github.com/deemkeen/stegodon/db"
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/util"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/feeds"
+)
+
+type TagPageData struct {
+	Title    string
+	Host     string
+	SSHPort  int
+	Tag      string
+	Posts    []PostView
+	HasPrev  bool
+	HasNext  bool
+	PrevPage int
+	NextPage int
+}
+
+// HandleTag renders the public local notes with a hashtag
+func HandleTag(c *gin.Context, conf *util.AppConfig) {
+	tag := util.NormalizeHashtag(c.Param("tag"))
+	database := db.GetDB()
+
+	// Pagination
+	page := 1
+	if pageStr := c.Query("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	postsPerPage := 20
+	offset := (page - 1) * postsPerPage
+
+	// Fetch one extra note to know if there is a next page
+	err, notes := database.ReadPublicNotesByTag(tag, postsPerPage+1, offset)
+	if err != nil {
+		log.Printf("Failed to read notes for tag %s: %v", tag, err)
+		c.HTML(500, "base.html", gin.H{"Title": "Error", "Error": "Failed to load tag"})
+		return
+	}
+
+	if notes == nil {
+		notes = &[]domain.Note{}
+	}
+
+	hasNext := len(*notes) > postsPerPage
+	if hasNext {
+		*notes = (*notes)[:postsPerPage]
+	}
+
+	posts := make([]PostView, 0, len(*notes))
+	likeCounts, err := database.CountLikesByNoteIds(noteIds(*notes))
+	if err != nil {
+		log.Printf("Failed to count likes: %v", err)
+	}
+	shareCounts, err := database.CountSharesByNoteIds(noteIds(*notes))
+	if err != nil {
+		log.Printf("Failed to count shares: %v", err)
+	}
+	for _, note := range *notes {
+		posts = append(posts, PostView{
+			Username:    note.CreatedBy,
+			Message:     note.Message,
+			MessageHTML: template.HTML(activitypub.NoteContentHTML(note.Message, readNoteMentions(note.Id), conf)),
+			TimeAgo:     formatTimeAgo(note.CreatedAt),
+			LikeCount:   likeCounts[note.Id],
+			ShareCount:  shareCounts[note.Id],
+		})
+	}
+
+	// Use SSLDomain if federation is enabled, otherwise use Host
+	host := conf.Conf.Host
+	if conf.Conf.WithAp {
+		host = conf.Conf.SslDomain
+	}
+
+	data := TagPageData{
+		Title:    fmt.Sprintf("#%s", tag),
+		Host:     host,
+		SSHPort:  conf.Conf.SshPort,
+		Tag:      tag,
+		Posts:    posts,
+		HasPrev:  page > 1,
+		HasNext:  hasNext,
+		PrevPage: page - 1,
+		NextPage: page + 1,
+	}
+
+	c.HTML(200, "tag.html", data)
+}
+
+// GetTagCollection returns an ActivityPub OrderedCollection of the public local notes with a hashtag
+// Without a page only the collection metadata is returned
+func GetTagCollection(tag string, page int, conf *util.AppConfig) (error, string) {
+	tag = util.NormalizeHashtag(tag)
+	database := db.GetDB()
+	tagURL := fmt.Sprintf("https://%s/tags/%s", conf.Conf.SslDomain, url.PathEscape(tag))
+
+	var collection map[string]interface{}
+	if page == 0 {
+		totalItems, err := database.CountPublicNotesByTag(tag)
+		if err != nil {
+			log.Printf("GetTagCollection: Failed to count notes for %s: %v", tag, err)
+			return err, "{}"
+		}
+		collection = makeTagCollection(tagURL, totalItems)
+	} else {
+		itemsPerPage := 20
+		err, notes := database.ReadPublicNotesByTag(tag, itemsPerPage+1, (page-1)*itemsPerPage)
+		if err != nil {
+			log.Printf("GetTagCollection: Failed to fetch notes page %d for %s: %v", page, tag, err)
+			return err, "{}"
+		}
+		if notes == nil {
+			notes = &[]domain.Note{}
+		}
+		collection = makeTagCollectionPage(tagURL, page, *notes, itemsPerPage, conf)
+	}
+
+	jsonData, err := json.Marshal(collection)
+	if err != nil {
+		log.Printf("GetTagCollection: Failed to marshal collection: %v", err)
+		return err, "{}"
+	}
+	return nil, string(jsonData)
+}
+
+// makeTagCollection builds the metadata of a hashtag collection
+func makeTagCollection(tagURL string, totalItems int) map[string]interface{} {
+	return map[string]interface{}{
+		"@context":   "https://www.w3.org/ns/activitystreams",
+		"id":         tagURL,
+		"type":       "OrderedCollection",
+		"totalItems": totalItems,
+		"first":      fmt.Sprintf("%s?page=1", tagURL),
+	}
+}
+
+// makeTagCollectionPage builds one page of a hashtag collection listing note URIs
+// notes may hold one more item than itemsPerPage to signal a next page
+func makeTagCollectionPage(tagURL string, page int, notes []domain.Note, itemsPerPage int, conf *util.AppConfig) map[string]interface{} {
+	hasMore := len(notes) > itemsPerPage
+	if hasMore {
+		notes = notes[:itemsPerPage]
+	}
+
+	items := make([]string, 0, len(notes))
+	for _, note := range notes {
+		objectURI := note.ObjectURI
+		if objectURI == "" {
+			objectURI = fmt.Sprintf("https://%s/notes/%s", conf.Conf.SslDomain, note.Id.String())
+		}
+		items = append(items, objectURI)
+	}
+
+	collectionPage := map[string]interface{}{
+		"@context":     "https://www.w3.org/ns/activitystreams",
+		"id":           fmt.Sprintf("%s?page=%d", tagURL, page),
+		"type":         "OrderedCollectionPage",
+		"partOf":       tagURL,
+		"orderedItems": items,
+	}
+	if hasMore {
+		collectionPage["next"] = fmt.Sprintf("%s?page=%d", tagURL, page+1)
+	}
+	if page > 1 {
+		collectionPage["prev"] = fmt.Sprintf("%s?page=%d", tagURL, page-1)
+	}
+	return collectionPage
+}
+
+// GetTagRSS returns an RSS feed of the public local notes with a hashtag
+func GetTagRSS(conf *util.AppConfig, tag string) (string, error) {
+	tag = util.NormalizeHashtag(tag)
+	err, notes := db.GetDB().ReadPublicNotesByTag(tag, 100, 0)
+	if err != nil {
+		log.Println(fmt.Sprintf("Could not get notes for tag %s!", tag), err)
+		return "", errors.New("error retrieving notes by tag")
+	}
+
+	link := fmt.Sprintf("http://%s:%d/feed?tag=%s", conf.Conf.Host, conf.Conf.HttpPort, url.QueryEscape(tag))
+	feed := &feeds.Feed{
+		Title:       fmt.Sprintf("Stegodon Notes - #%s", tag),
+		Link:        &feeds.Link{Href: link},
+		Description: fmt.Sprintf("notes tagged #%s", tag),
+		Author:      &feeds.Author{Name: "everyone", Email: "everyone@stegodon"},
+		Created:     time.Now(),
+	}
+
+	var feedItems []*feeds.Item
+	if notes != nil {
+		for _, note := range *notes {
+			email := fmt.Sprintf("%s@stegodon", note.CreatedBy)
+			feedItems = append(feedItems,
+				&feeds.Item{
+					Id:      note.Id.String(),
+					Title:   note.CreatedAt.Format(util.DateTimeFormat()),
+					Link:    &feeds.Link{Href: fmt.Sprintf("http://%s:%d/feed/%s", conf.Conf.Host, conf.Conf.HttpPort, note.Id)},
+					Content: note.Message,
+					Author:  &feeds.Author{Name: note.CreatedBy, Email: email},
+					Created: note.CreatedAt,
+				})
+		}
+	}
+
+	feed.Items = feedItems
+	return feed.ToRss()
+}
@@ -0,0 +1,58 @@
+package web
+
+import (
+	"testing"
+
+	"github.com/deemkeen/stegodon/domain"
+	"github.com/deemkeen/stegodon/util"
+	"github.com/google/uuid"
+)
+
+func TestMakeTagCollection(t *testing.T) {
+	collection := makeTagCollection("https://example.com/tags/stegodon", 3)
+
+	if collection["type"] != "OrderedCollection" {
+		t.Errorf("Expected type OrderedCollection, got %v", collection["type"])
+	}
+	if collection["totalItems"] != 3 {
+		t.Errorf("Expected totalItems 3, got %v", collection["totalItems"])
+	}
+	if collection["first"] != "https://example.com/tags/stegodon?page=1" {
+		t.Errorf("Expected first page link, got %v", collection["first"])
+	}
+}
+
+func TestMakeTagCollectionPage(t *testing.T) {
+	conf := &util.AppConfig{}
+	conf.Conf.SslDomain = "example.com"
+	tagURL := "https://example.com/tags/stegodon"
+
+	first := domain.Note{Id: uuid.New()}
+	second := domain.Note{Id: uuid.New(), ObjectURI: "https://example.com/notes/custom"}
+	extra := domain.Note{Id: uuid.New()}
+
+	page := makeTagCollectionPage(tagURL, 1, []domain.Note{first, second, extra}, 2, conf)
+
+	items, ok := page["orderedItems"].([]string)
+	if !ok {
+		t.Fatalf("Expected orderedItems to be a list of URIs, got %T", page["orderedItems"])
+	}
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+	if items[0] != "https://example.com/notes/"+first.Id.String() {
+		t.Errorf("Expected generated note URI, got %s", items[0])
+	}
+	if items[1] != second.ObjectURI {
+		t.Errorf("Expected stored object URI, got %s", items[1])
+	}
+	if page["next"] != tagURL+"?page=2" {
+		t.Errorf("Expected next page link, got %v", page["next"])
+	}
+	if _, hasPrev := page["prev"]; hasPrev {
+		t.Error("First page should not have a prev link")
+	}
+	if page["partOf"] != tagURL {
+		t.Errorf("Expected partOf %s, got %v", tagURL, page["partOf"])
+	}
+}
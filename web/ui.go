@@ -7,10 +7,12 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/deemkeen/stegodon/activitypub"
 	"github.com/deemkeen/stegodon/db"
 	"github.com/deemkeen/stegodon/domain"
 	"github.com/deemkeen/stegodon/util"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type IndexPageData struct {
@@ -49,6 +51,17 @@ type PostView struct {
 	Message     string
 	MessageHTML template.HTML // HTML-rendered message with clickable links
 	TimeAgo     string
+	LikeCount   int
+	ShareCount  int
+}
+
+// noteIds returns the ids of notes, e.g. to count the likes of a page of notes at once
+func noteIds(notes []domain.Note) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(notes))
+	for _, note := range notes {
+		ids = append(ids, note.Id)
+	}
+	return ids
 }
 
 func formatTimeAgo(t time.Time) string {
@@ -121,12 +134,22 @@ func HandleIndex(c *gin.Context, conf *util.AppConfig) {
 
 	// Convert to PostView
 	posts := make([]PostView, 0, len(paginatedNotes))
+	likeCounts, err := database.CountLikesByNoteIds(noteIds(paginatedNotes))
+	if err != nil {
+		log.Printf("Failed to count likes: %v", err)
+	}
+	shareCounts, err := database.CountSharesByNoteIds(noteIds(paginatedNotes))
+	if err != nil {
+		log.Printf("Failed to count shares: %v", err)
+	}
 	for _, note := range paginatedNotes {
 		posts = append(posts, PostView{
 			Username:    note.CreatedBy,
 			Message:     note.Message,
-			MessageHTML: template.HTML(util.MarkdownLinksToHTML(note.Message)),
+			MessageHTML: template.HTML(activitypub.NoteContentHTML(note.Message, readNoteMentions(note.Id), conf)),
 			TimeAgo:     formatTimeAgo(note.CreatedAt),
+			LikeCount:   likeCounts[note.Id],
+			ShareCount:  shareCounts[note.Id],
 		})
 	}
 
@@ -201,12 +224,22 @@ func HandleProfile(c *gin.Context, conf *util.AppConfig) {
 
 	// Convert to PostView
 	posts := make([]PostView, 0, len(paginatedNotes))
+	likeCounts, err := database.CountLikesByNoteIds(noteIds(paginatedNotes))
+	if err != nil {
+		log.Printf("Failed to count likes: %v", err)
+	}
+	shareCounts, err := database.CountSharesByNoteIds(noteIds(paginatedNotes))
+	if err != nil {
+		log.Printf("Failed to count shares: %v", err)
+	}
 	for _, note := range paginatedNotes {
 		posts = append(posts, PostView{
 			Username:    note.CreatedBy,
 			Message:     note.Message,
-			MessageHTML: template.HTML(util.MarkdownLinksToHTML(note.Message)),
+			MessageHTML: template.HTML(activitypub.NoteContentHTML(note.Message, readNoteMentions(note.Id), conf)),
 			TimeAgo:     formatTimeAgo(note.CreatedAt),
+			LikeCount:   likeCounts[note.Id],
+			ShareCount:  shareCounts[note.Id],
 		})
 	}
 
@@ -1,12 +1,9 @@
 package web
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"time"
 
+	"github.com/deemkeen/stegodon/activitypub"
 	"github.com/deemkeen/stegodon/db"
 	"github.com/deemkeen/stegodon/util"
 )
@@ -54,44 +51,5 @@ type WebFingerResponse struct {
 // ResolveWebFinger resolves a user@domain to an ActivityPub actor URI
 // Example: ResolveWebFinger("alice", "mastodon.social") -> "https://mastodon.social/users/alice"
 func ResolveWebFinger(username, domain string) (string, error) {
-	webfingerURL := fmt.Sprintf("https://%s/.well-known/webfinger?resource=acct:%s@%s",
-		domain, username, domain)
-
-	req, err := http.NewRequest("GET", webfingerURL, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Accept", "application/jrd+json")
-	req.Header.Set("User-Agent", "stegodon/1.0 ActivityPub")
-
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("webfinger request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("webfinger failed with status: %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
-	var result WebFingerResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("failed to parse webfinger response: %w", err)
-	}
-
-	// Find self link with type application/activity+json
-	for _, link := range result.Links {
-		if link.Rel == "self" && link.Type == "application/activity+json" {
-			return link.Href, nil
-		}
-	}
-
-	return "", fmt.Errorf("no ActivityPub actor found in webfinger response")
+	return activitypub.ResolveWebFinger(username, domain)
 }